@@ -29,6 +29,7 @@ var sudoPaths = map[string]*regexp.Regexp{
 	"/sys/config/ui/headers/{header}":               regexp.MustCompile(`^/sys/config/ui/headers/.+$`),
 	"/sys/internal/inspect/router/{tag}":            regexp.MustCompile(`^/sys/internal/inspect/router/.+$`),
 	"/sys/internal/counters/activity/export":        regexp.MustCompile(`^/sys/internal/counters/activity/export$`),
+	"/sys/keyring/escrow":                           regexp.MustCompile(`^/sys/keyring/escrow$`),
 	"/sys/leases":                                   regexp.MustCompile(`^/sys/leases$`),
 	// This entry is a bit wrong... sys/leases/lookup does NOT require sudo. But sys/leases/lookup/ with a trailing
 	// slash DOES require sudo. But the part of the Vault CLI that uses this logic doesn't pass operation-appropriate
@@ -46,8 +47,12 @@ var sudoPaths = map[string]*regexp.Regexp{
 	"/sys/revoke-force/{prefix}":                  regexp.MustCompile(`^/sys/revoke-force/.+$`),
 	"/sys/revoke-prefix/{prefix}":                 regexp.MustCompile(`^/sys/revoke-prefix/.+$`),
 	"/sys/rotate":                                 regexp.MustCompile(`^/sys/rotate$`),
+	"/sys/rotate/path-binding":                    regexp.MustCompile(`^/sys/rotate/path-binding$`),
 	"/sys/seal":                                   regexp.MustCompile(`^/sys/seal$`),
+	"/sys/sealwrap/rewrap":                        regexp.MustCompile(`^/sys/sealwrap/rewrap$`),
 	"/sys/step-down":                              regexp.MustCompile(`^/sys/step-down$`),
+	"/sys/storage/migration":                      regexp.MustCompile(`^/sys/storage/migration$`),
+	"/sys/storage/migration/cutover":              regexp.MustCompile(`^/sys/storage/migration/cutover$`),
 
 	// enterprise-only paths
 	"/sys/replication/dr/primary/secondary-token":          regexp.MustCompile(`^/sys/replication/dr/primary/secondary-token$`),
@@ -55,6 +55,51 @@ func (c *Sys) AuditHashWithContext(ctx context.Context, path string, input strin
 	return hashStr, nil
 }
 
+// AuditVerify verifies the entries of a hash chained audit log written by the
+// audit device at the given path, carrying on the chain from the previous entry
+// (empty if unknown). The chained HMACs are verified by Vault, which only
+// reports whether the entries are valid and the offset of the first invalid
+// entry.
+func (c *Sys) AuditVerify(path string, previous string, entries []string) (*AuditVerifyOutput, error) {
+	return c.AuditVerifyWithContext(context.Background(), path, previous, entries)
+}
+
+func (c *Sys) AuditVerifyWithContext(ctx context.Context, path string, previous string, entries []string) (*AuditVerifyOutput, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	body := map[string]interface{}{
+		"entries":  entries,
+		"previous": previous,
+	}
+
+	r := c.c.NewRequest(http.MethodPut, fmt.Sprintf("/v1/sys/audit-verify/%s", path))
+	if err := r.SetJSONBody(body); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	result := &AuditVerifyOutput{FirstInvalid: -1}
+	if err := mapstructure.WeakDecode(secret.Data, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
 func (c *Sys) ListAudit() (map[string]*Audit, error) {
 	return c.ListAuditWithContext(context.Background())
 }
@@ -157,3 +202,11 @@ type Audit struct {
 	Local       bool              `json:"local" mapstructure:"local"`
 	Path        string            `json:"path" mapstructure:"path"`
 }
+
+// AuditVerifyOutput is the result of verifying entries of a hash chained audit
+// log. FirstInvalid is the offset of the first invalid entry, or -1 when all
+// entries are valid.
+type AuditVerifyOutput struct {
+	Valid        bool `json:"valid" mapstructure:"valid"`
+	FirstInvalid int  `json:"first_invalid" mapstructure:"first_invalid"`
+}
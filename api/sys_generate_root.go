@@ -6,6 +6,7 @@ package api
 import (
 	"context"
 	"net/http"
+	"time"
 )
 
 func (c *Sys) GenerateRootStatus() (*GenerateRootStatusResponse, error) {
@@ -53,6 +54,12 @@ func (c *Sys) GenerateRootInit(otp, pgpKey string) (*GenerateRootStatusResponse,
 	return c.GenerateRootInitWithContext(context.Background(), otp, pgpKey)
 }
 
+// GenerateRootInitWithOptions starts a root token generation where the
+// generated token is limited by the given options.
+func (c *Sys) GenerateRootInitWithOptions(otp, pgpKey string, opts *GenerateRootInitOptions) (*GenerateRootStatusResponse, error) {
+	return c.GenerateRootInitWithOptionsWithContext(context.Background(), otp, pgpKey, opts)
+}
+
 func (c *Sys) GenerateDROperationTokenInit(otp, pgpKey string) (*GenerateRootStatusResponse, error) {
 	return c.GenerateDROperationTokenInitWithContext(context.Background(), otp, pgpKey)
 }
@@ -65,6 +72,22 @@ func (c *Sys) GenerateRootInitWithContext(ctx context.Context, otp, pgpKey strin
 	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/generate-root/attempt", otp, pgpKey)
 }
 
+func (c *Sys) GenerateRootInitWithOptionsWithContext(ctx context.Context, otp, pgpKey string, opts *GenerateRootInitOptions) (*GenerateRootStatusResponse, error) {
+	body := map[string]interface{}{
+		"otp":     otp,
+		"pgp_key": pgpKey,
+	}
+	if opts != nil {
+		if opts.TTL != 0 {
+			body["ttl"] = opts.TTL.String()
+		}
+		if len(opts.Policies) > 0 {
+			body["policies"] = opts.Policies
+		}
+	}
+	return c.generateRootInitBodyWithContext(ctx, "/v1/sys/generate-root/attempt", body)
+}
+
 func (c *Sys) GenerateDROperationTokenInitWithContext(ctx context.Context, otp, pgpKey string) (*GenerateRootStatusResponse, error) {
 	return c.generateRootInitCommonWithContext(ctx, "/v1/sys/replication/dr/secondary/generate-operation-token/attempt", otp, pgpKey)
 }
@@ -74,13 +97,16 @@ func (c *Sys) GenerateRecoveryOperationTokenInitWithContext(ctx context.Context,
 }
 
 func (c *Sys) generateRootInitCommonWithContext(ctx context.Context, path, otp, pgpKey string) (*GenerateRootStatusResponse, error) {
-	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
-	defer cancelFunc()
-
 	body := map[string]interface{}{
 		"otp":     otp,
 		"pgp_key": pgpKey,
 	}
+	return c.generateRootInitBodyWithContext(ctx, path, body)
+}
+
+func (c *Sys) generateRootInitBodyWithContext(ctx context.Context, path string, body map[string]interface{}) (*GenerateRootStatusResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
 
 	r := c.c.NewRequest(http.MethodPut, path)
 	if err := r.SetJSONBody(body); err != nil {
@@ -185,14 +211,28 @@ func (c *Sys) generateRootUpdateCommonWithContext(ctx context.Context, path, sha
 }
 
 type GenerateRootStatusResponse struct {
-	Nonce            string `json:"nonce"`
-	Started          bool   `json:"started"`
-	Progress         int    `json:"progress"`
-	Required         int    `json:"required"`
-	Complete         bool   `json:"complete"`
-	EncodedToken     string `json:"encoded_token"`
-	EncodedRootToken string `json:"encoded_root_token"`
-	PGPFingerprint   string `json:"pgp_fingerprint"`
-	OTP              string `json:"otp"`
-	OTPLength        int    `json:"otp_length"`
+	Nonce            string   `json:"nonce"`
+	Started          bool     `json:"started"`
+	Progress         int      `json:"progress"`
+	Required         int      `json:"required"`
+	Complete         bool     `json:"complete"`
+	EncodedToken     string   `json:"encoded_token"`
+	EncodedRootToken string   `json:"encoded_root_token"`
+	PGPFingerprint   string   `json:"pgp_fingerprint"`
+	OTP              string   `json:"otp"`
+	OTPLength        int      `json:"otp_length"`
+	TTL              int64    `json:"ttl"`
+	Policies         []string `json:"policies"`
+}
+
+// GenerateRootInitOptions holds limits for the token created by a root token
+// generation.
+type GenerateRootInitOptions struct {
+	// TTL is how long the generated token is valid for. The token cannot be
+	// renewed. If not set, the token does not expire.
+	TTL time.Duration
+
+	// Policies are given to the generated token instead of the root policy.
+	// A TTL is required when they are set.
+	Policies []string
 }
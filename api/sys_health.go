@@ -58,4 +58,5 @@ type HealthResponse struct {
 	RemovedFromCluster                   *bool  `json:"removed_from_cluster,omitempty"`
 	HAConnectionHealthy                  *bool  `json:"ha_connection_healthy,omitempty"`
 	LastRequestForwardingHeartbeatMillis int64  `json:"last_request_forwarding_heartbeat_ms,omitempty"`
+	ReadReplica                          bool   `json:"read_replica,omitempty"`
 }
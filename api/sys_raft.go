@@ -14,6 +14,7 @@ import (
 	"io/ioutil"
 	"net/http"
 	"net/url"
+	"strings"
 	"sync"
 	"time"
 
@@ -136,11 +137,12 @@ type AutopilotServer struct {
 	UpgradeVersion string `mapstructure:"upgrade_version,omitempty"`
 	RedundancyZone string `mapstructure:"redundancy_zone,omitempty"`
 	NodeType       string `mapstructure:"node_type,omitempty"`
+	ReadReplica    bool   `mapstructure:"read_replica,omitempty"`
 }
 
 func (a *AutopilotServer) String() string {
-	return fmt.Sprintf("ID: %s. Name: %s. Address: %s. NodeStatus: %s. LastContact: %s. LastTerm: %d. LastIndex: %d. Healthy: %t. StableSince: %s. Status: %s. Version: %s. UpgradeVersion: %s. RedundancyZone: %s. NodeType: %s",
-		a.ID, a.Name, a.Address, a.NodeStatus, a.LastContact, a.LastTerm, a.LastIndex, a.Healthy, a.StableSince, a.Status, a.Version, a.UpgradeVersion, a.RedundancyZone, a.NodeType)
+	return fmt.Sprintf("ID: %s. Name: %s. Address: %s. NodeStatus: %s. LastContact: %s. LastTerm: %d. LastIndex: %d. Healthy: %t. StableSince: %s. Status: %s. Version: %s. UpgradeVersion: %s. RedundancyZone: %s. NodeType: %s. ReadReplica: %t",
+		a.ID, a.Name, a.Address, a.NodeStatus, a.LastContact, a.LastTerm, a.LastIndex, a.Healthy, a.StableSince, a.Status, a.Version, a.UpgradeVersion, a.RedundancyZone, a.NodeType, a.ReadReplica)
 }
 
 type AutopilotZone struct {
@@ -188,6 +190,23 @@ func (a *AutopilotZoneUpgradeVersions) String() string {
 		a.TargetVersionVoters, a.TargetVersionNonVoters, a.OtherVersionVoters, a.OtherVersionNonVoters)
 }
 
+// RaftCompactionStatus represents the status of the latest compaction of the
+// raft storage.
+type RaftCompactionStatus struct {
+	Status    string                        `mapstructure:"status"`
+	StartTime string                        `mapstructure:"start_time"`
+	EndTime   string                        `mapstructure:"end_time,omitempty"`
+	Error     string                        `mapstructure:"error,omitempty"`
+	Servers   []*RaftCompactionServerStatus `mapstructure:"servers"`
+}
+
+// RaftCompactionServerStatus represents the status of the compaction of the
+// raft storage of a single node.
+type RaftCompactionServerStatus struct {
+	ID     string `mapstructure:"id"`
+	Status string `mapstructure:"status"`
+}
+
 // RaftJoin wraps RaftJoinWithContext using context.Background.
 func (c *Sys) RaftJoin(opts *RaftJoinRequest) (*RaftJoinResponse, error) {
 	return c.RaftJoinWithContext(context.Background(), opts)
@@ -315,6 +334,56 @@ func (c *Sys) RaftSnapshotRestoreWithContext(ctx context.Context, snapReader io.
 	return nil
 }
 
+// RaftSnapshotRestoreMount wraps RaftSnapshotRestoreMountWithContext using context.Background.
+func (c *Sys) RaftSnapshotRestoreMount(snapReader io.Reader, mountPath string) (*Secret, error) {
+	return c.RaftSnapshotRestoreMountWithContext(context.Background(), snapReader, mountPath)
+}
+
+// RaftSnapshotRestoreMountWithContext reads the snapshot from the io.Reader
+// and restores only the secrets mount at mountPath, or the auth mount if
+// mountPath starts with "auth/", leaving the rest of the cluster unchanged.
+func (c *Sys) RaftSnapshotRestoreMountWithContext(ctx context.Context, snapReader io.Reader, mountPath string) (*Secret, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/storage/raft/snapshot/restore-mount/"+strings.Trim(mountPath, "/"))
+	r.Body = snapReader
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
+// RaftSnapshotRestoreMountByUUID wraps RaftSnapshotRestoreMountByUUIDWithContext using context.Background.
+func (c *Sys) RaftSnapshotRestoreMountByUUID(snapReader io.Reader, mountUUID, mountPath string) (*Secret, error) {
+	return c.RaftSnapshotRestoreMountByUUIDWithContext(context.Background(), snapReader, mountUUID, mountPath)
+}
+
+// RaftSnapshotRestoreMountByUUIDWithContext reads the snapshot from the
+// io.Reader and restores only the secrets or auth mount with the given UUID
+// in the snapshot. The mount is restored at mountPath, or at its path in the
+// snapshot if mountPath is empty.
+func (c *Sys) RaftSnapshotRestoreMountByUUIDWithContext(ctx context.Context, snapReader io.Reader, mountUUID, mountPath string) (*Secret, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/storage/raft/snapshot/restore-mount/"+strings.Trim(mountPath, "/"))
+	r.Params.Set("mount_uuid", mountUUID)
+	r.Body = snapReader
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	return ParseSecret(resp.Body)
+}
+
 // RaftAutopilotState wraps RaftAutopilotStateWithContext using context.Background.
 func (c *Sys) RaftAutopilotState() (*AutopilotState, error) {
 	return c.RaftAutopilotStateWithContext(context.Background())
@@ -445,6 +514,68 @@ func (c *Sys) PutRaftAutopilotConfigurationWithContext(ctx context.Context, opts
 	return nil
 }
 
+// RaftCompact wraps RaftCompactWithContext using context.Background.
+func (c *Sys) RaftCompact() error {
+	return c.RaftCompactWithContext(context.Background())
+}
+
+// RaftCompactWithContext starts compacting the raft storage of all the nodes
+// of the cluster. Use RaftCompactionStatus to follow its progress.
+func (c *Sys) RaftCompactWithContext(ctx context.Context) error {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/storage/raft/compact")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return nil
+}
+
+// RaftCompactionStatus wraps RaftCompactionStatusWithContext using context.Background.
+func (c *Sys) RaftCompactionStatus() (*RaftCompactionStatus, error) {
+	return c.RaftCompactionStatusWithContext(context.Background())
+}
+
+// RaftCompactionStatusWithContext returns the status of the latest compaction
+// of the raft storage, or nil if none was started.
+func (c *Sys) RaftCompactionStatusWithContext(ctx context.Context) (*RaftCompactionStatus, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodGet, "/v1/sys/storage/raft/compact")
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if resp != nil {
+		defer resp.Body.Close()
+		if resp.StatusCode == 404 || resp.StatusCode == 204 {
+			return nil, nil
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, nil
+	}
+
+	var result RaftCompactionStatus
+	if err := mapstructure.Decode(secret.Data, &result); err != nil {
+		return nil, err
+	}
+
+	return &result, nil
+}
+
 // RaftLoadLocalSnapshot wraps RaftLoadLocalSnapshotWithContext using context.Background.
 func (c *Sys) RaftLoadLocalSnapshot(snapReader io.Reader) (*Secret, error) {
 	return c.RaftLoadLocalSnapshotWithContext(context.Background(), snapReader)
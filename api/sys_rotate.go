@@ -9,6 +9,8 @@ import (
 	"errors"
 	"net/http"
 	"time"
+
+	"github.com/mitchellh/mapstructure"
 )
 
 func (c *Sys) Rotate() error {
@@ -28,6 +30,49 @@ func (c *Sys) RotateWithContext(ctx context.Context) error {
 	return err
 }
 
+// KeyringEscrow exports the barrier keyring as a bundle encrypted to the
+// given PGP keys, for decrypting storage offline when the seal is lost.
+func (c *Sys) KeyringEscrow(input *KeyringEscrowInput) (*KeyringEscrowOutput, error) {
+	return c.KeyringEscrowWithContext(context.Background(), input)
+}
+
+func (c *Sys) KeyringEscrowWithContext(ctx context.Context, input *KeyringEscrowInput) (*KeyringEscrowOutput, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPost, "/v1/sys/keyring/escrow")
+	if err := r.SetJSONBody(input); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	secret, err := ParseSecret(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if secret == nil || secret.Data == nil {
+		return nil, errors.New("data from server response is empty")
+	}
+
+	var result KeyringEscrowOutput
+	d, err := mapstructure.NewDecoder(&mapstructure.DecoderConfig{
+		DecodeHook: mapstructure.StringToTimeHookFunc(time.RFC3339Nano),
+		Result:     &result,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := d.Decode(secret.Data); err != nil {
+		return nil, err
+	}
+	return &result, nil
+}
+
 func (c *Sys) KeyStatus() (*KeyStatus, error) {
 	return c.KeyStatusWithContext(context.Background())
 }
@@ -95,11 +140,79 @@ func (c *Sys) KeyStatusWithContext(ctx context.Context) (*KeyStatus, error) {
 		result.Encryptions = int(encryptions64)
 	}
 
+	historyRaw, ok := secret.Data["rotation_history"]
+	if ok {
+		history, ok := historyRaw.([]interface{})
+		if !ok {
+			return nil, errors.New("could not convert rotation_history to a list")
+		}
+		for _, rotationRaw := range history {
+			rotation, err := parseKeyRotation(rotationRaw)
+			if err != nil {
+				return nil, err
+			}
+			result.RotationHistory = append(result.RotationHistory, rotation)
+		}
+	}
+
 	return &result, err
 }
 
+func parseKeyRotation(raw interface{}) (*KeyRotation, error) {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil, errors.New("could not convert rotation_history entry to a map")
+	}
+
+	var rotation KeyRotation
+	term, ok := m["term"].(json.Number)
+	if !ok {
+		return nil, errors.New("could not convert rotation_history term to a number")
+	}
+	term64, err := term.Int64()
+	if err != nil {
+		return nil, err
+	}
+	rotation.Term = int(term64)
+
+	if installTimeStr, ok := m["install_time"].(string); ok {
+		rotation.InstallTime, err = time.Parse(time.RFC3339Nano, installTimeStr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	rotation.Reason, _ = m["reason"].(string)
+
+	return &rotation, nil
+}
+
 type KeyStatus struct {
+	Term            int            `json:"term"`
+	InstallTime     time.Time      `json:"install_time"`
+	Encryptions     int            `json:"encryptions"`
+	RotationHistory []*KeyRotation `json:"rotation_history"`
+}
+
+// KeyRotation describes when and why a barrier key term was installed.
+type KeyRotation struct {
 	Term        int       `json:"term"`
 	InstallTime time.Time `json:"install_time"`
-	Encryptions int       `json:"encryptions"`
+	Reason      string    `json:"reason"`
+}
+
+type KeyringEscrowInput struct {
+	PGPKeys   []string `json:"pgp_keys"`
+	Threshold int      `json:"threshold,omitempty"`
+}
+
+// KeyringEscrowOutput is the keyring escrow bundle. It is meant to be stored
+// as JSON and later opened offline.
+type KeyringEscrowOutput struct {
+	Version          int       `json:"version" mapstructure:"version"`
+	Term             uint32    `json:"term" mapstructure:"term"`
+	CreatedTime      time.Time `json:"created_time" mapstructure:"created_time"`
+	Threshold        int       `json:"threshold" mapstructure:"threshold"`
+	PGPFingerprints  []string  `json:"pgp_fingerprints" mapstructure:"pgp_fingerprints"`
+	EncryptedShares  []string  `json:"encrypted_shares" mapstructure:"encrypted_shares"`
+	EncryptedKeyring string    `json:"encrypted_keyring" mapstructure:"encrypted_keyring"`
 }
@@ -80,6 +80,32 @@ func (c *Sys) UnsealWithOptionsWithContext(ctx context.Context, opts *UnsealOpts
 	return sealStatusRequestWithContext(ctx, c, r)
 }
 
+// UnsealVerify checks whether the given key share belongs to the current split
+// of the unseal key, or of the recovery key, without using it to unseal.
+func (c *Sys) UnsealVerify(shard string) (*UnsealVerifyResponse, error) {
+	return c.UnsealVerifyWithContext(context.Background(), shard)
+}
+
+func (c *Sys) UnsealVerifyWithContext(ctx context.Context, shard string) (*UnsealVerifyResponse, error) {
+	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
+	defer cancelFunc()
+
+	r := c.c.NewRequest(http.MethodPut, "/v1/sys/unseal-verify")
+	if err := r.SetJSONBody(map[string]interface{}{"key": shard}); err != nil {
+		return nil, err
+	}
+
+	resp, err := c.c.rawRequestWithContext(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var result UnsealVerifyResponse
+	err = resp.DecodeJSON(&result)
+	return &result, err
+}
+
 func sealStatusRequestWithContext(ctx context.Context, c *Sys, r *Request) (*SealStatusResponse, error) {
 	ctx, cancelFunc := c.c.withConfiguredTimeout(ctx)
 	defer cancelFunc()
@@ -122,3 +148,8 @@ type UnsealOpts struct {
 	Reset   bool   `json:"reset"`
 	Migrate bool   `json:"migrate"`
 }
+
+type UnsealVerifyResponse struct {
+	Valid       bool `json:"valid"`
+	RecoveryKey bool `json:"recovery_key"`
+}
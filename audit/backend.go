@@ -6,6 +6,7 @@ package audit
 import (
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"sync/atomic"
 
@@ -30,6 +31,7 @@ const (
 	TypeFile   = "file"
 	TypeSocket = "socket"
 	TypeSyslog = "syslog"
+	TypeHTTP   = "http"
 )
 
 var _ Backend = (*backend)(nil)
@@ -92,8 +94,13 @@ type backend struct {
 // It handles basic validation of config and creates required pipelines nodes that
 // precede the sink node.
 func newBackend(headersConfig HeaderFormatter, conf *BackendConfig) (*backend, error) {
+	ent, err := newBackendEnt(conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
 	b := &backend{
-		backendEnt: newBackendEnt(conf.Config),
+		backendEnt: ent,
 		name:       conf.MountPath,
 		saltConfig: conf.SaltConfig,
 		saltView:   conf.SaltView,
@@ -120,6 +127,30 @@ func newBackend(headersConfig HeaderFormatter, conf *BackendConfig) (*backend, e
 	return b, nil
 }
 
+// configureFilterNode is used to configure a filter node and associated ID on the Backend.
+// Empty (including whitespace) filters are ignored and no node is configured.
+func (b *backend) configureFilterNode(filter string) error {
+	filter = strings.TrimSpace(filter)
+	if filter == "" {
+		return nil
+	}
+
+	filterNodeID, err := event.GenerateNodeID()
+	if err != nil {
+		return fmt.Errorf("error generating random NodeID for filter node: %w: %w", ErrInternal, err)
+	}
+
+	filterNode, err := newEntryFilter(filter)
+	if err != nil {
+		return fmt.Errorf("error creating filter node: %w", err)
+	}
+
+	b.nodeIDList = append(b.nodeIDList, filterNodeID)
+	b.nodeMap[filterNodeID] = filterNode
+
+	return nil
+}
+
 // configureFormatterNode is used to configure a formatter node and associated ID on the Backend.
 func (b *backend) configureFormatterNode(name string, formatConfig formatterConfig, logger hclog.Logger) error {
 	formatterNodeID, err := event.GenerateNodeID()
@@ -255,8 +286,6 @@ func HasInvalidOptions(options map[string]string) bool {
 func hasEnterpriseAuditOptions(options map[string]string) bool {
 	enterpriseAuditOptions := []string{
 		optionExclude,
-		optionFallback,
-		optionFilter,
 	}
 
 	for _, o := range enterpriseAuditOptions {
@@ -5,23 +5,46 @@
 
 package audit
 
-import "github.com/hashicorp/vault/internal/observability/event"
+import (
+	"fmt"
+	"strconv"
 
-type backendEnt struct{}
+	"github.com/hashicorp/vault/internal/observability/event"
+)
 
-func newBackendEnt(_ map[string]string) *backendEnt {
-	return &backendEnt{}
+type backendEnt struct {
+	fallback bool
 }
 
-func (b *backendEnt) IsFallback() bool {
-	return false
+// newBackendEnt parses the options of the backend which are shared with the
+// enterprise edition. A fallback device cannot also have a filter, as it
+// receives the entries which every other device filtered out.
+func newBackendEnt(config map[string]string) (*backendEnt, error) {
+	b := &backendEnt{}
+
+	if fallbackRaw, ok := config[optionFallback]; ok {
+		v, err := strconv.ParseBool(fallbackRaw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %q: %w", optionFallback, ErrExternalOptions)
+		}
+		b.fallback = v
+	}
+
+	if b.fallback && config[optionFilter] != "" {
+		return nil, fmt.Errorf("cannot configure a fallback device with a filter: %w", ErrExternalOptions)
+	}
+
+	return b, nil
 }
 
-// configureFilterNode is a no-op as filters are an Enterprise-only feature.
-func (b *backend) configureFilterNode(_ string) error {
-	return nil
+func (b *backendEnt) IsFallback() bool {
+	return b.fallback
 }
 
 func (b *backend) getMetricLabeler() event.Labeler {
+	if b.IsFallback() {
+		return &metricLabelerAuditFallback{}
+	}
+
 	return &metricLabelerAuditSink{}
 }
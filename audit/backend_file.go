@@ -6,6 +6,7 @@ package audit
 import (
 	"fmt"
 	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/hashicorp/eventlogger"
@@ -18,12 +19,19 @@ const (
 
 	optionFilePath = "file_path"
 	optionMode     = "mode"
+	optionMaxBytes = "max_bytes"
+	optionMaxAge   = "max_age"
+	optionMaxFiles = "max_files"
+	optionCompress = "compress"
+
+	optionHashChain = "hash_chain"
 )
 
 var _ Backend = (*fileBackend)(nil)
 
 type fileBackend struct {
 	*backend
+	hashChain bool
 }
 
 // NewFileBackend provides a wrapper to support the expectation elsewhere in Vault that
@@ -62,6 +70,14 @@ func newFileBackend(conf *BackendConfig, headersConfig HeaderFormatter) (*fileBa
 	}
 	b := &fileBackend{backend: bec}
 
+	if raw, ok := conf.Config[optionHashChain]; ok {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %q: %w", optionHashChain, ErrExternalOptions)
+		}
+		b.hashChain = v
+	}
+
 	// normalize file path if configured for stdout
 	if strings.EqualFold(filePath, stdout) {
 		filePath = stdout
@@ -76,11 +92,31 @@ func newFileBackend(conf *BackendConfig, headersConfig HeaderFormatter) (*fileBa
 		return nil, err
 	}
 
-	sinkOpts := []event.Option{event.WithLogger(conf.Logger)}
+	if b.hashChain {
+		switch {
+		case cfg.requiredFormat != jsonFormat:
+			return nil, fmt.Errorf("%q requires the %q format: %w", optionHashChain, jsonFormat, ErrExternalOptions)
+		case cfg.prefix != "":
+			return nil, fmt.Errorf("cannot configure %q with a %q: %w", optionHashChain, optionPrefix, ErrExternalOptions)
+		}
+	}
+
+	sinkOpts := []event.Option{
+		event.WithLogger(conf.Logger),
+		event.WithMaxBytes(conf.Config[optionMaxBytes]),
+		event.WithMaxAge(conf.Config[optionMaxAge]),
+		event.WithMaxFiles(conf.Config[optionMaxFiles]),
+		event.WithCompress(conf.Config[optionCompress]),
+	}
 	if mode, ok := conf.Config[optionMode]; ok {
 		sinkOpts = append(sinkOpts, event.WithFileMode(mode))
 	}
 
+	err = event.ValidateOptions(sinkOpts...)
+	if err != nil {
+		return nil, err
+	}
+
 	err = b.configureSinkNode(conf.MountPath, filePath, cfg.requiredFormat, sinkOpts...)
 	if err != nil {
 		return nil, err
@@ -133,6 +169,14 @@ func (b *fileBackend) configureSinkNode(name string, filePath string, format for
 		return fmt.Errorf("file sink creation failed for path %q: %w", filePath, err)
 	}
 
+	// Chain the entries before they reach the sink, so they are written in order.
+	if b.hashChain {
+		sinkNode, err = newSinkHashChain(sinkNode, b)
+		if err != nil {
+			return fmt.Errorf("unable to add hash chain to sink for path %q: %w", filePath, err)
+		}
+	}
+
 	// Wrap the sink node with metrics middleware
 	err = b.wrapMetrics(sinkName, sinkNodeID, sinkNode)
 	if err != nil {
@@ -16,45 +16,47 @@ import (
 )
 
 // TestFileBackend_newFileBackend_fallback ensures that we get the correct errors
-// in CE when we try to enable a fileBackend with enterprise options like fallback
-// and filter.
+// in CE when we try to enable a fileBackend with the fallback option, which
+// cannot be combined with a filter.
 func TestFileBackend_newFileBackend_fallback(t *testing.T) {
 	t.Parallel()
 
 	tests := map[string]struct {
-		backendConfig        *BackendConfig
+		config               map[string]string
 		isErrorExpected      bool
 		expectedErrorMessage string
+		expectedFallback     bool
 	}{
 		"non-fallback-device-with-filter": {
-			backendConfig: &BackendConfig{
-				MountPath:  "discard",
-				SaltConfig: &salt.Config{},
-				SaltView:   &logical.InmemStorage{},
-				Logger:     hclog.NewNullLogger(),
-				Config: map[string]string{
-					"fallback":  "false",
-					"file_path": discard,
-					"filter":    "mount_type == kv",
-				},
+			config: map[string]string{
+				"fallback":  "false",
+				"file_path": discard,
+				"filter":    "mount_type == kv",
 			},
-			isErrorExpected:      true,
-			expectedErrorMessage: "enterprise-only options supplied: invalid configuration",
+		},
+		"fallback-device": {
+			config: map[string]string{
+				"fallback":  "true",
+				"file_path": discard,
+			},
+			expectedFallback: true,
 		},
 		"fallback-device-with-filter": {
-			backendConfig: &BackendConfig{
-				MountPath:  "discard",
-				SaltConfig: &salt.Config{},
-				SaltView:   &logical.InmemStorage{},
-				Logger:     hclog.NewNullLogger(),
-				Config: map[string]string{
-					"fallback":  "true",
-					"file_path": discard,
-					"filter":    "mount_type == kv",
-				},
+			config: map[string]string{
+				"fallback":  "true",
+				"file_path": discard,
+				"filter":    "mount_type == kv",
+			},
+			isErrorExpected:      true,
+			expectedErrorMessage: "cannot configure a fallback device with a filter: invalid configuration",
+		},
+		"bad-fallback": {
+			config: map[string]string{
+				"fallback":  "juan",
+				"file_path": discard,
 			},
 			isErrorExpected:      true,
-			expectedErrorMessage: "enterprise-only options supplied: invalid configuration",
+			expectedErrorMessage: "unable to parse \"fallback\": invalid configuration",
 		},
 	}
 
@@ -62,7 +64,16 @@ func TestFileBackend_newFileBackend_fallback(t *testing.T) {
 		name := name
 		tc := tc
 		t.Run(name, func(t *testing.T) {
-			be, err := newFileBackend(tc.backendConfig, &noopHeaderFormatter{})
+			t.Parallel()
+
+			cfg := &BackendConfig{
+				MountPath:  "discard",
+				SaltConfig: &salt.Config{},
+				SaltView:   &logical.InmemStorage{},
+				Logger:     hclog.NewNullLogger(),
+				Config:     tc.config,
+			}
+			be, err := newFileBackend(cfg, &noopHeaderFormatter{})
 
 			if tc.isErrorExpected {
 				require.Error(t, err)
@@ -70,14 +81,15 @@ func TestFileBackend_newFileBackend_fallback(t *testing.T) {
 			} else {
 				require.NoError(t, err)
 				require.NotNil(t, be)
+				require.Equal(t, tc.expectedFallback, be.IsFallback())
 			}
 		})
 	}
 }
 
 // TestFileBackend_newFileBackend_FilterFormatterSink ensures that when configuring
-// a backend in community edition we cannot configure a filter node.
-// We can verify that we have formatter and sink nodes added to the backend.
+// a backend in community edition we can configure a filter node.
+// We can verify that we have filter, formatter and sink nodes added to the backend.
 // The order of calls influences the slice of IDs on the Backend.
 func TestFileBackend_newFileBackend_FilterFormatterSink(t *testing.T) {
 	t.Parallel()
@@ -98,27 +110,25 @@ func TestFileBackend_newFileBackend_FilterFormatterSink(t *testing.T) {
 	}
 
 	b, err := newFileBackend(backendConfig, &noopHeaderFormatter{})
-	require.Error(t, err)
-	require.EqualError(t, err, "enterprise-only options supplied: invalid configuration")
-
-	// Try without filter option
-	delete(cfg, "filter")
-	b, err = newFileBackend(backendConfig, &noopHeaderFormatter{})
 	require.NoError(t, err)
 
-	require.Len(t, b.nodeIDList, 2)
-	require.Len(t, b.nodeMap, 2)
+	require.Len(t, b.nodeIDList, 3)
+	require.Len(t, b.nodeMap, 3)
 
 	id := b.nodeIDList[0]
 	node := b.nodeMap[id]
-	require.Equal(t, eventlogger.NodeTypeFormatter, node.Type())
+	require.Equal(t, eventlogger.NodeTypeFilter, node.Type())
 
 	id = b.nodeIDList[1]
 	node = b.nodeMap[id]
+	require.Equal(t, eventlogger.NodeTypeFormatter, node.Type())
+
+	id = b.nodeIDList[2]
+	node = b.nodeMap[id]
 	require.Equal(t, eventlogger.NodeTypeSink, node.Type())
 }
 
-// TestBackend_IsFallback ensures that no CE audit device can be a fallback.
+// TestBackend_IsFallback ensures that a CE audit device can be a fallback.
 func TestBackend_IsFallback(t *testing.T) {
 	t.Parallel()
 
@@ -134,8 +144,9 @@ func TestBackend_IsFallback(t *testing.T) {
 	}
 
 	be, err := newFileBackend(cfg, &noopHeaderFormatter{})
-	require.Error(t, err)
-	require.EqualError(t, err, "enterprise-only options supplied: invalid configuration")
+	require.NoError(t, err)
+	require.NotNil(t, be)
+	require.Equal(t, true, be.IsFallback())
 
 	// Remove the option and try again
 	delete(cfg.Config, "fallback")
@@ -287,3 +287,140 @@ func TestFileBackend_newFileBackend(t *testing.T) {
 		})
 	}
 }
+
+// TestFileBackend_newFileBackend_rotation ensures that the rotation options are
+// validated when the backend is created.
+func TestFileBackend_newFileBackend_rotation(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config         map[string]string
+		wantErr        bool
+		expectedErrMsg string
+	}{
+		"bad-max-bytes": {
+			config:         map[string]string{"max_bytes": "juan"},
+			wantErr:        true,
+			expectedErrMsg: "unable to parse max bytes: invalid parameter: could not parse capacity from input",
+		},
+		"bad-max-age": {
+			config:         map[string]string{"max_age": "juan"},
+			wantErr:        true,
+			expectedErrMsg: "unable to parse max age: invalid parameter: time: invalid duration \"juan\"",
+		},
+		"negative-max-files": {
+			config:         map[string]string{"max_files": "-1"},
+			wantErr:        true,
+			expectedErrMsg: "max files cannot be negative: invalid parameter",
+		},
+		"bad-compress": {
+			config:         map[string]string{"compress": "juan"},
+			wantErr:        true,
+			expectedErrMsg: "unable to parse compress: invalid parameter: strconv.ParseBool: parsing \"juan\": invalid syntax",
+		},
+		"happy": {
+			config: map[string]string{
+				"max_bytes": "100MB",
+				"max_age":   "24h",
+				"max_files": "7",
+				"compress":  "true",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.config["file_path"] = filepath.Join(t.TempDir(), "audit.log")
+			cfg := &BackendConfig{
+				SaltView:   &logical.InmemStorage{},
+				SaltConfig: &salt.Config{},
+				Logger:     hclog.NewNullLogger(),
+				Config:     tc.config,
+				MountPath:  "foo",
+			}
+			b, err := newFileBackend(cfg, &noopHeaderFormatter{})
+
+			if tc.wantErr {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expectedErrMsg)
+				require.Nil(t, b)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, b.nodeIDList, 2) // Expect formatter + the sink
+			}
+		})
+	}
+}
+
+// TestFileBackend_newFileBackend_hashChain ensures that the hash chain option is
+// validated, and that the sink is wrapped with the hash chain when it is enabled.
+func TestFileBackend_newFileBackend_hashChain(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config         map[string]string
+		wantErr        bool
+		expectedErrMsg string
+		wantChain      bool
+	}{
+		"bad-hash-chain": {
+			config:         map[string]string{"hash_chain": "juan"},
+			wantErr:        true,
+			expectedErrMsg: "unable to parse \"hash_chain\": invalid configuration",
+		},
+		"jsonx": {
+			config:         map[string]string{"hash_chain": "true", "format": "jsonx"},
+			wantErr:        true,
+			expectedErrMsg: "\"hash_chain\" requires the \"json\" format: invalid configuration",
+		},
+		"prefix": {
+			config:         map[string]string{"hash_chain": "true", "prefix": "vault"},
+			wantErr:        true,
+			expectedErrMsg: "cannot configure \"hash_chain\" with a \"prefix\": invalid configuration",
+		},
+		"disabled": {
+			config: map[string]string{"hash_chain": "false"},
+		},
+		"happy": {
+			config:    map[string]string{"hash_chain": "true"},
+			wantChain: true,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			tc.config["file_path"] = filepath.Join(t.TempDir(), "audit.log")
+			cfg := &BackendConfig{
+				SaltView:   &logical.InmemStorage{},
+				SaltConfig: &salt.Config{},
+				Logger:     hclog.NewNullLogger(),
+				Config:     tc.config,
+				MountPath:  "foo",
+			}
+			b, err := newFileBackend(cfg, &noopHeaderFormatter{})
+
+			if tc.wantErr {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expectedErrMsg)
+				require.Nil(t, b)
+			} else {
+				require.NoError(t, err)
+				require.Equal(t, tc.wantChain, b.hashChain)
+				mc, ok := b.nodeMap[b.nodeIDList[1]].(*event.MetricsCounter)
+				require.True(t, ok)
+				timer, ok := mc.Unwrap().(*sinkMetricTimer)
+				require.True(t, ok)
+				_, ok = timer.Unwrap().(*sinkHashChain)
+				require.Equal(t, tc.wantChain, ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,176 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"crypto/tls"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"github.com/hashicorp/go-rootcerts"
+	"github.com/hashicorp/vault/internal/observability/event"
+)
+
+const (
+	optionURL             = "url"
+	optionBatchSize       = "batch_size"
+	optionFlushInterval   = "flush_interval"
+	optionMaxRetries      = "max_retries"
+	optionRetryMinBackoff = "retry_min_backoff"
+	optionRetryMaxBackoff = "retry_max_backoff"
+	optionRequestTimeout  = "request_timeout"
+	optionSpoolDir        = "spool_dir"
+	optionSpoolMaxBytes   = "spool_max_bytes"
+	optionTLSCACert       = "tls_ca_cert"
+	optionTLSClientCert   = "tls_client_cert"
+	optionTLSClientKey    = "tls_client_key"
+	optionTLSServerName   = "tls_server_name"
+	optionTLSSkipVerify   = "tls_skip_verify"
+)
+
+var _ Backend = (*httpBackend)(nil)
+
+type httpBackend struct {
+	*backend
+}
+
+// NewHTTPBackend provides a means to create HTTP backend audit devices that
+// satisfy the Factory pattern expected elsewhere in Vault.
+func NewHTTPBackend(conf *BackendConfig, headersConfig HeaderFormatter) (be Backend, err error) {
+	be, err = newHTTPBackend(conf, headersConfig)
+	return
+}
+
+// newHTTPBackend creates a backend and configures all nodes including an HTTP sink.
+func newHTTPBackend(conf *BackendConfig, headersConfig HeaderFormatter) (*httpBackend, error) {
+	if headersConfig == nil || reflect.ValueOf(headersConfig).IsNil() {
+		return nil, fmt.Errorf("nil header formatter: %w", ErrInvalidParameter)
+	}
+	if conf == nil {
+		return nil, fmt.Errorf("nil config: %w", ErrInvalidParameter)
+	}
+	if err := conf.Validate(); err != nil {
+		return nil, err
+	}
+
+	bec, err := newBackend(headersConfig, conf)
+	if err != nil {
+		return nil, err
+	}
+
+	url, ok := conf.Config[optionURL]
+	if !ok {
+		return nil, fmt.Errorf("%q is required: %w", optionURL, ErrExternalOptions)
+	}
+	url = strings.TrimSpace(url)
+	if url == "" {
+		return nil, fmt.Errorf("%q cannot be empty: %w", optionURL, ErrExternalOptions)
+	}
+
+	// The default leaves time for a retry, and to spool the batch, before the
+	// audit broker gives up on the request.
+	requestTimeout, ok := conf.Config[optionRequestTimeout]
+	if !ok {
+		requestTimeout = "5s"
+	}
+
+	tlsConfig, err := newHTTPTLSConfig(conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	sinkOpts := []event.Option{
+		event.WithMaxDuration(requestTimeout),
+		event.WithBatchSize(conf.Config[optionBatchSize]),
+		event.WithFlushInterval(conf.Config[optionFlushInterval]),
+		event.WithMaxRetries(conf.Config[optionMaxRetries]),
+		event.WithRetryMinBackoff(conf.Config[optionRetryMinBackoff]),
+		event.WithRetryMaxBackoff(conf.Config[optionRetryMaxBackoff]),
+		event.WithSpoolDir(conf.Config[optionSpoolDir]),
+		event.WithSpoolMaxBytes(conf.Config[optionSpoolMaxBytes]),
+		event.WithTLSConfig(tlsConfig),
+		event.WithLogger(conf.Logger),
+	}
+
+	err = event.ValidateOptions(sinkOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &httpBackend{backend: bec}
+
+	// Configure the sink.
+	cfg, err := newFormatterConfig(headersConfig, conf.Config)
+	if err != nil {
+		return nil, err
+	}
+
+	err = b.configureSinkNode(conf.MountPath, url, cfg.requiredFormat, sinkOpts...)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// newHTTPTLSConfig creates the TLS configuration used to connect to the receiver
+// from the tls_* options. A client certificate and key enable mutual TLS.
+func newHTTPTLSConfig(config map[string]string) (*tls.Config, error) {
+	tlsConfig := &tls.Config{
+		MinVersion: tls.VersionTLS12,
+		ServerName: strings.TrimSpace(config[optionTLSServerName]),
+	}
+
+	if raw, ok := config[optionTLSSkipVerify]; ok {
+		v, err := strconv.ParseBool(raw)
+		if err != nil {
+			return nil, fmt.Errorf("unable to parse %q: %w", optionTLSSkipVerify, ErrExternalOptions)
+		}
+		tlsConfig.InsecureSkipVerify = v
+	}
+
+	if caCert := strings.TrimSpace(config[optionTLSCACert]); caCert != "" {
+		err := rootcerts.ConfigureTLS(tlsConfig, &rootcerts.Config{CAFile: caCert})
+		if err != nil {
+			return nil, fmt.Errorf("unable to load %q: %w: %w", optionTLSCACert, ErrExternalOptions, err)
+		}
+	}
+
+	clientCert := strings.TrimSpace(config[optionTLSClientCert])
+	clientKey := strings.TrimSpace(config[optionTLSClientKey])
+	switch {
+	case clientCert != "" && clientKey != "":
+		cert, err := tls.LoadX509KeyPair(clientCert, clientKey)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w: %w", ErrExternalOptions, err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	case clientCert != "" || clientKey != "":
+		return nil, fmt.Errorf("both %q and %q are required for client authentication: %w", optionTLSClientCert, optionTLSClientKey, ErrExternalOptions)
+	}
+
+	return tlsConfig, nil
+}
+
+func (b *httpBackend) configureSinkNode(name string, url string, format format, opts ...event.Option) error {
+	sinkNodeID, err := event.GenerateNodeID()
+	if err != nil {
+		return fmt.Errorf("error generating random NodeID for sink node: %w", err)
+	}
+
+	n, err := event.NewHTTPSink(url, format.String(), opts...)
+	if err != nil {
+		return err
+	}
+
+	// Wrap the sink node with metrics middleware
+	err = b.wrapMetrics(name, sinkNodeID, n)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
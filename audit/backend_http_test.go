@@ -0,0 +1,200 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-hclog"
+	nshelper "github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/testhelpers/certhelpers"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
+)
+
+// TestHTTPBackend_newHTTPBackend ensures that we can correctly configure the sink
+// node on the Backend, and any incorrect parameters result in the relevant errors.
+func TestHTTPBackend_newHTTPBackend(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		config         map[string]string
+		wantErr        bool
+		expectedErrMsg string
+	}{
+		"url-missing": {
+			config:         map[string]string{},
+			wantErr:        true,
+			expectedErrMsg: "\"url\" is required: invalid configuration",
+		},
+		"url-whitespace": {
+			config:         map[string]string{"url": "   "},
+			wantErr:        true,
+			expectedErrMsg: "\"url\" cannot be empty: invalid configuration",
+		},
+		"url-not-http": {
+			config:         map[string]string{"url": "tcp://localhost:9090"},
+			wantErr:        true,
+			expectedErrMsg: "url must be an absolute http or https url: invalid parameter",
+		},
+		"bad-batch-size": {
+			config:         map[string]string{"url": "https://localhost/audit", "batch_size": "juan"},
+			wantErr:        true,
+			expectedErrMsg: "unable to parse batch size: invalid parameter: strconv.Atoi: parsing \"juan\": invalid syntax",
+		},
+		"bad-request-timeout": {
+			config:         map[string]string{"url": "https://localhost/audit", "request_timeout": "juan"},
+			wantErr:        true,
+			expectedErrMsg: "unable to parse max duration: invalid parameter: time: invalid duration \"juan\"",
+		},
+		"bad-spool-max-bytes": {
+			config:         map[string]string{"url": "https://localhost/audit", "spool_max_bytes": "0"},
+			wantErr:        true,
+			expectedErrMsg: "spool max bytes must be positive: invalid parameter",
+		},
+		"bad-tls-skip-verify": {
+			config:         map[string]string{"url": "https://localhost/audit", "tls_skip_verify": "juan"},
+			wantErr:        true,
+			expectedErrMsg: "unable to parse \"tls_skip_verify\": invalid configuration",
+		},
+		"client-cert-without-key": {
+			config:         map[string]string{"url": "https://localhost/audit", "tls_client_cert": "/tmp/cert.pem"},
+			wantErr:        true,
+			expectedErrMsg: "both \"tls_client_cert\" and \"tls_client_key\" are required for client authentication: invalid configuration",
+		},
+		"happy": {
+			config: map[string]string{
+				"url":            "https://localhost/audit",
+				"batch_size":     "10",
+				"flush_interval": "1s",
+				"max_retries":    "5",
+			},
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg := &BackendConfig{
+				SaltView:   &logical.InmemStorage{},
+				SaltConfig: &salt.Config{},
+				Logger:     hclog.NewNullLogger(),
+				Config:     tc.config,
+				MountPath:  "http",
+			}
+			b, err := newHTTPBackend(cfg, &noopHeaderFormatter{})
+
+			if tc.wantErr {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expectedErrMsg)
+				require.Nil(t, b)
+			} else {
+				require.NoError(t, err)
+				require.Len(t, b.nodeIDList, 2) // formatter + sink
+				require.Len(t, b.nodeMap, 2)
+				n, ok := b.nodeMap[b.nodeIDList[1]]
+				require.True(t, ok)
+				require.Equal(t, eventlogger.NodeTypeSink, n.Type())
+			}
+		})
+	}
+}
+
+// TestHTTPBackend_MutualTLS ensures that the HTTP backend authenticates with the
+// configured client certificate and verifies the receiver with the CA, and
+// that the broker closes the sink once the device is deregistered.
+func TestHTTPBackend_MutualTLS(t *testing.T) {
+	t.Parallel()
+
+	caCert := certhelpers.NewCert(t, certhelpers.CommonName("ca"), certhelpers.IsCA(true), certhelpers.SelfSign())
+	serverCert := certhelpers.NewCert(t, certhelpers.CommonName("receiver"), certhelpers.IP("127.0.0.1"), certhelpers.Parent(caCert))
+	clientCert := certhelpers.NewCert(t, certhelpers.CommonName("vault"), certhelpers.Parent(caCert))
+
+	dir := t.TempDir()
+	caPath := filepath.Join(dir, "ca.pem")
+	certPath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client-key.pem")
+	require.NoError(t, os.WriteFile(caPath, caCert.Pem, 0o600))
+	require.NoError(t, os.WriteFile(certPath, clientCert.Pem, 0o600))
+	require.NoError(t, os.WriteFile(keyPath, clientCert.PrivateKeyPEM(), 0o600))
+
+	ca, err := x509.ParseCertificate(caCert.RawCert)
+	require.NoError(t, err)
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+	received := make(chan string, 10)
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- string(body)
+	}))
+	server.TLS = &tls.Config{
+		Certificates: []tls.Certificate{serverCert.TLSCert},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+	}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	cfg := &BackendConfig{
+		SaltView:   &logical.InmemStorage{},
+		SaltConfig: &salt.Config{},
+		Logger:     hclog.NewNullLogger(),
+		Config: map[string]string{
+			"url":             server.URL,
+			"flush_interval":  "10ms",
+			"tls_ca_cert":     caPath,
+			"tls_client_cert": certPath,
+			"tls_client_key":  keyPath,
+		},
+		MountPath: "http",
+	}
+	be, err := NewHTTPBackend(cfg, &noopHeaderFormatter{})
+	require.NoError(t, err)
+
+	broker, err := NewBroker(hclog.NewNullLogger())
+	require.NoError(t, err)
+	require.NoError(t, broker.Register(be, false))
+
+	ctx := nshelper.RootContext(context.Background())
+	in := &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "secret/foo",
+		},
+	}
+	require.NoError(t, broker.LogRequest(ctx, in))
+
+	select {
+	case body := <-received:
+		lines := strings.Split(strings.TrimSpace(body), "\n")
+		require.Len(t, lines, 1)
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(lines[0]), &entry))
+		require.Equal(t, "request", entry["type"])
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected the receiver to get the audit entry")
+	}
+
+	// Once deregistered, the sink is closed and can't be used anymore.
+	require.NoError(t, broker.Deregister(ctx, "http"))
+	err = be.LogTestMessage(ctx, in)
+	require.Error(t, err)
+	require.ErrorContains(t, err, "is closed")
+}
@@ -70,6 +70,16 @@ type Broker struct {
 
 	// broker is used to register pipelines for audit devices.
 	broker *eventlogger.Broker
+
+	// fallbackBroker is used to register the pipeline of the fallback device,
+	// which receives the audit entries that were not written to any other
+	// device, because every registered device filtered them out.
+	// NOTE: there should only ever be a single device registered on the fallbackBroker.
+	fallbackBroker *eventlogger.Broker
+
+	// fallbackName stores the name (path) of the audit device which has been
+	// configured as the fallback pipeline (its eventlogger.PipelineID).
+	fallbackName string
 }
 
 // NewBroker initializes a broker, which can be used to perform audit logging.
@@ -83,16 +93,22 @@ func NewBroker(logger hclog.Logger) (*Broker, error) {
 		return nil, fmt.Errorf("error creating event broker for audit events: %w", err)
 	}
 
+	fallbackBroker, err := eventlogger.NewBroker()
+	if err != nil {
+		return nil, fmt.Errorf("error creating event fallback broker for audit event: %w", err)
+	}
+
 	ent, err := newBrokerEnt()
 	if err != nil {
 		return nil, fmt.Errorf("error creating audit broker extensions: %w", err)
 	}
 
 	return &Broker{
-		backends:  make(map[string]backendEntry),
-		broker:    eventBroker,
-		brokerEnt: ent,
-		logger:    logger,
+		backends:       make(map[string]backendEntry),
+		broker:         eventBroker,
+		brokerEnt:      ent,
+		fallbackBroker: fallbackBroker,
+		logger:         logger,
 	}, nil
 }
 
@@ -173,6 +189,74 @@ func (b *Broker) deregister(ctx context.Context, name string) error {
 	return nil
 }
 
+// registerFallback can be used to register a fallback device, it will also
+// configure the success threshold required for sinks.
+func (b *Broker) registerFallback(backend Backend) error {
+	err := registerNodesAndPipeline(b.fallbackBroker, backend)
+	if err != nil {
+		return fmt.Errorf("fallback device pipeline registration error: %w", err)
+	}
+
+	// Store the name of the fallback audit device so that we can check when
+	// deregistering if the device is the single fallback one.
+	b.fallbackName = backend.Name()
+
+	// We need to turn on the threshold for the fallback broker, so we can
+	// guarantee it ends up somewhere
+	err = b.fallbackBroker.SetSuccessThresholdSinks(event.AuditType.AsEventType(), 1)
+	if err != nil {
+		return fmt.Errorf("unable to configure fallback sink success threshold (1) for %q: %w", backend.Name(), err)
+	}
+
+	return nil
+}
+
+// deregisterFallback can be used to deregister a fallback audit device, it will
+// also configure the success threshold required for sinks.
+func (b *Broker) deregisterFallback(ctx context.Context, name string) error {
+	err := b.fallbackBroker.SetSuccessThresholdSinks(event.AuditType.AsEventType(), 0)
+	if err != nil {
+		return fmt.Errorf("unable to reconfigure fallback sink success threshold (0): %w", err)
+	}
+
+	_, err = b.fallbackBroker.RemovePipelineAndNodes(ctx, event.AuditType.AsEventType(), eventlogger.PipelineID(name))
+	if err != nil {
+		return fmt.Errorf("unable to deregister fallback device %q: %w", name, err)
+	}
+
+	// Clear the fallback device name now we've deregistered.
+	b.fallbackName = ""
+
+	return nil
+}
+
+// isFallbackRegistered returns whether a fallback device is registered with the broker.
+func (b *Broker) isFallbackRegistered() bool {
+	return b.fallbackName != ""
+}
+
+// logFallback sends an audit entry, which was not written to any other audit
+// device, to the fallback device. When no fallback device is registered, the
+// entry is dropped and the fallback miss metric is emitted so operators can
+// track how many entries are missing from the audit record.
+func (b *Broker) logFallback(ctx context.Context, e *Event) error {
+	if !b.isFallbackRegistered() {
+		metrics.IncrCounter(metricLabelAuditFallbackMiss, 1)
+		return nil
+	}
+
+	status, err := b.fallbackBroker.Send(ctx, event.AuditType.AsEventType(), e)
+	if err != nil {
+		return fmt.Errorf("auditing to fallback device failed: %w", errors.Join(append([]error{err}, status.Warnings...)...))
+	}
+
+	if len(status.Warnings) > 0 {
+		b.logger.Error("fallback device underlying pipeline error(s)", "error", errors.Join(status.Warnings...))
+	}
+
+	return nil
+}
+
 // registerNodesAndPipeline registers eventlogger nodes and a pipeline with the
 // backend's name, on the specified eventlogger.Broker using the Backend to supply them.
 func registerNodesAndPipeline(broker *eventlogger.Broker, b Backend) error {
@@ -421,6 +505,27 @@ func (b *Broker) GetHash(ctx context.Context, name string, input string) (string
 	return hashString(ctx, be.backend, input)
 }
 
+// VerifyHashChain verifies the supplied entries of a hash chained audit log
+// written by the given backend, carrying on the chain from the previous entry
+// (nil if unknown). It returns the offset of the first entry failing
+// verification, or -1 when all entries are verified.
+func (b *Broker) VerifyHashChain(ctx context.Context, name string, previous []byte, entries [][]byte) (int, error) {
+	b.RLock()
+	defer b.RUnlock()
+
+	be, ok := b.backends[name]
+	if !ok {
+		return 0, fmt.Errorf("unknown audit backend %q", name)
+	}
+
+	salt, err := be.backend.Salt(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	return verifyHashChain(salt, previous, entries)
+}
+
 // IsRegistered is used to check if a given audit backend is registered.
 func (b *Broker) IsRegistered(name string) bool {
 	b.RLock()
@@ -17,11 +17,26 @@ func newBrokerEnt() (*brokerEnt, error) {
 	return &brokerEnt{}, nil
 }
 
-func (b *Broker) validateRegistrationRequest(_ Backend) error {
+// validateRegistrationRequest ensures that only a single fallback device can be
+// registered with the broker.
+func (b *Broker) validateRegistrationRequest(backend Backend) error {
+	if backend.IsFallback() && b.isFallbackRegistered() {
+		return fmt.Errorf("vault only supports a single fallback audit device, %q is already the fallback: %w", b.fallbackName, ErrExternalOptions)
+	}
+
 	return nil
 }
 
 func (b *Broker) handlePipelineRegistration(backend Backend) error {
+	if backend.IsFallback() {
+		err := b.registerFallback(backend)
+		if err != nil {
+			return fmt.Errorf("unable to register fallback device for %q: %w", backend.Name(), err)
+		}
+
+		return nil
+	}
+
 	err := b.register(backend)
 	if err != nil {
 		return fmt.Errorf("unable to register device for %q: %w", backend.Name(), err)
@@ -31,19 +46,29 @@ func (b *Broker) handlePipelineRegistration(backend Backend) error {
 }
 
 func (b *Broker) handlePipelineDeregistration(ctx context.Context, name string) error {
+	if name == b.fallbackName {
+		return b.deregisterFallback(ctx, name)
+	}
+
 	return b.deregister(ctx, name)
 }
 
 // requiredSuccessThresholdSinks is the value that should be used as the success
-// threshold in the eventlogger broker.
+// threshold in the eventlogger broker. Entries are sent to every device without
+// a filter, so one of them must succeed. Entries filtered out by all filtered
+// devices are sent to the fallback device, which is registered separately.
 func (b *Broker) requiredSuccessThresholdSinks() int {
-	if len(b.backends) > 0 {
-		return 1
+	for _, be := range b.backends {
+		if !be.backend.HasFiltering() && !be.backend.IsFallback() {
+			return 1
+		}
 	}
 
 	return 0
 }
 
-func (b *brokerEnt) handleAdditionalAudit(_ context.Context, _ *Event) error {
-	return nil
+// handleAdditionalAudit is called when an entry was not written to any audit
+// device, and sends it to the fallback device.
+func (b *Broker) handleAdditionalAudit(ctx context.Context, e *Event) error {
+	return b.logFallback(ctx, e)
 }
@@ -6,6 +6,8 @@ package audit
 import (
 	"context"
 	"crypto/sha256"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
@@ -88,6 +90,132 @@ func TestAuditBroker_Register_MultipleFails(t *testing.T) {
 	require.EqualError(t, err, "backend already registered 'b2-no-filter': invalid configuration")
 }
 
+// testFileAuditBackend will create a file audit backend which writes to a file
+// in a temporary directory, and returns the backend and the path of the file.
+func testFileAuditBackend(t *testing.T, name string, config map[string]string) (Backend, string) {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	config["file_path"] = path
+
+	backendConfig := &BackendConfig{
+		Config:     config,
+		MountPath:  name,
+		SaltConfig: &salt.Config{},
+		SaltView:   &logical.InmemStorage{},
+		Logger:     corehelpers.NewTestLogger(t),
+	}
+
+	be, err := NewFileBackend(backendConfig, &noopHeaderFormatter{})
+	require.NoError(t, err)
+	require.NotNil(t, be)
+
+	return be, path
+}
+
+// TestAuditBroker_LogRequest_Filtered ensures that requests filtered out by
+// every registered device are not audited and don't fail, while requests
+// matching the filter are written to the device.
+func TestAuditBroker_LogRequest_Filtered(t *testing.T) {
+	t.Parallel()
+
+	be, path := testFileAuditBackend(t, "filtered", map[string]string{
+		"filter": "operation == \"create\"",
+	})
+	require.True(t, be.HasFiltering())
+
+	broker, err := NewBroker(corehelpers.NewTestLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, broker.Register(be, false))
+
+	ctx := nshelper.RootContext(context.Background())
+	in := &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.ReadOperation,
+			Path:      "secret/foo",
+		},
+	}
+	require.NoError(t, broker.LogRequest(ctx, in))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Empty(t, data)
+
+	in.Request.Operation = logical.CreateOperation
+	require.NoError(t, broker.LogRequest(ctx, in))
+
+	data, err = os.ReadFile(path)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"operation":"create"`)
+}
+
+// TestAuditBroker_Fallback ensures that the fallback device receives only the
+// entries which were not written to any other device.
+func TestAuditBroker_Fallback(t *testing.T) {
+	t.Parallel()
+
+	filtered, filteredPath := testFileAuditBackend(t, "filtered", map[string]string{
+		"filter": "operation == \"create\"",
+	})
+	fallback, fallbackPath := testFileAuditBackend(t, "fallback", map[string]string{
+		"fallback": "true",
+	})
+	require.True(t, fallback.IsFallback())
+
+	broker, err := NewBroker(corehelpers.NewTestLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, broker.Register(filtered, false))
+	require.NoError(t, broker.Register(fallback, false))
+	require.Equal(t, "fallback", broker.fallbackName)
+
+	ctx := nshelper.RootContext(context.Background())
+	in := &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.CreateOperation,
+			Path:      "secret/foo",
+		},
+	}
+	require.NoError(t, broker.LogRequest(ctx, in))
+	in.Request.Operation = logical.ReadOperation
+	require.NoError(t, broker.LogResponse(ctx, in))
+
+	data, err := os.ReadFile(filteredPath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"operation":"create"`)
+	require.NotContains(t, string(data), `"operation":"read"`)
+
+	data, err = os.ReadFile(fallbackPath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"operation":"read"`)
+	require.NotContains(t, string(data), `"operation":"create"`)
+
+	// Once the fallback is removed, filtered out entries are dropped.
+	require.NoError(t, broker.Deregister(ctx, "fallback"))
+	require.Empty(t, broker.fallbackName)
+	require.NoError(t, broker.LogRequest(ctx, in))
+}
+
+// TestAuditBroker_Register_MultipleFallbacks ensures that only a single
+// fallback device can be registered.
+func TestAuditBroker_Register_MultipleFallbacks(t *testing.T) {
+	t.Parallel()
+
+	broker, err := NewBroker(corehelpers.NewTestLogger(t))
+	require.NoError(t, err)
+
+	fallback, _ := testFileAuditBackend(t, "fallback", map[string]string{"fallback": "true"})
+	require.NoError(t, broker.Register(fallback, false))
+
+	fallback2, _ := testFileAuditBackend(t, "fallback2", map[string]string{"fallback": "true"})
+	err = broker.Register(fallback2, false)
+	require.EqualError(t, err, `vault only supports a single fallback audit device, "fallback" is already the fallback: invalid configuration`)
+	require.False(t, broker.IsRegistered("fallback2"))
+
+	// Once the fallback is removed, another device can become the fallback.
+	require.NoError(t, broker.Deregister(context.Background(), "fallback"))
+	require.NoError(t, broker.Register(fallback2, false))
+}
+
 // BenchmarkAuditBroker_File_Request_DevNull Attempts to register a single `file`
 // audit device on the broker, which points at /dev/null.
 // It will then attempt to benchmark how long it takes Vault to complete logging
@@ -18,6 +18,10 @@ var (
 	// ErrExternalOptions should be used to represent an error related to
 	// invalid configuration provided to Vault (i.e. by the Vault Operator).
 	ErrExternalOptions = errors.New("invalid configuration")
+
+	// ErrHashChain should be used to represent an entry of a hash chained audit
+	// log which failed verification.
+	ErrHashChain = errors.New("hash chain verification failed")
 )
 
 // ConvertToExternalError handles converting an audit related error that was generated
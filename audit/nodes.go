@@ -74,6 +74,13 @@ func processManual(ctx context.Context, data *logical.LogInput, ids []eventlogge
 			if formatNode, ok := node.(*entryFormatter); ok && formatNode != nil {
 				e, err = newTemporaryEntryFormatter(formatNode).Process(ctx, e)
 			}
+		case eventlogger.NodeTypeSink:
+			// Hash chained sinks require the salt, so the test message isn't written.
+			if chainNode := unwrapSinkHashChain(node); chainNode != nil {
+				e, err = chainNode.processTestMessage(e)
+			} else {
+				e, err = node.Process(ctx, e)
+			}
 		default:
 			e, err = node.Process(ctx, e)
 		}
@@ -0,0 +1,557 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/vault/sdk/helper/salt"
+)
+
+const (
+	chainHMACField     = "chain_hmac"
+	chainSequenceField = "chain_sequence"
+
+	// chainKeyPurpose is used to derive the chain key from the salt of the audit
+	// device, so that chained HMACs can't be computed using sys/audit-hash.
+	chainKeyPurpose = "audit-hash-chain"
+	chainHMACType   = "hmac-sha256"
+)
+
+var (
+	_ eventlogger.Node          = (*sinkHashChain)(nil)
+	_ eventlogger.NodeUnwrapper = (*sinkHashChain)(nil)
+
+	chainHMACPrefix     = []byte(`{"` + chainHMACField + `":"`)
+	chainSequencePrefix = []byte(`{"` + chainSequenceField + `":`)
+
+	errChainHMACMismatch = fmt.Errorf("%q mismatch, the entry or the one preceding it was modified: %w", chainHMACField, ErrHashChain)
+)
+
+// sinkHashChain is a wrapper for any kind of eventlogger.NodeTypeSink node that
+// processes events formatted as JSON.
+// It decorates the implemented eventlogger.Node Process method in order to make
+// the audit log tamper-evident: each entry is given a sequence number and an HMAC
+// chaining it to the previous entry, keyed using a chain key derived from the
+// salt of the audit device. The chain key is never exposed, unlike the HMACs
+// computed with the salt itself by sys/audit-hash.
+// Entries are written by the underlying sink in the order of their sequence number.
+//
+// The chained HMAC of an entry is computed over the HMAC of the previous entry
+// (empty for the first entry of a chain) followed by the entry, which includes
+// its sequence number but not its own HMAC. e.g.
+//
+//	{"chain_hmac":"hmac-sha256:...","chain_sequence":1,"auth":...}
+//
+// When the audit device is created again (e.g. when Vault is unsealed), the
+// chain carries on from the last entry written by the underlying sink, if it
+// supports reading it back.
+type sinkHashChain struct {
+	sink   eventlogger.Node
+	salter Salter
+
+	// lock guards the state of the chain and ensures entries are written in order.
+	lock     sync.Mutex
+	resumed  bool
+	sequence uint64
+	prevHMAC string
+
+	// key is the chain key derived from keySalt, the salt of the audit device.
+	key     []byte
+	keySalt *salt.Salt
+}
+
+// chainHistory is implemented by sinks which can read back the last entries
+// they wrote (e.g. event.FileSink), oldest first.
+type chainHistory interface {
+	LastEntries(n int) ([][]byte, error)
+}
+
+// newSinkHashChain should be used to create the sinkHashChain.
+// It expects that an eventlogger.NodeTypeSink should be supplied as the sink.
+func newSinkHashChain(sink eventlogger.Node, salter Salter) (*sinkHashChain, error) {
+	if sink == nil || reflect.ValueOf(sink).IsNil() {
+		return nil, fmt.Errorf("sink node is required: %w", ErrInvalidParameter)
+	}
+
+	if sink.Type() != eventlogger.NodeTypeSink {
+		return nil, fmt.Errorf("sink node must be of type 'sink': %w", ErrInvalidParameter)
+	}
+
+	if salter == nil || reflect.ValueOf(salter).IsNil() {
+		return nil, fmt.Errorf("salter is required: %w", ErrInvalidParameter)
+	}
+
+	return &sinkHashChain{
+		sink:   sink,
+		salter: salter,
+	}, nil
+}
+
+// Process adds the next sequence number and chained HMAC to the JSON formatted
+// entry of the supplied eventlogger.Event, then passes it to the underlying sink.
+// The chain only advances when the underlying sink succeeds, so that an entry
+// which could not be written doesn't leave a gap in the chain.
+func (s *sinkHashChain) Process(ctx context.Context, e *eventlogger.Event) (*eventlogger.Event, error) {
+	if e == nil {
+		return nil, fmt.Errorf("event is nil: %w", ErrInvalidParameter)
+	}
+
+	formatted, found := e.Format(jsonFormat.String())
+	if !found {
+		return nil, fmt.Errorf("unable to retrieve event formatted as %q: %w", jsonFormat, ErrInvalidParameter)
+	}
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	key, err := s.chainKey(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if !s.resumed {
+		if err := s.resume(key); err != nil {
+			return nil, fmt.Errorf("unable to resume hash chain: %w", err)
+		}
+		s.resumed = true
+	}
+
+	sequence := s.sequence + 1
+	unsigned, err := newChainUnsignedEntry(formatted, sequence)
+	if err != nil {
+		return nil, err
+	}
+
+	hmac := chainHMAC(key, s.prevHMAC+string(unsigned))
+
+	// Create a new event, so we can store our chained data without conflict.
+	e2 := &eventlogger.Event{
+		Type:      e.Type,
+		CreatedAt: e.CreatedAt,
+		Formatted: make(map[string][]byte),
+		Payload:   e.Payload,
+	}
+	e2.FormattedAs(jsonFormat.String(), newChainSignedEntry(unsigned, hmac))
+
+	result, err := s.sink.Process(ctx, e2)
+	if err != nil {
+		return nil, err
+	}
+
+	s.sequence = sequence
+	s.prevHMAC = hmac
+
+	return result, nil
+}
+
+// chainKey returns the chain key derived from the salt of the audit device,
+// which is only derived again when the salt changes.
+// NOTE: chainKey expects the caller to hold the lock.
+func (s *sinkHashChain) chainKey(ctx context.Context) ([]byte, error) {
+	salt, err := s.salter.Salt(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get salt for hash chain: %w", err)
+	}
+
+	if salt != s.keySalt {
+		key, err := newChainKey(salt)
+		if err != nil {
+			return nil, err
+		}
+		s.key = key
+		s.keySalt = salt
+	}
+
+	return s.key, nil
+}
+
+// resume carries on the chain from the last entry written by the underlying
+// sink, so that entries removed from the end of the log before the audit device
+// was created are detected. The last entry is only trusted when its HMAC is
+// verified with the chain key of the audit device, otherwise a new chain is
+// started.
+// NOTE: resume expects the caller to hold the lock.
+func (s *sinkHashChain) resume(key []byte) error {
+	h, ok := s.sink.(chainHistory)
+	if !ok {
+		return nil
+	}
+
+	entries, err := h.LastEntries(2)
+	if err != nil || len(entries) == 0 {
+		return err
+	}
+
+	hmac, sequence, unsigned, err := parseChainEntry(entries[len(entries)-1])
+	if err != nil {
+		return nil
+	}
+
+	var prevHMAC string
+	if sequence > 1 {
+		if len(entries) < 2 {
+			return nil
+		}
+
+		var prevSequence uint64
+		prevHMAC, prevSequence, _, err = parseChainEntry(entries[0])
+		if err != nil || prevSequence != sequence-1 {
+			return nil
+		}
+	}
+
+	if !chainHMACEqual(chainHMAC(key, prevHMAC+string(unsigned)), hmac) {
+		return nil
+	}
+
+	s.sequence = sequence
+	s.prevHMAC = hmac
+
+	return nil
+}
+
+// processTestMessage ensures that the entry of the supplied eventlogger.Event
+// can be chained, without writing it.
+// Test messages are processed before the salt of the audit device can be
+// persisted, and an entry chained using a temporary salt would fail verification.
+func (s *sinkHashChain) processTestMessage(e *eventlogger.Event) (*eventlogger.Event, error) {
+	if e == nil {
+		return nil, fmt.Errorf("event is nil: %w", ErrInvalidParameter)
+	}
+
+	formatted, found := e.Format(jsonFormat.String())
+	if !found {
+		return nil, fmt.Errorf("unable to retrieve event formatted as %q: %w", jsonFormat, ErrInvalidParameter)
+	}
+
+	if _, err := newChainUnsignedEntry(formatted, 1); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Reopen wraps the Reopen method of this underlying sink (eventlogger.Node).
+func (s *sinkHashChain) Reopen() error {
+	return s.sink.Reopen()
+}
+
+// Type wraps the Type method of this underlying sink (eventlogger.Node).
+func (s *sinkHashChain) Type() eventlogger.NodeType {
+	return s.sink.Type()
+}
+
+// Unwrap returns the underlying sink (eventlogger.Node), so that the eventlogger
+// can close it when the node is removed.
+func (s *sinkHashChain) Unwrap() eventlogger.Node {
+	return s.sink
+}
+
+// unwrapSinkHashChain returns the sinkHashChain wrapped by the supplied node, or
+// nil when the node doesn't wrap one.
+func unwrapSinkHashChain(n eventlogger.Node) *sinkHashChain {
+	for n != nil {
+		if c, ok := n.(*sinkHashChain); ok {
+			return c
+		}
+
+		u, ok := n.(eventlogger.NodeUnwrapper)
+		if !ok {
+			return nil
+		}
+		n = u.Unwrap()
+	}
+
+	return nil
+}
+
+// newChainKey derives the chain key from the supplied salt of an audit device.
+func newChainKey(salt *salt.Salt) ([]byte, error) {
+	key, err := salt.DeriveKey(chainKeyPurpose, sha256.Size)
+	if err != nil {
+		return nil, fmt.Errorf("unable to derive hash chain key: %w", err)
+	}
+
+	return key, nil
+}
+
+// chainHMAC returns the identified HMAC of the supplied input, keyed with the
+// chain key.
+func chainHMAC(key []byte, input string) string {
+	hm := hmac.New(sha256.New, key)
+	hm.Write([]byte(input))
+	return chainHMACType + ":" + hex.EncodeToString(hm.Sum(nil))
+}
+
+// chainHMACEqual compares chained HMACs in constant time.
+func chainHMACEqual(a, b string) bool {
+	return hmac.Equal([]byte(a), []byte(b))
+}
+
+// newChainUnsignedEntry adds the sequence number to the supplied JSON object,
+// which is returned without its trailing newline.
+func newChainUnsignedEntry(formatted []byte, sequence uint64) ([]byte, error) {
+	formatted = bytes.TrimRight(formatted, "\r\n")
+	if len(formatted) < 2 || formatted[0] != '{' {
+		return nil, fmt.Errorf("hash chain requires entries formatted as a JSON object: %w", ErrInvalidParameter)
+	}
+
+	unsigned := make([]byte, 0, len(formatted)+len(chainSequencePrefix)+21)
+	unsigned = append(unsigned, chainSequencePrefix...)
+	unsigned = strconv.AppendUint(unsigned, sequence, 10)
+	if formatted[1] != '}' {
+		unsigned = append(unsigned, ',')
+	}
+	unsigned = append(unsigned, formatted[1:]...)
+
+	return unsigned, nil
+}
+
+// newChainSignedEntry adds the chained HMAC to the supplied unsigned entry, and
+// terminates it with a newline.
+func newChainSignedEntry(unsigned []byte, hmac string) []byte {
+	signed := make([]byte, 0, len(unsigned)+len(chainHMACPrefix)+len(hmac)+3)
+	signed = append(signed, chainHMACPrefix...)
+	signed = append(signed, hmac...)
+	signed = append(signed, '"', ',')
+	signed = append(signed, unsigned[1:]...)
+	signed = append(signed, '\n')
+
+	return signed
+}
+
+// parseChainEntry splits an entry of a hash chained audit log into its chained
+// HMAC, its sequence number and the unsigned entry the HMAC was computed over.
+func parseChainEntry(line []byte) (string, uint64, []byte, error) {
+	line = bytes.TrimRight(line, "\r\n")
+	if !bytes.HasPrefix(line, chainHMACPrefix) {
+		return "", 0, nil, fmt.Errorf("entry has no %q: %w", chainHMACField, ErrHashChain)
+	}
+
+	rest := line[len(chainHMACPrefix):]
+	end := bytes.IndexByte(rest, '"')
+	if end < 0 || len(rest) < end+2 || rest[end+1] != ',' {
+		return "", 0, nil, fmt.Errorf("entry has a malformed %q: %w", chainHMACField, ErrHashChain)
+	}
+	hmac := string(rest[:end])
+	unsigned := append([]byte{'{'}, rest[end+2:]...)
+
+	if !bytes.HasPrefix(unsigned, chainSequencePrefix) || !json.Valid(unsigned) {
+		return "", 0, nil, fmt.Errorf("entry has no %q: %w", chainSequenceField, ErrHashChain)
+	}
+	digits := unsigned[len(chainSequencePrefix):]
+	if end := bytes.IndexAny(digits, ",}"); end >= 0 {
+		digits = digits[:end]
+	}
+	sequence, err := strconv.ParseUint(string(digits), 10, 64)
+	if err != nil || sequence == 0 {
+		return "", 0, nil, fmt.Errorf("entry has a malformed %q: %w", chainSequenceField, ErrHashChain)
+	}
+
+	return hmac, sequence, unsigned, nil
+}
+
+// ChainVerification describes the result of verifying an entry of a hash chained
+// audit log.
+type ChainVerification struct {
+	// Sequence is the sequence number of the entry.
+	Sequence uint64
+
+	// Restarted indicates that the entry starts a new chain, which happens when
+	// the audit device is created and the chain can't be carried on from the
+	// last entry of the log (e.g. the audit device was enabled again with a new
+	// salt).
+	Restarted bool
+
+	// Unverified indicates that the chained HMAC of the entry could not be
+	// verified, as it is the first entry supplied and the previous entry of its
+	// chain is unknown.
+	Unverified bool
+}
+
+// ChainVerifier verifies the entries of a hash chained audit log, which must be
+// supplied in the order they were written. The chained HMACs are verified with
+// the chain key of the audit device, which only Vault holds, so a verifier
+// created outside of Vault delegates their verification to Vault (e.g. using
+// the sys/audit-verify API).
+// NOTE: Use NewChainVerifier or NewRemoteChainVerifier to initialize the
+// ChainVerifier struct.
+type ChainVerifier struct {
+	key      []byte
+	check    ChainCheckFunc
+	started  bool
+	sequence uint64
+	prevHMAC string
+
+	// prevEntry is the last entry which could be parsed, which the chain
+	// carries on from.
+	prevEntry []byte
+}
+
+// ChainCheckFunc verifies the supplied entries of a hash chained audit log,
+// carrying on the chain from the previous entry (nil if unknown). It returns
+// the offset of the first entry failing verification, or -1 when all entries
+// are verified.
+type ChainCheckFunc func(previous []byte, entries [][]byte) (int, error)
+
+// NewChainVerifier should be used to create a ChainVerifier using the chain key
+// of the audit device.
+func NewChainVerifier(key []byte) (*ChainVerifier, error) {
+	if len(key) == 0 {
+		return nil, fmt.Errorf("key is required: %w", ErrInvalidParameter)
+	}
+
+	return &ChainVerifier{key: key}, nil
+}
+
+// NewRemoteChainVerifier should be used to create a ChainVerifier which doesn't
+// hold the chain key of the audit device. The sequence of the entries is
+// verified locally, while the check function verifies their chained HMACs.
+func NewRemoteChainVerifier(check ChainCheckFunc) (*ChainVerifier, error) {
+	if check == nil {
+		return nil, fmt.Errorf("check function is required: %w", ErrInvalidParameter)
+	}
+
+	return &ChainVerifier{check: check}, nil
+}
+
+// Verify verifies the next entry of the audit log. An error wrapping ErrHashChain
+// is returned when the entry is not chained, when entries are missing or out of
+// order, or when the entry (or the one preceding it) was modified. Verification
+// carries on from the supplied entry, so that all problems can be reported.
+func (v *ChainVerifier) Verify(line []byte) (*ChainVerification, error) {
+	results, errs, err := v.VerifyBatch([][]byte{line})
+	if err != nil {
+		return nil, err
+	}
+
+	return results[0], errs[0]
+}
+
+// VerifyBatch verifies the next entries of the audit log like Verify. The
+// result and the error wrapping ErrHashChain of each entry are returned in the
+// order of the entries. The returned error is only set when the check function
+// of a remote verifier fails.
+func (v *ChainVerifier) VerifyBatch(lines [][]byte) ([]*ChainVerification, []error, error) {
+	results := make([]*ChainVerification, len(lines))
+	errs := make([]error, len(lines))
+
+	// previous holds the entry the chain carries on from before each entry.
+	previous := make([][]byte, len(lines))
+	for i, line := range lines {
+		previous[i] = v.prevEntry
+
+		hmac, input, result, err := v.next(line)
+		results[i], errs[i] = result, err
+		if err == nil && input != "" && v.key != nil && !chainHMACEqual(chainHMAC(v.key, input), hmac) {
+			errs[i] = errChainHMACMismatch
+		}
+	}
+
+	if v.check == nil {
+		return results, errs, nil
+	}
+
+	// The check function only reports the first entry failing verification, so
+	// check the entries following it again, carrying on from it.
+	for start := 0; start < len(lines); {
+		offset, err := v.check(previous[start], lines[start:])
+		if err != nil {
+			return nil, nil, fmt.Errorf("unable to verify chained hmac: %w", err)
+		}
+		if offset < 0 || start+offset >= len(lines) {
+			break
+		}
+
+		i := start + offset
+		if errs[i] == nil {
+			errs[i] = errChainHMACMismatch
+		}
+		start = i + 1
+	}
+
+	return results, errs, nil
+}
+
+// next checks the sequence number of the next entry of the audit log, and
+// advances the chain. The chained HMAC of the entry is returned with the input
+// it must be the HMAC of, unless the input is empty as the HMAC can't be
+// verified.
+func (v *ChainVerifier) next(line []byte) (string, string, *ChainVerification, error) {
+	hmac, sequence, unsigned, err := parseChainEntry(line)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	result := &ChainVerification{Sequence: sequence}
+	expected := v.sequence + 1
+	started := v.started
+	prevHMAC := v.prevHMAC
+
+	v.started = true
+	v.sequence = sequence
+	v.prevHMAC = hmac
+	v.prevEntry = line
+
+	switch {
+	case sequence == 1:
+		result.Restarted = started
+		prevHMAC = ""
+	case !started:
+		result.Unverified = true
+		return "", "", result, nil
+	case sequence == expected+1:
+		return "", "", result, fmt.Errorf("entry %d is missing: %w", expected, ErrHashChain)
+	case sequence > expected:
+		return "", "", result, fmt.Errorf("entries %d to %d are missing: %w", expected, sequence-1, ErrHashChain)
+	case sequence < expected:
+		return "", "", result, fmt.Errorf("entry is out of order, expected sequence %d: %w", expected, ErrHashChain)
+	}
+
+	return hmac, prevHMAC + string(unsigned), result, nil
+}
+
+// verifyHashChain verifies the supplied entries of a hash chained audit log with
+// the chain key derived from the supplied salt, carrying on the chain from the
+// previous entry (nil if unknown). It returns the offset of the first entry
+// failing verification, or -1 when all entries are verified.
+func verifyHashChain(salt *salt.Salt, previous []byte, entries [][]byte) (int, error) {
+	key, err := newChainKey(salt)
+	if err != nil {
+		return 0, err
+	}
+
+	v, err := NewChainVerifier(key)
+	if err != nil {
+		return 0, err
+	}
+
+	if len(previous) > 0 {
+		// Only the chain state matters, the previous entry was already verified.
+		_, _ = v.Verify(previous)
+	}
+
+	_, errs, err := v.VerifyBatch(entries)
+	if err != nil {
+		return 0, err
+	}
+	for i, err := range errs {
+		if err != nil {
+			return i, nil
+		}
+	}
+
+	return -1, nil
+}
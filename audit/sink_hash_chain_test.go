@@ -0,0 +1,347 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+
+	"github.com/hashicorp/eventlogger"
+	nshelper "github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
+	"github.com/hashicorp/vault/internal/observability/event"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNewSinkHashChain ensures that parameters are checked correctly and errors
+// reported as expected when attempting to create a sinkHashChain.
+func TestNewSinkHashChain(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		node                 eventlogger.Node
+		salter               Salter
+		isErrorExpected      bool
+		expectedErrorMessage string
+	}{
+		"happy": {
+			node:   &event.FileSink{},
+			salter: newStaticSalt(t),
+		},
+		"no-node": {
+			node:                 nil,
+			salter:               newStaticSalt(t),
+			isErrorExpected:      true,
+			expectedErrorMessage: "sink node is required: invalid internal parameter",
+		},
+		"bad-node": {
+			node:                 &entryFormatter{},
+			salter:               newStaticSalt(t),
+			isErrorExpected:      true,
+			expectedErrorMessage: "sink node must be of type 'sink': invalid internal parameter",
+		},
+		"no-salter": {
+			node:                 &event.FileSink{},
+			isErrorExpected:      true,
+			expectedErrorMessage: "salter is required: invalid internal parameter",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			s, err := newSinkHashChain(tc.node, tc.salter)
+
+			switch {
+			case tc.isErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expectedErrorMessage)
+				require.Nil(t, s)
+			default:
+				require.NoError(t, err)
+				require.NotNil(t, s)
+				require.Equal(t, eventlogger.NodeTypeSink, s.Type())
+				require.Equal(t, tc.node, s.Unwrap())
+			}
+		})
+	}
+}
+
+// TestSinkHashChain_Process ensures that the sequence number and chained HMAC
+// are added to the entry, and that the chain only advances when the entry is
+// written by the underlying sink.
+func TestSinkHashChain_Process(t *testing.T) {
+	t.Parallel()
+
+	salter := newStaticSalt(t)
+	path := t.TempDir() + "/audit.log"
+	sink, err := event.NewFileSink(path, jsonFormat.String())
+	require.NoError(t, err)
+	s, err := newSinkHashChain(sink, salter)
+	require.NoError(t, err)
+
+	e := &eventlogger.Event{Formatted: make(map[string][]byte)}
+	e.FormattedAs(jsonFormat.String(), []byte("{\"type\":\"request\"}\n"))
+	_, err = s.Process(context.Background(), e)
+	require.NoError(t, err)
+
+	unsigned := `{"chain_sequence":1,"type":"request"}`
+	key, err := newChainKey(salter.salt)
+	require.NoError(t, err)
+	hmac := chainHMAC(key, unsigned)
+	// The chained HMAC can't be obtained from sys/audit-hash.
+	require.NotEqual(t, salter.salt.GetIdentifiedHMAC(unsigned), hmac)
+	got, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, fmt.Sprintf("{\"chain_hmac\":%q,\"chain_sequence\":1,\"type\":\"request\"}\n", hmac), string(got))
+
+	// Entries which are not JSON objects are rejected, without advancing the chain.
+	e.FormattedAs(jsonFormat.String(), []byte("request\n"))
+	_, err = s.Process(context.Background(), e)
+	require.EqualError(t, err, "hash chain requires entries formatted as a JSON object: invalid internal parameter")
+	require.Equal(t, uint64(1), s.sequence)
+	require.Equal(t, hmac, s.prevHMAC)
+}
+
+// TestSinkHashChain_Resume ensures that the chain carries on from the last entry
+// of the file when the sink is created again with the same salt, and that a new
+// chain is started otherwise.
+func TestSinkHashChain_Resume(t *testing.T) {
+	t.Parallel()
+
+	salter := newStaticSalt(t)
+	path := t.TempDir() + "/audit.log"
+
+	process := func(salter Salter, count int) {
+		sink, err := event.NewFileSink(path, jsonFormat.String())
+		require.NoError(t, err)
+		defer sink.Close(context.Background())
+		s, err := newSinkHashChain(sink, salter)
+		require.NoError(t, err)
+
+		for i := 0; i < count; i++ {
+			e := &eventlogger.Event{Formatted: make(map[string][]byte)}
+			e.FormattedAs(jsonFormat.String(), []byte("{\"type\":\"request\"}\n"))
+			_, err = s.Process(context.Background(), e)
+			require.NoError(t, err)
+		}
+	}
+
+	sequences := func() []uint64 {
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+
+		var got []uint64
+		for _, line := range bytes.SplitAfter(bytes.TrimSpace(data), []byte("\n")) {
+			_, sequence, _, err := parseChainEntry(line)
+			require.NoError(t, err)
+			got = append(got, sequence)
+		}
+		return got
+	}
+
+	process(salter, 1)
+	process(salter, 2)
+	require.Equal(t, []uint64{1, 2, 3}, sequences())
+
+	// The last entry can't be verified once it was modified.
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	i := bytes.LastIndex(data, []byte(`"type":"request"`))
+	data = append(data[:i:i], []byte(`"type":"response"}`+"\n")...)
+	require.NoError(t, os.WriteFile(path, data, 0o600))
+	process(salter, 1)
+	require.Equal(t, []uint64{1, 2, 3, 1}, sequences())
+
+	process(salter, 1)
+	require.Equal(t, []uint64{1, 2, 3, 1, 2}, sequences())
+
+	// Nor can it be verified with another salt.
+	process(newStaticSalt(t), 1)
+	require.Equal(t, []uint64{1, 2, 3, 1, 2, 1}, sequences())
+}
+
+// TestChainVerifier_Verify ensures that the entries written by a file audit
+// device with a hash chain are verified, and that gaps, reordering and edits
+// are detected.
+func TestChainVerifier_Verify(t *testing.T) {
+	t.Parallel()
+
+	be, path := testFileAuditBackend(t, "file", map[string]string{"hash_chain": "true"})
+	broker, err := NewBroker(corehelpers.NewTestLogger(t))
+	require.NoError(t, err)
+	require.NoError(t, broker.Register(be, false))
+
+	// Entries are written in the order of their sequence number, even when
+	// requests are audited concurrently.
+	ctx := nshelper.RootContext(context.Background())
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			in := &logical.LogInput{
+				Request: &logical.Request{
+					Operation: logical.UpdateOperation,
+					Path:      fmt.Sprintf("secret/%d", i),
+				},
+			}
+			assert.NoError(t, broker.LogRequest(ctx, in))
+		}(i)
+	}
+	wg.Wait()
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	lines := bytes.SplitAfter(bytes.TrimSpace(data), []byte("\n"))
+	require.Len(t, lines, 10)
+
+	salt, err := be.Salt(ctx)
+	require.NoError(t, err)
+	key, err := newChainKey(salt)
+	require.NoError(t, err)
+
+	// The entries are verified with the chain key, or by the broker for a
+	// remote verifier, which must report the same errors.
+	var checkCalls int
+	check := func(previous []byte, entries [][]byte) (int, error) {
+		checkCalls++
+		return broker.VerifyHashChain(ctx, "file", previous, entries)
+	}
+
+	verify := func(lines [][]byte) []error {
+		v, err := NewChainVerifier(key)
+		require.NoError(t, err)
+
+		var errs []error
+		for _, line := range lines {
+			_, err := v.Verify(line)
+			if err != nil {
+				require.ErrorIs(t, err, ErrHashChain)
+				errs = append(errs, err)
+			}
+		}
+
+		remote, err := NewRemoteChainVerifier(check)
+		require.NoError(t, err)
+		_, remoteErrs, err := remote.VerifyBatch(lines)
+		require.NoError(t, err)
+		var got []error
+		for _, err := range remoteErrs {
+			if err != nil {
+				got = append(got, err)
+			}
+		}
+		require.Equal(t, errs, got)
+
+		return errs
+	}
+
+	require.Empty(t, verify(lines))
+
+	// A batch of entries is checked at once when all are verified.
+	v, err := NewRemoteChainVerifier(check)
+	require.NoError(t, err)
+	checkCalls = 0
+	results, errs, err := v.VerifyBatch(lines)
+	require.NoError(t, err)
+	require.Equal(t, 1, checkCalls)
+	require.Len(t, results, 10)
+	require.Equal(t, make([]error, 10), errs)
+	require.Equal(t, &ChainVerification{Sequence: 10}, results[9])
+
+	// Entries can't be verified with the salt itself, as returned by
+	// sys/audit-hash.
+	_, err = NewChainVerifier(nil)
+	require.Error(t, err)
+	v, err = NewChainVerifier([]byte(salt.GetHMAC(chainKeyPurpose)))
+	require.NoError(t, err)
+	_, errs, err = v.VerifyBatch(lines)
+	require.NoError(t, err)
+	require.ErrorIs(t, errs[0], ErrHashChain)
+
+	// The first entry supplied doesn't need to start the chain, but its HMAC
+	// can't be verified.
+	v, err = NewChainVerifier(key)
+	require.NoError(t, err)
+	result, err := v.Verify(lines[4])
+	require.NoError(t, err)
+	require.Equal(t, &ChainVerification{Sequence: 5, Unverified: true}, result)
+	result, err = v.Verify(lines[5])
+	require.NoError(t, err)
+	require.Equal(t, &ChainVerification{Sequence: 6}, result)
+
+	// A new chain starts when the audit device is created again.
+	restarted := append(append([][]byte{}, lines...), lines[0])
+	v, err = NewChainVerifier(key)
+	require.NoError(t, err)
+	for _, line := range restarted[:10] {
+		_, err := v.Verify(line)
+		require.NoError(t, err)
+	}
+	result, err = v.Verify(restarted[10])
+	require.NoError(t, err)
+	require.Equal(t, &ChainVerification{Sequence: 1, Restarted: true}, result)
+
+	// Removed entries.
+	removed := append(append([][]byte{}, lines[:3]...), lines[5:]...)
+	errs = verify(removed)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "entries 4 to 5 are missing: hash chain verification failed")
+
+	// Reordered entries.
+	reordered := append([][]byte{}, lines...)
+	reordered[3], reordered[4] = reordered[4], reordered[3]
+	errs = verify(reordered)
+	require.Len(t, errs, 3)
+	require.EqualError(t, errs[0], "entry 4 is missing: hash chain verification failed")
+	require.EqualError(t, errs[1], "entry is out of order, expected sequence 6: hash chain verification failed")
+
+	// Modified entry.
+	modified := append([][]byte{}, lines...)
+	modified[6] = bytes.Replace(modified[6], []byte(`"operation":"update"`), []byte(`"operation":"read"`), 1)
+	errs = verify(modified)
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "\"chain_hmac\" mismatch, the entry or the one preceding it was modified: hash chain verification failed")
+
+	// Modified sequence number.
+	resequenced := append([][]byte{}, lines...)
+	resequenced[9] = bytes.Replace(resequenced[9], []byte(`"chain_sequence":10`), []byte(`"chain_sequence":1`), 1)
+	errs = verify(resequenced)
+	require.Len(t, errs, 1)
+
+	// Entries which are not chained.
+	errs = verify([][]byte{[]byte(`{"type":"request"}`)})
+	require.Len(t, errs, 1)
+	require.EqualError(t, errs[0], "entry has no \"chain_hmac\": hash chain verification failed")
+}
+
+// TestSinkHashChain_LogTestMessage ensures that test messages are not written
+// by a hash chained device, so that they don't break the chain.
+func TestSinkHashChain_LogTestMessage(t *testing.T) {
+	t.Parallel()
+
+	be, path := testFileAuditBackend(t, "file", map[string]string{"hash_chain": "true"})
+
+	in := &logical.LogInput{
+		Request: &logical.Request{
+			Operation: logical.UpdateOperation,
+			Path:      "sys/audit/test",
+		},
+	}
+	require.NoError(t, be.LogTestMessage(nshelper.RootContext(context.Background()), in))
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Empty(t, data)
+}
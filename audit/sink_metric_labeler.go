@@ -8,11 +8,16 @@ import (
 	"github.com/hashicorp/vault/internal/observability/event"
 )
 
-var _ event.Labeler = (*metricLabelerAuditSink)(nil)
+var (
+	_ event.Labeler = (*metricLabelerAuditSink)(nil)
+	_ event.Labeler = (*metricLabelerAuditFallback)(nil)
+)
 
 var (
-	metricLabelAuditSinkSuccess = []string{"audit", "sink", "success"}
-	metricLabelAuditSinkFailure = []string{"audit", "sink", "failure"}
+	metricLabelAuditSinkSuccess     = []string{"audit", "sink", "success"}
+	metricLabelAuditSinkFailure     = []string{"audit", "sink", "failure"}
+	metricLabelAuditFallbackSuccess = []string{"audit", "fallback", "success"}
+	metricLabelAuditFallbackMiss    = []string{"audit", "fallback", "miss"}
 )
 
 // metricLabelerAuditSink can be used to provide labels for the success or failure
@@ -30,3 +35,18 @@ func (m metricLabelerAuditSink) Labels(_ *eventlogger.Event, err error) []string
 
 	return metricLabelAuditSinkSuccess
 }
+
+// metricLabelerAuditFallback can be used to provide labels for the success or failure
+// of a sink node used for an audit fallback device.
+type metricLabelerAuditFallback struct{}
+
+// Labels provides the success and failure labels for an audit fallback sink, based on the error supplied.
+// Success: 'vault.audit.fallback.success'
+// Failure: 'vault.audit.sink.failure'
+func (m metricLabelerAuditFallback) Labels(_ *eventlogger.Event, err error) []string {
+	if err != nil {
+		return metricLabelAuditSinkFailure
+	}
+
+	return metricLabelAuditFallbackSuccess
+}
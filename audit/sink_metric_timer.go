@@ -13,7 +13,10 @@ import (
 	"github.com/hashicorp/eventlogger"
 )
 
-var _ eventlogger.Node = (*sinkMetricTimer)(nil)
+var (
+	_ eventlogger.Node          = (*sinkMetricTimer)(nil)
+	_ eventlogger.NodeUnwrapper = (*sinkMetricTimer)(nil)
+)
 
 // sinkMetricTimer is a wrapper for any kind of eventlogger.NodeTypeSink node that
 // processes events containing an AuditEvent payload.
@@ -74,3 +77,9 @@ func (s *sinkMetricTimer) Reopen() error {
 func (s *sinkMetricTimer) Type() eventlogger.NodeType {
 	return s.sink.Type()
 }
+
+// Unwrap returns the underlying sink (eventlogger.Node), so that the eventlogger
+// can close it when the node is removed.
+func (s *sinkMetricTimer) Unwrap() eventlogger.Node {
+	return s.sink
+}
@@ -34,7 +34,7 @@ func TestAuditEnableCommand_Run(t *testing.T) {
 		{
 			"empty",
 			nil,
-			"Error enabling audit device: audit type missing. Valid types include 'file', 'socket' and 'syslog'.",
+			"Error enabling audit device: audit type missing. Valid types include 'file', 'http', 'socket' and 'syslog'.",
 			1,
 		},
 		{
@@ -168,7 +168,7 @@ func TestAuditEnableCommand_Run(t *testing.T) {
 		client, closer := testVaultServerAllBackends(t)
 		defer closer()
 
-		for _, name := range []string{"file", "socket", "syslog"} {
+		for _, name := range []string{"file", "http", "socket", "syslog"} {
 			ui, cmd := testAuditEnableCommand(t)
 			cmd.client = client
 
@@ -176,6 +176,8 @@ func TestAuditEnableCommand_Run(t *testing.T) {
 			switch name {
 			case "file":
 				args = append(args, "file_path=discard")
+			case "http":
+				args = append(args, "url=http://127.0.0.1:8888", "skip_test=true")
 			case "socket":
 				args = append(args, "address=127.0.0.1:8888", "skip_test=true")
 			case "syslog":
@@ -0,0 +1,236 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/audit"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*AuditVerifyCommand)(nil)
+	_ cli.CommandAutocomplete = (*AuditVerifyCommand)(nil)
+)
+
+const (
+	// auditVerifyBatchEntries and auditVerifyBatchBytes bound the entries
+	// verified with a single request, as each request is audited itself.
+	auditVerifyBatchEntries = 500
+	auditVerifyBatchBytes   = 4 * 1024 * 1024
+)
+
+type AuditVerifyCommand struct {
+	*BaseCommand
+
+	flagPath string
+}
+
+func (c *AuditVerifyCommand) Synopsis() string {
+	return "Verifies the hash chain of an audit log"
+}
+
+func (c *AuditVerifyCommand) Help() string {
+	helpText := `
+Usage: vault audit verify [options] FILE...
+
+  Verifies the hash chain of an audit log written by a file audit device with
+  the "hash_chain" option enabled. Missing, reordered and modified entries are
+  reported. The chained HMACs are verified by Vault with a key derived from the
+  salt of the audit device, which is never exposed, so the device must still be
+  enabled. Entries are sent to Vault in batches, so verifying the audit log only
+  adds a few entries to it.
+
+  Rotated files may be supplied in the order they were written, including files
+  compressed with gzip.
+
+  Verify the audit log of the audit device enabled at "file/":
+
+      $ vault audit verify /var/log/vault_audit.log
+
+  Verify rotated audit logs of the audit device enabled at "audit/":
+
+      $ vault audit verify -path=audit \
+          /var/log/vault_audit-1712345678901234567.log.gz \
+          /var/log/vault_audit.log
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *AuditVerifyCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "path",
+		Target:     &c.flagPath,
+		Default:    "file/",
+		EnvVar:     "",
+		Completion: c.PredictVaultAudits(),
+		Usage:      "Path of the audit device which wrote the audit log.",
+	})
+
+	return set
+}
+
+func (c *AuditVerifyCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictFiles("*")
+}
+
+func (c *AuditVerifyCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *AuditVerifyCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) < 1 {
+		c.UI.Error(fmt.Sprintf("Not enough arguments (expected at least 1, got %d)", len(args)))
+		return 1
+	}
+
+	path := ensureTrailingSlash(sanitizePath(c.flagPath))
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	verifier, err := audit.NewRemoteChainVerifier(func(previous []byte, entries [][]byte) (int, error) {
+		lines := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			lines = append(lines, string(entry))
+		}
+		result, err := client.Sys().AuditVerify(path, string(previous), lines)
+		if err != nil {
+			return 0, err
+		}
+		if result.Valid {
+			return -1, nil
+		}
+		return result.FirstInvalid, nil
+	})
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	var entries, chains, failures int
+	for _, file := range args {
+		err := c.verifyFile(verifier, file, func(line int, result *audit.ChainVerification, err error) {
+			entries++
+			switch {
+			case err != nil:
+				failures++
+				c.UI.Error(fmt.Sprintf("%s:%d: %s", file, line, err))
+			case result.Unverified:
+				chains++
+				c.UI.Warn(fmt.Sprintf("%s:%d: The first entry doesn't start a chain (sequence %d), its HMAC cannot be verified", file, line, result.Sequence))
+			case result.Sequence == 1:
+				chains++
+			}
+		})
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error verifying audit log %q: %s", file, err))
+			return 2
+		}
+	}
+
+	if failures > 0 {
+		c.UI.Error(fmt.Sprintf("Verification failed! %d of %d entries failed verification", failures, entries))
+		return 2
+	}
+
+	c.UI.Output(fmt.Sprintf("Success! Verified %d entries in %d chain(s)", entries, chains))
+
+	return 0
+}
+
+// verifyFile verifies each entry of the file in order, in batches, calling fn
+// with the line number and the result of the verification.
+func (c *AuditVerifyCommand) verifyFile(verifier *audit.ChainVerifier, file string, fn func(int, *audit.ChainVerification, error)) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	// Verifying the audit log of an enabled device appends entries to it, so
+	// only verify the entries written before we started.
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = io.LimitReader(f, info.Size())
+	if strings.HasSuffix(file, ".gz") {
+		gz, err := gzip.NewReader(r)
+		if err != nil {
+			return err
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	var batch [][]byte
+	var batchLines []int
+	var batchBytes int
+	verifyBatch := func() error {
+		if len(batch) == 0 {
+			return nil
+		}
+
+		results, errs, err := verifier.VerifyBatch(batch)
+		if err != nil {
+			return err
+		}
+		for i := range batch {
+			fn(batchLines[i], results[i], errs[i])
+		}
+
+		batch, batchLines, batchBytes = nil, nil, 0
+		return nil
+	}
+
+	// Entries can be larger than the maximum token size of a bufio.Scanner.
+	reader := bufio.NewReader(r)
+	for line := 1; ; line++ {
+		data, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(data)) > 0 {
+			batch = append(batch, data)
+			batchLines = append(batchLines, line)
+			batchBytes += len(data)
+		}
+
+		switch {
+		case errors.Is(err, io.EOF):
+			return verifyBatch()
+		case err != nil:
+			return err
+		case len(batch) >= auditVerifyBatchEntries || batchBytes >= auditVerifyBatchBytes:
+			if err := verifyBatch(); err != nil {
+				return err
+			}
+		}
+	}
+}
@@ -0,0 +1,198 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/api"
+)
+
+func testAuditVerifyCommand(tb testing.TB) (*cli.MockUi, *AuditVerifyCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &AuditVerifyCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestAuditVerifyCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	t.Run("not_enough_args", func(t *testing.T) {
+		t.Parallel()
+
+		ui, cmd := testAuditVerifyCommand(t)
+
+		code := cmd.Run(nil)
+		if exp := 1; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Not enough arguments"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		path := filepath.Join(t.TempDir(), "audit.log")
+		if err := client.Sys().EnableAuditWithOptions("integration_audit_verify", &api.EnableAuditOptions{
+			Type: "file",
+			Options: map[string]string{
+				"file_path":  path,
+				"hash_chain": "true",
+			},
+		}); err != nil {
+			t.Fatal(err)
+		}
+
+		for i := 0; i < 3; i++ {
+			if _, err := client.Sys().ListMounts(); err != nil {
+				t.Fatal(err)
+			}
+		}
+
+		before, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-path", "integration_audit_verify",
+			path,
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "Success! Verified"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		// The entries are verified with a single request, which is audited.
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got, exp := bytes.Count(data, []byte("\n"))-bytes.Count(before, []byte("\n")), 2; got != exp {
+			t.Errorf("expected %d entries to be appended to the audit log, got %d", exp, got)
+		}
+
+		// Remove the second entry from a copy of the audit log.
+		lines := bytes.SplitAfter(data, []byte("\n"))
+		tampered := filepath.Join(t.TempDir(), "tampered.log")
+		if err := os.WriteFile(tampered, bytes.Join(append(lines[:1:1], lines[2:]...), nil), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd = testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code = cmd.Run([]string{
+			"-path", "integration_audit_verify",
+			tampered,
+		})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		for _, expected := range []string{
+			tampered + ":2: entry 2 is missing",
+			"Verification failed! 1 of",
+		} {
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+
+		// Modify the third entry of a copy of the audit log, which is detected
+		// by Vault when verifying its chained HMAC.
+		modified := append([][]byte{}, lines...)
+		modified[2] = bytes.Replace(modified[2], []byte(`"operation":"read"`), []byte(`"operation":"update"`), 1)
+		if bytes.Equal(modified[2], lines[2]) {
+			t.Fatal("expected the third entry to be modified")
+		}
+		tampered = filepath.Join(t.TempDir(), "modified.log")
+		if err := os.WriteFile(tampered, bytes.Join(modified, nil), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd = testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code = cmd.Run([]string{
+			"-path", "integration_audit_verify",
+			tampered,
+		})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		for _, expected := range []string{
+			tampered + `:3: "chain_hmac" mismatch`,
+			"Verification failed! 1 of",
+		} {
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		path := filepath.Join(t.TempDir(), "audit.log")
+		if err := os.WriteFile(path, []byte(`{"chain_hmac":"hmac-sha256:abc","chain_sequence":1,"type":"request"}`+"\n"), 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		ui, cmd := testAuditVerifyCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			path,
+		})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error verifying audit log "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testAuditVerifyCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
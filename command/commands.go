@@ -163,6 +163,7 @@ func newMinimalVaultHandlers() *vaultHandlers {
 			"file":   audit.NewFileBackend,
 			"socket": audit.NewSocketBackend,
 			"syslog": audit.NewSyslogBackend,
+			"http":   audit.NewHTTPBackend,
 		},
 		credentialBackends: map[string]logical.Factory{
 			"plugin": plugin.Factory,
@@ -240,6 +241,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"audit verify": func() (cli.Command, error) {
+			return &AuditVerifyCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"auth tune": func() (cli.Command, error) {
 			return &AuthTuneCommand{
 				BaseCommand: getBaseCommand(),
@@ -388,6 +394,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator keyring-escrow": func() (cli.Command, error) {
+			return &OperatorKeyringEscrowCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator migrate": func() (cli.Command, error) {
 			return &OperatorMigrateCommand{
 				BaseCommand:      getBaseCommand(),
@@ -415,6 +426,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator raft compact": func() (cli.Command, error) {
+			return &OperatorRaftCompactCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft list-peers": func() (cli.Command, error) {
 			return &OperatorRaftListPeersCommand{
 				BaseCommand: getBaseCommand(),
@@ -435,6 +451,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator raft snapshot export": func() (cli.Command, error) {
+			return &OperatorRaftSnapshotExportCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft snapshot inspect": func() (cli.Command, error) {
 			return &OperatorRaftSnapshotInspectCommand{
 				BaseCommand: getBaseCommand(),
@@ -445,6 +466,11 @@ func initCommands(ui, serverCmdUi cli.Ui, runOpts *RunOptions) map[string]cli.Co
 				BaseCommand: getBaseCommand(),
 			}, nil
 		},
+		"operator raft snapshot restore-mount": func() (cli.Command, error) {
+			return &OperatorRaftSnapshotRestoreMountCommand{
+				BaseCommand: getBaseCommand(),
+			}, nil
+		},
 		"operator raft snapshot save": func() (cli.Command, error) {
 			return &OperatorRaftSnapshotSaveCommand{
 				BaseCommand: getBaseCommand(),
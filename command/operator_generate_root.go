@@ -9,6 +9,7 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
 	"github.com/hashicorp/cli"
 	"github.com/hashicorp/go-secure-stdlib/password"
@@ -45,6 +46,8 @@ type OperatorGenerateRootCommand struct {
 	flagGenerateOTP   bool
 	flagDRToken       bool
 	flagRecoveryToken bool
+	flagTTL           time.Duration
+	flagPolicies      []string
 
 	testStdin io.Reader // for tests
 }
@@ -55,7 +58,7 @@ func (c *OperatorGenerateRootCommand) Synopsis() string {
 
 func (c *OperatorGenerateRootCommand) Help() string {
 	helpText := `
-Usage: vault operator generate-root [options] -init [-otp=...] [-pgp-key=...]
+Usage: vault operator generate-root [options] -init [-otp=...] [-pgp-key=...] [-ttl=...] [-policy=...]
        vault operator generate-root [options] [-nonce=... KEY]
        vault operator generate-root [options] -decode=... -otp=...
        vault operator generate-root [options] -generate-otp
@@ -100,6 +103,14 @@ Usage: vault operator generate-root [options] -init [-otp=...] [-pgp-key=...]
 
             $ vault operator generate-root -init -pgp-key="..."
 
+    For emergency access, the generated root token can be limited with a TTL,
+    after which it is revoked, and a set of policies to use instead of the
+    root policy. A TTL is required when policies are given. The token cannot
+    be renewed. Generated root tokens are always marked with "generated_root"
+    metadata in audit logs:
+
+            $ vault operator generate-root -init -ttl=1h -policy=admin
+
   Form 2 (no option) - Enter an unseal key to progress root token generation:
 
     In the sub-form intended for interactive use, the command will
@@ -244,6 +255,24 @@ func (c *OperatorGenerateRootCommand) Flags() *FlagSets {
 			"key. Must be used with \"-init\".",
 	})
 
+	f.DurationVar(&DurationVar{
+		Name:       "ttl",
+		Target:     &c.flagTTL,
+		Completion: complete.PredictAnything,
+		Usage: "TTL of the generated root token, after which it is revoked. The " +
+			"token cannot be renewed. This is specified as a numeric string with " +
+			"suffix like \"30m\" or \"1h\". Must be used with \"-init\".",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "policy",
+		Target:     &c.flagPolicies,
+		Completion: c.PredictVaultPolicies(),
+		Usage: "Name of a policy to give the generated root token instead of the " +
+			"root policy. This can be specified multiple times to attach multiple " +
+			"policies. Requires \"-ttl\". Must be used with \"-init\".",
+	})
+
 	f.StringVar(&StringVar{
 		Name:       "nonce",
 		Target:     &c.flagNonce,
@@ -439,6 +468,18 @@ func (c *OperatorGenerateRootCommand) init(client *api.Client, otp, pgpKey strin
 	case generateRootRecovery:
 		f = client.Sys().GenerateRecoveryOperationTokenInit
 	}
+	if c.flagTTL != 0 || len(c.flagPolicies) > 0 {
+		if kind != generateRootRegular {
+			c.UI.Error("Error initializing: -ttl and -policy can only be used when generating a root token")
+			return 1
+		}
+		f = func(otp, pgpKey string) (*api.GenerateRootStatusResponse, error) {
+			return client.Sys().GenerateRootInitWithOptions(otp, pgpKey, &api.GenerateRootInitOptions{
+				TTL:      c.flagTTL,
+				Policies: c.flagPolicies,
+			})
+		}
+	}
 	status, err := f(otp, pgpKey)
 	if err != nil {
 		c.UI.Error(fmt.Sprintf("Error initializing root generation: %s", err))
@@ -609,6 +650,12 @@ func (c *OperatorGenerateRootCommand) printStatus(status *api.GenerateRootStatus
 	if status.PGPFingerprint != "" {
 		out = append(out, fmt.Sprintf("PGP Fingerprint | %s", status.PGPFingerprint))
 	}
+	if status.TTL != 0 {
+		out = append(out, fmt.Sprintf("TTL | %v", humanDurationInt(status.TTL)))
+	}
+	if len(status.Policies) > 0 {
+		out = append(out, fmt.Sprintf("Policies | %s", strings.Join(status.Policies, ", ")))
+	}
 	switch {
 	case status.EncodedToken != "":
 		out = append(out, fmt.Sprintf("Encoded Token | %s", status.EncodedToken))
@@ -76,6 +76,25 @@ func TestOperatorGenerateRootCommand_Run(t *testing.T) {
 			"cannot specify both -otp and -pgp-key",
 			1,
 		},
+		{
+			"init_ttl_recovery_token",
+			[]string{
+				"-init",
+				"-recovery-token",
+				"-ttl", "1h",
+			},
+			"can only be used when generating a root token",
+			1,
+		},
+		{
+			"init_policy_no_ttl",
+			[]string{
+				"-init",
+				"-policy", "foo",
+			},
+			"ttl must be set when policies are set",
+			2,
+		},
 	}
 
 	t.Run("validations", func(t *testing.T) {
@@ -320,6 +339,40 @@ func TestOperatorGenerateRootCommand_Run(t *testing.T) {
 		}
 	})
 
+	t.Run("init_ttl", func(t *testing.T) {
+		t.Parallel()
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testOperatorGenerateRootCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-init",
+			"-ttl", "30m",
+			"-policy", "foo",
+		})
+		if exp := 0; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Policies"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		status, err := client.Sys().GenerateRootStatus()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if status.TTL != 1800 || len(status.Policies) != 1 || status.Policies[0] != "foo" {
+			t.Errorf("expected ttl and policies to be set: %#v", status)
+		}
+	})
+
 	t.Run("init_pgp", func(t *testing.T) {
 		t.Parallel()
 
@@ -0,0 +1,165 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorKeyringEscrowCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorKeyringEscrowCommand)(nil)
+)
+
+type OperatorKeyringEscrowCommand struct {
+	*BaseCommand
+
+	flagPGPKeys   []string
+	flagThreshold int
+}
+
+func (c *OperatorKeyringEscrowCommand) Synopsis() string {
+	return "Exports the encryption keyring encrypted to PGP keys"
+}
+
+func (c *OperatorKeyringEscrowCommand) Help() string {
+	helpText := `
+Usage: vault operator keyring-escrow [options] -pgp-keys=... PATH
+
+  Exports the keyring used to encrypt Vault's storage to a bundle at PATH, for
+  disaster recovery when the seal is lost. The keyring is encrypted with a
+  random escrow key, which is split into one share per PGP key. Each share is
+  encrypted with its PGP key, and -threshold shares are required to open the
+  bundle.
+
+  Anyone able to open the bundle can decrypt all data in a snapshot of the
+  storage taken before the next key rotation, so store it offline. This
+  command requires a token with sudo capability on sys/keyring/escrow.
+
+  Export the keyring so that two of three key holders can open it:
+
+      $ vault operator keyring-escrow \
+          -pgp-keys="keybase:hashicorp,keybase:jefferai,keybase:sethvargo" \
+          -threshold=2 \
+          keyring-escrow.json
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorKeyringEscrowCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.VarFlag(&VarFlag{
+		Name:       "pgp-keys",
+		Value:      (*pgpkeys.PubKeyFilesFlag)(&c.flagPGPKeys),
+		Completion: complete.PredictAnything,
+		Usage: "Comma-separated list of paths to files on disk containing " +
+			"public PGP keys OR a comma-separated list of Keybase usernames using " +
+			"the format \"keybase:<username>\". One escrow key share is encrypted " +
+			"with each key, in the order specified in this list.",
+	})
+
+	f.IntVar(&IntVar{
+		Name:       "threshold",
+		Target:     &c.flagThreshold,
+		Default:    1,
+		Completion: complete.PredictAnything,
+		Usage: "Number of escrow key shares required to open the bundle. With " +
+			"the default of 1, each PGP key can open the bundle on its own.",
+	})
+
+	return set
+}
+
+func (c *OperatorKeyringEscrowCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *OperatorKeyringEscrowCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorKeyringEscrowCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) != 1 {
+		c.UI.Error(fmt.Sprintf("Incorrect arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+	path := strings.TrimSpace(args[0])
+	if path == "" {
+		c.UI.Error("Output file name is required")
+		return 1
+	}
+
+	if len(c.flagPGPKeys) == 0 {
+		c.UI.Error("Missing PGP keys: use -pgp-keys to supply them")
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	escrow, err := client.Sys().KeyringEscrow(&api.KeyringEscrowInput{
+		PGPKeys:   c.flagPGPKeys,
+		Threshold: c.flagThreshold,
+	})
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error exporting keyring: %s", err))
+		return 2
+	}
+
+	buf, err := json.MarshalIndent(escrow, "", "  ")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encoding keyring escrow bundle: %s", err))
+		return 2
+	}
+	if err := os.WriteFile(path, buf, 0o600); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing keyring escrow bundle: %s", err))
+		return 2
+	}
+
+	switch Format(c.UI) {
+	case "table":
+		out := []string{
+			"Key | Value",
+			fmt.Sprintf("Path | %s", path),
+			fmt.Sprintf("Term | %d", escrow.Term),
+			fmt.Sprintf("Threshold | %d", escrow.Threshold),
+		}
+		for i, fingerprint := range escrow.PGPFingerprints {
+			out = append(out, fmt.Sprintf("PGP Fingerprint %d | %s", i+1, fingerprint))
+		}
+		c.UI.Output(tableOutput(out, nil))
+		return 0
+	default:
+		return OutputData(c.UI, map[string]interface{}{
+			"path":             path,
+			"term":             escrow.Term,
+			"threshold":        escrow.Threshold,
+			"pgp_fingerprints": escrow.PGPFingerprints,
+		})
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/vault"
+)
+
+func testOperatorKeyringEscrowCommand(tb testing.TB) (*cli.MockUi, *OperatorKeyringEscrowCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorKeyringEscrowCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorKeyringEscrowCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name string
+		args []string
+		out  string
+		code int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"Incorrect arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"foo", "bar"},
+			"Incorrect arguments",
+			1,
+		},
+		{
+			"no_pgp_keys",
+			[]string{"foo"},
+			"Missing PGP keys",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				client, closer := testVaultServer(t)
+				defer closer()
+
+				ui, cmd := testOperatorKeyringEscrowCommand(t)
+				cmd.client = client
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		tempDir, pubFiles, err := getPubKeyFiles(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		client, closer := testVaultServer(t)
+		defer closer()
+
+		ui, cmd := testOperatorKeyringEscrowCommand(t)
+		cmd.client = client
+
+		path := filepath.Join(tempDir, "escrow.json")
+		code := cmd.Run([]string{
+			"-pgp-keys", pubFiles[0] + "," + pubFiles[1],
+			"-threshold", "2",
+			path,
+		})
+		if exp := 0; code != exp {
+			t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+		}
+
+		expected := "PGP Fingerprint 2"
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		escrow, err := vault.ParseKeyringEscrow(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var shares [][]byte
+		for i, privKey := range []string{pgpkeys.TestPrivKey1, pgpkeys.TestPrivKey2} {
+			share, err := hex.DecodeString(testPGPDecrypt(t, privKey, escrow.EncryptedShares[i]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			shares = append(shares, share)
+		}
+		keyring, err := vault.OpenKeyringEscrow(escrow, shares)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if keyring.ActiveTerm() != escrow.Term {
+			t.Errorf("expected term %d to be %d", keyring.ActiveTerm(), escrow.Term)
+		}
+	})
+
+	t.Run("communication_failure", func(t *testing.T) {
+		t.Parallel()
+
+		tempDir, pubFiles, err := getPubKeyFiles(t)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer os.RemoveAll(tempDir)
+
+		client, closer := testVaultServerBad(t)
+		defer closer()
+
+		ui, cmd := testOperatorKeyringEscrowCommand(t)
+		cmd.client = client
+
+		code := cmd.Run([]string{
+			"-pgp-keys", pubFiles[0],
+			filepath.Join(tempDir, "escrow.json"),
+		})
+		if exp := 2; code != exp {
+			t.Errorf("expected %d to be %d", code, exp)
+		}
+
+		expected := "Error exporting keyring: "
+		combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+		if !strings.Contains(combined, expected) {
+			t.Errorf("expected %q to contain %q", combined, expected)
+		}
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorKeyringEscrowCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -39,6 +39,10 @@ Usage: vault operator raft <subcommand> [options] [args]
 
       $ vault operator raft remove-peer
 
+  Compacts the raft storage of all the nodes to reclaim disk space:
+
+      $ vault operator raft compact
+
   Restores and saves snapshots from the raft cluster:
 
       $ vault operator raft snapshot save out.snap
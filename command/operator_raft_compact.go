@@ -0,0 +1,138 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorRaftCompactCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorRaftCompactCommand)(nil)
+)
+
+type OperatorRaftCompactCommand struct {
+	*BaseCommand
+
+	flagStatus bool
+}
+
+func (c *OperatorRaftCompactCommand) Synopsis() string {
+	return "Compacts the raft storage to reclaim disk space"
+}
+
+func (c *OperatorRaftCompactCommand) Help() string {
+	helpText := `
+Usage: vault operator raft compact [options]
+
+  Compacts the raft storage of the standby nodes of the cluster, returning the
+  disk space freed by large deletes such as lease tidies to the filesystem.
+  Nodes are compacted one at a time, and only while autopilot reports the
+  cluster as healthy. A node does not serve requests while its storage is
+  being compacted, so the active node is skipped. To compact it, step it down
+  and run the command again on the new active node.
+
+  Start compacting the raft storage:
+
+      $ vault operator raft compact
+
+  Check the progress of the latest compaction:
+
+      $ vault operator raft compact -status
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftCompactCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetHTTP | FlagSetOutputFormat)
+	f := set.NewFlagSet("Command Options")
+
+	f.BoolVar(&BoolVar{
+		Name:    "status",
+		Target:  &c.flagStatus,
+		Default: false,
+		Usage:   "Display the progress of the latest compaction instead of starting a new one.",
+	})
+
+	return set
+}
+
+func (c *OperatorRaftCompactCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictNothing
+}
+
+func (c *OperatorRaftCompactCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorRaftCompactCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) > 0 {
+		c.UI.Error(fmt.Sprintf("Incorrect arguments (expected 0, got %d)", len(args)))
+		return 1
+	}
+
+	client, err := c.Client()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 2
+	}
+
+	if !c.flagStatus {
+		if err := client.Sys().RaftCompact(); err != nil {
+			c.UI.Error(fmt.Sprintf("Error starting the compaction of the raft storage: %s", err))
+			return 2
+		}
+
+		c.UI.Output("Compaction started. Run \"vault operator raft compact -status\" to check its progress.")
+		return 0
+	}
+
+	status, err := client.Sys().RaftCompactionStatus()
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error reading the compaction status: %s", err))
+		return 2
+	}
+	if status == nil {
+		c.UI.Error("No compaction of the raft storage was started")
+		return 2
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, status)
+	}
+
+	out := []string{
+		fmt.Sprintf("Status | %s", status.Status),
+		fmt.Sprintf("Start Time | %s", status.StartTime),
+	}
+	if status.EndTime != "" {
+		out = append(out, fmt.Sprintf("End Time | %s", status.EndTime))
+	}
+	if status.Error != "" {
+		out = append(out, fmt.Sprintf("Error | %s", status.Error))
+	}
+	c.UI.Output(tableOutput(out, nil))
+	c.UI.Output("")
+
+	out = []string{"Node | Status"}
+	for _, srv := range status.Servers {
+		out = append(out, fmt.Sprintf("%s | %s", srv.ID, srv.Status))
+	}
+	c.UI.Output(tableOutput(out, nil))
+	return 0
+}
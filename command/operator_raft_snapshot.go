@@ -31,6 +31,11 @@ Usage: vault operator raft snapshot <subcommand> [options] [args]
 
       $ vault operator raft snapshot restore raft.snap
 
+  Restores a single mount from the provided snapshot, leaving the rest of the
+  cluster unchanged:
+
+      $ vault operator raft snapshot restore-mount secret/ raft.snap
+
   Saves a snapshot of the current state of the Raft cluster into a file:
 
       $ vault operator raft snapshot save raft.snap
@@ -39,6 +44,10 @@ Usage: vault operator raft snapshot <subcommand> [options] [args]
 
       $ vault operator raft snapshot inspect raft.snap
 
+  Decrypts a snapshot offline and exports its KV secrets, policies and identity:
+
+      $ vault operator raft snapshot export raft.snap
+
   Please see the individual subcommand help for detailed usage information.
 `
 
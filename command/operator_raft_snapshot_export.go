@@ -0,0 +1,410 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"crypto/aes"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/password"
+	protoio "github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/inmem"
+	"github.com/hashicorp/vault/sdk/plugin/pb"
+	"github.com/hashicorp/vault/shamir"
+	"github.com/hashicorp/vault/vault"
+	"github.com/posener/complete"
+)
+
+var (
+	_ cli.Command             = (*OperatorRaftSnapshotExportCommand)(nil)
+	_ cli.CommandAutocomplete = (*OperatorRaftSnapshotExportCommand)(nil)
+)
+
+const (
+	snapshotExportKV       = "kv"
+	snapshotExportPolicies = "policies"
+	snapshotExportIdentity = "identity"
+)
+
+type OperatorRaftSnapshotExportCommand struct {
+	*BaseCommand
+
+	flagKeySharesFile string
+	flagKeyringEscrow string
+	flagInclude       string
+	flagKVPaths       []string
+	flagOutput        string
+
+	testStdin io.Reader // for tests
+}
+
+// SnapshotExport is the output of the export command
+type SnapshotExport struct {
+	Snapshot *MetadataInfo                   `json:"snapshot"`
+	KV       map[string]*vault.KVMountExport `json:"kv,omitempty"`
+	Policies map[string]string               `json:"policies,omitempty"`
+	Identity *vault.IdentityExport           `json:"identity,omitempty"`
+}
+
+func (c *OperatorRaftSnapshotExportCommand) Synopsis() string {
+	return "Decrypts a raft snapshot offline and exports data as JSON"
+}
+
+func (c *OperatorRaftSnapshotExportCommand) Help() string {
+	helpText := `
+Usage: vault operator raft snapshot export [options] <snapshot_file>
+
+  Decrypts a snapshot file offline, without a running Vault server, and
+  exports KV secrets, ACL policies and identity entities and groups as JSON.
+  This allows recovering a single deleted secret, or inspecting the state of
+  the cluster at the time of the snapshot, without restoring the snapshot.
+
+  The snapshot is decrypted with either the unseal keys of a Shamir sealed
+  cluster, or with a keyring escrow bundle created with
+  "vault operator keyring-escrow" and enough of its decrypted shares.
+
+  The key shares are prompted for using the terminal (tty), one at a time,
+  until an empty value is entered. They can also be read from a file, or from
+  stdin, with one key share per line. Key shares cannot be supplied as
+  arguments, so that they don't end up in the shell history.
+
+  The output contains secrets in plain text. Anyone with the unseal material
+  can read it, so protect it accordingly.
+
+  Export everything, entering the unseal keys when prompted:
+
+      $ vault operator raft snapshot export raft.snap
+
+  Export a single KV secret using a keyring escrow bundle, reading the
+  decrypted escrow key shares from a file:
+
+      $ vault operator raft snapshot export \
+          -keyring-escrow=keyring-escrow.json -key-shares-file=shares.txt \
+          -include=kv -kv-path=secret/app/db raft.snap
+
+` + c.Flags().Help()
+
+	return strings.TrimSpace(helpText)
+}
+
+func (c *OperatorRaftSnapshotExportCommand) Flags() *FlagSets {
+	set := c.flagSet(FlagSetNone)
+
+	f := set.NewFlagSet("Command Options")
+
+	f.StringVar(&StringVar{
+		Name:       "key-shares-file",
+		Target:     &c.flagKeySharesFile,
+		Completion: complete.PredictFiles("*"),
+		Usage: "Path to a file containing the hex or base64 encoded key shares, " +
+			"one per line, up to the threshold. These are the unseal keys of a " +
+			"Shamir sealed cluster, or the decrypted escrow key shares when " +
+			"-keyring-escrow is specified. If the value is \"-\", the key shares " +
+			"are read from stdin. By default, the key shares are prompted for " +
+			"using the terminal (tty).",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "keyring-escrow",
+		Target:     &c.flagKeyringEscrow,
+		Completion: complete.PredictFiles("*.json"),
+		Usage: "Path to a keyring escrow bundle to decrypt the snapshot with, " +
+			"instead of unseal keys.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "include",
+		Target:     &c.flagInclude,
+		Default:    strings.Join([]string{snapshotExportKV, snapshotExportPolicies, snapshotExportIdentity}, ","),
+		Completion: complete.PredictSet(snapshotExportKV, snapshotExportPolicies, snapshotExportIdentity),
+		Usage: "Comma-separated list of the data to export. Valid values are " +
+			"\"kv\", \"policies\" and \"identity\".",
+	})
+
+	f.StringSliceVar(&StringSliceVar{
+		Name:       "kv-path",
+		Target:     &c.flagKVPaths,
+		Completion: complete.PredictAnything,
+		Usage: "Path of a KV mount, optionally followed by the path of a secret " +
+			"or folder in the mount, to export, such as \"secret/\" or " +
+			"\"secret/app/db\". This can be specified multiple times. All KV " +
+			"mounts are exported by default.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:       "output",
+		Target:     &c.flagOutput,
+		Completion: complete.PredictAnything,
+		Usage:      "Path of the file to write the export to. Defaults to standard output.",
+	})
+
+	return set
+}
+
+func (c *OperatorRaftSnapshotExportCommand) AutocompleteArgs() complete.Predictor {
+	return complete.PredictAnything
+}
+
+func (c *OperatorRaftSnapshotExportCommand) AutocompleteFlags() complete.Flags {
+	return c.Flags().Completions()
+}
+
+func (c *OperatorRaftSnapshotExportCommand) Run(args []string) int {
+	f := c.Flags()
+
+	if err := f.Parse(args); err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	args = f.Args()
+	if len(args) != 1 {
+		c.UI.Error(fmt.Sprintf("Incorrect arguments (expected 1, got %d)", len(args)))
+		return 1
+	}
+	file := strings.TrimSpace(args[0])
+
+	include := make(map[string]bool)
+	for _, v := range strings.Split(c.flagInclude, ",") {
+		v = strings.TrimSpace(v)
+		switch v {
+		case snapshotExportKV, snapshotExportPolicies, snapshotExportIdentity:
+			include[v] = true
+		case "":
+		default:
+			c.UI.Error(fmt.Sprintf("Invalid value %q for -include", v))
+			return 1
+		}
+	}
+	if len(c.flagKVPaths) > 0 && !include[snapshotExportKV] {
+		c.UI.Error("-kv-path can only be used when exporting KV secrets")
+		return 1
+	}
+
+	logger := hclog.New(&hclog.LoggerOptions{
+		Output: os.Stderr,
+		Level:  hclog.Warn,
+	})
+
+	shares, err := c.readKeyShares()
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	conf := &vault.StorageExporterConfig{
+		Logger: logger,
+	}
+	if c.flagKeyringEscrow != "" {
+		conf.Keyring, err = c.openKeyringEscrow(shares)
+	} else {
+		conf.UnsealKeys = shares
+	}
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	backend, meta, err := loadSnapshotStorage(logger, file)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+	conf.Backend = backend
+
+	ctx := context.Background()
+	exporter, err := vault.NewStorageExporter(ctx, conf)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error decrypting snapshot: %s", err))
+		return 2
+	}
+
+	export := &SnapshotExport{
+		Snapshot: meta,
+	}
+	if include[snapshotExportKV] {
+		export.KV, err = exporter.ExportKV(ctx, c.flagKVPaths)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error exporting KV secrets: %s", err))
+			return 2
+		}
+	}
+	if include[snapshotExportPolicies] {
+		export.Policies, err = exporter.ExportPolicies(ctx)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error exporting policies: %s", err))
+			return 2
+		}
+	}
+	if include[snapshotExportIdentity] {
+		export.Identity, err = exporter.ExportIdentity(ctx)
+		if err != nil {
+			c.UI.Error(fmt.Sprintf("Error exporting identity: %s", err))
+			return 2
+		}
+	}
+
+	buf, err := json.MarshalIndent(export, "", "  ")
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error encoding export: %s", err))
+		return 2
+	}
+
+	if c.flagOutput == "" {
+		c.UI.Output(string(buf))
+		return 0
+	}
+	if err := os.WriteFile(c.flagOutput, buf, 0o600); err != nil {
+		c.UI.Error(fmt.Sprintf("Error writing export: %s", err))
+		return 2
+	}
+	return 0
+}
+
+func (c *OperatorRaftSnapshotExportCommand) openKeyringEscrow(shares [][]byte) (*vault.Keyring, error) {
+	buf, err := os.ReadFile(c.flagKeyringEscrow)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading keyring escrow bundle: %s", err)
+	}
+	escrow, err := vault.ParseKeyringEscrow(buf)
+	if err != nil {
+		return nil, fmt.Errorf("Error reading keyring escrow bundle: %s", err)
+	}
+	keyring, err := vault.OpenKeyringEscrow(escrow, shares)
+	if err != nil {
+		return nil, fmt.Errorf("Error opening keyring escrow bundle: %s", err)
+	}
+	return keyring, nil
+}
+
+// readKeyShares reads the key shares, one per line, from the file supplied
+// with -key-shares-file, or from stdin when it is "-". Otherwise, the key
+// shares are prompted for using the tty until an empty value is entered.
+func (c *OperatorRaftSnapshotExportCommand) readKeyShares() ([][]byte, error) {
+	var encoded []string
+	switch c.flagKeySharesFile {
+	case "": // Prompt using the tty
+		w := getWriterFromUI(c.UI)
+		for i := 1; ; i++ {
+			fmt.Fprintf(w, "Key Share %d (will be hidden, empty when done): ", i)
+			value, err := password.Read(os.Stdin)
+			fmt.Fprintf(w, "\n")
+			if err != nil {
+				if err == password.ErrInterrupted {
+					return nil, errors.New("user canceled")
+				}
+				return nil, errors.New(wrapAtLength(fmt.Sprintf("An error occurred attempting to "+
+					"ask for the key shares. The raw error message is shown below, but "+
+					"usually this is because you attempted to pipe a value into the "+
+					"command or you are executing outside of a terminal (tty). If you "+
+					"want to pipe the key shares, use -key-shares-file=\"-\" to read "+
+					"them from stdin. The raw error was: %s", err)))
+			}
+			value = strings.TrimSpace(value)
+			if value == "" {
+				break
+			}
+			encoded = append(encoded, value)
+		}
+	case "-": // Read from stdin
+		stdin := (io.Reader)(os.Stdin)
+		if c.testStdin != nil {
+			stdin = c.testStdin
+		}
+		buf, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, fmt.Errorf("Failed to read from stdin: %s", err)
+		}
+		encoded = strings.Fields(string(buf))
+	default:
+		buf, err := os.ReadFile(c.flagKeySharesFile)
+		if err != nil {
+			return nil, fmt.Errorf("Error reading key shares file: %s", err)
+		}
+		encoded = strings.Fields(string(buf))
+	}
+
+	if len(encoded) == 0 {
+		return nil, fmt.Errorf("Missing key shares: enter them when prompted, or use -key-shares-file to supply them")
+	}
+	return decodeKeyShares(encoded)
+}
+
+// decodeKeyShares decodes hex or base64 encoded key shares.
+func decodeKeyShares(encoded []string) ([][]byte, error) {
+	// These are the bounds the unseal endpoint uses, so that a base64 encoded
+	// key share which is also valid hex is base64 decoded.
+	min, max := aes.BlockSize, 2*aes.BlockSize+shamir.ShareOverhead
+
+	shares := make([][]byte, 0, len(encoded))
+	for _, e := range encoded {
+		share, err := hex.DecodeString(e)
+		if err != nil || len(share) < min || len(share) > max {
+			share, err = base64.StdEncoding.DecodeString(e)
+			if err != nil {
+				return nil, fmt.Errorf("Key shares must be valid hex or base64 strings")
+			}
+		}
+		shares = append(shares, share)
+	}
+	return shares, nil
+}
+
+// loadSnapshotStorage reads the storage entries of a snapshot file into an
+// in-memory physical backend.
+func loadSnapshotStorage(logger hclog.Logger, file string) (physical.Backend, *MetadataInfo, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error opening snapshot file: %s", err)
+	}
+	defer f.Close()
+
+	backend, err := inmem.NewInmem(nil, logger)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ctx := context.Background()
+	meta, err := readSnapshot(logger, f, func(r io.Reader) error {
+		protoReader := protoio.NewDelimitedReader(r, math.MaxInt32)
+		for {
+			entry := new(pb.StorageEntry)
+			if err := protoReader.ReadMsg(entry); err != nil {
+				if err == io.EOF {
+					return nil
+				}
+				return err
+			}
+			if entry.Key == "" {
+				continue
+			}
+			if err := backend.Put(ctx, &physical.Entry{Key: entry.Key, Value: entry.Value}); err != nil {
+				return err
+			}
+		}
+	})
+	if err != nil {
+		return nil, nil, fmt.Errorf("Error reading snapshot: %s", err)
+	}
+
+	return backend, &MetadataInfo{
+		ID:      meta.ID,
+		Size:    meta.Size,
+		Index:   meta.Index,
+		Term:    meta.Term,
+		Version: meta.Version,
+	}, nil
+}
@@ -0,0 +1,277 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/cli"
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+)
+
+func testOperatorRaftSnapshotExportCommand(tb testing.TB) (*cli.MockUi, *OperatorRaftSnapshotExportCommand) {
+	tb.Helper()
+
+	ui := cli.NewMockUi()
+	return ui, &OperatorRaftSnapshotExportCommand{
+		BaseCommand: &BaseCommand{
+			UI: ui,
+		},
+	}
+}
+
+func TestOperatorRaftSnapshotExportCommand_Run(t *testing.T) {
+	t.Parallel()
+
+	cases := []struct {
+		name  string
+		args  []string
+		stdin string
+		out   string
+		code  int
+	}{
+		{
+			"not_enough_args",
+			[]string{},
+			"",
+			"Incorrect arguments",
+			1,
+		},
+		{
+			"too_many_args",
+			[]string{"-key-shares-file", "-", "foo", "bar"},
+			"abcd",
+			"Incorrect arguments",
+			1,
+		},
+		{
+			"no_key_shares",
+			[]string{"-key-shares-file", "-", "raft.snap"},
+			"\n\n",
+			"Missing key shares",
+			1,
+		},
+		{
+			"missing_key_shares_file",
+			[]string{"-key-shares-file", "missing.txt", "raft.snap"},
+			"",
+			"Error reading key shares file",
+			1,
+		},
+		{
+			"invalid_include",
+			[]string{"-key-shares-file", "-", "-include", "kv,tokens", "raft.snap"},
+			"abcd",
+			`Invalid value "tokens" for -include`,
+			1,
+		},
+		{
+			"kv_path_without_kv",
+			[]string{"-key-shares-file", "-", "-include", "policies", "-kv-path", "secret/", "raft.snap"},
+			"abcd",
+			"-kv-path can only be used when exporting KV secrets",
+			1,
+		},
+		{
+			"invalid_key",
+			[]string{"-key-shares-file", "-", "raft.snap"},
+			"not-a-key!",
+			"Key shares must be valid hex or base64 strings",
+			1,
+		},
+		{
+			"missing_escrow",
+			[]string{"-key-shares-file", "-", "-keyring-escrow", "escrow.json", "raft.snap"},
+			"abcd",
+			"Error reading keyring escrow bundle",
+			1,
+		},
+		{
+			"missing_file",
+			[]string{"-key-shares-file", "-", "missing.snap"},
+			"abcd",
+			"Error opening snapshot file",
+			1,
+		},
+	}
+
+	t.Run("validations", func(t *testing.T) {
+		t.Parallel()
+
+		for _, tc := range cases {
+			tc := tc
+
+			t.Run(tc.name, func(t *testing.T) {
+				t.Parallel()
+
+				ui, cmd := testOperatorRaftSnapshotExportCommand(t)
+				cmd.testStdin = strings.NewReader(tc.stdin)
+
+				code := cmd.Run(tc.args)
+				if code != tc.code {
+					t.Errorf("expected %d to be %d", code, tc.code)
+				}
+
+				combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+				if !strings.Contains(combined, tc.out) {
+					t.Errorf("expected %q to contain %q", combined, tc.out)
+				}
+			})
+		}
+	})
+
+	t.Run("integration", func(t *testing.T) {
+		t.Parallel()
+
+		cluster := testVaultRaftCluster(t)
+		defer cluster.Cleanup()
+
+		client := cluster.Cores[0].Client
+		ctx := context.Background()
+
+		if err := client.Sys().Mount("kv-v2/", &api.MountInput{
+			Type: "kv-v2",
+		}); err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range []string{"app/db", "app/cache"} {
+			if _, err := client.KVv2("kv-v2").Put(ctx, path, map[string]interface{}{
+				"password": "Hashi123",
+			}); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := client.Sys().PutPolicy("app", `path "kv-v2/*" { capabilities = ["read"] }`); err != nil {
+			t.Fatal(err)
+		}
+
+		escrow, err := client.Sys().KeyringEscrow(&api.KeyringEscrowInput{
+			PGPKeys: []string{pgpkeys.TestPubKey1},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		share := testPGPDecrypt(t, pgpkeys.TestPrivKey1, escrow.EncryptedShares[0])
+
+		tempDir := t.TempDir()
+		escrowPath := filepath.Join(tempDir, "escrow.json")
+		buf, err := json.Marshal(escrow)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(escrowPath, buf, 0o600); err != nil {
+			t.Fatal(err)
+		}
+
+		snapPath := filepath.Join(tempDir, "raft.snap")
+		snap, err := os.Create(snapPath)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := client.Sys().RaftSnapshot(snap); err != nil {
+			t.Fatal(err)
+		}
+		snap.Close()
+
+		t.Run("unseal_keys", func(t *testing.T) {
+			ui, cmd := testOperatorRaftSnapshotExportCommand(t)
+			cmd.testStdin = strings.NewReader(strings.Join(encodeKeys(cluster.BarrierKeys), "\n"))
+
+			code := cmd.Run([]string{
+				"-key-shares-file", "-",
+				"-include", "kv",
+				"-kv-path", "kv-v2/app/db",
+				snapPath,
+			})
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+
+			var export SnapshotExport
+			if err := json.Unmarshal(ui.OutputWriter.Bytes(), &export); err != nil {
+				t.Fatal(err)
+			}
+			if export.Policies != nil || export.Identity != nil {
+				t.Fatalf("expected only KV secrets to be exported: %s", ui.OutputWriter.String())
+			}
+			secrets := export.KV["kv-v2/"].Secrets
+			if len(secrets) != 1 {
+				t.Fatalf("expected exactly one secret, got %v", secrets)
+			}
+			db := secrets["app/db"].(map[string]interface{})
+			version := db["versions"].(map[string]interface{})["1"].(map[string]interface{})
+			if got := version["data"].(map[string]interface{})["password"]; got != "Hashi123" {
+				t.Errorf("expected password %q to be %q", got, "Hashi123")
+			}
+		})
+
+		t.Run("keyring_escrow", func(t *testing.T) {
+			ui, cmd := testOperatorRaftSnapshotExportCommand(t)
+
+			sharesPath := filepath.Join(tempDir, "shares.txt")
+			if err := os.WriteFile(sharesPath, []byte(share+"\n"), 0o600); err != nil {
+				t.Fatal(err)
+			}
+
+			outPath := filepath.Join(tempDir, "export.json")
+			code := cmd.Run([]string{
+				"-keyring-escrow", escrowPath,
+				"-key-shares-file", sharesPath,
+				"-include", "policies",
+				"-output", outPath,
+				snapPath,
+			})
+			if exp := 0; code != exp {
+				t.Fatalf("expected %d to be %d: %s", code, exp, ui.ErrorWriter.String())
+			}
+
+			buf, err := os.ReadFile(outPath)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var export SnapshotExport
+			if err := json.Unmarshal(buf, &export); err != nil {
+				t.Fatal(err)
+			}
+			if got := export.Policies["app"]; !strings.Contains(got, "kv-v2/*") {
+				t.Errorf("expected policy %q to contain %q", got, "kv-v2/*")
+			}
+		})
+
+		t.Run("wrong_keys", func(t *testing.T) {
+			ui, cmd := testOperatorRaftSnapshotExportCommand(t)
+
+			// Shares of another cluster cannot decrypt the snapshot
+			var shares []string
+			for range cluster.BarrierKeys {
+				shares = append(shares, strings.Repeat("ab", 33))
+			}
+			cmd.testStdin = strings.NewReader(strings.Join(shares, "\n"))
+
+			code := cmd.Run([]string{"-key-shares-file", "-", snapPath})
+			if exp := 2; code != exp {
+				t.Errorf("expected %d to be %d", code, exp)
+			}
+
+			expected := "Error decrypting snapshot: "
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			if !strings.Contains(combined, expected) {
+				t.Errorf("expected %q to contain %q", combined, expected)
+			}
+		})
+	})
+
+	t.Run("no_tabs", func(t *testing.T) {
+		t.Parallel()
+
+		_, cmd := testOperatorRaftSnapshotExportCommand(t)
+		assertNoTabs(t, cmd)
+	})
+}
@@ -10,6 +10,7 @@ import (
 	"compress/gzip"
 	"crypto/sha256"
 	"encoding/json"
+	"flag"
 	"fmt"
 	"hash"
 	"io"
@@ -35,9 +36,15 @@ var (
 
 type OperatorRaftSnapshotInspectCommand struct {
 	*BaseCommand
-	details bool
-	depth   int
-	filter  string
+	details  bool
+	depth    int
+	filter   string
+	diff     string
+	listKeys string
+
+	// collectKeys is set when the individual keys of the snapshot are needed,
+	// rather than just the per-prefix stats.
+	collectKeys bool
 }
 
 func (c *OperatorRaftSnapshotInspectCommand) Synopsis() string {
@@ -52,6 +59,14 @@ func (c *OperatorRaftSnapshotInspectCommand) Help() string {
 	
 	$ vault operator raft snapshot inspect raft.snap
 	
+	Lists the storage keys under a prefix, with their sizes:
+	
+	$ vault operator raft snapshot inspect -list-keys sys/expire/id/ raft.snap
+	
+	Reports the storage keys added, removed or changed since an older snapshot:
+	
+	$ vault operator raft snapshot inspect -diff yesterday.snap raft.snap
+	
 	` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -79,7 +94,22 @@ func (c *OperatorRaftSnapshotInspectCommand) Flags() *FlagSets {
 		Name:    "filter",
 		Target:  &c.filter,
 		Default: "",
-		Usage:   "Can only be used with -details. Limits the key breakdown using this prefix filter.",
+		Usage:   "Can only be used with -details or -diff. Limits the key breakdown using this prefix filter.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "diff",
+		Target:  &c.diff,
+		Default: "",
+		Usage: "Path to an older snapshot file to compare against. Reports the keys added, removed or changed " +
+			"since that snapshot, and a breakdown of the changes by key prefix using -depth.",
+	})
+
+	f.StringVar(&StringVar{
+		Name:    "list-keys",
+		Target:  &c.listKeys,
+		Default: "",
+		Usage:   "Lists the keys in the snapshot starting with this prefix, along with their sizes. Set to an empty string to list all keys.",
 	})
 
 	return set
@@ -107,6 +137,9 @@ type SnapshotInfo struct {
 	StatsKV      map[string]typeStats
 	TotalCountKV int
 	TotalSizeKV  int
+
+	// keys holds every key in the snapshot when the command needs them
+	keys map[string]keyInfo
 }
 
 type MetadataInfo struct {
@@ -123,6 +156,39 @@ type typeStats struct {
 	Size  int
 }
 
+// keyInfo describes a single storage key in a snapshot
+type keyInfo struct {
+	Key  string
+	Size int
+	hash [sha256.Size]byte
+}
+
+// KeyListFormat is the output of the -list-keys mode
+type KeyListFormat struct {
+	Meta      *MetadataInfo
+	Keys      []keyInfo
+	TotalSize int
+}
+
+// DiffFormat is the output of the -diff mode
+type DiffFormat struct {
+	Meta      *MetadataInfo
+	OtherMeta *MetadataInfo
+	Added     []keyInfo
+	Removed   []keyInfo
+	Changed   []keyInfo
+	Prefixes  []diffStats
+}
+
+// diffStats summarizes the changes under a key prefix
+type diffStats struct {
+	Name      string
+	Added     int
+	Removed   int
+	Changed   int
+	SizeDelta int
+}
+
 func (c *OperatorRaftSnapshotInspectCommand) Run(args []string) int {
 	flags := c.Flags()
 
@@ -137,6 +203,18 @@ func (c *OperatorRaftSnapshotInspectCommand) Run(args []string) int {
 		return 1
 	}
 
+	listKeysSet := false
+	flags.Visit(func(fl *flag.Flag) {
+		if fl.Name == "list-keys" {
+			listKeysSet = true
+		}
+	})
+	if listKeysSet && c.diff != "" {
+		c.UI.Error("Only one of -list-keys and -diff can be used")
+		return 1
+	}
+	c.collectKeys = listKeysSet || c.diff != ""
+
 	var file string
 	args = c.flags.Args()
 
@@ -151,61 +229,259 @@ func (c *OperatorRaftSnapshotInspectCommand) Run(args []string) int {
 		return 1
 	}
 
+	info, metaformat, err := c.readFile(file)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	switch {
+	case listKeysSet:
+		return c.outputKeyList(info, metaformat)
+	case c.diff != "":
+		otherInfo, otherMeta, err := c.readFile(c.diff)
+		if err != nil {
+			c.UI.Error(err.Error())
+			return 1
+		}
+		return c.outputDiff(info, metaformat, otherInfo, otherMeta)
+	}
+
+	formattedStatsKV := generateKVStats(*info)
+
+	data := &OutputFormat{
+		Meta:         metaformat,
+		StatsKV:      formattedStatsKV,
+		TotalCountKV: info.TotalCountKV,
+		TotalSizeKV:  info.TotalSizeKV,
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, data)
+	}
+
+	tableData, err := formatTable(data)
+	if err != nil {
+		c.UI.Error(err.Error())
+		return 1
+	}
+
+	c.UI.Output(tableData)
+
+	return 0
+}
+
+// readFile reads the snapshot at the given path and returns its snapshot info
+// and metadata.
+func (c *OperatorRaftSnapshotInspectCommand) readFile(file string) (*SnapshotInfo, *MetadataInfo, error) {
 	// Open the file.
 	f, err := os.Open(file)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error opening snapshot file: %s", err))
-		return 1
+		return nil, nil, fmt.Errorf("Error opening snapshot file: %s", err)
 	}
 	defer f.Close()
 
 	// Extract metadata and snapshot info from snapshot file
-	var info *SnapshotInfo
-	var meta *raft.SnapshotMeta
-	info, meta, err = c.Read(hclog.New(nil), f)
+	info, meta, err := c.Read(hclog.New(nil), f)
 	if err != nil {
-		c.UI.Error(fmt.Sprintf("Error reading snapshot: %s", err))
-		return 1
+		return nil, nil, fmt.Errorf("Error reading snapshot: %s", err)
 	}
 
 	if info == nil {
-		c.UI.Error(fmt.Sprintf("Error calculating snapshot info: %s", err))
-		return 1
+		return nil, nil, fmt.Errorf("Error calculating snapshot info: %s", err)
 	}
 
 	// Generate structs for the formatter with information we read in
-	metaformat := &MetadataInfo{
+	return info, &MetadataInfo{
 		ID:      meta.ID,
 		Size:    meta.Size,
 		Index:   meta.Index,
 		Term:    meta.Term,
 		Version: meta.Version,
+	}, nil
+}
+
+func (c *OperatorRaftSnapshotInspectCommand) outputKeyList(info *SnapshotInfo, meta *MetadataInfo) int {
+	data := &KeyListFormat{
+		Meta: meta,
+		Keys: make([]keyInfo, 0),
 	}
+	for key, ki := range info.keys {
+		if !strings.HasPrefix(key, c.listKeys) {
+			continue
+		}
+		data.Keys = append(data.Keys, ki)
+		data.TotalSize += ki.Size
+	}
+	sortKeyInfos(data.Keys)
 
-	formattedStatsKV := generateKVStats(*info)
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, data)
+	}
 
-	data := &OutputFormat{
-		Meta:         metaformat,
-		StatsKV:      formattedStatsKV,
-		TotalCountKV: info.TotalCountKV,
-		TotalSizeKV:  info.TotalSizeKV,
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 8, 8, 6, ' ', 0)
+	fmt.Fprintln(tw, " Key	Size")
+	fmt.Fprintf(tw, " %s	%s", "----", "----")
+	for _, k := range data.Keys {
+		fmt.Fprintf(tw, "\n %s\t%s", k.Key, ByteSize(uint64(k.Size)))
+	}
+	fmt.Fprintf(tw, "\n %s\t%s", "----", "----")
+	fmt.Fprintf(tw, "\n Total Keys\t%d", len(data.Keys))
+	fmt.Fprintf(tw, "\n Total Size\t%s", ByteSize(uint64(data.TotalSize)))
+	if err := tw.Flush(); err != nil {
+		c.UI.Error(err.Error())
+		return 1
 	}
 
+	c.UI.Output(b.String())
+	return 0
+}
+
+func (c *OperatorRaftSnapshotInspectCommand) outputDiff(info *SnapshotInfo, meta *MetadataInfo, otherInfo *SnapshotInfo, otherMeta *MetadataInfo) int {
+	data := diffSnapshots(info.keys, otherInfo.keys, c.filter, c.depth)
+	data.Meta = meta
+	data.OtherMeta = otherMeta
+
 	if Format(c.UI) != "table" {
 		return OutputData(c.UI, data)
 	}
 
-	tableData, err := formatTable(data)
-	if err != nil {
+	var b bytes.Buffer
+	tw := tabwriter.NewWriter(&b, 8, 8, 6, ' ', 0)
+	fmt.Fprintf(tw, " ID\t%s\t%s", otherMeta.ID, meta.ID)
+	fmt.Fprintf(tw, "\n Index\t%d\t%d", otherMeta.Index, meta.Index)
+	fmt.Fprintf(tw, "\n Term\t%d\t%d", otherMeta.Term, meta.Term)
+	fmt.Fprintf(tw, "\n")
+
+	if len(data.Prefixes) > 0 {
+		fmt.Fprintf(tw, "\n")
+		fmt.Fprintln(tw, "\n Key Name\tAdded\tRemoved\tChanged\tSize Change")
+		fmt.Fprintf(tw, " %s\t%s\t%s\t%s\t%s", "----", "----", "----", "----", "----")
+		for _, p := range data.Prefixes {
+			fmt.Fprintf(tw, "\n %s\t%d\t%d\t%d\t%s", p.Name, p.Added, p.Removed, p.Changed, signedByteSize(p.SizeDelta))
+		}
+	}
+
+	fmt.Fprintf(tw, "\n")
+	fmt.Fprintln(tw, "\n Change\tKey\tSize")
+	fmt.Fprintf(tw, " %s\t%s\t%s", "----", "----", "----")
+	for _, k := range data.Added {
+		fmt.Fprintf(tw, "\n +\t%s\t%s", k.Key, ByteSize(uint64(k.Size)))
+	}
+	for _, k := range data.Removed {
+		fmt.Fprintf(tw, "\n -\t%s\t%s", k.Key, ByteSize(uint64(k.Size)))
+	}
+	for _, k := range data.Changed {
+		fmt.Fprintf(tw, "\n ~\t%s\t%s", k.Key, ByteSize(uint64(k.Size)))
+	}
+	fmt.Fprintf(tw, "\n %s\t%s", "----", "----")
+	fmt.Fprintf(tw, "\n Added\t%d", len(data.Added))
+	fmt.Fprintf(tw, "\n Removed\t%d", len(data.Removed))
+	fmt.Fprintf(tw, "\n Changed\t%d", len(data.Changed))
+	if err := tw.Flush(); err != nil {
 		c.UI.Error(err.Error())
 		return 1
 	}
 
-	c.UI.Output(tableData)
-
+	c.UI.Output(b.String())
 	return 0
 }
 
+// diffSnapshots compares the keys of a snapshot with those of an older one.
+// Only keys starting with filter are considered, and the changes are
+// summarized by key prefix up to the given depth.
+func diffSnapshots(keys, oldKeys map[string]keyInfo, filter string, depth int) *DiffFormat {
+	data := &DiffFormat{
+		Added:   make([]keyInfo, 0),
+		Removed: make([]keyInfo, 0),
+		Changed: make([]keyInfo, 0),
+	}
+	prefixes := make(map[string]*diffStats)
+	stats := func(key string) *diffStats {
+		prefix := keyPrefix(key, depth)
+		ds, ok := prefixes[prefix]
+		if !ok {
+			ds = &diffStats{Name: prefix}
+			prefixes[prefix] = ds
+		}
+		return ds
+	}
+
+	for key, ki := range keys {
+		if !strings.HasPrefix(key, filter) {
+			continue
+		}
+		old, ok := oldKeys[key]
+		switch {
+		case !ok:
+			data.Added = append(data.Added, ki)
+			ds := stats(key)
+			ds.Added++
+			ds.SizeDelta += ki.Size
+		case old.hash != ki.hash:
+			data.Changed = append(data.Changed, ki)
+			ds := stats(key)
+			ds.Changed++
+			ds.SizeDelta += ki.Size - old.Size
+		}
+	}
+	for key, old := range oldKeys {
+		if !strings.HasPrefix(key, filter) {
+			continue
+		}
+		if _, ok := keys[key]; !ok {
+			data.Removed = append(data.Removed, old)
+			ds := stats(key)
+			ds.Removed++
+			ds.SizeDelta -= old.Size
+		}
+	}
+
+	sortKeyInfos(data.Added)
+	sortKeyInfos(data.Removed)
+	sortKeyInfos(data.Changed)
+
+	data.Prefixes = make([]diffStats, 0, len(prefixes))
+	for _, ds := range prefixes {
+		data.Prefixes = append(data.Prefixes, *ds)
+	}
+	// Sort by the number of changes, most first, then alphabetically
+	sort.Slice(data.Prefixes, func(i, j int) bool {
+		ci := data.Prefixes[i].Added + data.Prefixes[i].Removed + data.Prefixes[i].Changed
+		cj := data.Prefixes[j].Added + data.Prefixes[j].Removed + data.Prefixes[j].Changed
+		if ci == cj {
+			return data.Prefixes[i].Name < data.Prefixes[j].Name
+		}
+		return ci > cj
+	})
+
+	return data
+}
+
+func sortKeyInfos(keys []keyInfo) {
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i].Key < keys[j].Key
+	})
+}
+
+// keyPrefix returns the first depth segments of the key. If depth is 0 or
+// larger than the number of segments, the whole key is returned.
+func keyPrefix(key string, depth int) string {
+	split := strings.Split(key, "/")
+	if depth == 0 || depth > len(split) {
+		return key
+	}
+	return strings.Join(split[0:depth], "/")
+}
+
+func signedByteSize(delta int) string {
+	if delta < 0 {
+		return "-" + ByteSize(uint64(-delta))
+	}
+	return "+" + ByteSize(uint64(delta))
+}
+
 func (c *OperatorRaftSnapshotInspectCommand) kvEnhance(val *pb.StorageEntry, info *SnapshotInfo, read int) {
 	if !c.details {
 		return
@@ -221,16 +497,7 @@ func (c *OperatorRaftSnapshotInspectCommand) kvEnhance(val *pb.StorageEntry, inf
 		return
 	}
 
-	split := strings.Split(val.Key, "/")
-
-	// handle the situation where the key is shorter than
-	// the specified depth.
-	actualDepth := c.depth
-	if c.depth == 0 || c.depth > len(split) {
-		actualDepth = len(split)
-	}
-
-	prefix := strings.Join(split[0:actualDepth], "/")
+	prefix := keyPrefix(val.Key, c.depth)
 	kvs := info.StatsKV[prefix]
 	if kvs.Name == "" {
 		kvs.Name = prefix
@@ -261,6 +528,17 @@ func (c *OperatorRaftSnapshotInspectCommand) parseState(r io.Reader) (SnapshotIn
 		}
 		size := protoReader.GetLastReadSize()
 		c.kvEnhance(s, &info, size)
+
+		if c.collectKeys && s.Key != "" {
+			if info.keys == nil {
+				info.keys = make(map[string]keyInfo)
+			}
+			info.keys[s.Key] = keyInfo{
+				Key:  s.Key,
+				Size: size,
+				hash: sha256.Sum256(s.Value),
+			}
+		}
 	}
 
 	return info, nil
@@ -269,10 +547,25 @@ func (c *OperatorRaftSnapshotInspectCommand) parseState(r io.Reader) (SnapshotIn
 // Read contents of snapshot. Parse metadata and snapshot info
 // Also, verify validity of snapshot
 func (c *OperatorRaftSnapshotInspectCommand) Read(logger hclog.Logger, in io.Reader) (*SnapshotInfo, *raft.SnapshotMeta, error) {
+	var snapshotInfo SnapshotInfo
+	metadata, err := readSnapshot(logger, in, func(r io.Reader) error {
+		var err error
+		snapshotInfo, err = c.parseState(r)
+		return err
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	return &snapshotInfo, metadata, nil
+}
+
+// readSnapshot decompresses a snapshot, passing its state to parseState, and
+// returns its metadata once the integrity of the snapshot is verified.
+func readSnapshot(logger hclog.Logger, in io.Reader, parseState func(io.Reader) error) (*raft.SnapshotMeta, error) {
 	// Wrap the reader in a gzip decompressor.
 	decomp, err := gzip.NewReader(in)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to decompress snapshot: %v", err)
+		return nil, fmt.Errorf("failed to decompress snapshot: %v", err)
 	}
 
 	defer func() {
@@ -286,20 +579,20 @@ func (c *OperatorRaftSnapshotInspectCommand) Read(logger hclog.Logger, in io.Rea
 	}()
 
 	// Read the archive.
-	snapshotInfo, metadata, err := c.read(decomp)
+	metadata, err := readSnapshotArchive(decomp, parseState)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to read snapshot file: %v", err)
+		return nil, fmt.Errorf("failed to read snapshot file: %v", err)
 	}
 
 	if err := concludeGzipRead(decomp); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 
 	if err := decomp.Close(); err != nil {
-		return nil, nil, err
+		return nil, err
 	}
 	decomp = nil
-	return snapshotInfo, metadata, nil
+	return metadata, nil
 }
 
 func formatTable(info *OutputFormat) (string, error) {
@@ -474,9 +767,10 @@ func (hl *hashList) DecodeAndVerify(r io.Reader) error {
 	return nil
 }
 
-// read takes a reader and extracts the snapshot metadata and snapshot
-// info. It also checks the integrity of the snapshot data.
-func (c *OperatorRaftSnapshotInspectCommand) read(in io.Reader) (*SnapshotInfo, *raft.SnapshotMeta, error) {
+// readSnapshotArchive takes a reader and extracts the snapshot metadata,
+// passing the snapshot state to parseState. It also checks the integrity of
+// the snapshot data.
+func readSnapshotArchive(in io.Reader, parseState func(io.Reader) error) (*raft.SnapshotMeta, error) {
 	// Start a new tar reader.
 	archive := tar.NewReader(in)
 
@@ -492,7 +786,6 @@ func (c *OperatorRaftSnapshotInspectCommand) read(in io.Reader) (*SnapshotInfo,
 
 	// Look through the archive for the pieces we care about.
 	var shaBuffer bytes.Buffer
-	var snapshotInfo SnapshotInfo
 	var metadata raft.SnapshotMeta
 	for {
 		hdr, err := archive.Next()
@@ -500,7 +793,7 @@ func (c *OperatorRaftSnapshotInspectCommand) read(in io.Reader) (*SnapshotInfo,
 			break
 		}
 		if err != nil {
-			return nil, nil, fmt.Errorf("failed reading snapshot: %v", err)
+			return nil, fmt.Errorf("failed reading snapshot: %v", err)
 		}
 
 		switch hdr.Name {
@@ -515,23 +808,21 @@ func (c *OperatorRaftSnapshotInspectCommand) read(in io.Reader) (*SnapshotInfo,
 			// independent of how json.Decode works internally.
 			buf, err := io.ReadAll(io.TeeReader(archive, metaHash))
 			if err != nil {
-				return nil, nil, fmt.Errorf("failed to read snapshot metadata: %v", err)
+				return nil, fmt.Errorf("failed to read snapshot metadata: %v", err)
 			}
 			if err := json.Unmarshal(buf, &metadata); err != nil {
-				return nil, nil, fmt.Errorf("failed to decode snapshot metadata: %v", err)
+				return nil, fmt.Errorf("failed to decode snapshot metadata: %v", err)
 			}
 		case "state.bin":
 			// create reader that writes to snapHash what it reads from archive
 			wrappedReader := io.TeeReader(archive, snapHash)
-			var err error
-			snapshotInfo, err = c.parseState(wrappedReader)
-			if err != nil {
-				return nil, nil, fmt.Errorf("error parsing snapshot state: %v", err)
+			if err := parseState(wrappedReader); err != nil {
+				return nil, fmt.Errorf("error parsing snapshot state: %v", err)
 			}
 
 		case "SHA256SUMS":
 			if _, err := io.CopyN(&shaBuffer, archive, 10000); err != nil && err != io.EOF {
-				return nil, nil, fmt.Errorf("failed to read snapshot hashes: %v", err)
+				return nil, fmt.Errorf("failed to read snapshot hashes: %v", err)
 			}
 
 		case "SHA256SUMS.sealed":
@@ -539,16 +830,16 @@ func (c *OperatorRaftSnapshotInspectCommand) read(in io.Reader) (*SnapshotInfo,
 			continue
 
 		default:
-			return nil, nil, fmt.Errorf("unexpected file %q in snapshot", hdr.Name)
+			return nil, fmt.Errorf("unexpected file %q in snapshot", hdr.Name)
 		}
 	}
 
 	// Verify all the hashes.
 	if err := hl.DecodeAndVerify(&shaBuffer); err != nil {
-		return nil, nil, fmt.Errorf("failed checking integrity of snapshot: %v", err)
+		return nil, fmt.Errorf("failed checking integrity of snapshot: %v", err)
 	}
 
-	return &snapshotInfo, &metadata, nil
+	return &metadata, nil
 }
 
 // concludeGzipRead should be invoked after you think you've consumed all of
@@ -5,8 +5,10 @@ package command
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -27,15 +29,23 @@ func testOperatorRaftSnapshotInspectCommand(tb testing.TB) (*cli.MockUi, *Operat
 }
 
 func createSnapshot(tb testing.TB) (*os.File, func(), error) {
+	entries := make(map[string]string)
+	for i := 0; i < 100; i++ {
+		entries[fmt.Sprintf("key-%d", i)] = fmt.Sprintf("value-%d", i)
+	}
+	return createSnapshotWithEntries(tb, entries)
+}
+
+func createSnapshotWithEntries(tb testing.TB, entries map[string]string) (*os.File, func(), error) {
 	// Create new raft backend
 	r, raftDir := raft.GetRaft(tb, true, false)
 	defer os.RemoveAll(raftDir)
 
 	// Write some data
-	for i := 0; i < 100; i++ {
+	for key, value := range entries {
 		err := r.Put(context.Background(), &physical.Entry{
-			Key:   fmt.Sprintf("key-%d", i),
-			Value: []byte(fmt.Sprintf("value-%d", i)),
+			Key:   key,
+			Value: []byte(value),
 		})
 		if err != nil {
 			return nil, nil, fmt.Errorf("Error adding data to snapshot %s", err)
@@ -139,3 +149,156 @@ func TestOperatorRaftSnapshotInspectCommand_Run(t *testing.T) {
 		}
 	})
 }
+
+func TestOperatorRaftSnapshotInspectCommand_ListKeysAndDiff(t *testing.T) {
+	t.Parallel()
+
+	oldSnap, cleanupOld, err := createSnapshotWithEntries(t, map[string]string{
+		"sys/expire/id/auth/token/a": "lease-a",
+		"sys/expire/id/auth/token/b": "lease-b",
+		"logical/kv/foo":             "foo",
+		"logical/kv/bar":             "bar",
+	})
+	if err != nil {
+		t.Fatalf("Error creating snapshot %s", err)
+	}
+	t.Cleanup(cleanupOld)
+
+	newSnap, cleanupNew, err := createSnapshotWithEntries(t, map[string]string{
+		"sys/expire/id/auth/token/a": "lease-a",
+		"sys/expire/id/auth/token/c": "lease-c",
+		"sys/expire/id/auth/token/d": "lease-d",
+		"logical/kv/foo":             "foo-updated",
+	})
+	if err != nil {
+		t.Fatalf("Error creating snapshot %s", err)
+	}
+	t.Cleanup(cleanupNew)
+
+	cases := []struct {
+		name   string
+		args   []string
+		out    []string
+		notOut []string
+		code   int
+	}{
+		{
+			"list_keys_prefix",
+			[]string{"-list-keys", "sys/expire/", newSnap.Name()},
+			[]string{"sys/expire/id/auth/token/a", "sys/expire/id/auth/token/d", "Total Keys 3"},
+			[]string{"logical/kv/foo"},
+			0,
+		},
+		{
+			"list_keys_all",
+			[]string{"-list-keys=", newSnap.Name()},
+			[]string{"sys/expire/id/auth/token/c", "logical/kv/foo", "Total Keys 4"},
+			nil,
+			0,
+		},
+		{
+			"diff",
+			[]string{"-diff", oldSnap.Name(), newSnap.Name()},
+			[]string{
+				"+ sys/expire/id/auth/token/c",
+				"+ sys/expire/id/auth/token/d",
+				"- sys/expire/id/auth/token/b",
+				"- logical/kv/bar",
+				"~ logical/kv/foo",
+				"Added 2",
+				"Removed 2",
+				"Changed 1",
+			},
+			[]string{"sys/expire/id/auth/token/a"},
+			0,
+		},
+		{
+			"diff_filter",
+			[]string{"-diff", oldSnap.Name(), "-filter", "logical/", newSnap.Name()},
+			[]string{"logical/kv/bar", "logical/kv/foo", "Added 0"},
+			[]string{"sys/expire"},
+			0,
+		},
+		{
+			"diff_and_list_keys",
+			[]string{"-diff", oldSnap.Name(), "-list-keys", "sys/", newSnap.Name()},
+			[]string{"Only one of -list-keys and -diff can be used"},
+			nil,
+			1,
+		},
+		{
+			"diff_missing_file",
+			[]string{"-diff", "does-not-exist.snap", newSnap.Name()},
+			[]string{"Error opening snapshot file"},
+			nil,
+			1,
+		},
+	}
+
+	for _, tc := range cases {
+		tc := tc
+		t.Run(tc.name, func(t *testing.T) {
+			t.Parallel()
+
+			ui, cmd := testOperatorRaftSnapshotInspectCommand(t)
+			code := cmd.Run(tc.args)
+			combined := ui.OutputWriter.String() + ui.ErrorWriter.String()
+			// Collapse the table padding so the expectations don't depend on it
+			combined = strings.Join(strings.Fields(combined), " ")
+			if code != tc.code {
+				t.Errorf("expected %d to be %d: %s", code, tc.code, combined)
+			}
+			for _, out := range tc.out {
+				if !strings.Contains(combined, out) {
+					t.Errorf("expected %q to contain %q", combined, out)
+				}
+			}
+			for _, out := range tc.notOut {
+				if strings.Contains(combined, out) {
+					t.Errorf("expected %q not to contain %q", combined, out)
+				}
+			}
+		})
+	}
+}
+
+func TestDiffSnapshots(t *testing.T) {
+	key := func(k string, size int, value string) keyInfo {
+		return keyInfo{Key: k, Size: size, hash: sha256.Sum256([]byte(value))}
+	}
+	oldKeys := map[string]keyInfo{
+		"sys/expire/id/a": key("sys/expire/id/a", 10, "a"),
+		"logical/kv/foo":  key("logical/kv/foo", 10, "foo"),
+	}
+	keys := map[string]keyInfo{
+		"sys/expire/id/a": key("sys/expire/id/a", 10, "a"),
+		"sys/expire/id/b": key("sys/expire/id/b", 20, "b"),
+		"sys/expire/id/c": key("sys/expire/id/c", 30, "c"),
+		"logical/kv/foo":  key("logical/kv/foo", 15, "foo-2"),
+	}
+
+	diff := diffSnapshots(keys, oldKeys, "", 2)
+	if len(diff.Added) != 2 || diff.Added[0].Key != "sys/expire/id/b" || diff.Added[1].Key != "sys/expire/id/c" {
+		t.Fatalf("unexpected added keys: %#v", diff.Added)
+	}
+	if len(diff.Removed) != 0 {
+		t.Fatalf("unexpected removed keys: %#v", diff.Removed)
+	}
+	if len(diff.Changed) != 1 || diff.Changed[0].Key != "logical/kv/foo" {
+		t.Fatalf("unexpected changed keys: %#v", diff.Changed)
+	}
+
+	expected := []diffStats{
+		{Name: "sys/expire", Added: 2, SizeDelta: 50},
+		{Name: "logical/kv", Changed: 1, SizeDelta: 5},
+	}
+	if !reflect.DeepEqual(expected, diff.Prefixes) {
+		t.Fatalf("expected prefixes %#v, got %#v", expected, diff.Prefixes)
+	}
+
+	// Reversing the direction turns additions into removals
+	diff = diffSnapshots(oldKeys, keys, "sys/", 0)
+	if len(diff.Removed) != 2 || len(diff.Added) != 0 || len(diff.Changed) != 0 {
+		t.Fatalf("unexpected diff: %#v", diff)
+	}
+}
@@ -25,6 +25,7 @@ type OperatorUnsealCommand struct {
 
 	flagReset   bool
 	flagMigrate bool
+	flagVerify  bool
 
 	testOutput io.Writer // for tests
 }
@@ -51,6 +52,11 @@ Usage: vault operator unseal [options] [KEY]
       $ vault operator unseal
       Key (will be hidden): IXyR0OJnSFobekZMMCKCoVEpT7wI6l+USMzE3IcyDyo=
 
+  Check that an unseal key, or a recovery key with auto-unseal, belongs to the
+  current split of the key, without unsealing:
+
+      $ vault operator unseal -verify
+
 ` + c.Flags().Help()
 
 	return strings.TrimSpace(helpText)
@@ -81,6 +87,17 @@ func (c *OperatorUnsealCommand) Flags() *FlagSets {
 		Usage:      "Indicate that this share is provided with the intent that it is part of a seal migration process.",
 	})
 
+	f.BoolVar(&BoolVar{
+		Name:       "verify",
+		Aliases:    []string{},
+		Target:     &c.flagVerify,
+		Default:    false,
+		EnvVar:     "",
+		Completion: complete.PredictNothing,
+		Usage: "Only check that the key belongs to the current split of the " +
+			"unseal key, or of the recovery key, without unsealing.",
+	})
+
 	return set
 }
 
@@ -152,6 +169,10 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 		unsealKey = strings.TrimSpace(value)
 	}
 
+	if c.flagVerify {
+		return c.verify(client, unsealKey)
+	}
+
 	status, err := client.Sys().UnsealWithOptions(&api.UnsealOpts{
 		Key:     unsealKey,
 		Migrate: c.flagMigrate,
@@ -163,3 +184,28 @@ func (c *OperatorUnsealCommand) Run(args []string) int {
 
 	return OutputSealStatus(c.UI, client, status)
 }
+
+// verify checks the key share without unsealing, and returns 2 if the key is
+// not valid.
+func (c *OperatorUnsealCommand) verify(client *api.Client, unsealKey string) int {
+	resp, err := client.Sys().UnsealVerify(unsealKey)
+	if err != nil {
+		c.UI.Error(fmt.Sprintf("Error verifying key: %s", err))
+		return 2
+	}
+
+	if Format(c.UI) != "table" {
+		return OutputData(c.UI, resp)
+	}
+
+	keyType := "unseal key"
+	if resp.RecoveryKey {
+		keyType = "recovery key"
+	}
+	if !resp.Valid {
+		c.UI.Error(fmt.Sprintf("The key is not a share of the current %s", keyType))
+		return 2
+	}
+	c.UI.Output(fmt.Sprintf("Success! The key is a share of the current %s", keyType))
+	return 0
+}
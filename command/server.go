@@ -2923,6 +2923,7 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		AuditBackends:                  c.AuditBackends,
 		CredentialBackends:             c.CredentialBackends,
 		LogicalBackends:                c.LogicalBackends,
+		PhysicalBackends:               c.PhysicalBackends,
 		LogLevel:                       config.LogLevel,
 		Logger:                         c.logger,
 		DetectDeadlocks:                config.DetectDeadlocks,
@@ -2961,6 +2962,16 @@ func createCoreConfig(c *ServerCommand, config *server.Config, backend physical.
 		ObservationSystemConfig:        config.Observations,
 	}
 
+	if config.Cache != nil {
+		coreConfig.CacheConfig = &physical.CacheConfig{
+			Size:                 config.Cache.Size,
+			PrefixSizes:          config.Cache.PrefixSizes,
+			ExcludePaths:         config.Cache.ExcludePaths,
+			TTL:                  config.Cache.TTL,
+			DisableNegativeCache: config.Cache.DisableNegativeCache,
+		}
+	}
+
 	if c.flagDev {
 		coreConfig.EnableRaw = true
 		coreConfig.EnableIntrospection = true
@@ -3234,12 +3245,7 @@ func (c *ServerCommand) reloadSeals(ctx context.Context, grabStateLock bool, cor
 	}
 
 	currentConfig := core.GetCoreConfigInternal()
-
-	// We only want to reload if multiseal is currently enabled, or it is being enabled
-	if !(currentConfig.IsMultisealEnabled() || newConfig.IsMultisealEnabled()) {
-		c.logger.Debug("not reloading seal configuration since enable_multiseal is not set, nor is it being disabled")
-		return false, nil
-	}
+	multisealEnabled := currentConfig.IsMultisealEnabled() || newConfig.IsMultisealEnabled()
 
 	if conf, err := core.PhysicalBarrierSealConfig(ctx); err != nil {
 		return false, fmt.Errorf("error reading barrier seal configuration from storage while reloading seals: %w", err)
@@ -3248,6 +3254,13 @@ func (c *ServerCommand) reloadSeals(ctx context.Context, grabStateLock bool, cor
 		return false, nil
 	}
 
+	// Without multiseal, only changes to the configuration of the current seal
+	// can be reloaded, for example to rotate its credentials
+	if !multisealEnabled && cmp.Equal(currentConfig.Seals, newConfig.Seals) {
+		c.logger.Debug("not reloading seal configuration since it did not change")
+		return false, nil
+	}
+
 	if core.SealAccess().BarrierSealConfigType() == vault.SealConfigTypeShamir {
 		switch {
 		case len(newConfig.Seals) == 0:
@@ -3286,6 +3299,12 @@ func (c *ServerCommand) reloadSeals(ctx context.Context, grabStateLock bool, cor
 		return false, errors.New("not reloading seal configuration: moving from Shamir to autoseal requires seal migration")
 	}
 
+	if !multisealEnabled {
+		if err := validateSingleSealReload(currentConfig.Seals, newConfig.Seals); err != nil {
+			return false, err
+		}
+	}
+
 	infoKeysReload := make([]string, 0)
 	infoReload := make(map[string]string)
 
@@ -3298,6 +3317,15 @@ func (c *ServerCommand) reloadSeals(ctx context.Context, grabStateLock bool, cor
 		return false, fmt.Errorf("error reloading seal configuration: %w", setSealResponse.sealConfigError)
 	}
 
+	// Make sure the new seal can decrypt what the current one encrypted before
+	// switching over to it
+	if !multisealEnabled {
+		if err := core.ValidateSeal(ctx, setSealResponse.barrierSeal); err != nil {
+			c.finalizeSeals(ctx, setSealResponse.getCreatedSeals())
+			return false, fmt.Errorf("error validating reloaded seal configuration: %w", err)
+		}
+	}
+
 	newGen := setSealResponse.barrierSeal.GetAccess().GetSealGenerationInfo()
 
 	var standby, perf bool
@@ -3313,11 +3341,15 @@ func (c *ServerCommand) reloadSeals(ctx context.Context, grabStateLock bool, cor
 			return false, fmt.Errorf("error setting seal: %s", err)
 		}
 
-		if err := core.SetPhysicalSealGenInfo(ctx, newGen); err != nil {
-			c.logger.Warn("could not update seal information in storage", "err", err)
+		if multisealEnabled {
+			if err := core.SetPhysicalSealGenInfo(ctx, newGen); err != nil {
+				c.logger.Warn("could not update seal information in storage", "err", err)
+			}
 		}
-	case perf:
-		c.logger.Debug("updating reloaded seals in memory on perf standby")
+	case perf || !multisealEnabled:
+		// Without multiseal the seal generation cannot change, so standbys can
+		// switch over to the reloaded seal right away
+		c.logger.Debug("updating reloaded seals in memory on standby")
 		err = core.SetSealsOnPerfStandby(ctx, grabStateLock, setSealResponse.barrierSeal, secureRandomReader)
 		if err != nil {
 			return false, fmt.Errorf("error setting seal on perf standby: %s", err)
@@ -3334,6 +3366,19 @@ func (c *ServerCommand) reloadSeals(ctx context.Context, grabStateLock bool, cor
 	return true, nil
 }
 
+// validateSingleSealReload checks that a seal configuration reloaded without
+// multiseal only changes the settings of the current seal. Changing the seal
+// itself requires a seal migration.
+func validateSingleSealReload(current, reloaded []*configutil.KMS) error {
+	if len(reloaded) != 1 || reloaded[0].Disabled {
+		return errors.New("not reloading seal configuration: adding or disabling seals requires seal migration or enable_multiseal")
+	}
+	if len(current) != 1 || current[0].Type != reloaded[0].Type || current[0].Name != reloaded[0].Name {
+		return errors.New("not reloading seal configuration: changing the seal requires seal migration")
+	}
+	return nil
+}
+
 // Attempt to read the cluster name from the insecure storage.
 func (c *ServerCommand) readClusterNameFromInsecureStorage(b physical.Backend) (string, error) {
 	ctx := context.Background()
@@ -57,6 +57,7 @@ type Config struct {
 	Experiments []string `hcl:"experiments"`
 
 	CacheSize                int         `hcl:"cache_size"`
+	Cache                    *Cache      `hcl:"cache"`
 	DisableCache             bool        `hcl:"-"`
 	DisableCacheRaw          interface{} `hcl:"disable_cache"`
 	DisablePrintableCheck    bool        `hcl:"-"`
@@ -252,6 +253,52 @@ func (b *Storage) GoString() string {
 	return fmt.Sprintf("*%#v", *b)
 }
 
+// Cache configures the read cache in front of the storage backend. The
+// top-level cache_size is used if it does not set a size.
+type Cache struct {
+	Size         int            `hcl:"size"`
+	PrefixSizes  map[string]int `hcl:"prefix_sizes"`
+	ExcludePaths []string       `hcl:"exclude_paths"`
+
+	TTL    time.Duration `hcl:"-"`
+	TTLRaw interface{}   `hcl:"ttl"`
+
+	DisableNegativeCache    bool        `hcl:"-"`
+	DisableNegativeCacheRaw interface{} `hcl:"disable_negative_cache"`
+}
+
+func (c *Cache) parse() error {
+	var err error
+	if c.TTLRaw != nil {
+		if c.TTL, err = parseutil.ParseDurationSecond(c.TTLRaw); err != nil {
+			return fmt.Errorf("invalid ttl: %w", err)
+		}
+		c.TTLRaw = nil
+	}
+	if c.DisableNegativeCacheRaw != nil {
+		if c.DisableNegativeCache, err = parseutil.ParseBool(c.DisableNegativeCacheRaw); err != nil {
+			return fmt.Errorf("invalid disable_negative_cache: %w", err)
+		}
+		c.DisableNegativeCacheRaw = nil
+	}
+
+	if c.Size < 0 {
+		return errors.New("size must not be negative")
+	}
+	if c.TTL < 0 {
+		return errors.New("ttl must not be negative")
+	}
+	for prefix, size := range c.PrefixSizes {
+		if prefix == "" {
+			return errors.New("prefix_sizes must not contain an empty prefix")
+		}
+		if size <= 0 {
+			return fmt.Errorf("size of prefix %q must be greater than zero", prefix)
+		}
+	}
+	return nil
+}
+
 // ServiceRegistration is the optional service discovery for the server.
 type ServiceRegistration struct {
 	UnusedKeys configutil.UnusedKeyMap `hcl:",unusedKeyPositions"`
@@ -306,6 +353,11 @@ func (c *Config) Merge(c2 *Config) *Config {
 		result.CacheSize = c2.CacheSize
 	}
 
+	result.Cache = c.Cache
+	if c2.Cache != nil {
+		result.Cache = c2.Cache
+	}
+
 	// merging these booleans via an OR operation
 	result.DisableCache = c.DisableCache
 	if c2.DisableCache {
@@ -696,6 +748,12 @@ func ParseConfigCheckDuplicate(d, source string) (cfg *Config, duplicate bool, e
 		}
 	}
 
+	if result.Cache != nil {
+		if err := result.Cache.parse(); err != nil {
+			return nil, duplicate, fmt.Errorf("error parsing 'cache': %w", err)
+		}
+	}
+
 	if result.DisablePrintableCheckRaw != nil {
 		if result.DisablePrintableCheck, err = parseutil.ParseBool(result.DisablePrintableCheckRaw); err != nil {
 			return nil, duplicate, err
@@ -1414,6 +1472,17 @@ func (c *Config) Sanitized() map[string]interface{} {
 		result["storage"] = sanitizedStorage
 	}
 
+	// Sanitize cache stanza
+	if c.Cache != nil {
+		result["cache"] = map[string]interface{}{
+			"size":                   c.Cache.Size,
+			"prefix_sizes":           c.Cache.PrefixSizes,
+			"exclude_paths":          c.Cache.ExcludePaths,
+			"ttl":                    c.Cache.TTL / time.Second,
+			"disable_negative_cache": c.Cache.DisableNegativeCache,
+		}
+	}
+
 	// Sanitize observations stanza
 	if c.Observations != nil {
 		sanitizedObservations := map[string]interface{}{
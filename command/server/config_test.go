@@ -8,6 +8,7 @@ import (
 	"reflect"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/vault/internalshared/configutil"
 	"github.com/stretchr/testify/require"
@@ -105,6 +106,22 @@ func Test_ObservationSystemConfig(t *testing.T) {
 	require.Equal(t, "/var/ledger.log", config.Observations.LedgerPath)
 }
 
+// Test_CacheConfig makes sure that the cache config is properly loaded.
+func Test_CacheConfig(t *testing.T) {
+	config, err := LoadConfigFile("./test-fixtures/cache.hcl")
+	require.NoError(t, err)
+	require.NotNil(t, config.Cache)
+	require.Equal(t, 1024, config.CacheSize)
+	require.Equal(t, 4096, config.Cache.Size)
+	require.Equal(t, 30*time.Second, config.Cache.TTL)
+	require.True(t, config.Cache.DisableNegativeCache)
+	require.Equal(t, map[string]int{"logical/": 2048, "identity/": 512}, config.Cache.PrefixSizes)
+	require.Equal(t, []string{"sys/counters/"}, config.Cache.ExcludePaths)
+
+	_, err = ParseConfig(`cache { prefix_sizes = { "identity/" = 0 } }`, "")
+	require.ErrorContains(t, err, `size of prefix "identity/" must be greater than zero`)
+}
+
 // TestDuplicateKeyValidationHcl checks that the server command displays a warning when the HCL config file contains duplicate keys.
 func TestDuplicateKeyValidationHcl(t *testing.T) {
 	testDuplicateKeyValidationHcl(t)
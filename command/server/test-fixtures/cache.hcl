@@ -0,0 +1,18 @@
+# Copyright (c) HashiCorp, Inc.
+# SPDX-License-Identifier: BUSL-1.1
+
+cache_size = 1024
+
+cache {
+    size = 4096
+    ttl  = "30s"
+
+    disable_negative_cache = true
+
+    prefix_sizes = {
+        "logical/"  = 2048
+        "identity/" = 512
+    }
+
+    exclude_paths = ["sys/counters/"]
+}
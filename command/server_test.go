@@ -451,3 +451,55 @@ func TestReloadSeals(t *testing.T) {
 	require.NoError(t, err)
 	require.False(t, reloaded, "reloadSeals does not support Shamir seals")
 }
+
+func TestValidateSingleSealReload(t *testing.T) {
+	current := []*configutil.KMS{{Type: "transit", Name: "transit", Priority: 1}}
+
+	tests := []struct {
+		name     string
+		reloaded []*configutil.KMS
+		wantErr  bool
+	}{
+		{
+			name: "same seal with new settings",
+			reloaded: []*configutil.KMS{{
+				Type: "transit", Name: "transit", Priority: 1,
+				Config: map[string]string{"token": "new-token"},
+			}},
+		},
+		{
+			name:     "different seal type",
+			reloaded: []*configutil.KMS{{Type: "awskms", Name: "awskms", Priority: 1}},
+			wantErr:  true,
+		},
+		{
+			name:     "different seal name",
+			reloaded: []*configutil.KMS{{Type: "transit", Name: "other", Priority: 1}},
+			wantErr:  true,
+		},
+		{
+			name: "added seal",
+			reloaded: []*configutil.KMS{
+				{Type: "transit", Name: "transit", Priority: 1},
+				{Type: "transit", Name: "other", Priority: 2},
+			},
+			wantErr: true,
+		},
+		{
+			name:     "disabled seal",
+			reloaded: []*configutil.KMS{{Type: "transit", Name: "transit", Priority: 1, Disabled: true}},
+			wantErr:  true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateSingleSealReload(current, tt.reloaded)
+			if tt.wantErr {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
@@ -65,6 +65,7 @@ func NewTestSoloCluster(t testing.TB, config *vault.CoreConfig) *vault.TestClust
 			"file":   audit.NewFileBackend,
 			"socket": audit.NewSocketBackend,
 			"syslog": audit.NewSyslogBackend,
+			"http":   audit.NewHTTPBackend,
 		}
 	}
 	if mycfg.BuiltinRegistry == nil {
@@ -324,6 +324,7 @@ func ClusterSetup(conf *vault.CoreConfig, opts *vault.TestClusterOptions, setup
 			"file":   audit.NewFileBackend,
 			"socket": audit.NewSocketBackend,
 			"syslog": audit.NewSyslogBackend,
+			"http":   audit.NewHTTPBackend,
 			"noop":   audit.NoopAuditFactory(nil),
 		}
 	}
@@ -133,6 +133,8 @@ func init() {
 		"sys/storage/raft/snapshot-force",
 		"!sys/storage/raft/snapshot-auto/config",
 		"sys/storage/raft/snapshot-load",
+		"sys/storage/raft/snapshot/restore-mount",
+		"sys/storage/raft/snapshot/restore-mount/*",
 	})
 	websocketPaths.AddPaths(websocketRawPaths)
 }
@@ -185,6 +187,7 @@ func handler(props *vault.HandlerProperties) http.Handler {
 		mux.Handle("/v1/sys/seal", handleSysSeal(core))
 		mux.Handle("/v1/sys/step-down", handleRequestForwarding(core, handleSysStepDown(core)))
 		mux.Handle("/v1/sys/unseal", handleSysUnseal(core))
+		mux.Handle("/v1/sys/unseal-verify", handleSysUnsealVerify(core))
 		mux.Handle("/v1/sys/leader", handleSysLeader(core,
 			WithRedactAddresses(props.ListenerConfig.RedactAddresses)))
 		mux.Handle("/v1/sys/health", handleSysHealth(core,
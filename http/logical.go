@@ -113,10 +113,15 @@ func buildLogicalRequestNoAuth(perfStandby bool, ra *vault.RouterAccess, w http.
 		if (ra != nil && ra.IsBinaryPath(r.Context(), path)) ||
 			path == "sys/storage/raft/snapshot" ||
 			path == "sys/storage/raft/snapshot-force" ||
-			path == "sys/storage/raft/snapshot-load" {
+			path == "sys/storage/raft/snapshot-load" ||
+			isRaftSnapshotRestoreMountPath(path) {
 
 			passHTTPReq = true
 			origBody = r.Body
+			// The body holds the snapshot, so parameters are passed in the query
+			if isRaftSnapshotRestoreMountPath(path) {
+				data = parseQuery(r.URL.Query())
+			}
 		} else {
 			// Sample the first bytes to determine whether this should be parsed as
 			// a form or as JSON. The amount to look ahead (512 bytes) is arbitrary
@@ -253,6 +258,13 @@ func buildLogicalRequestNoAuth(perfStandby bool, ra *vault.RouterAccess, w http.
 	return req, origBody, 0, nil
 }
 
+// isRaftSnapshotRestoreMountPath returns whether the path is the endpoint
+// restoring a single mount from a raft snapshot, with or without a mount path.
+func isRaftSnapshotRestoreMountPath(path string) bool {
+	return path == "sys/storage/raft/snapshot/restore-mount" ||
+		strings.HasPrefix(path, "sys/storage/raft/snapshot/restore-mount/")
+}
+
 func buildLogicalPath(r *http.Request) (string, int, error) {
 	ns, err := namespace.FromContext(r.Context())
 	if err != nil {
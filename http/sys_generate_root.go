@@ -12,6 +12,7 @@ import (
 	"net/http"
 
 	"github.com/hashicorp/go-secure-stdlib/base62"
+	"github.com/hashicorp/go-secure-stdlib/parseutil"
 	"github.com/hashicorp/vault/vault"
 )
 
@@ -87,6 +88,8 @@ func handleSysGenerateRootAttemptGet(core *vault.Core, w http.ResponseWriter, r
 		status.Nonce = generationConfig.Nonce
 		status.Started = true
 		status.PGPFingerprint = generationConfig.PGPFingerprint
+		status.TTL = int64(generationConfig.TTL.Seconds())
+		status.Policies = generationConfig.Policies
 	}
 
 	respondOk(w, status)
@@ -118,8 +121,18 @@ func handleSysGenerateRootAttemptPut(core *vault.Core, w http.ResponseWriter, r
 		}
 	}
 
+	ttl, err := parseutil.ParseDurationSecond(req.TTL)
+	if err != nil {
+		respondError(w, http.StatusBadRequest, fmt.Errorf("invalid ttl: %w", err))
+		return
+	}
+
 	// Attemptialize the generation
-	if err := core.GenerateRootInit(req.OTP, req.PGPKey, generateStrategy); err != nil {
+	opts := &vault.GenerateRootOptions{
+		TTL:      ttl,
+		Policies: req.Policies,
+	}
+	if err := core.GenerateRootInitWithOptions(req.OTP, req.PGPKey, generateStrategy, opts); err != nil {
 		respondError(w, http.StatusBadRequest, err)
 		return
 	}
@@ -201,21 +214,25 @@ func handleSysGenerateRootUpdate(core *vault.Core, generateStrategy vault.Genera
 }
 
 type GenerateRootInitRequest struct {
-	OTP    string `json:"otp"`
-	PGPKey string `json:"pgp_key"`
+	OTP      string      `json:"otp"`
+	PGPKey   string      `json:"pgp_key"`
+	TTL      interface{} `json:"ttl"`
+	Policies []string    `json:"policies"`
 }
 
 type GenerateRootStatusResponse struct {
-	Nonce            string `json:"nonce"`
-	Started          bool   `json:"started"`
-	Progress         int    `json:"progress"`
-	Required         int    `json:"required"`
-	Complete         bool   `json:"complete"`
-	EncodedToken     string `json:"encoded_token"`
-	EncodedRootToken string `json:"encoded_root_token"`
-	PGPFingerprint   string `json:"pgp_fingerprint"`
-	OTP              string `json:"otp"`
-	OTPLength        int    `json:"otp_length"`
+	Nonce            string   `json:"nonce"`
+	Started          bool     `json:"started"`
+	Progress         int      `json:"progress"`
+	Required         int      `json:"required"`
+	Complete         bool     `json:"complete"`
+	EncodedToken     string   `json:"encoded_token"`
+	EncodedRootToken string   `json:"encoded_root_token"`
+	PGPFingerprint   string   `json:"pgp_fingerprint"`
+	OTP              string   `json:"otp"`
+	OTPLength        int      `json:"otp_length"`
+	TTL              int64    `json:"ttl,omitempty"`
+	Policies         []string `json:"policies,omitempty"`
 }
 
 type GenerateRootUpdateRequest struct {
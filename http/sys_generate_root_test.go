@@ -149,6 +149,47 @@ func TestSysGenerateRootAttempt_Setup_PGP(t *testing.T) {
 	}
 }
 
+func TestSysGenerateRootAttempt_Setup_TTL(t *testing.T) {
+	core, _, token := vault.TestCoreUnsealed(t)
+	ln, addr := TestServer(t, core)
+	defer ln.Close()
+	TestServerAuth(t, addr, token)
+
+	resp := testHttpPut(t, token, addr+"/v1/sys/generate-root/attempt", map[string]interface{}{
+		"policies": []string{"foo"},
+	})
+	testResponseStatus(t, resp, 400)
+
+	resp = testHttpPut(t, token, addr+"/v1/sys/generate-root/attempt", map[string]interface{}{
+		"ttl":      "1h",
+		"policies": []string{"foo"},
+	})
+	testResponseStatus(t, resp, 200)
+
+	resp = testHttpGet(t, token, addr+"/v1/sys/generate-root/attempt")
+
+	var actual map[string]interface{}
+	expected := map[string]interface{}{
+		"started":            true,
+		"progress":           json.Number("0"),
+		"required":           json.Number("3"),
+		"complete":           false,
+		"encoded_token":      "",
+		"encoded_root_token": "",
+		"pgp_fingerprint":    "",
+		"otp":                "",
+		"otp_length":         json.Number(tokenLength),
+		"ttl":                json.Number("3600"),
+		"policies":           []interface{}{"foo"},
+	}
+	testResponseStatus(t, resp, 200)
+	testResponseBody(t, resp, &actual)
+	expected["nonce"] = actual["nonce"]
+	if diff := deep.Equal(actual, expected); diff != nil {
+		t.Fatal(diff)
+	}
+}
+
 func TestSysGenerateRootAttempt_Cancel(t *testing.T) {
 	core, _, token := vault.TestCoreUnsealed(t)
 	ln, addr := TestServer(t, core)
@@ -348,7 +389,7 @@ func TestSysGenerateRoot_Update_OTP(t *testing.T) {
 	expected = map[string]interface{}{
 		"id":               newRootToken,
 		"display_name":     "root",
-		"meta":             interface{}(nil),
+		"meta":             map[string]interface{}{"generated_root": "true"},
 		"num_uses":         json.Number("0"),
 		"policies":         []interface{}{"root"},
 		"orphan":           true,
@@ -451,7 +492,7 @@ func TestSysGenerateRoot_Update_PGP(t *testing.T) {
 	expected = map[string]interface{}{
 		"id":               newRootToken,
 		"display_name":     "root",
-		"meta":             interface{}(nil),
+		"meta":             map[string]interface{}{"generated_root": "true"},
 		"num_uses":         json.Number("0"),
 		"policies":         []interface{}{"root"},
 		"orphan":           true,
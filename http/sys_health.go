@@ -252,6 +252,9 @@ func getSysHealth(core *vault.Core, r *http.Request) (int, *HealthResponse, erro
 	if standby {
 		body.ReplicationPrimaryCanaryAgeMillis = core.GetReplicationLagMillisIgnoreErrs()
 	}
+	if init && !sealed {
+		body.ReadReplica = core.IsRaftReadReplica()
+	}
 
 	licenseState, err := core.EntGetLicenseState()
 	if err != nil {
@@ -310,4 +313,5 @@ type HealthResponse struct {
 	RemovedFromCluster                   *bool                  `json:"removed_from_cluster,omitempty"`
 	HAConnectionHealthy                  *bool                  `json:"ha_connection_healthy,omitempty"`
 	LastRequestForwardingHeartbeatMillis int64                  `json:"last_request_forwarding_heartbeat_ms,omitempty"`
+	ReadReplica                          bool                   `json:"read_replica,omitempty"`
 }
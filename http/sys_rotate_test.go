@@ -40,7 +40,7 @@ func TestSysRotate(t *testing.T) {
 	testResponseStatus(t, resp, 200)
 	testResponseBody(t, resp, &actual)
 
-	for _, field := range []string{"install_time", "encryptions"} {
+	for _, field := range []string{"install_time", "encryptions", "rotation_history"} {
 		actualVal, ok := actual["data"].(map[string]interface{})[field]
 		if !ok || actualVal == "" {
 			t.Fatal(field, " missing in data")
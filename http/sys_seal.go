@@ -117,20 +117,10 @@ func handleSysUnseal(core *vault.Core) http.Handler {
 			return
 		}
 
-		// Decode the key, which is base64 or hex encoded
-		min, max := core.BarrierKeyLength()
-		key, err := hex.DecodeString(req.Key)
-		// We check min and max here to ensure that a string that is base64
-		// encoded but also valid hex will not be valid and we instead base64
-		// decode it
-		if err != nil || len(key) < min || len(key) > max {
-			key, err = base64.StdEncoding.DecodeString(req.Key)
-			if err != nil {
-				respondError(
-					w, http.StatusBadRequest,
-					errors.New("'key' must be a valid hex or base64 string"))
-				return
-			}
+		key, err := decodeUnsealKey(core, req.Key)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
 		}
 
 		// Attempt the unseal.  If migrate was specified, the key should correspond
@@ -160,6 +150,72 @@ func handleSysUnseal(core *vault.Core) http.Handler {
 	})
 }
 
+// handleSysUnsealVerify checks whether a key share belongs to the current
+// split of the unseal or recovery key, without using it to unseal.
+func handleSysUnsealVerify(core *vault.Core) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "PUT":
+		case "POST":
+		default:
+			respondError(w, http.StatusMethodNotAllowed, nil)
+			return
+		}
+
+		var req UnsealVerifyRequest
+		if _, err := parseJSONRequest(core.PerfStandby(), r, w, &req); err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+		if req.Key == "" {
+			respondError(
+				w, http.StatusBadRequest,
+				errors.New("'key' must be specified in request body as JSON"))
+			return
+		}
+
+		key, err := decodeUnsealKey(core, req.Key)
+		if err != nil {
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		valid, recovery, err := core.VerifyUnsealShare(r.Context(), key)
+		if err != nil {
+			switch {
+			case errors.Is(err, vault.ErrNotInit):
+			case errors.Is(err, vault.ErrNoShareVerifiers):
+			default:
+				respondError(w, http.StatusInternalServerError, err)
+				return
+			}
+			respondError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		respondOk(w, &UnsealVerifyResponse{
+			Valid:       valid,
+			RecoveryKey: recovery,
+		})
+	})
+}
+
+// decodeUnsealKey decodes an unseal key share, which is base64 or hex encoded
+func decodeUnsealKey(core *vault.Core, encoded string) ([]byte, error) {
+	min, max := core.BarrierKeyLength()
+	key, err := hex.DecodeString(encoded)
+	// We check min and max here to ensure that a string that is base64
+	// encoded but also valid hex will not be valid and we instead base64
+	// decode it
+	if err != nil || len(key) < min || len(key) > max {
+		key, err = base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, errors.New("'key' must be a valid hex or base64 string")
+		}
+	}
+	return key, nil
+}
+
 func handleSysSealStatus(core *vault.Core, opt ...ListenerConfigOption) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != "GET" {
@@ -227,3 +283,12 @@ type UnsealRequest struct {
 	Reset   bool
 	Migrate bool
 }
+
+type UnsealVerifyRequest struct {
+	Key string `json:"key"`
+}
+
+type UnsealVerifyResponse struct {
+	Valid       bool `json:"valid"`
+	RecoveryKey bool `json:"recovery_key"`
+}
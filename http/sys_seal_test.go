@@ -26,6 +26,7 @@ import (
 	"github.com/hashicorp/vault/vault/seal"
 	"github.com/hashicorp/vault/version"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func TestSysSealStatus(t *testing.T) {
@@ -652,3 +653,73 @@ func TestSysSealStatusRedaction(t *testing.T) {
 	testResponseBody(t, resp, &actual)
 	assert.Empty(t, actual["version"])
 }
+
+// TestSysUnsealVerify verifies that key shares can be checked against the
+// current split without unsealing, for unseal keys and recovery keys.
+func TestSysUnsealVerify(t *testing.T) {
+	for name, storedKeys := range map[string]seal.StoredKeysSupport{
+		"shamir":      seal.StoredKeysSupportedShamirRoot,
+		"auto-unseal": seal.StoredKeysSupportedGeneric,
+	} {
+		t.Run(name, func(t *testing.T) {
+			core := vault.TestCoreWithSeal(t, vault.NewTestSeal(t, &seal.TestSealOpts{StoredKeys: storedKeys}), false)
+			result, err := core.Initialize(context.Background(), &vault.InitParams{
+				BarrierConfig: &vault.SealConfig{
+					SecretShares:    3,
+					SecretThreshold: 2,
+				},
+				RecoveryConfig: &vault.SealConfig{
+					SecretShares:    3,
+					SecretThreshold: 2,
+				},
+			})
+			require.NoError(t, err)
+			shares := result.SecretShares
+			recovery := storedKeys == seal.StoredKeysSupportedGeneric
+			if recovery {
+				shares = result.RecoveryShares
+			}
+			require.Len(t, shares, 3)
+
+			ln, addr := TestServer(t, core)
+			defer ln.Close()
+
+			for i, share := range shares {
+				encoded := hex.EncodeToString(share)
+				if i%2 == 1 {
+					encoded = base64.StdEncoding.EncodeToString(share)
+				}
+				resp := testHttpPut(t, "", addr+"/v1/sys/unseal-verify", map[string]interface{}{
+					"key": encoded,
+				})
+				var actual map[string]interface{}
+				testResponseStatus(t, resp, 200)
+				testResponseBody(t, resp, &actual)
+				require.Equal(t, map[string]interface{}{
+					"valid":        true,
+					"recovery_key": recovery,
+				}, actual)
+			}
+
+			// A share of another split is not valid
+			other := make([]byte, len(shares[0]))
+			copy(other, shares[0])
+			other[0] ^= 0xff
+			resp := testHttpPut(t, "", addr+"/v1/sys/unseal-verify", map[string]interface{}{
+				"key": hex.EncodeToString(other),
+			})
+			var actual map[string]interface{}
+			testResponseStatus(t, resp, 200)
+			testResponseBody(t, resp, &actual)
+			require.Equal(t, false, actual["valid"])
+
+			// Verifying does not contribute to the unseal progress
+			status, err := core.GetSealStatus(context.Background(), true)
+			require.NoError(t, err)
+			require.Equal(t, 0, status.Progress)
+
+			resp = testHttpPut(t, "", addr+"/v1/sys/unseal-verify", map[string]interface{}{})
+			testResponseStatus(t, resp, 400)
+		})
+	}
+}
@@ -20,7 +20,7 @@ import (
 	"github.com/hashicorp/vault/vault/quotas"
 )
 
-var nonVotersAllowed = false
+var nonVotersAllowed = true
 
 func wrapMaxRequestSizeHandler(handler http.Handler, props *vault.HandlerProperties) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
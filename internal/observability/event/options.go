@@ -4,7 +4,9 @@
 package event
 
 import (
+	"crypto/tls"
 	"fmt"
+	"math"
 	"os"
 	"reflect"
 	"strconv"
@@ -29,6 +31,20 @@ type options struct {
 	withMaxDuration time.Duration
 	withFileMode    *os.FileMode
 	withLogger      hclog.Logger
+
+	withBatchSize       int
+	withFlushInterval   time.Duration
+	withMaxRetries      int
+	withRetryMinBackoff time.Duration
+	withRetryMaxBackoff time.Duration
+	withSpoolDir        string
+	withSpoolMaxBytes   int64
+	withTLSConfig       *tls.Config
+
+	withMaxBytes int64
+	withMaxAge   time.Duration
+	withMaxFiles int
+	withCompress bool
 }
 
 // getDefaultOptions returns Options with their default values.
@@ -42,6 +58,13 @@ func getDefaultOptions() options {
 		withSocketType:  "tcp",
 		withMaxDuration: 2 * time.Second,
 		withFileMode:    &fileMode,
+
+		withBatchSize:       100,
+		withFlushInterval:   100 * time.Millisecond,
+		withMaxRetries:      3,
+		withRetryMinBackoff: 250 * time.Millisecond,
+		withRetryMaxBackoff: 5 * time.Second,
+		withSpoolMaxBytes:   1 << 30,
 	}
 }
 
@@ -216,3 +239,249 @@ func WithLogger(l hclog.Logger) Option {
 		return nil
 	}
 }
+
+// WithBatchSize provides an Option to represent the maximum number of events
+// sent in a single request by an HTTP sink.
+func WithBatchSize(size string) Option {
+	return func(o *options) error {
+		size = strings.TrimSpace(size)
+		if size == "" {
+			return nil
+		}
+
+		parsed, err := strconv.Atoi(size)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse batch size: %w: %w", ErrInvalidParameter, err)
+		case parsed < 1:
+			return fmt.Errorf("batch size must be at least 1: %w", ErrInvalidParameter)
+		}
+
+		o.withBatchSize = parsed
+
+		return nil
+	}
+}
+
+// WithFlushInterval provides an Option to represent the maximum duration an
+// event waits in a batch of an HTTP sink before the batch is sent.
+func WithFlushInterval(interval string) Option {
+	return func(o *options) error {
+		interval = strings.TrimSpace(interval)
+		if interval == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseDurationSecond(interval)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse flush interval: %w: %w", ErrInvalidParameter, err)
+		case parsed <= 0:
+			return fmt.Errorf("flush interval must be positive: %w", ErrInvalidParameter)
+		}
+
+		o.withFlushInterval = parsed
+
+		return nil
+	}
+}
+
+// WithMaxRetries provides an Option to represent the number of times an HTTP
+// sink retries sending a batch before giving up.
+func WithMaxRetries(retries string) Option {
+	return func(o *options) error {
+		retries = strings.TrimSpace(retries)
+		if retries == "" {
+			return nil
+		}
+
+		parsed, err := strconv.Atoi(retries)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse max retries: %w: %w", ErrInvalidParameter, err)
+		case parsed < 0:
+			return fmt.Errorf("max retries cannot be negative: %w", ErrInvalidParameter)
+		}
+
+		o.withMaxRetries = parsed
+
+		return nil
+	}
+}
+
+// WithRetryMinBackoff provides an Option to represent the duration an HTTP
+// sink waits before the first retry. The duration doubles on every retry.
+func WithRetryMinBackoff(duration string) Option {
+	return func(o *options) error {
+		duration = strings.TrimSpace(duration)
+		if duration == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseDurationSecond(duration)
+		if err != nil {
+			return fmt.Errorf("unable to parse retry min backoff: %w: %w", ErrInvalidParameter, err)
+		}
+
+		o.withRetryMinBackoff = parsed
+
+		return nil
+	}
+}
+
+// WithRetryMaxBackoff provides an Option to represent the maximum duration an
+// HTTP sink waits between two retries.
+func WithRetryMaxBackoff(duration string) Option {
+	return func(o *options) error {
+		duration = strings.TrimSpace(duration)
+		if duration == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseDurationSecond(duration)
+		if err != nil {
+			return fmt.Errorf("unable to parse retry max backoff: %w: %w", ErrInvalidParameter, err)
+		}
+
+		o.withRetryMaxBackoff = parsed
+
+		return nil
+	}
+}
+
+// WithSpoolDir provides an Option to represent the directory where an HTTP sink
+// stores the batches it could not send.
+// Supplying an empty string or whitespace disables spooling.
+func WithSpoolDir(dir string) Option {
+	return func(o *options) error {
+		o.withSpoolDir = strings.TrimSpace(dir)
+
+		return nil
+	}
+}
+
+// WithSpoolMaxBytes provides an Option to represent the maximum size of the
+// spool directory of an HTTP sink. Sizes such as '100MB' or '1GiB' are supported.
+func WithSpoolMaxBytes(size string) Option {
+	return func(o *options) error {
+		size = strings.TrimSpace(size)
+		if size == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseCapacityString(size)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse spool max bytes: %w: %w", ErrInvalidParameter, err)
+		case parsed == 0:
+			return fmt.Errorf("spool max bytes must be positive: %w", ErrInvalidParameter)
+		case parsed > math.MaxInt64:
+			return fmt.Errorf("spool max bytes is too large: %w", ErrInvalidParameter)
+		}
+
+		o.withSpoolMaxBytes = int64(parsed)
+
+		return nil
+	}
+}
+
+// WithTLSConfig provides an Option to supply the TLS configuration used by an
+// HTTP sink to connect to its receiver.
+func WithTLSConfig(cfg *tls.Config) Option {
+	return func(o *options) error {
+		o.withTLSConfig = cfg
+
+		return nil
+	}
+}
+
+// WithMaxBytes provides an Option to represent the size a file sink's file can
+// reach before it is rotated. Sizes such as '100MB' or '1GiB' are supported.
+// Zero disables size based rotation.
+func WithMaxBytes(size string) Option {
+	return func(o *options) error {
+		size = strings.TrimSpace(size)
+		if size == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseCapacityString(size)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse max bytes: %w: %w", ErrInvalidParameter, err)
+		case parsed > math.MaxInt64:
+			return fmt.Errorf("max bytes is too large: %w", ErrInvalidParameter)
+		}
+
+		o.withMaxBytes = int64(parsed)
+
+		return nil
+	}
+}
+
+// WithMaxAge provides an Option to represent the duration a file sink writes
+// to a file, from its creation, before it is rotated. Zero disables time based
+// rotation.
+func WithMaxAge(duration string) Option {
+	return func(o *options) error {
+		duration = strings.TrimSpace(duration)
+		if duration == "" {
+			return nil
+		}
+
+		parsed, err := parseutil.ParseDurationSecond(duration)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse max age: %w: %w", ErrInvalidParameter, err)
+		case parsed < 0:
+			return fmt.Errorf("max age cannot be negative: %w", ErrInvalidParameter)
+		}
+
+		o.withMaxAge = parsed
+
+		return nil
+	}
+}
+
+// WithMaxFiles provides an Option to represent the number of rotated files a
+// file sink keeps. Zero keeps all rotated files.
+func WithMaxFiles(files string) Option {
+	return func(o *options) error {
+		files = strings.TrimSpace(files)
+		if files == "" {
+			return nil
+		}
+
+		parsed, err := strconv.Atoi(files)
+		switch {
+		case err != nil:
+			return fmt.Errorf("unable to parse max files: %w: %w", ErrInvalidParameter, err)
+		case parsed < 0:
+			return fmt.Errorf("max files cannot be negative: %w", ErrInvalidParameter)
+		}
+
+		o.withMaxFiles = parsed
+
+		return nil
+	}
+}
+
+// WithCompress provides an Option to represent whether a file sink compresses
+// its rotated files with gzip.
+func WithCompress(compress string) Option {
+	return func(o *options) error {
+		compress = strings.TrimSpace(compress)
+		if compress == "" {
+			return nil
+		}
+
+		parsed, err := strconv.ParseBool(compress)
+		if err != nil {
+			return fmt.Errorf("unable to parse compress: %w: %w", ErrInvalidParameter, err)
+		}
+
+		o.withCompress = parsed
+
+		return nil
+	}
+}
@@ -107,6 +107,17 @@ func TestOptions_Default(t *testing.T) {
 	require.Equal(t, "AUTH", opts.withFacility)
 	require.Equal(t, "vault", opts.withTag)
 	require.Equal(t, 2*time.Second, opts.withMaxDuration)
+	require.Equal(t, 100, opts.withBatchSize)
+	require.Equal(t, 100*time.Millisecond, opts.withFlushInterval)
+	require.Equal(t, 3, opts.withMaxRetries)
+	require.Equal(t, 250*time.Millisecond, opts.withRetryMinBackoff)
+	require.Equal(t, 5*time.Second, opts.withRetryMaxBackoff)
+	require.Empty(t, opts.withSpoolDir)
+	require.Equal(t, int64(1<<30), opts.withSpoolMaxBytes)
+	require.Zero(t, opts.withMaxBytes)
+	require.Zero(t, opts.withMaxAge)
+	require.Zero(t, opts.withMaxFiles)
+	require.False(t, opts.withCompress)
 }
 
 // TestOptions_Opts exercises getOpts with various Option values.
@@ -458,3 +469,371 @@ func TestOptions_WithLogger(t *testing.T) {
 		})
 	}
 }
+
+// TestOptions_WithBatchSize exercises WithBatchSize Option to ensure it performs as expected.
+func TestOptions_WithBatchSize(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        int
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"bad-value": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse batch size: invalid parameter: strconv.Atoi: parsing \"juan\": invalid syntax",
+		},
+		"zero": {
+			Value:                "0",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "batch size must be at least 1: invalid parameter",
+		},
+		"spacey-value": {
+			Value:         "  50  ",
+			ExpectedValue: 50,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithBatchSize(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withBatchSize)
+			}
+		})
+	}
+}
+
+// TestOptions_WithFlushInterval exercises WithFlushInterval Option to ensure it performs as expected.
+func TestOptions_WithFlushInterval(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        time.Duration
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"bad-value": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse flush interval: invalid parameter: time: invalid duration \"juan\"",
+		},
+		"zero": {
+			Value:                "0s",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "flush interval must be positive: invalid parameter",
+		},
+		"duration-500ms": {
+			Value:         "500ms",
+			ExpectedValue: 500 * time.Millisecond,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithFlushInterval(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withFlushInterval)
+			}
+		})
+	}
+}
+
+// TestOptions_WithMaxRetries exercises WithMaxRetries Option to ensure it performs as expected.
+func TestOptions_WithMaxRetries(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        int
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"negative": {
+			Value:                "-1",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "max retries cannot be negative: invalid parameter",
+		},
+		"zero": {
+			Value:         "0",
+			ExpectedValue: 0,
+		},
+		"five": {
+			Value:         "5",
+			ExpectedValue: 5,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithMaxRetries(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withMaxRetries)
+			}
+		})
+	}
+}
+
+// TestOptions_WithMaxBytes exercises WithMaxBytes Option to ensure it performs as expected.
+func TestOptions_WithMaxBytes(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        int64
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"not-a-size": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse max bytes: invalid parameter: could not parse capacity from input",
+		},
+		"bytes": {
+			Value:         "1024",
+			ExpectedValue: 1024,
+		},
+		"megabytes": {
+			Value:         "10MB",
+			ExpectedValue: 10_000_000,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithMaxBytes(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withMaxBytes)
+			}
+		})
+	}
+}
+
+// TestOptions_WithSpoolMaxBytes exercises WithSpoolMaxBytes Option to ensure it performs as expected.
+func TestOptions_WithSpoolMaxBytes(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        int64
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"not-a-size": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse spool max bytes: invalid parameter: could not parse capacity from input",
+		},
+		"zero": {
+			Value:                "0",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "spool max bytes must be positive: invalid parameter",
+		},
+		"gibibytes": {
+			Value:         "2GiB",
+			ExpectedValue: 2 << 30,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithSpoolMaxBytes(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withSpoolMaxBytes)
+			}
+		})
+	}
+}
+
+// TestOptions_WithMaxAge exercises WithMaxAge Option to ensure it performs as expected.
+func TestOptions_WithMaxAge(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        time.Duration
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"not-a-duration": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse max age: invalid parameter: time: invalid duration \"juan\"",
+		},
+		"negative": {
+			Value:                "-1s",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "max age cannot be negative: invalid parameter",
+		},
+		"day": {
+			Value:         "24h",
+			ExpectedValue: 24 * time.Hour,
+		},
+		"seconds": {
+			Value:         "60",
+			ExpectedValue: time.Minute,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithMaxAge(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withMaxAge)
+			}
+		})
+	}
+}
+
+// TestOptions_WithMaxFiles exercises WithMaxFiles Option to ensure it performs as expected.
+func TestOptions_WithMaxFiles(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        int
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"negative": {
+			Value:                "-1",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "max files cannot be negative: invalid parameter",
+		},
+		"five": {
+			Value:         "5",
+			ExpectedValue: 5,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithMaxFiles(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withMaxFiles)
+			}
+		})
+	}
+}
+
+// TestOptions_WithCompress exercises WithCompress Option to ensure it performs as expected.
+func TestOptions_WithCompress(t *testing.T) {
+	tests := map[string]struct {
+		Value                string
+		ExpectedValue        bool
+		IsErrorExpected      bool
+		ExpectedErrorMessage string
+	}{
+		"empty-gives-default": {
+			Value: "",
+		},
+		"not-a-bool": {
+			Value:                "juan",
+			IsErrorExpected:      true,
+			ExpectedErrorMessage: "unable to parse compress: invalid parameter: strconv.ParseBool: parsing \"juan\": invalid syntax",
+		},
+		"true": {
+			Value:         "true",
+			ExpectedValue: true,
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+			opts := &options{}
+			applyOption := WithCompress(tc.Value)
+			err := applyOption(opts)
+			switch {
+			case tc.IsErrorExpected:
+				require.Error(t, err)
+				require.EqualError(t, err, tc.ExpectedErrorMessage)
+			default:
+				require.NoError(t, err)
+				require.Equal(t, tc.ExpectedValue, opts.withCompress)
+			}
+		})
+	}
+}
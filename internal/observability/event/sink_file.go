@@ -4,17 +4,25 @@
 package event
 
 import (
+	"bufio"
 	"bytes"
+	"compress/gzip"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/hashicorp/eventlogger"
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
 )
 
 // defaultFileMode is the default file permissions (read/write for everyone).
@@ -26,6 +34,8 @@ const (
 var _ eventlogger.Node = (*FileSink)(nil)
 
 // FileSink is a sink node which handles writing events to file.
+// When a maximum size or age is configured, the file is rotated by renaming it
+// to '<name>-<unix nano timestamp><ext>' before a new file is opened.
 type FileSink struct {
 	file           *os.File
 	fileLock       sync.RWMutex
@@ -33,10 +43,24 @@ type FileSink struct {
 	path           string
 	requiredFormat string
 	logger         hclog.Logger
+
+	maxBytes     int64
+	maxAge       time.Duration
+	maxFiles     int
+	compress     bool
+	bytesWritten int64
+	created      time.Time
+
+	// rotatedLock serializes the compression and pruning of rotated files,
+	// which happens in the background, and rotatedWG tracks it so that Close
+	// can wait for it to complete.
+	rotatedLock sync.Mutex
+	rotatedWG   sync.WaitGroup
 }
 
 // NewFileSink should be used to create a new FileSink.
-// Accepted options: WithFileMode.
+// Accepted options: WithFileMode, WithLogger, WithMaxBytes, WithMaxAge,
+// WithMaxFiles and WithCompress.
 func NewFileSink(path string, format string, opt ...Option) (*FileSink, error) {
 	// Parse and check path
 	p := strings.TrimSpace(path)
@@ -72,6 +96,10 @@ func NewFileSink(path string, format string, opt ...Option) (*FileSink, error) {
 		requiredFormat: format,
 		path:           p,
 		logger:         opts.withLogger,
+		maxBytes:       opts.withMaxBytes,
+		maxAge:         opts.withMaxAge,
+		maxFiles:       opts.withMaxFiles,
+		compress:       opts.withCompress,
 	}
 
 	// Ensure that the file can be successfully opened for writing;
@@ -148,6 +176,26 @@ func (s *FileSink) Reopen() error {
 	return s.open()
 }
 
+// Close waits for rotated files to be compressed and pruned, then closes the file.
+func (s *FileSink) Close(_ context.Context) error {
+	s.rotatedWG.Wait()
+
+	s.fileLock.Lock()
+	defer s.fileLock.Unlock()
+
+	if s.file == nil {
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("unable to close file for sink %q: %w", s.path, err)
+	}
+
+	return nil
+}
+
 // Type describes the type of this node (sink).
 func (s *FileSink) Type() eventlogger.NodeType {
 	return eventlogger.NodeTypeSink
@@ -185,9 +233,331 @@ func (s *FileSink) open() error {
 		}
 	}
 
+	// Track the size of the file, which may already contain entries, and when
+	// it was created so that we know when to rotate it. The age of the file is
+	// kept when it is reopened or Vault restarts.
+	fileInfo, err := s.file.Stat()
+	if err != nil {
+		return fmt.Errorf("unable to stat file for sink %q: %w", s.path, err)
+	}
+	s.bytesWritten = fileInfo.Size()
+	s.created = s.fileCreated(fileInfo)
+
 	return nil
 }
 
+// fileCreated returns when the file was created. Where the file system doesn't
+// record it, a file created by a rotation is as old as the most recently
+// rotated file, and the modification time is used for a file which was never
+// rotated.
+func (s *FileSink) fileCreated(info os.FileInfo) time.Time {
+	if created, ok := fileBirthTime(s.path, info); ok {
+		return created
+	}
+
+	// Rotated files may be compressed in the background while we list them,
+	// which keeps their timestamp.
+	files, err := s.rotatedFiles()
+	if err == nil && len(files) > 0 {
+		if ts, ok := s.rotatedTimestamp(files[len(files)-1]); ok {
+			return time.Unix(0, ts)
+		}
+	}
+
+	return info.ModTime()
+}
+
+// rotate closes and renames the file if writing the next entry of the supplied
+// size would exceed the maximum size, or if the file reached its maximum age.
+// Rotated files are then compressed and pruned in the background.
+// It doesn't have any locking and relies on the log method to handle this.
+func (s *FileSink) rotate(size int) error {
+	switch {
+	case s.path == devnull, s.file == nil, s.bytesWritten == 0:
+		return nil
+	case s.maxBytes > 0 && s.bytesWritten+int64(size) > s.maxBytes:
+	case s.maxAge > 0 && time.Since(s.created) >= s.maxAge:
+	default:
+		return nil
+	}
+
+	err := s.file.Close()
+	s.file = nil
+	if err != nil {
+		return fmt.Errorf("unable to close file for rotation on sink %q: %w", s.path, err)
+	}
+
+	ext := filepath.Ext(s.path)
+	rotatedPath := fmt.Sprintf("%s-%d%s", strings.TrimSuffix(s.path, ext), time.Now().UnixNano(), ext)
+	if err := os.Rename(s.path, rotatedPath); err != nil {
+		return fmt.Errorf("unable to rename file for rotation on sink %q: %w", s.path, err)
+	}
+
+	if err := s.open(); err != nil {
+		return err
+	}
+
+	s.rotatedWG.Add(1)
+	go func() {
+		defer s.rotatedWG.Done()
+
+		s.rotatedLock.Lock()
+		defer s.rotatedLock.Unlock()
+
+		if s.compress {
+			if err := s.compressFile(rotatedPath); err != nil && s.logger != nil {
+				s.logger.Error("unable to compress rotated file", "path", rotatedPath, "error", err)
+			}
+		}
+
+		if err := s.pruneFiles(); err != nil && s.logger != nil {
+			s.logger.Error("unable to prune rotated files", "path", s.path, "error", err)
+		}
+	}()
+
+	return nil
+}
+
+// compressFile writes a gzip compressed copy of the supplied file, with the
+// '.gz' extension, and removes the original file.
+func (s *FileSink) compressFile(path string) (retErr error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	// Compress to a temporary file first so that a partially compressed file
+	// is never mistaken for a rotated file.
+	tmpPath := path + ".gz.tmp"
+	dst, err := os.OpenFile(tmpPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, s.fileMode)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if retErr != nil {
+			_ = dst.Close()
+			_ = os.Remove(tmpPath)
+		}
+	}()
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		return err
+	}
+	if err := gz.Close(); err != nil {
+		return err
+	}
+	if err := dst.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmpPath, path+".gz"); err != nil {
+		return err
+	}
+
+	return os.Remove(path)
+}
+
+// rotatedFiles returns the paths of the rotated files of the sink, sorted from
+// oldest to newest.
+func (s *FileSink) rotatedFiles() ([]string, error) {
+	ext := filepath.Ext(s.path)
+	base := strings.TrimSuffix(s.path, ext)
+	matches, err := filepath.Glob(globEscape(base) + "-*")
+	if err != nil {
+		return nil, err
+	}
+
+	timestamps := make(map[string]int64)
+	var files []string
+	for _, match := range matches {
+		ts, ok := s.rotatedTimestamp(match)
+		if !ok {
+			continue
+		}
+		timestamps[match] = ts
+		files = append(files, match)
+	}
+
+	sort.Slice(files, func(i, j int) bool {
+		return timestamps[files[i]] < timestamps[files[j]]
+	})
+
+	return files, nil
+}
+
+// rotatedTimestamp returns the unix nano timestamp of the rotation of the
+// supplied file, if it is a rotated file of the sink.
+func (s *FileSink) rotatedTimestamp(path string) (int64, bool) {
+	ext := filepath.Ext(s.path)
+	base := strings.TrimSuffix(s.path, ext)
+	if !strings.HasPrefix(path, base) {
+		return 0, false
+	}
+
+	rotated := regexp.MustCompile(`^-(\d+)` + regexp.QuoteMeta(ext) + `(\.gz)?$`)
+	m := rotated.FindStringSubmatch(strings.TrimPrefix(path, base))
+	if m == nil {
+		return 0, false
+	}
+	ts, err := strconv.ParseInt(m[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return ts, true
+}
+
+// LastEntries returns up to n of the last entries written to the file, oldest
+// first. When the file holds fewer entries, the previous ones are read from the
+// most recently rotated file.
+func (s *FileSink) LastEntries(n int) ([][]byte, error) {
+	s.fileLock.RLock()
+	defer s.fileLock.RUnlock()
+
+	if s.path == devnull || n <= 0 {
+		return nil, nil
+	}
+
+	entries, err := lastLines(s.path, n)
+	if err != nil {
+		return nil, fmt.Errorf("unable to read last entries of %q: %w", s.path, err)
+	}
+	if len(entries) == n {
+		return entries, nil
+	}
+
+	// Rotated files must not be compressed or pruned while we read them.
+	s.rotatedLock.Lock()
+	defer s.rotatedLock.Unlock()
+
+	files, err := s.rotatedFiles()
+	if err != nil {
+		return nil, fmt.Errorf("unable to list rotated files of %q: %w", s.path, err)
+	}
+	if len(files) == 0 {
+		return entries, nil
+	}
+
+	rotated, err := lastLines(files[len(files)-1], n-len(entries))
+	if err != nil {
+		return nil, fmt.Errorf("unable to read last entries of %q: %w", files[len(files)-1], err)
+	}
+
+	return append(rotated, entries...), nil
+}
+
+// lastLines returns up to n of the last non-empty lines of the supplied file,
+// oldest first. Files compressed with gzip are read entirely, others are read
+// from the end.
+func lastLines(path string, n int) ([][]byte, error) {
+	f, err := os.Open(path)
+	switch {
+	case errors.Is(err, os.ErrNotExist):
+		return nil, nil
+	case err != nil:
+		return nil, err
+	}
+	defer f.Close()
+
+	if strings.HasSuffix(path, ".gz") {
+		gz, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, err
+		}
+		defer gz.Close()
+
+		var lines [][]byte
+		reader := bufio.NewReader(gz)
+		for {
+			line, err := reader.ReadBytes('\n')
+			if len(bytes.TrimSpace(line)) > 0 {
+				lines = append(lines, line)
+				if len(lines) > n {
+					lines = lines[1:]
+				}
+			}
+
+			switch {
+			case errors.Is(err, io.EOF):
+				return lines, nil
+			case err != nil:
+				return nil, err
+			}
+		}
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, err
+	}
+
+	// Read more of the end of the file until it holds n complete lines.
+	size := info.Size()
+	for chunk := int64(64 * 1024); ; chunk *= 2 {
+		offset := max(size-chunk, 0)
+		buf := make([]byte, size-offset)
+		if _, err := f.ReadAt(buf, offset); err != nil && !errors.Is(err, io.EOF) {
+			return nil, err
+		}
+
+		split := bytes.SplitAfter(buf, []byte("\n"))
+		if offset > 0 {
+			// The first line may be incomplete.
+			split = split[1:]
+		}
+
+		var lines [][]byte
+		for _, line := range split {
+			if len(bytes.TrimSpace(line)) > 0 {
+				lines = append(lines, line)
+			}
+		}
+
+		if len(lines) >= n || offset == 0 {
+			return lines[max(len(lines)-n, 0):], nil
+		}
+	}
+}
+
+// pruneFiles removes the oldest rotated files so that only the configured
+// maximum number of files are kept.
+func (s *FileSink) pruneFiles() error {
+	if s.maxFiles == 0 {
+		return nil
+	}
+
+	files, err := s.rotatedFiles()
+	if err != nil {
+		return err
+	}
+	if len(files) <= s.maxFiles {
+		return nil
+	}
+
+	var errs error
+	for _, file := range files[:len(files)-s.maxFiles] {
+		if err := os.Remove(file); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = multierror.Append(errs, fmt.Errorf("error removing file %q: %w", file, err))
+		}
+	}
+
+	return errs
+}
+
+// globEscape escapes the characters in the path which have a special meaning
+// in patterns supplied to filepath.Glob.
+func globEscape(path string) string {
+	var b strings.Builder
+	for _, r := range path {
+		switch r {
+		case '*', '?', '[', '\\':
+			b.WriteRune('\\')
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
 // log writes the buffer to the file.
 // NOTE: We attempt to acquire a lock on the file in order to write, but will
 // yield if the context is 'done'.
@@ -208,12 +578,18 @@ func (s *FileSink) log(ctx context.Context, data []byte) error {
 		return fmt.Errorf("unable to open file for sink %q: %w", s.path, err)
 	}
 
-	if _, err := reader.WriteTo(s.file); err == nil {
+	if err := s.rotate(len(data)); err != nil {
+		return fmt.Errorf("unable to rotate file for sink %q: %w", s.path, err)
+	}
+
+	n, err := reader.WriteTo(s.file)
+	s.bytesWritten += n
+	if err == nil {
 		return nil
 	}
 
 	// Otherwise, opportunistically try to re-open the FD, once per call (1 retry attempt).
-	err := s.file.Close()
+	err = s.file.Close()
 	if err != nil {
 		return fmt.Errorf("unable to close file for sink %q: %w", s.path, err)
 	}
@@ -229,7 +605,8 @@ func (s *FileSink) log(ctx context.Context, data []byte) error {
 		return fmt.Errorf("unable to seek to start of file for sink %q: %w", s.path, err)
 	}
 
-	_, err = reader.WriteTo(s.file)
+	n, err = reader.WriteTo(s.file)
+	s.bytesWritten += n
 	if err != nil {
 		return fmt.Errorf("unable to re-write to file for sink %q: %w", s.path, err)
 	}
@@ -0,0 +1,16 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !linux && !darwin && !windows
+
+package event
+
+import (
+	"os"
+	"time"
+)
+
+// fileBirthTime is not supported on this platform.
+func fileBirthTime(_ string, _ os.FileInfo) (time.Time, bool) {
+	return time.Time{}, false
+}
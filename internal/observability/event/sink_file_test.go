@@ -4,11 +4,14 @@
 package event
 
 import (
+	"compress/gzip"
 	"context"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync/atomic"
 	"testing"
 	"time"
@@ -352,3 +355,212 @@ func TestFileSink_log_cancelledContext(t *testing.T) {
 	// We expect that the error now has context cancelled in it.
 	require.True(t, errors.Is(err, context.Canceled))
 }
+
+// testFileSinkEvent returns an event formatted as JSON with the given data.
+func testFileSinkEvent(data string) *eventlogger.Event {
+	e := &eventlogger.Event{Formatted: make(map[string][]byte)}
+	e.FormattedAs("json", []byte(data+"\n"))
+	return e
+}
+
+// TestFileSink_Process_RotateMaxBytes ensures that the file is rotated before
+// it would exceed the maximum size, and that only the configured number of
+// rotated files are kept.
+func TestFileSink_Process_RotateMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit.log")
+	sink, err := NewFileSink(path, "json", WithMaxBytes("10"), WithMaxFiles("1"))
+	require.NoError(t, err)
+
+	for _, data := range []string{"aaaa", "bbbb", "cccc", "dddd", "eeee"} {
+		_, err = sink.Process(context.Background(), testFileSinkEvent(data))
+		require.NoError(t, err)
+	}
+	require.NoError(t, sink.Close(context.Background()))
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "eeee\n", string(current))
+
+	rotated, err := sink.rotatedFiles()
+	require.NoError(t, err)
+	require.Len(t, rotated, 1)
+	require.Regexp(t, `audit-\d+\.log$`, rotated[0])
+	got, err := os.ReadFile(rotated[0])
+	require.NoError(t, err)
+	require.Equal(t, "cccc\ndddd\n", string(got))
+}
+
+// TestFileSink_Process_RotateMaxAge ensures that the file is rotated once it
+// reaches its maximum age, and that rotated files are compressed when required.
+func TestFileSink_Process_RotateMaxAge(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, "audit")
+	sink, err := NewFileSink(path, "json", WithMaxAge("50ms"), WithCompress("true"))
+	require.NoError(t, err)
+
+	_, err = sink.Process(context.Background(), testFileSinkEvent("aaaa"))
+	require.NoError(t, err)
+	time.Sleep(100 * time.Millisecond)
+	_, err = sink.Process(context.Background(), testFileSinkEvent("bbbb"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Close(context.Background()))
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "bbbb\n", string(current))
+
+	rotated, err := sink.rotatedFiles()
+	require.NoError(t, err)
+	require.Len(t, rotated, 1)
+	require.Regexp(t, `audit-\d+\.gz$`, rotated[0])
+
+	f, err := os.Open(rotated[0])
+	require.NoError(t, err)
+	defer f.Close()
+	gz, err := gzip.NewReader(f)
+	require.NoError(t, err)
+	got, err := io.ReadAll(gz)
+	require.NoError(t, err)
+	require.Equal(t, "aaaa\n", string(got))
+}
+
+// TestFileSink_Process_RotateMaxAge_Reopen ensures that the age of the file
+// counts from its creation, so that it isn't reset when the file is reopened
+// or when a new sink is created for the same path, as on a restart.
+func TestFileSink_Process_RotateMaxAge_Reopen(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	sink, err := NewFileSink(path, "json", WithMaxAge("100ms"))
+	require.NoError(t, err)
+
+	_, err = sink.Process(context.Background(), testFileSinkEvent("aaaa"))
+	require.NoError(t, err)
+	time.Sleep(150 * time.Millisecond)
+	require.NoError(t, sink.Reopen())
+	_, err = sink.Process(context.Background(), testFileSinkEvent("bbbb"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Close(context.Background()))
+
+	rotated, err := sink.rotatedFiles()
+	require.NoError(t, err)
+	require.Len(t, rotated, 1)
+
+	// The file created by the rotation reaches its maximum age while no sink
+	// is running.
+	time.Sleep(150 * time.Millisecond)
+	sink, err = NewFileSink(path, "json", WithMaxAge("100ms"))
+	require.NoError(t, err)
+	_, err = sink.Process(context.Background(), testFileSinkEvent("cccc"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Close(context.Background()))
+
+	rotated, err = sink.rotatedFiles()
+	require.NoError(t, err)
+	require.Len(t, rotated, 2)
+	got, err := os.ReadFile(rotated[1])
+	require.NoError(t, err)
+	require.Equal(t, "bbbb\n", string(got))
+
+	current, err := os.ReadFile(path)
+	require.NoError(t, err)
+	require.Equal(t, "cccc\n", string(current))
+}
+
+// TestFileSink_Reopen_ExistingSize ensures that the size of an existing file
+// counts towards the maximum size, including once the file is reopened.
+func TestFileSink_Reopen_ExistingSize(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	require.NoError(t, os.WriteFile(path, []byte("aaaaaaaa\n"), defaultFileMode))
+
+	sink, err := NewFileSink(path, "json", WithMaxBytes("10"))
+	require.NoError(t, err)
+	require.Equal(t, int64(9), sink.bytesWritten)
+	require.NoError(t, sink.Reopen())
+	require.Equal(t, int64(9), sink.bytesWritten)
+
+	_, err = sink.Process(context.Background(), testFileSinkEvent("bbbb"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Close(context.Background()))
+
+	rotated, err := sink.rotatedFiles()
+	require.NoError(t, err)
+	require.Len(t, rotated, 1)
+}
+
+// TestFileSink_LastEntries ensures that the last entries are read from the end
+// of the file, and from the most recently rotated file, compressed or not, when
+// the file holds fewer entries.
+func TestFileSink_LastEntries(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		opts []Option
+	}{
+		"rotated": {
+			opts: []Option{WithMaxBytes("10")},
+		},
+		"rotated-compressed": {
+			opts: []Option{WithMaxBytes("10"), WithCompress("true")},
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			path := filepath.Join(t.TempDir(), "audit.log")
+			sink, err := NewFileSink(path, "json", tc.opts...)
+			require.NoError(t, err)
+
+			entries, err := sink.LastEntries(2)
+			require.NoError(t, err)
+			require.Empty(t, entries)
+
+			for _, data := range []string{"aaaa", "bbbb", "cccc"} {
+				_, err = sink.Process(context.Background(), testFileSinkEvent(data))
+				require.NoError(t, err)
+			}
+			require.NoError(t, sink.Close(context.Background()))
+
+			sink, err = NewFileSink(path, "json", tc.opts...)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = sink.Close(context.Background()) })
+
+			entries, err = sink.LastEntries(1)
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{[]byte("cccc\n")}, entries)
+
+			entries, err = sink.LastEntries(3)
+			require.NoError(t, err)
+			require.Equal(t, [][]byte{[]byte("aaaa\n"), []byte("bbbb\n"), []byte("cccc\n")}, entries)
+		})
+	}
+}
+
+// TestFileSink_lastLines ensures that the last lines of a file larger than the
+// size first read from its end are returned.
+func TestFileSink_lastLines(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "audit.log")
+	long := strings.Repeat("a", 100*1024)
+	require.NoError(t, os.WriteFile(path, []byte("first\n"+long+"\n\nlast\n"), defaultFileMode))
+
+	lines, err := lastLines(path, 2)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte(long + "\n"), []byte("last\n")}, lines)
+
+	lines, err = lastLines(path, 5)
+	require.NoError(t, err)
+	require.Equal(t, [][]byte{[]byte("first\n"), []byte(long + "\n"), []byte("last\n")}, lines)
+}
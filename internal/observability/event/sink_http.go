@@ -0,0 +1,541 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package event
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/vault/sdk/helper/backoff"
+)
+
+var (
+	_ eventlogger.Node   = (*HTTPSink)(nil)
+	_ eventlogger.Closer = (*HTTPSink)(nil)
+)
+
+const (
+	// spoolFileExt is the extension of the files storing spooled batches.
+	spoolFileExt = ".batch"
+
+	// spoolFailedFileExt is the extension given to spooled batches which were
+	// permanently rejected by the receiver.
+	spoolFailedFileExt = ".failed"
+
+	// spoolReserve is the time kept before the deadline of a batch to spool it,
+	// when it could not be sent in time.
+	spoolReserve = 250 * time.Millisecond
+)
+
+// errHTTPSinkPermanent is returned when the receiver rejected a batch with a
+// status code which will not change on retry (e.g. 400 or 401).
+var errHTTPSinkPermanent = errors.New("request rejected by receiver")
+
+// HTTPSink is a sink node which sends events in batches to an HTTP endpoint
+// using POST requests, one formatted event per line.
+// Events are added to the current batch, which is sent when it is full or
+// when the flush interval elapses. Process only returns once the batch
+// containing the event was sent, or stored in the spool directory (if
+// configured) when the receiver could not be reached after all retries.
+// Sending a batch, including its retries, is bounded by the earliest deadline
+// of the contexts of its events, so that it can be spooled before they time out.
+// Spooled batches are sent again, oldest first, before any new batch.
+type HTTPSink struct {
+	requiredFormat string
+	url            string
+	client         *http.Client
+	maxDuration    time.Duration
+	batchSize      int
+	flushInterval  time.Duration
+	maxRetries     int
+	minBackoff     time.Duration
+	maxBackoff     time.Duration
+	spoolDir       string
+	spoolMaxBytes  int64
+	spoolSeq       atomic.Uint64
+	logger         hclog.Logger
+
+	// batchLock protects batch, pending and closed.
+	batchLock sync.Mutex
+	batch     *httpBatch
+	pending   []*httpBatch
+	closed    bool
+
+	// Batches are only sent by the flush loop, one at a time and in order.
+	startOnce sync.Once
+	started   atomic.Bool
+	flushCh   chan struct{}
+	stopCh    chan struct{}
+	doneCh    chan struct{}
+}
+
+// httpBatch holds the events waiting to be sent in the same request, and the
+// result of sending them.
+type httpBatch struct {
+	entries  [][]byte
+	deadline time.Time
+	done     chan struct{}
+	err      error
+}
+
+// NewHTTPSink should be used to create a new HTTPSink.
+// Accepted options: WithMaxDuration, WithBatchSize, WithFlushInterval,
+// WithMaxRetries, WithRetryMinBackoff, WithRetryMaxBackoff, WithSpoolDir,
+// WithSpoolMaxBytes, WithTLSConfig and WithLogger.
+func NewHTTPSink(address string, format string, opt ...Option) (*HTTPSink, error) {
+	address = strings.TrimSpace(address)
+	if address == "" {
+		return nil, fmt.Errorf("url is required: %w", ErrInvalidParameter)
+	}
+
+	u, err := url.Parse(address)
+	if err != nil {
+		return nil, fmt.Errorf("unable to parse url: %w: %w", ErrInvalidParameter, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("url must be an absolute http or https url: %w", ErrInvalidParameter)
+	}
+
+	format = strings.TrimSpace(format)
+	if format == "" {
+		return nil, fmt.Errorf("format is required: %w", ErrInvalidParameter)
+	}
+
+	opts, err := getOpts(opt...)
+	if err != nil {
+		return nil, err
+	}
+
+	if opts.withRetryMaxBackoff < opts.withRetryMinBackoff {
+		return nil, fmt.Errorf("retry max backoff cannot be less than retry min backoff: %w", ErrInvalidParameter)
+	}
+
+	if opts.withSpoolDir != "" {
+		if err := os.MkdirAll(opts.withSpoolDir, 0o700); err != nil {
+			return nil, fmt.Errorf("unable to create spool directory %q: %w", opts.withSpoolDir, err)
+		}
+	}
+
+	transport := cleanhttp.DefaultPooledTransport()
+	if opts.withTLSConfig != nil {
+		transport.TLSClientConfig = opts.withTLSConfig.Clone()
+	}
+
+	sink := &HTTPSink{
+		requiredFormat: format,
+		url:            u.String(),
+		client:         &http.Client{Transport: transport},
+		maxDuration:    opts.withMaxDuration,
+		batchSize:      opts.withBatchSize,
+		flushInterval:  opts.withFlushInterval,
+		maxRetries:     opts.withMaxRetries,
+		minBackoff:     opts.withRetryMinBackoff,
+		maxBackoff:     opts.withRetryMaxBackoff,
+		spoolDir:       opts.withSpoolDir,
+		spoolMaxBytes:  opts.withSpoolMaxBytes,
+		logger:         opts.withLogger,
+		flushCh:        make(chan struct{}, 1),
+		stopCh:         make(chan struct{}),
+		doneCh:         make(chan struct{}),
+	}
+
+	return sink, nil
+}
+
+// Process adds the event to the current batch and waits until the batch was
+// sent or spooled by the flush loop.
+func (s *HTTPSink) Process(ctx context.Context, e *eventlogger.Event) (_ *eventlogger.Event, retErr error) {
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	default:
+	}
+
+	defer func() {
+		// If the context is errored (cancelled), and we were planning to return
+		// an error, let's also log (if we have a logger) in case the eventlogger's
+		// status channel and errors propagated.
+		if err := ctx.Err(); err != nil && retErr != nil && s.logger != nil {
+			s.logger.Error("http sink error", "context", err, "error", retErr)
+		}
+	}()
+
+	if e == nil {
+		return nil, fmt.Errorf("event is nil: %w", ErrInvalidParameter)
+	}
+
+	formatted, found := e.Format(s.requiredFormat)
+	if !found {
+		return nil, fmt.Errorf("unable to retrieve event formatted as %q: %w", s.requiredFormat, ErrInvalidParameter)
+	}
+
+	// The flush loop is only started once the sink is used, so that sinks
+	// which are never registered don't leak it.
+	s.startOnce.Do(func() {
+		s.started.Store(true)
+		go s.flushLoop()
+	})
+
+	s.batchLock.Lock()
+	if s.closed {
+		s.batchLock.Unlock()
+		return nil, fmt.Errorf("http sink for %q is closed: %w", s.url, ErrInvalidParameter)
+	}
+	if s.batch == nil {
+		s.batch = &httpBatch{done: make(chan struct{})}
+	}
+	b := s.batch
+	b.entries = append(b.entries, formatted)
+	if deadline, ok := ctx.Deadline(); ok && (b.deadline.IsZero() || deadline.Before(b.deadline)) {
+		b.deadline = deadline
+	}
+	full := len(b.entries) >= s.batchSize
+	if full {
+		s.pending = append(s.pending, b)
+		s.batch = nil
+	}
+	s.batchLock.Unlock()
+
+	// Full batches are sent right away, without waiting for the flush interval.
+	if full {
+		select {
+		case s.flushCh <- struct{}{}:
+		default:
+		}
+	}
+
+	select {
+	case <-b.done:
+		return nil, b.err
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// Reopen closes the idle connections to the receiver, so that new ones are
+// established for the next batches.
+func (s *HTTPSink) Reopen() error {
+	s.client.CloseIdleConnections()
+
+	return nil
+}
+
+// Type describes the type of this node (sink).
+func (_ *HTTPSink) Type() eventlogger.NodeType {
+	return eventlogger.NodeTypeSink
+}
+
+// Close stops the flush loop once the current batch was sent. Events processed
+// after the sink is closed return an error.
+func (s *HTTPSink) Close(ctx context.Context) error {
+	s.batchLock.Lock()
+	if s.closed {
+		s.batchLock.Unlock()
+		return nil
+	}
+	s.closed = true
+	s.batchLock.Unlock()
+
+	// Prevent the flush loop from starting after we checked it.
+	s.startOnce.Do(func() {})
+	if !s.started.Load() {
+		s.client.CloseIdleConnections()
+		return nil
+	}
+
+	close(s.stopCh)
+	select {
+	case <-s.doneCh:
+	case <-ctx.Done():
+		return fmt.Errorf("timed out waiting for http sink for %q to flush: %w", s.url, ctx.Err())
+	}
+
+	s.client.CloseIdleConnections()
+
+	return nil
+}
+
+// flushLoop sends the full batches as soon as they are filled, and the current
+// batch every flush interval, until the sink is closed.
+func (s *HTTPSink) flushLoop() {
+	defer close(s.doneCh)
+
+	ticker := time.NewTicker(s.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			s.flush(true)
+			return
+		case <-s.flushCh:
+			s.flush(false)
+		case <-ticker.C:
+			s.flush(true)
+		}
+	}
+}
+
+// flush sends the full batches, followed by the current batch when partial is
+// true. When there is nothing to send, it sends the spooled batches so that
+// they don't wait for new events, unless the sink is being closed.
+func (s *HTTPSink) flush(partial bool) {
+	s.batchLock.Lock()
+	batches := s.pending
+	s.pending = nil
+	if partial && s.batch != nil {
+		batches = append(batches, s.batch)
+		s.batch = nil
+	}
+	s.batchLock.Unlock()
+
+	for _, b := range batches {
+		b.err = s.deliver(b)
+		close(b.done)
+	}
+
+	if len(batches) > 0 || s.spoolDir == "" {
+		return
+	}
+
+	select {
+	case <-s.stopCh:
+		return
+	default:
+	}
+
+	// Don't hold up new batches for longer than a single request.
+	ctx, cancel := context.WithTimeout(context.Background(), s.maxDuration)
+	defer cancel()
+	if err := s.drainSpool(ctx); err != nil && s.logger != nil {
+		s.logger.Debug("unable to send spooled audit batches", "url", s.url, "error", err)
+	}
+}
+
+// deliver sends the entries of the batch to the receiver, retrying with backoff
+// until the deadline of the batch. When the receiver cannot be reached in time,
+// the entries are stored in the spool directory.
+// The spooled batches are sent first, and a new batch is spooled without
+// trying to send it while they cannot be sent, to keep the order of entries.
+func (s *HTTPSink) deliver(b *httpBatch) error {
+	body := joinEntries(b.entries)
+
+	ctx := context.Background()
+	if !b.deadline.IsZero() {
+		deadline := b.deadline
+		if s.spoolDir != "" {
+			deadline = deadline.Add(-spoolReserve)
+		}
+
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithDeadline(ctx, deadline)
+		defer cancel()
+	}
+
+	if s.spoolDir != "" {
+		if err := s.drainSpool(ctx); err != nil {
+			return s.spool(body, err)
+		}
+	}
+
+	err := s.postWithRetry(ctx, body)
+	switch {
+	case err == nil:
+		return nil
+	case s.spoolDir == "" || errors.Is(err, errHTTPSinkPermanent):
+		return fmt.Errorf("error sending audit entries to %q: %w", s.url, err)
+	default:
+		return s.spool(body, err)
+	}
+}
+
+// postWithRetry sends the body, retrying with a capped exponential backoff
+// until it succeeds, the receiver permanently rejects it, the maximum number
+// of retries is reached, or the next retry would exceed the deadline of the
+// context. Retries are abandoned when the sink is closed.
+func (s *HTTPSink) postWithRetry(ctx context.Context, body []byte) error {
+	b := backoff.NewBackoff(s.maxRetries, s.minBackoff, s.maxBackoff)
+	for {
+		err := s.post(ctx, body)
+		if err == nil || errors.Is(err, errHTTPSinkPermanent) {
+			return err
+		}
+
+		wait, retryErr := b.Next()
+		if retryErr != nil {
+			return err
+		}
+		if deadline, ok := ctx.Deadline(); ok && time.Now().Add(wait).After(deadline) {
+			return err
+		}
+
+		if s.logger != nil {
+			s.logger.Debug("retrying to send audit entries", "url", s.url, "wait", wait, "error", err)
+		}
+
+		timer := time.NewTimer(wait)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-s.stopCh:
+			timer.Stop()
+			return err
+		}
+	}
+}
+
+// post sends the body in a single POST request, which is bounded by the
+// request timeout and the deadline of the context.
+func (s *HTTPSink) post(ctx context.Context, body []byte) error {
+	ctx, cancel := context.WithTimeout(ctx, s.maxDuration)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("unable to create request: %w", err)
+	}
+
+	contentType := "text/plain"
+	if s.requiredFormat == "json" {
+		contentType = "application/x-ndjson"
+	}
+	req.Header.Set("Content-Type", contentType)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("unable to send request: %w", err)
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode == http.StatusRequestTimeout || resp.StatusCode >= 500:
+		return fmt.Errorf("unexpected response status %q", resp.Status)
+	default:
+		return fmt.Errorf("%w: unexpected response status %q", errHTTPSinkPermanent, resp.Status)
+	}
+}
+
+// spool stores the body in a new file of the spool directory, which is sent
+// again later. sendErr is the error which prevented sending the body.
+func (s *HTTPSink) spool(body []byte, sendErr error) error {
+	name := fmt.Sprintf("%020d-%010d", time.Now().UnixNano(), s.spoolSeq.Add(1))
+	tmpPath := filepath.Join(s.spoolDir, name+".tmp")
+	path := filepath.Join(s.spoolDir, name+spoolFileExt)
+
+	err := s.checkSpoolSize(int64(len(body)))
+	if err == nil {
+		err = os.WriteFile(tmpPath, body, 0o600)
+	}
+	if err == nil {
+		err = os.Rename(tmpPath, path)
+	}
+	if err != nil {
+		_ = os.Remove(tmpPath)
+		return fmt.Errorf("error sending audit entries to %q: %w", s.url, multierror.Append(sendErr, fmt.Errorf("unable to spool audit entries: %w", err)))
+	}
+
+	if s.logger != nil {
+		s.logger.Warn("unable to send audit entries, spooled them to disk", "url", s.url, "path", path, "error", sendErr)
+	}
+
+	return nil
+}
+
+// checkSpoolSize returns an error when storing size more bytes would exceed the
+// maximum size of the spool directory. Rejected batches, which are kept for the
+// operator to inspect, count towards the maximum size.
+func (s *HTTPSink) checkSpoolSize(size int64) error {
+	entries, err := os.ReadDir(s.spoolDir)
+	if err != nil {
+		return fmt.Errorf("unable to list spool directory: %w", err)
+	}
+
+	for _, entry := range entries {
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		size += info.Size()
+	}
+
+	if size > s.spoolMaxBytes {
+		return fmt.Errorf("spool directory would exceed its maximum size of %d bytes", s.spoolMaxBytes)
+	}
+
+	return nil
+}
+
+// drainSpool sends the spooled batches, oldest first, and removes them once
+// sent. It stops at the first batch which cannot be sent. Batches rejected by
+// the receiver are renamed, so that they don't block the others, and kept for
+// the operator to inspect.
+func (s *HTTPSink) drainSpool(ctx context.Context) error {
+	paths, err := filepath.Glob(filepath.Join(s.spoolDir, "*"+spoolFileExt))
+	if err != nil {
+		return fmt.Errorf("unable to list spooled audit entries: %w", err)
+	}
+	sort.Strings(paths)
+
+	for _, path := range paths {
+		body, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("unable to read spooled audit entries %q: %w", path, err)
+		}
+
+		err = s.post(ctx, body)
+		switch {
+		case errors.Is(err, errHTTPSinkPermanent):
+			failedPath := strings.TrimSuffix(path, spoolFileExt) + spoolFailedFileExt
+			if s.logger != nil {
+				s.logger.Error("spooled audit entries rejected by receiver", "url", s.url, "path", failedPath, "error", err)
+			}
+			if err := os.Rename(path, failedPath); err != nil {
+				return fmt.Errorf("unable to rename rejected spooled audit entries %q: %w", path, err)
+			}
+			continue
+		case err != nil:
+			return err
+		}
+
+		if err := os.Remove(path); err != nil {
+			return fmt.Errorf("unable to remove spooled audit entries %q: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// joinEntries returns the entries separated by a new line.
+func joinEntries(entries [][]byte) []byte {
+	var buf bytes.Buffer
+	for _, entry := range entries {
+		buf.Write(entry)
+		if !bytes.HasSuffix(entry, []byte("\n")) {
+			buf.WriteByte('\n')
+		}
+	}
+
+	return buf.Bytes()
+}
@@ -0,0 +1,363 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package event
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/eventlogger"
+	"github.com/stretchr/testify/require"
+)
+
+// testHTTPReceiver is an HTTP server recording the bodies it receives, and
+// responding with the configured status code.
+type testHTTPReceiver struct {
+	*httptest.Server
+
+	lock   sync.Mutex
+	status int
+	bodies []string
+}
+
+func newTestHTTPReceiver(t *testing.T) *testHTTPReceiver {
+	t.Helper()
+
+	r := &testHTTPReceiver{status: http.StatusOK}
+	r.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+
+		r.lock.Lock()
+		defer r.lock.Unlock()
+		r.bodies = append(r.bodies, string(body))
+		w.WriteHeader(r.status)
+	}))
+	t.Cleanup(r.Close)
+
+	return r
+}
+
+func (r *testHTTPReceiver) setStatus(status int) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.status = status
+}
+
+func (r *testHTTPReceiver) received() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.bodies...)
+}
+
+// testHTTPSinkEvent returns an event formatted as JSON with the given data.
+func testHTTPSinkEvent(data string) *eventlogger.Event {
+	e := &eventlogger.Event{Formatted: make(map[string][]byte)}
+	e.FormattedAs("json", []byte(data+"\n"))
+	return e
+}
+
+// TestNewHTTPSink ensures that we validate the input arguments and can create
+// the HTTPSink if everything goes to plan.
+func TestNewHTTPSink(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		url            string
+		format         string
+		opts           []Option
+		wantErr        bool
+		expectedErrMsg string
+	}{
+		"url-empty": {
+			url:            "  ",
+			format:         "json",
+			wantErr:        true,
+			expectedErrMsg: "url is required: invalid parameter",
+		},
+		"url-not-http": {
+			url:            "tcp://localhost:9090",
+			format:         "json",
+			wantErr:        true,
+			expectedErrMsg: "url must be an absolute http or https url: invalid parameter",
+		},
+		"url-relative": {
+			url:            "/audit",
+			format:         "json",
+			wantErr:        true,
+			expectedErrMsg: "url must be an absolute http or https url: invalid parameter",
+		},
+		"format-empty": {
+			url:            "https://localhost/audit",
+			format:         "",
+			wantErr:        true,
+			expectedErrMsg: "format is required: invalid parameter",
+		},
+		"bad-backoff": {
+			url:            "https://localhost/audit",
+			format:         "json",
+			opts:           []Option{WithRetryMinBackoff("10s"), WithRetryMaxBackoff("1s")},
+			wantErr:        true,
+			expectedErrMsg: "retry max backoff cannot be less than retry min backoff: invalid parameter",
+		},
+		"happy": {
+			url:    "https://localhost/audit",
+			format: "json",
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := NewHTTPSink(tc.url, tc.format, tc.opts...)
+
+			if tc.wantErr {
+				require.Error(t, err)
+				require.EqualError(t, err, tc.expectedErrMsg)
+				require.Nil(t, got)
+			} else {
+				require.NoError(t, err)
+				require.NotNil(t, got)
+				require.Equal(t, 100, got.batchSize)
+				require.Equal(t, 100*time.Millisecond, got.flushInterval)
+				require.NoError(t, got.Close(context.Background()))
+			}
+		})
+	}
+}
+
+// TestHTTPSink_Process_Batch ensures that events are sent in a single request
+// once the batch is full, and that every event waits for the batch to be sent.
+func TestHTTPSink_Process_Batch(t *testing.T) {
+	t.Parallel()
+
+	r := newTestHTTPReceiver(t)
+	s, err := NewHTTPSink(r.URL, "json", WithBatchSize("3"), WithFlushInterval("1h"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 3)
+	for _, data := range []string{"a", "b", "c"} {
+		wg.Add(1)
+		go func(data string) {
+			defer wg.Done()
+			_, err := s.Process(context.Background(), testHTTPSinkEvent(data))
+			errs <- err
+		}(data)
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	bodies := r.received()
+	require.Len(t, bodies, 1)
+	lines := strings.Split(strings.TrimSpace(bodies[0]), "\n")
+	require.ElementsMatch(t, []string{"a", "b", "c"}, lines)
+}
+
+// TestHTTPSink_Process_FlushInterval ensures that a batch which isn't full is
+// sent once the flush interval elapses.
+func TestHTTPSink_Process_FlushInterval(t *testing.T) {
+	t.Parallel()
+
+	r := newTestHTTPReceiver(t)
+	s, err := NewHTTPSink(r.URL, "json", WithFlushInterval("10ms"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("a"))
+	require.NoError(t, err)
+	require.Equal(t, []string{"a\n"}, r.received())
+}
+
+// TestHTTPSink_Process_Retry ensures that a batch is retried when the receiver
+// is unavailable, up to the maximum number of retries.
+func TestHTTPSink_Process_Retry(t *testing.T) {
+	t.Parallel()
+
+	r := newTestHTTPReceiver(t)
+	r.setStatus(http.StatusServiceUnavailable)
+	s, err := NewHTTPSink(r.URL, "json", WithBatchSize("1"), WithMaxRetries("2"),
+		WithRetryMinBackoff("1ms"), WithRetryMaxBackoff("5ms"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("a"))
+	require.Error(t, err)
+	require.ErrorContains(t, err, "503 Service Unavailable")
+	require.Len(t, r.received(), 3)
+
+	// Client errors are not retried.
+	r.setStatus(http.StatusBadRequest)
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("b"))
+	require.Error(t, err)
+	require.ErrorContains(t, err, "request rejected by receiver")
+	require.Len(t, r.received(), 4)
+}
+
+// TestHTTPSink_Process_Spool ensures that batches are spooled to disk when the
+// receiver is unavailable, and sent in order once it is available again.
+func TestHTTPSink_Process_Spool(t *testing.T) {
+	t.Parallel()
+
+	r := newTestHTTPReceiver(t)
+	r.setStatus(http.StatusServiceUnavailable)
+	spoolDir := filepath.Join(t.TempDir(), "spool")
+	s, err := NewHTTPSink(r.URL, "json", WithBatchSize("1"), WithFlushInterval("1h"),
+		WithMaxRetries("1"), WithRetryMinBackoff("1ms"), WithRetryMaxBackoff("1ms"), WithSpoolDir(spoolDir))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("a"))
+	require.NoError(t, err)
+	require.Len(t, r.received(), 2)
+
+	// While batches are spooled, new batches are spooled after a single attempt
+	// to send the spooled ones.
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("b"))
+	require.NoError(t, err)
+	require.Len(t, r.received(), 3)
+
+	files, err := os.ReadDir(spoolDir)
+	require.NoError(t, err)
+	require.Len(t, files, 2)
+
+	r.setStatus(http.StatusOK)
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("c"))
+	require.NoError(t, err)
+
+	bodies := r.received()
+	require.Equal(t, []string{"a\n", "b\n", "c\n"}, bodies[len(bodies)-3:])
+
+	files, err = os.ReadDir(spoolDir)
+	require.NoError(t, err)
+	require.Len(t, files, 0)
+}
+
+// TestHTTPSink_Process_Deadline ensures that a batch which cannot be sent
+// before the deadline of its events is spooled in time, whether the receiver
+// doesn't respond or the next retry would exceed the deadline.
+func TestHTTPSink_Process_Deadline(t *testing.T) {
+	t.Parallel()
+
+	unblock := make(chan struct{})
+	hanging := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		select {
+		case <-unblock:
+		case <-req.Context().Done():
+		}
+	}))
+	t.Cleanup(hanging.Close)
+	t.Cleanup(func() { close(unblock) })
+
+	unavailable := newTestHTTPReceiver(t)
+	unavailable.setStatus(http.StatusServiceUnavailable)
+
+	tests := map[string]struct {
+		url  string
+		opts []Option
+	}{
+		"no-response": {
+			url:  hanging.URL,
+			opts: []Option{WithMaxDuration("1h")},
+		},
+		"retry-backoff": {
+			url:  unavailable.URL,
+			opts: []Option{WithRetryMinBackoff("1h"), WithRetryMaxBackoff("1h")},
+		},
+	}
+
+	for name, tc := range tests {
+		name := name
+		tc := tc
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			spoolDir := filepath.Join(t.TempDir(), "spool")
+			opts := append([]Option{WithBatchSize("1"), WithFlushInterval("1h"), WithSpoolDir(spoolDir)}, tc.opts...)
+			s, err := NewHTTPSink(tc.url, "json", opts...)
+			require.NoError(t, err)
+			t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+
+			_, err = s.Process(ctx, testHTTPSinkEvent("a"))
+			require.NoError(t, err)
+			require.NoError(t, ctx.Err())
+
+			files, err := os.ReadDir(spoolDir)
+			require.NoError(t, err)
+			require.Len(t, files, 1)
+		})
+	}
+}
+
+// TestHTTPSink_Process_SpoolMaxBytes ensures that batches are not spooled once
+// the spool directory reached its maximum size.
+func TestHTTPSink_Process_SpoolMaxBytes(t *testing.T) {
+	t.Parallel()
+
+	r := newTestHTTPReceiver(t)
+	r.setStatus(http.StatusServiceUnavailable)
+	spoolDir := filepath.Join(t.TempDir(), "spool")
+	s, err := NewHTTPSink(r.URL, "json", WithBatchSize("1"), WithFlushInterval("1h"),
+		WithMaxRetries("0"), WithSpoolDir(spoolDir), WithSpoolMaxBytes("3"))
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = s.Close(context.Background()) })
+
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("a"))
+	require.NoError(t, err)
+
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("b"))
+	require.Error(t, err)
+	require.ErrorContains(t, err, "spool directory would exceed its maximum size of 3 bytes")
+
+	files, err := os.ReadDir(spoolDir)
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+}
+
+// TestHTTPSink_Close ensures that the pending batch is sent when the sink is
+// closed, and that events can't be processed once it is closed.
+func TestHTTPSink_Close(t *testing.T) {
+	t.Parallel()
+
+	r := newTestHTTPReceiver(t)
+	s, err := NewHTTPSink(r.URL, "json", WithFlushInterval("1h"))
+	require.NoError(t, err)
+
+	errCh := make(chan error, 1)
+	go func() {
+		_, err := s.Process(context.Background(), testHTTPSinkEvent("a"))
+		errCh <- err
+	}()
+
+	require.Eventually(t, func() bool {
+		s.batchLock.Lock()
+		defer s.batchLock.Unlock()
+		return s.batch != nil
+	}, 5*time.Second, 10*time.Millisecond)
+
+	require.NoError(t, s.Close(context.Background()))
+	require.NoError(t, <-errCh)
+	require.Equal(t, []string{"a\n"}, r.received())
+
+	_, err = s.Process(context.Background(), testHTTPSinkEvent("b"))
+	require.Error(t, err)
+	require.ErrorContains(t, err, "is closed")
+}
@@ -82,6 +82,12 @@ var (
 		transit.EnvVaultTransitSealDisableRenewal: "disable_renewal",
 	}
 
+	KeyfileEnvVars = map[string]string{
+		"VAULT_KEYFILE_SEAL_PATH":       "path",
+		"VAULT_KEYFILE_SEAL_CREDENTIAL": "credential",
+		"VAULT_KEYFILE_SEAL_KEY_ID":     "key_id",
+	}
+
 	// TransitPrioritizeConfigValues are the variables where file config takes precedence over env vars in transit seals
 	TransitPrioritizeConfigValues = []string{
 		"token",
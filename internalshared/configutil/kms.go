@@ -6,10 +6,14 @@ package configutil
 import (
 	"context"
 	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
 	"errors"
 	"fmt"
 	"io"
 	"os"
+	"path/filepath"
 	"regexp"
 	"slices"
 	"strings"
@@ -52,6 +56,14 @@ const (
 	KmsRenameDisabledSuffix = "-disabled"
 )
 
+// WrapperTypeKeyfile is the type of the seal using an AES key read from a
+// local file or from a systemd credential.
+const WrapperTypeKeyfile = wrapping.WrapperType("keyfile")
+
+// keyfileMaxSize is the maximum size of a key file, large enough for a
+// base64-encoded 256-bit key followed by a newline.
+const keyfileMaxSize = 1024
+
 type Entropy struct {
 	Mode     EntropyMode
 	SealName string
@@ -234,6 +246,7 @@ var kmsSealAddressKeys = map[string][]string{
 	wrapping.WrapperTypeOciKms.String():        {"key_id", "crypto_endpoint", "management_endpoint"},
 	wrapping.WrapperTypePkcs11.String():        {},
 	wrapping.WrapperTypeTransit.String():       {"address"},
+	WrapperTypeKeyfile.String():                {},
 }
 
 // normalizeKMSSealConfigAddrs takes a kms seal type, a config key, and its
@@ -318,6 +331,9 @@ func configureWrapper(configKMS *KMS, infoKeys *[]string, info *map[string]strin
 	case wrapping.WrapperTypePkcs11:
 		return nil, fmt.Errorf("KMS type 'pkcs11' requires the Vault Enterprise HSM binary")
 
+	case WrapperTypeKeyfile:
+		wrapper, kmsInfo, err = GetKeyfileKMSFunc(configKMS, opts...)
+
 	default:
 		return nil, fmt.Errorf("Unknown KMS type %q", configKMS.Type)
 	}
@@ -353,6 +369,118 @@ func GetAEADKMSFunc(kms *KMS, opts ...wrapping.Option) (wrapping.Wrapper, map[st
 	return wrapper, info, nil
 }
 
+// GetKeyfileKMSFunc returns an AES-GCM wrapper using the 256-bit key read from
+// the file set in "path", or from the systemd credential named in
+// "credential". The key may be stored raw or base64-encoded, and the file must
+// not be accessible by the group or others.
+func GetKeyfileKMSFunc(kms *KMS, opts ...wrapping.Option) (wrapping.Wrapper, map[string]string, error) {
+	path, err := keyfilePath(kms.Config)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := readKeyfile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	keyId := kms.Config["key_id"]
+	if keyId == "" {
+		// Derive the key ID from the key so that a new key in the same file
+		// is detected as a new key, and the entries get rewrapped.
+		sum := sha256.Sum256(key)
+		keyId = hex.EncodeToString(sum[:8])
+	}
+
+	wrapper := aeadwrapper.NewWrapper()
+	_, err = wrapper.SetConfig(context.Background(), append(opts, wrapping.WithKeyId(keyId), aeadwrapper.WithKey(key))...)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	info := make(map[string]string)
+	info["Keyfile Path"] = path
+	info["Keyfile Key ID"] = keyId
+	return wrapper, info, nil
+}
+
+// keyfilePath returns the path of the key file configured for a keyfile seal.
+func keyfilePath(config map[string]string) (string, error) {
+	path, credential := config["path"], config["credential"]
+	switch {
+	case path != "" && credential != "":
+		return "", errors.New("only one of 'path' or 'credential' can be set for the keyfile seal")
+	case path != "":
+		return path, nil
+	case credential != "":
+		if strings.ContainsRune(credential, os.PathSeparator) || credential == "." || credential == ".." {
+			return "", fmt.Errorf("invalid credential name %q for the keyfile seal", credential)
+		}
+		dir := os.Getenv("CREDENTIALS_DIRECTORY")
+		if dir == "" {
+			return "", errors.New("CREDENTIALS_DIRECTORY is not set, the keyfile seal credential must be loaded by systemd")
+		}
+		return filepath.Join(dir, credential), nil
+	default:
+		return "", errors.New("'path' or 'credential' must be set for the keyfile seal")
+	}
+}
+
+// readKeyfile reads and decodes the key stored at path, after checking that
+// the file is a regular file with secure ownership and permissions.
+func readKeyfile(path string) ([]byte, error) {
+	info, err := os.Lstat(path)
+	if err != nil {
+		return nil, fmt.Errorf("error stating key file: %w", err)
+	}
+	if info.Mode()&os.ModeSymlink != 0 {
+		return nil, fmt.Errorf("key file %q is a symlink", path)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error opening key file: %w", err)
+	}
+	defer f.Close()
+
+	// Check the opened file, in case the path was swapped after the Lstat
+	info, err = f.Stat()
+	if err != nil {
+		return nil, fmt.Errorf("error stating key file: %w", err)
+	}
+	if !info.Mode().IsRegular() {
+		return nil, fmt.Errorf("key file %q is not a regular file", path)
+	}
+	if err := checkKeyfileInfo(info, path); err != nil {
+		return nil, err
+	}
+
+	data, err := io.ReadAll(io.LimitReader(f, keyfileMaxSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("error reading key file: %w", err)
+	}
+	if len(data) > keyfileMaxSize {
+		return nil, fmt.Errorf("key file %q is too large", path)
+	}
+
+	return decodeKeyfile(data)
+}
+
+// decodeKeyfile returns the 256-bit key stored either raw or base64-encoded
+// in data.
+func decodeKeyfile(data []byte) ([]byte, error) {
+	if len(data) == 32 {
+		return data, nil
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.New("key file must contain a raw or base64-encoded 256-bit key")
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key file must contain a 256-bit key, got %d bits", len(key)*8)
+	}
+	return key, nil
+}
+
 func GetAliCloudKMSFunc(kms *KMS, opts ...wrapping.Option) (wrapping.Wrapper, map[string]string, error) {
 	wrapper := alicloudkms.NewWrapper()
 	wrapperInfo, err := wrapper.SetConfig(context.Background(), append(opts, wrapping.WithDisallowEnvVars(true), wrapping.WithConfigMap(kms.Config))...)
@@ -498,6 +626,8 @@ func getEnvConfig(kms *KMS) map[string]string {
 		wrapperEnvVars = OCIKMSEnvVars
 	case wrapping.WrapperTypeTransit:
 		wrapperEnvVars = TransitEnvVars
+	case WrapperTypeKeyfile:
+		wrapperEnvVars = KeyfileEnvVars
 	default:
 		return nil
 	}
@@ -0,0 +1,27 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !windows
+
+package configutil
+
+import (
+	"fmt"
+	"io/fs"
+
+	"github.com/hashicorp/vault/helper/osutil"
+)
+
+// checkKeyfileInfo checks that the key file is owned by root or by the user
+// running Vault, and that it has no permissions for the group or others.
+func checkKeyfileInfo(info fs.FileInfo, path string) error {
+	if !osutil.FileUIDEqual(info, 0) {
+		if err := osutil.FileUidMatch(info, path, 0); err != nil {
+			return fmt.Errorf("key file %q must be owned by root or by the user running Vault: %w", path, err)
+		}
+	}
+	if info.Mode().Perm()&0o077 != 0 {
+		return fmt.Errorf("key file %q has insecure permissions %o, Vault expects no permissions for group or others", path, info.Mode().Perm())
+	}
+	return nil
+}
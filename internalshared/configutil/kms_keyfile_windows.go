@@ -0,0 +1,14 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build windows
+
+package configutil
+
+import "io/fs"
+
+// checkKeyfileInfo does nothing on windows, where the ownership and
+// permissions of the key file are managed with ACLs.
+func checkKeyfileInfo(info fs.FileInfo, path string) error {
+	return nil
+}
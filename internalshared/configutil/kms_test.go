@@ -4,9 +4,17 @@
 package configutil
 
 import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"os"
+	"path/filepath"
 	"reflect"
+	"runtime"
+	"strings"
 	"testing"
 
+	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead/v2"
 	"github.com/hashicorp/go-kms-wrapping/wrappers/ocikms/v2"
 	"github.com/stretchr/testify/require"
 )
@@ -459,3 +467,154 @@ func TestMergeKMSEnvConfigAddrConformance(t *testing.T) {
 		})
 	}
 }
+
+// TestGetKeyfileKMSFunc verifies that the keyfile seal reads the key from a
+// file or a systemd credential, and rejects insecure or invalid key files.
+func TestGetKeyfileKMSFunc(t *testing.T) {
+	key := bytes.Repeat([]byte{0x42}, 32)
+	encodedKey := base64.StdEncoding.EncodeToString(key) + "\n"
+
+	writeKeyfile := func(t *testing.T, dir string, content []byte, perm os.FileMode) string {
+		t.Helper()
+		path := filepath.Join(dir, "vault.key")
+		require.NoError(t, os.WriteFile(path, content, perm))
+		require.NoError(t, os.Chmod(path, perm))
+		return path
+	}
+
+	for name, tc := range map[string]struct {
+		content    []byte
+		perm       os.FileMode
+		credential bool
+		config     map[string]string
+		keyId      string
+		wantErr    string
+	}{
+		"raw key": {
+			content: key,
+			perm:    0o600,
+		},
+		"base64 key": {
+			content: []byte(encodedKey),
+			perm:    0o400,
+		},
+		"credential": {
+			content:    []byte(encodedKey),
+			perm:       0o400,
+			credential: true,
+		},
+		"key id": {
+			content: key,
+			perm:    0o600,
+			config:  map[string]string{"key_id": "edge-1"},
+			keyId:   "edge-1",
+		},
+		"group readable": {
+			content: key,
+			perm:    0o640,
+			wantErr: "insecure permissions",
+		},
+		"world readable": {
+			content: key,
+			perm:    0o604,
+			wantErr: "insecure permissions",
+		},
+		"short key": {
+			content: []byte(base64.StdEncoding.EncodeToString(key[:16])),
+			perm:    0o600,
+			wantErr: "got 128 bits",
+		},
+		"invalid key": {
+			content: []byte("not a key"),
+			perm:    0o600,
+			wantErr: "raw or base64-encoded 256-bit key",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if runtime.GOOS == "windows" && strings.Contains(tc.wantErr, "permissions") {
+				t.Skip("permissions are not checked on windows")
+			}
+
+			dir := t.TempDir()
+			path := writeKeyfile(t, dir, tc.content, tc.perm)
+
+			config := map[string]string{}
+			for k, v := range tc.config {
+				config[k] = v
+			}
+			if tc.credential {
+				t.Setenv("CREDENTIALS_DIRECTORY", dir)
+				config["credential"] = filepath.Base(path)
+			} else {
+				config["path"] = path
+			}
+
+			wrapper, info, err := GetKeyfileKMSFunc(&KMS{Type: WrapperTypeKeyfile.String(), Config: config})
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, path, info["Keyfile Path"])
+
+			keyId, err := wrapper.KeyId(context.Background())
+			require.NoError(t, err)
+			if tc.keyId != "" {
+				require.Equal(t, tc.keyId, keyId)
+			} else {
+				require.Len(t, keyId, 16)
+			}
+
+			// The wrapper must decrypt what was encrypted with the key
+			other := aeadwrapper.NewWrapper()
+			require.NoError(t, other.SetAesGcmKeyBytes(key))
+			blob, err := other.Encrypt(context.Background(), []byte("secret"))
+			require.NoError(t, err)
+			pt, err := wrapper.Decrypt(context.Background(), blob)
+			require.NoError(t, err)
+			require.Equal(t, []byte("secret"), pt)
+		})
+	}
+}
+
+// TestGetKeyfileKMSFunc_Config verifies the validation of the location of the
+// key file.
+func TestGetKeyfileKMSFunc_Config(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "vault.key")
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{0x42}, 32), 0o600))
+
+	_, _, err := GetKeyfileKMSFunc(&KMS{Config: map[string]string{}})
+	require.ErrorContains(t, err, "'path' or 'credential' must be set")
+
+	_, _, err = GetKeyfileKMSFunc(&KMS{Config: map[string]string{"path": path, "credential": "vault.key"}})
+	require.ErrorContains(t, err, "only one of 'path' or 'credential'")
+
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+	_, _, err = GetKeyfileKMSFunc(&KMS{Config: map[string]string{"credential": "vault.key"}})
+	require.ErrorContains(t, err, "CREDENTIALS_DIRECTORY is not set")
+
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+	_, _, err = GetKeyfileKMSFunc(&KMS{Config: map[string]string{"credential": "../vault.key"}})
+	require.ErrorContains(t, err, "invalid credential name")
+
+	if runtime.GOOS != "windows" {
+		link := filepath.Join(dir, "link.key")
+		require.NoError(t, os.Symlink(path, link))
+		_, _, err = GetKeyfileKMSFunc(&KMS{Config: map[string]string{"path": link}})
+		require.ErrorContains(t, err, "is a symlink")
+	}
+
+	// The default key ID changes with the key
+	wrapper, _, err := GetKeyfileKMSFunc(&KMS{Config: map[string]string{"path": path}})
+	require.NoError(t, err)
+	keyId, err := wrapper.KeyId(context.Background())
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, bytes.Repeat([]byte{0x24}, 32), 0o600))
+	wrapper, _, err = GetKeyfileKMSFunc(&KMS{Config: map[string]string{"path": path}})
+	require.NoError(t, err)
+	newKeyId, err := wrapper.KeyId(context.Background())
+	require.NoError(t, err)
+	require.NotEqual(t, keyId, newKeyId)
+}
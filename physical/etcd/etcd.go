@@ -20,6 +20,7 @@ var (
 	EtcdAddressError           = errors.New("client setup failed: address must be valid URL (ex. 'scheme://host:port')")
 	EtcdLockHeldError          = errors.New("lock already held")
 	EtcdLockNotHeldError       = errors.New("lock not held")
+	EtcdLockLostError          = errors.New("active node lock lost, write was rejected")
 	EtcdVersionUnknown         = errors.New("etcd: unknown API version")
 )
 
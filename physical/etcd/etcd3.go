@@ -12,6 +12,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -36,15 +37,34 @@ type EtcdBackend struct {
 	requestTimeout time.Duration
 
 	permitPool *permitpool.Pool
+	maxTxnOps  int
 
 	etcd *clientv3.Client
+
+	// activeNodeLock is the lock registered once this node becomes active. Its
+	// fencing comparison is added to every write so that writes are rejected
+	// once another node holds the lock.
+	activeNodeLock atomic.Pointer[EtcdLock]
 }
 
 // Verify EtcdBackend satisfies the correct interfaces
 var (
-	_ physical.Backend   = (*EtcdBackend)(nil)
-	_ physical.HABackend = (*EtcdBackend)(nil)
-	_ physical.Lock      = (*EtcdLock)(nil)
+	_ physical.Backend             = (*EtcdBackend)(nil)
+	_ physical.HABackend           = (*EtcdBackend)(nil)
+	_ physical.FencingHABackend    = (*EtcdBackend)(nil)
+	_ physical.Transactional       = (*EtcdBackend)(nil)
+	_ physical.TransactionalLimits = (*EtcdBackend)(nil)
+	_ physical.Lock                = (*EtcdLock)(nil)
+)
+
+const (
+	// defaultMaxTxnOps matches the default of the --max-txn-ops etcd server
+	// flag.
+	defaultMaxTxnOps = 128
+
+	// maxTxnSize leaves room for the request encoding below the 1.5MiB
+	// default of the --max-request-bytes etcd server flag.
+	maxTxnSize = 1024 * 1024
 )
 
 // newEtcd3Backend constructs a etcd3 backend.
@@ -176,10 +196,22 @@ func newEtcd3Backend(conf map[string]string, logger log.Logger) (physical.Backen
 		return nil, fmt.Errorf("value [%v] of 'lock_timeout' could not be understood: %w", sLock, err)
 	}
 
+	maxTxnOps := defaultMaxTxnOps
+	if sMaxTxnOps, ok := conf["max_txn_ops"]; ok {
+		maxTxnOps, err = strconv.Atoi(sMaxTxnOps)
+		if err != nil {
+			return nil, fmt.Errorf("value [%v] of 'max_txn_ops' could not be understood: %w", sMaxTxnOps, err)
+		}
+		if maxTxnOps < 1 {
+			return nil, fmt.Errorf("value [%v] of 'max_txn_ops' must be positive", sMaxTxnOps)
+		}
+	}
+
 	return &EtcdBackend{
 		path:           path,
 		etcd:           etcd,
 		permitPool:     permitpool.New(physical.DefaultParallelOperations),
+		maxTxnOps:      maxTxnOps,
 		logger:         logger,
 		haEnabled:      haEnabledBool,
 		lockTimeout:    lock,
@@ -195,9 +227,7 @@ func (c *EtcdBackend) Put(ctx context.Context, entry *physical.Entry) error {
 	}
 	defer c.permitPool.Release()
 
-	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
-	defer cancel()
-	_, err := c.etcd.Put(ctx, path.Join(c.path, entry.Key), string(entry.Value))
+	_, err := c.write(ctx, clientv3.OpPut(path.Join(c.path, entry.Key), string(entry.Value)))
 	return err
 }
 
@@ -236,13 +266,8 @@ func (c *EtcdBackend) Delete(ctx context.Context, key string) error {
 	}
 	defer c.permitPool.Release()
 
-	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
-	defer cancel()
-	_, err := c.etcd.Delete(ctx, path.Join(c.path, key))
-	if err != nil {
-		return err
-	}
-	return nil
+	_, err := c.write(ctx, clientv3.OpDelete(path.Join(c.path, key)))
+	return err
 }
 
 func (c *EtcdBackend) List(ctx context.Context, prefix string) ([]string, error) {
@@ -279,6 +304,139 @@ func (c *EtcdBackend) List(ctx context.Context, prefix string) ([]string, error)
 	return keys, nil
 }
 
+// Transaction runs the entries atomically in a single etcd transaction. Get
+// operations read the value of their key at their position in the
+// transaction, so a get following a put or delete of the same key returns the
+// written value. etcd rejects a transaction writing a key more than once, so
+// only the last write of each key is sent, and gets of a key written earlier
+// in the transaction are answered from that write.
+func (c *EtcdBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	if len(txns) == 0 {
+		return nil
+	}
+	defer metrics.MeasureSince([]string{"etcd", "transaction"}, time.Now())
+
+	lastWrite := make(map[string]int)
+	for i, t := range txns {
+		if t.Operation == physical.PutOperation || t.Operation == physical.DeleteOperation {
+			lastWrite[t.Entry.Key] = i
+		}
+	}
+
+	ops := make([]clientv3.Op, 0, len(txns))
+	// getOps maps the index of each get sent to etcd to the index of its
+	// operation, and written maps the gets answered locally to the write of
+	// their key preceding them.
+	getOps := make(map[int]int)
+	written := make(map[int]*physical.TxnEntry)
+	writes := make(map[string]*physical.TxnEntry)
+	for i, t := range txns {
+		key := path.Join(c.path, t.Entry.Key)
+		switch t.Operation {
+		case physical.GetOperation:
+			if w, ok := writes[t.Entry.Key]; ok {
+				written[i] = w
+				continue
+			}
+			getOps[i] = len(ops)
+			ops = append(ops, clientv3.OpGet(key))
+		case physical.PutOperation:
+			writes[t.Entry.Key] = t
+			if lastWrite[t.Entry.Key] == i {
+				ops = append(ops, clientv3.OpPut(key, string(t.Entry.Value)))
+			}
+		case physical.DeleteOperation:
+			writes[t.Entry.Key] = t
+			if lastWrite[t.Entry.Key] == i {
+				ops = append(ops, clientv3.OpDelete(key))
+			}
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", t.Operation)
+		}
+	}
+
+	if err := c.permitPool.Acquire(ctx); err != nil {
+		return err
+	}
+	defer c.permitPool.Release()
+
+	resp, err := c.write(ctx, ops...)
+	if err != nil {
+		return err
+	}
+
+	for i, w := range written {
+		if w.Operation == physical.PutOperation {
+			txns[i].Entry.Value = w.Entry.Value
+		} else {
+			txns[i].Entry.Value = nil
+		}
+	}
+	for i, op := range getOps {
+		kvs := resp.Responses[op].GetResponseRange().GetKvs()
+		if len(kvs) == 0 {
+			continue
+		}
+		txns[i].Entry.Value = kvs[0].Value
+	}
+	return nil
+}
+
+// TransactionLimits returns the limits of a transaction, which must fit within
+// the max-txn-ops and max-request-bytes limits of the etcd server.
+func (c *EtcdBackend) TransactionLimits() (int, int) {
+	return c.maxTxnOps, maxTxnSize
+}
+
+// write commits the operations in a transaction. Once an active node lock is
+// registered, the transaction only commits if the lock is still held, unless
+// the context is marked for unfenced writes.
+func (c *EtcdBackend) write(ctx context.Context, ops ...clientv3.Op) (*clientv3.TxnResponse, error) {
+	var fence *clientv3.Cmp
+	lock := c.activeNodeLock.Load()
+	if lock != nil && !physical.IsUnfencedWrite(ctx) {
+		fence = lock.fence()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
+	defer cancel()
+
+	txn := c.etcd.Txn(ctx)
+	if fence != nil {
+		txn = txn.If(*fence)
+	}
+	resp, err := txn.Then(ops...).Commit()
+	if err != nil {
+		if strings.Contains(err.Error(), "request is too large") {
+			return nil, fmt.Errorf("%s: %w", physical.ErrValueTooLarge, err)
+		}
+		return nil, err
+	}
+	if !resp.Succeeded {
+		// The fencing comparison failed, so another node holds the lock. Step
+		// down now rather than when the session expires, unless the lock was
+		// acquired again in the meantime.
+		if lock.fence() == fence {
+			c.logger.Warn("fencing check failed on write, we lost active node lock, stepping down")
+			lock.Unlock()
+		}
+		return nil, EtcdLockLostError
+	}
+	return resp, nil
+}
+
+// RegisterActiveNodeLock is called after active node lock is obtained to allow
+// us to fence future writes.
+func (c *EtcdBackend) RegisterActiveNodeLock(l physical.Lock) error {
+	el, ok := l.(*EtcdLock)
+	if !ok {
+		return fmt.Errorf("invalid Lock type")
+	}
+	c.activeNodeLock.Store(el)
+	c.logger.Info("registered active node lock", "key", el.prefix)
+	return nil
+}
+
 func (e *EtcdBackend) HAEnabled() bool {
 	return e.haEnabled
 }
@@ -290,9 +448,18 @@ type EtcdLock struct {
 	timeout        time.Duration
 	requestTimeout time.Duration
 
+	// etcdSession keeps the lease of the lock alive while the lock is held
 	etcdSession *concurrency.Session
 	etcdMu      *concurrency.Mutex
 
+	// unlockCh is closed on Unlock to close the leader channel
+	unlockCh chan struct{}
+
+	// fenceCmp checks that the key of the last acquisition of the mutex still
+	// exists with its create revision, which acts as a fencing token. It is
+	// kept after Unlock so that writes still in flight are rejected.
+	fenceCmp *clientv3.Cmp
+
 	prefix string
 	value  string
 
@@ -355,9 +522,23 @@ func (c *EtcdLock) Lock(stopCh <-chan struct{}) (<-chan struct{}, error) {
 		return nil, err
 	}
 
+	fence := c.etcdMu.IsOwner()
+	c.fenceCmp = &fence
 	c.held = true
+	c.unlockCh = make(chan struct{})
+
+	// The leader channel is closed when the session expires because its lease
+	// could not be kept alive, or when the lock is released.
+	leaderCh := make(chan struct{})
+	go func(sessionDoneCh <-chan struct{}, unlockCh <-chan struct{}) {
+		select {
+		case <-sessionDoneCh:
+		case <-unlockCh:
+		}
+		close(leaderCh)
+	}(c.etcdSession.Done(), c.unlockCh)
 
-	return c.etcdSession.Done(), nil
+	return leaderCh, nil
 }
 
 func (c *EtcdLock) Unlock() error {
@@ -367,12 +548,23 @@ func (c *EtcdLock) Unlock() error {
 	if !c.held {
 		return EtcdLockNotHeldError
 	}
+	c.held = false
+	close(c.unlockCh)
 
 	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
 	return c.etcdMu.Unlock(ctx)
 }
 
+// fence returns the comparison used to fence writes, or nil if the lock was
+// never acquired.
+func (c *EtcdLock) fence() *clientv3.Cmp {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	return c.fenceCmp
+}
+
 func (c *EtcdLock) Value() (bool, string, error) {
 	ctx, cancel := context.WithTimeout(context.Background(), c.requestTimeout)
 	defer cancel()
@@ -4,6 +4,7 @@
 package etcd
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -12,16 +13,18 @@ import (
 	"github.com/hashicorp/vault/helper/testhelpers/etcd"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/stretchr/testify/require"
 )
 
-func TestEtcd3Backend(t *testing.T) {
+func testEtcd3Config(t *testing.T) map[string]string {
+	t.Helper()
+
 	cleanup, config := etcd.PrepareTestContainer(t, "v3.5.0")
-	defer cleanup()
+	t.Cleanup(cleanup)
 
-	logger := logging.NewVaultLogger(log.Debug)
-	configMap := map[string]string{
+	return map[string]string{
 		"address":  config.URL().String(),
-		"path":     fmt.Sprintf("/vault-%d", time.Now().Unix()),
+		"path":     fmt.Sprintf("/vault-%d", time.Now().UnixNano()),
 		"etcd_api": "3",
 		"username": "root",
 		"password": "insecure",
@@ -29,6 +32,11 @@ func TestEtcd3Backend(t *testing.T) {
 		// Syncing advertised client urls should be disabled since docker port mapping confuses the client.
 		"sync": "false",
 	}
+}
+
+func TestEtcd3Backend(t *testing.T) {
+	configMap := testEtcd3Config(t)
+	logger := logging.NewVaultLogger(log.Debug)
 
 	b, err := NewEtcdBackend(configMap, logger)
 	if err != nil {
@@ -42,5 +50,129 @@ func TestEtcd3Backend(t *testing.T) {
 
 	physical.ExerciseBackend(t, b)
 	physical.ExerciseBackend_ListPrefix(t, b)
+	physical.ExerciseTransactionalBackend(t, b)
 	physical.ExerciseHABackend(t, b.(physical.HABackend), b2.(physical.HABackend))
 }
+
+// TestEtcd3Backend_TransactionGet verifies that get operations in a
+// transaction return the stored values of keys not written before them.
+func TestEtcd3Backend_TransactionGet(t *testing.T) {
+	configMap := testEtcd3Config(t)
+	logger := logging.NewVaultLogger(log.Debug)
+
+	b, err := NewEtcdBackend(configMap, logger)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, b.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+
+	txns := []*physical.TxnEntry{
+		{Operation: physical.GetOperation, Entry: &physical.Entry{Key: "foo"}},
+		{Operation: physical.GetOperation, Entry: &physical.Entry{Key: "missing"}},
+		{Operation: physical.PutOperation, Entry: &physical.Entry{Key: "foo", Value: []byte("baz")}},
+	}
+	require.NoError(t, b.(physical.Transactional).Transaction(ctx, txns))
+	require.Equal(t, []byte("bar"), txns[0].Entry.Value)
+	require.Nil(t, txns[1].Entry.Value)
+
+	entry, err := b.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte("baz"), entry.Value)
+
+	maxEntries, maxSize := b.(physical.TransactionalLimits).TransactionLimits()
+	require.Equal(t, defaultMaxTxnOps, maxEntries)
+	require.Equal(t, maxTxnSize, maxSize)
+}
+
+// TestEtcd3Backend_TransactionDuplicateKeys verifies that a transaction
+// writing the same key more than once is accepted by etcd, that the last write
+// of each key wins, and that gets return the value written before them in the
+// transaction.
+func TestEtcd3Backend_TransactionDuplicateKeys(t *testing.T) {
+	configMap := testEtcd3Config(t)
+	logger := logging.NewVaultLogger(log.Debug)
+
+	b, err := NewEtcdBackend(configMap, logger)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	require.NoError(t, b.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+	require.NoError(t, b.Put(ctx, &physical.Entry{Key: "zip", Value: []byte("zap")}))
+
+	txns := []*physical.TxnEntry{
+		{Operation: physical.GetOperation, Entry: &physical.Entry{Key: "foo"}},
+		{Operation: physical.PutOperation, Entry: &physical.Entry{Key: "foo", Value: []byte("baz")}},
+		{Operation: physical.GetOperation, Entry: &physical.Entry{Key: "foo"}},
+		{Operation: physical.DeleteOperation, Entry: &physical.Entry{Key: "foo"}},
+		{Operation: physical.GetOperation, Entry: &physical.Entry{Key: "foo"}},
+		{Operation: physical.DeleteOperation, Entry: &physical.Entry{Key: "zip"}},
+		{Operation: physical.PutOperation, Entry: &physical.Entry{Key: "zip", Value: []byte("zop")}},
+	}
+	require.NoError(t, b.(physical.Transactional).Transaction(ctx, txns))
+	require.Equal(t, []byte("bar"), txns[0].Entry.Value)
+	require.Equal(t, []byte("baz"), txns[2].Entry.Value)
+	require.Nil(t, txns[4].Entry.Value)
+
+	entry, err := b.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.Nil(t, entry)
+
+	entry, err = b.Get(ctx, "zip")
+	require.NoError(t, err)
+	require.Equal(t, []byte("zop"), entry.Value)
+}
+
+// TestEtcd3Backend_Fencing verifies that writes of a node are rejected once
+// its lock was lost and another node acquired it, and that the node steps
+// down.
+func TestEtcd3Backend_Fencing(t *testing.T) {
+	configMap := testEtcd3Config(t)
+	configMap["lock_timeout"] = "5s"
+	logger := logging.NewVaultLogger(log.Debug)
+	ctx := context.Background()
+
+	b, err := NewEtcdBackend(configMap, logger)
+	require.NoError(t, err)
+	b2, err := NewEtcdBackend(configMap, logger)
+	require.NoError(t, err)
+
+	lock, err := b.(physical.HABackend).LockWith("core/lock", "node1")
+	require.NoError(t, err)
+	leaderCh, err := lock.Lock(nil)
+	require.NoError(t, err)
+	require.NotNil(t, leaderCh)
+	require.NoError(t, b.(physical.FencingHABackend).RegisterActiveNodeLock(lock))
+
+	require.NoError(t, b.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+
+	// Revoke the lease of the lock, as if the node was partitioned from etcd
+	// for longer than the lock timeout.
+	el := lock.(*EtcdLock)
+	_, err = el.etcd.Revoke(ctx, el.etcdSession.Lease())
+	require.NoError(t, err)
+
+	lock2, err := b2.(physical.HABackend).LockWith("core/lock", "node2")
+	require.NoError(t, err)
+	leaderCh2, err := lock2.Lock(nil)
+	require.NoError(t, err)
+	require.NotNil(t, leaderCh2)
+	require.NoError(t, b2.(physical.FencingHABackend).RegisterActiveNodeLock(lock2))
+
+	err = b.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("stale")})
+	require.ErrorIs(t, err, EtcdLockLostError)
+	select {
+	case <-leaderCh:
+	case <-time.After(10 * time.Second):
+		t.Fatal("expected the leader channel to be closed")
+	}
+
+	// Unfenced writes are still allowed
+	require.NoError(t, b.Put(physical.UnfencedWriteCtx(ctx), &physical.Entry{Key: "unfenced", Value: []byte("ok")}))
+
+	require.NoError(t, b2.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("baz")}))
+	entry, err := b2.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte("baz"), entry.Value)
+
+	require.NoError(t, lock2.Unlock())
+}
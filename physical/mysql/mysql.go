@@ -32,9 +32,22 @@ import (
 
 // Verify MySQLBackend satisfies the correct interfaces
 var (
-	_ physical.Backend   = (*MySQLBackend)(nil)
-	_ physical.HABackend = (*MySQLBackend)(nil)
-	_ physical.Lock      = (*MySQLHALock)(nil)
+	_ physical.Backend             = (*MySQLBackend)(nil)
+	_ physical.HABackend           = (*MySQLBackend)(nil)
+	_ physical.Lock                = (*MySQLHALock)(nil)
+	_ physical.Transactional       = (*MySQLBackend)(nil)
+	_ physical.TransactionalLimits = (*MySQLBackend)(nil)
+)
+
+const (
+	// mysqlMaxTxnEntries bounds the number of operations in a transaction,
+	// which are run one statement at a time while holding the row locks.
+	mysqlMaxTxnEntries = 128
+
+	// mysqlMaxTxnSize bounds the total size of the keys and values in a
+	// transaction, leaving room below the 4MiB default max_allowed_packet of
+	// older servers.
+	mysqlMaxTxnSize = 1024 * 1024
 )
 
 // Unreserved tls key
@@ -468,6 +481,68 @@ func (m *MySQLBackend) List(ctx context.Context, prefix string) ([]string, error
 	return keys, nil
 }
 
+// Transaction is used to run multiple entries via a transaction. The values
+// read by get operations are set on their entries.
+func (m *MySQLBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	defer metrics.MeasureSince([]string{"mysql", "transaction"}, time.Now())
+	if len(txns) == 0 {
+		return nil
+	}
+
+	if err := m.permitPool.Acquire(ctx); err != nil {
+		return err
+	}
+	defer m.permitPool.Release()
+
+	tx, err := m.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.transaction(ctx, tx, txns); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *MySQLBackend) transaction(ctx context.Context, tx *sql.Tx, txns []*physical.TxnEntry) error {
+	getStmt := tx.StmtContext(ctx, m.statements["get"])
+	defer getStmt.Close()
+	deleteStmt := tx.StmtContext(ctx, m.statements["delete"])
+	defer deleteStmt.Close()
+	putStmt := tx.StmtContext(ctx, m.statements["put"])
+	defer putStmt.Close()
+
+	for _, op := range txns {
+		var err error
+		switch op.Operation {
+		case physical.GetOperation:
+			var result []byte
+			err = getStmt.QueryRowContext(ctx, op.Entry.Key).Scan(&result)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			op.Entry.Value = result
+		case physical.DeleteOperation:
+			_, err = deleteStmt.ExecContext(ctx, op.Entry.Key)
+		case physical.PutOperation:
+			_, err = putStmt.ExecContext(ctx, op.Entry.Key, op.Entry.Value)
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", op.Operation)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TransactionLimits implements physical.TransactionalLimits.
+func (m *MySQLBackend) TransactionLimits() (int, int) {
+	return mysqlMaxTxnEntries, mysqlMaxTxnSize
+}
+
 // LockWith is used for mutual exclusion based on the given key.
 func (m *MySQLBackend) LockWith(key, value string) (physical.Lock, error) {
 	l := &MySQLHALock{
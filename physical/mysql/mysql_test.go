@@ -106,6 +106,13 @@ func TestMySQLBackend(t *testing.T) {
 
 	physical.ExerciseBackend(t, b)
 	physical.ExerciseBackend_ListPrefix(t, b)
+
+	mysql := b.(*MySQLBackend)
+	if _, err := mysql.client.Exec("DELETE FROM " + mysql.dbTable); err != nil {
+		t.Fatalf("Failed to truncate table: %v", err)
+	}
+	physical.ExerciseTransactionalBackend(t, b)
+	physical.ExerciseTransactionalGet(t, b)
 }
 
 func TestMySQLHABackend(t *testing.T) {
@@ -35,10 +35,23 @@ const (
 	// PostgreSQLLockRetryInterval is the amount of time to wait
 	// if a lock fails before trying again.
 	PostgreSQLLockRetryInterval = time.Second
+
+	// postgreSQLMaxTxnEntries bounds the number of operations in a
+	// transaction, which are run one statement at a time while holding the
+	// row locks.
+	postgreSQLMaxTxnEntries = 128
+
+	// postgreSQLMaxTxnSize bounds the total size of the keys and values in a
+	// transaction.
+	postgreSQLMaxTxnSize = 1024 * 1024
 )
 
 // Verify PostgreSQLBackend satisfies the correct interfaces
-var _ physical.Backend = (*PostgreSQLBackend)(nil)
+var (
+	_ physical.Backend             = (*PostgreSQLBackend)(nil)
+	_ physical.Transactional       = (*PostgreSQLBackend)(nil)
+	_ physical.TransactionalLimits = (*PostgreSQLBackend)(nil)
+)
 
 // HA backend was implemented based on the DynamoDB backend pattern
 // With distinction using central postgres clock, hereby avoiding
@@ -330,6 +343,63 @@ func (m *PostgreSQLBackend) List(ctx context.Context, prefix string) ([]string,
 	return keys, nil
 }
 
+// Transaction is used to run multiple entries via a transaction. The values
+// read by get operations are set on their entries.
+func (m *PostgreSQLBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	defer metrics.MeasureSince([]string{"postgres", "transaction"}, time.Now())
+	if len(txns) == 0 {
+		return nil
+	}
+
+	if err := m.permitPool.Acquire(ctx); err != nil {
+		return err
+	}
+	defer m.permitPool.Release()
+
+	tx, err := m.client.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := m.transaction(ctx, tx, txns); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (m *PostgreSQLBackend) transaction(ctx context.Context, tx *sql.Tx, txns []*physical.TxnEntry) error {
+	for _, op := range txns {
+		parentPath, path, key := m.splitKey(op.Entry.Key)
+
+		var err error
+		switch op.Operation {
+		case physical.GetOperation:
+			var result []byte
+			err = tx.QueryRowContext(ctx, m.get_query, path, key).Scan(&result)
+			if err == sql.ErrNoRows {
+				continue
+			}
+			op.Entry.Value = result
+		case physical.DeleteOperation:
+			_, err = tx.ExecContext(ctx, m.delete_query, path, key)
+		case physical.PutOperation:
+			_, err = tx.ExecContext(ctx, m.put_query, parentPath, path, key, op.Entry.Value)
+		default:
+			return fmt.Errorf("%q is not a supported transaction operation", op.Operation)
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// TransactionLimits implements physical.TransactionalLimits.
+func (m *PostgreSQLBackend) TransactionLimits() (int, int) {
+	return postgreSQLMaxTxnEntries, postgreSQLMaxTxnSize
+}
+
 // LockWith is used for mutual exclusion based on the given key.
 func (p *PostgreSQLBackend) LockWith(key, value string) (physical.Lock, error) {
 	identity, err := uuid.GenerateUUID()
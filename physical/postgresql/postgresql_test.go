@@ -80,6 +80,12 @@ func TestPostgreSQLBackend(t *testing.T) {
 	physical.ExerciseBackend(t, b1)
 	logger.Info("Running list prefix backend tests")
 	physical.ExerciseBackend_ListPrefix(t, b1)
+	if _, err := pg.client.Exec(fmt.Sprintf(" TRUNCATE TABLE %v ", pg.table)); err != nil {
+		t.Fatalf("Failed to truncate table: %v", err)
+	}
+	logger.Info("Running transactional backend tests")
+	physical.ExerciseTransactionalBackend(t, b1)
+	physical.ExerciseTransactionalGet(t, b1)
 
 	ha1, ok := b1.(physical.HABackend)
 	if !ok {
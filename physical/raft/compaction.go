@@ -0,0 +1,372 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package raft
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/armon/go-metrics"
+	"github.com/hashicorp/go-multierror"
+	"github.com/hashicorp/raft"
+	bolt "go.etcd.io/bbolt"
+)
+
+const (
+	// CompactionStatusRunning means that the FSM databases are being compacted.
+	CompactionStatusRunning = "running"
+
+	// CompactionStatusCompleted means that the FSM databases of all the
+	// servers have been compacted.
+	CompactionStatusCompleted = "completed"
+
+	// CompactionStatusFailed means that the compaction was aborted.
+	CompactionStatusFailed = "failed"
+
+	// CompactionStatusPending means that the server has not been compacted yet.
+	CompactionStatusPending = "pending"
+
+	// CompactionStatusSkipped means that the server was the leader and was
+	// not compacted. Compacting blocks every apply, so the leader is never
+	// compacted; step it down and start another compaction to compact it.
+	CompactionStatusSkipped = "skipped"
+
+	// compactTxMaxSize is the maximum size of the transactions used to copy
+	// the database during compaction.
+	compactTxMaxSize = 64 * 1024 * 1024
+
+	compactFilenameSuffix = ".compact"
+)
+
+var (
+	// compactionPollInterval is how often the leader checks on the progress of
+	// the server being compacted.
+	compactionPollInterval = time.Second
+
+	// compactionServerTimeout is how long the leader waits for a server to
+	// compact its database and for the cluster to be healthy again.
+	compactionServerTimeout = time.Hour
+)
+
+// CompactionStatus describes the progress of the compaction of the FSM
+// databases of the cluster.
+type CompactionStatus struct {
+	Status    string                    `json:"status"`
+	StartTime time.Time                 `json:"start_time"`
+	EndTime   time.Time                 `json:"end_time,omitempty"`
+	Error     string                    `json:"error,omitempty"`
+	Servers   []*CompactionServerStatus `json:"servers"`
+}
+
+// CompactionServerStatus describes the progress of the compaction of the FSM
+// database of a single server.
+type CompactionServerStatus struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+}
+
+func (s *CompactionStatus) clone() *CompactionStatus {
+	c := *s
+	c.Servers = make([]*CompactionServerStatus, len(s.Servers))
+	for i, srv := range s.Servers {
+		srvCopy := *srv
+		c.Servers[i] = &srvCopy
+	}
+	return &c
+}
+
+// Compact rewrites the database file, releasing the pages freed by deletes
+// back to the filesystem. While it runs the FSM is locked and no reads or
+// writes can be performed, so it must not be run on the leader.
+func (f *FSM) Compact() error {
+	defer metrics.MeasureSince([]string{"raft_storage", "fsm", "compact"}, time.Now())
+
+	f.l.Lock()
+	defer f.l.Unlock()
+
+	dbPath := filepath.Join(f.path, databaseFilename)
+	compactPath := dbPath + compactFilenameSuffix
+
+	before, err := os.Stat(dbPath)
+	if err != nil {
+		return err
+	}
+
+	// Remove any leftover from a previously interrupted compaction
+	if err := os.Remove(compactPath); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	opts := boltOptions(compactPath)
+	opts.NoSync = true
+	dst, err := bolt.Open(compactPath, 0o600, opts)
+	if err != nil {
+		return fmt.Errorf("failed to open compacted database: %w", err)
+	}
+
+	err = bolt.Compact(dst, f.db, compactTxMaxSize)
+	if err == nil {
+		err = dst.Sync()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(compactPath)
+		return fmt.Errorf("failed to compact database: %w", err)
+	}
+
+	if err := f.db.Close(); err != nil {
+		os.Remove(compactPath)
+		return fmt.Errorf("failed to close database: %w", err)
+	}
+
+	// Reopen the database regardless of whether the rename worked, so that
+	// the FSM keeps on using the uncompacted one if it failed.
+	var retErr *multierror.Error
+	if err := os.Rename(compactPath, dbPath); err != nil {
+		os.Remove(compactPath)
+		retErr = multierror.Append(retErr, fmt.Errorf("failed to install compacted database: %w", err))
+	}
+	if err := f.openDBFile(dbPath); err != nil {
+		retErr = multierror.Append(retErr, fmt.Errorf("failed to open database: %w", err))
+	}
+	if err := retErr.ErrorOrNil(); err != nil {
+		return err
+	}
+
+	if after, err := os.Stat(dbPath); err == nil {
+		f.logger.Info("compacted database", "size_before", before.Size(), "size_after", after.Size())
+	}
+	return nil
+}
+
+// compactAfterApply compacts the database in response to a compactOp. Errors
+// are only logged, as the data itself is unaffected by a failed compaction.
+// The leader ignores the compactOp, since compacting would block every write
+// to the cluster.
+func (f *FSM) compactAfterApply() {
+	if isLeader, ok := f.isLeader.Load().(func() bool); ok && isLeader() {
+		f.logger.Warn("not compacting database on the leader")
+		return
+	}
+
+	f.logger.Info("compacting database")
+	if err := f.Compact(); err != nil {
+		f.logger.Error("failed to compact database", "error", err)
+	}
+}
+
+// CompactionStatus returns the status of the latest compaction coordinated by
+// this node, or nil if there was none.
+func (b *RaftBackend) CompactionStatus() *CompactionStatus {
+	b.compactionLock.Lock()
+	defer b.compactionLock.Unlock()
+
+	if b.compaction == nil {
+		return nil
+	}
+	return b.compaction.clone()
+}
+
+// StartCompaction compacts the FSM databases of the followers in the
+// background. The followers are compacted one at a time, and only while
+// autopilot considers the cluster healthy. The leader is reported as skipped,
+// since compacting it would block every write to the cluster. It must be
+// called on the leader.
+func (b *RaftBackend) StartCompaction(ctx context.Context) error {
+	b.l.RLock()
+	raftObj := b.raft
+	b.l.RUnlock()
+	if raftObj == nil {
+		return errors.New("raft storage is not initialized")
+	}
+	if raftObj.State() != raft.Leader {
+		return errors.New("compaction must be started on the leader")
+	}
+
+	state, err := b.GetAutopilotServerState(ctx)
+	if err != nil {
+		return err
+	}
+	if state == nil {
+		return errors.New("autopilot must be enabled to compact raft storage")
+	}
+
+	// Older servers do not understand the compaction log and would fail to
+	// apply it.
+	local, ok := state.Servers[b.localID]
+	if !ok {
+		return errors.New("local server is missing from the autopilot state")
+	}
+	for _, srv := range state.Servers {
+		if srv.Version != local.Version {
+			return errors.New("all servers must run the same version to compact raft storage")
+		}
+	}
+
+	ids := make([]string, 0, len(state.Servers))
+	for id := range state.Servers {
+		if id != b.localID {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	b.compactionLock.Lock()
+	defer b.compactionLock.Unlock()
+
+	if b.compaction != nil && b.compaction.Status == CompactionStatusRunning {
+		return errors.New("a compaction is already in progress")
+	}
+
+	status := &CompactionStatus{
+		Status:    CompactionStatusRunning,
+		StartTime: time.Now(),
+	}
+	for _, id := range ids {
+		status.Servers = append(status.Servers, &CompactionServerStatus{
+			ID:     id,
+			Status: CompactionStatusPending,
+		})
+	}
+	status.Servers = append(status.Servers, &CompactionServerStatus{
+		ID:     b.localID,
+		Status: CompactionStatusSkipped,
+	})
+	b.compaction = status
+
+	go func() {
+		err := b.compactServers(ctx, status)
+
+		b.compactionLock.Lock()
+		defer b.compactionLock.Unlock()
+
+		status.EndTime = time.Now()
+		if err != nil {
+			b.logger.Error("failed to compact raft storage", "error", err)
+			status.Status = CompactionStatusFailed
+			status.Error = err.Error()
+			return
+		}
+		b.logger.Info("compacted raft storage")
+		status.Status = CompactionStatusCompleted
+	}()
+
+	return nil
+}
+
+func (b *RaftBackend) compactServers(ctx context.Context, status *CompactionStatus) error {
+	setStatus := func(srv *CompactionServerStatus, s string) {
+		b.compactionLock.Lock()
+		srv.Status = s
+		b.compactionLock.Unlock()
+	}
+
+	b.l.RLock()
+	followerStates := b.followerStates
+	b.l.RUnlock()
+
+	for _, srv := range status.Servers {
+		if srv.Status == CompactionStatusSkipped {
+			continue
+		}
+
+		if err := b.waitForCompaction(ctx, func() (bool, error) {
+			state, err := b.GetAutopilotServerState(ctx)
+			if err != nil || state == nil {
+				return false, err
+			}
+			return state.Healthy, nil
+		}); err != nil {
+			setStatus(srv, CompactionStatusFailed)
+			return fmt.Errorf("cluster did not become healthy before compacting %q: %w", srv.ID, err)
+		}
+
+		b.logger.Info("compacting raft storage", "id", srv.ID)
+		setStatus(srv, CompactionStatusRunning)
+
+		index, err := b.applyCompactLog(ctx, srv.ID)
+		if err != nil {
+			setStatus(srv, CompactionStatusFailed)
+			return err
+		}
+
+		// Followers only report having applied the log once they are done
+		if err := b.waitForCompaction(ctx, func() (bool, error) {
+			followerStates.l.RLock()
+			defer followerStates.l.RUnlock()
+
+			follower, ok := followerStates.followers[srv.ID]
+			if !ok {
+				return false, fmt.Errorf("server %q is no longer part of the cluster", srv.ID)
+			}
+			return follower.AppliedIndex >= index, nil
+		}); err != nil {
+			setStatus(srv, CompactionStatusFailed)
+			return fmt.Errorf("failed to compact %q: %w", srv.ID, err)
+		}
+
+		setStatus(srv, CompactionStatusCompleted)
+	}
+
+	return nil
+}
+
+// waitForCompaction polls the given condition until it holds, it fails or
+// compactionServerTimeout elapses.
+func (b *RaftBackend) waitForCompaction(ctx context.Context, cond func() (bool, error)) error {
+	ctx, cancel := context.WithTimeout(ctx, compactionServerTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(compactionPollInterval)
+	defer ticker.Stop()
+
+	for {
+		done, err := cond()
+		if err != nil {
+			return err
+		}
+		if done {
+			return nil
+		}
+
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
+
+// applyCompactLog applies a log instructing the given server to compact its
+// database, and returns the index the server reports as applied once it is
+// done.
+func (b *RaftBackend) applyCompactLog(ctx context.Context, id string) (uint64, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	err := b.applyLog(ctx, &LogData{
+		Operations: []*LogOperation{
+			{
+				OpType: compactOp,
+				Key:    id,
+			},
+		},
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// The compactOp has been applied locally, so its index is at most the
+	// latest one. Followers only advance their applied index up to it once
+	// they compacted.
+	index, _ := b.fsm.LatestState()
+	return index.Index, nil
+}
@@ -232,9 +232,6 @@ func parseRaftBackendConfig(conf map[string]string, logger log.Logger) (*RaftBac
 	if c.RaftNonVoter && c.RetryJoin == "" {
 		return nil, fmt.Errorf("setting %s to true is only valid if at least one retry_join stanza is specified", raftNonVoterConfigKey)
 	}
-	if c.RaftNonVoter {
-		emitEntWarning(logger, raftNonVoterConfigKey)
-	}
 
 	c.AutopilotRedundancyZone = conf["autopilot_redundancy_zone"]
 	if c.AutopilotRedundancyZone != "" {
@@ -164,7 +164,6 @@ func TestRaft_ParseConfig(t *testing.T) {
 				cfg.RetryJoin = "not-empty"
 				cfg.RaftNonVoter = true
 			},
-			wantWarns: ceOnlyWarnings("configuration for a Vault Enterprise feature has been ignored: field=retry_join_as_non_voter"),
 		},
 		{
 			name: "non-voter, no retry-join, invalid empty",
@@ -232,7 +231,6 @@ func TestRaft_ParseConfig(t *testing.T) {
 				cfg.RetryJoin = "not-empty"
 				cfg.RaftNonVoter = true // Any non-empty value is true
 			},
-			wantWarns: ceOnlyWarnings("configuration for a Vault Enterprise feature has been ignored: field=retry_join_as_non_voter"),
 		},
 		{
 			name: "non-voter, no retry-join, valid env true",
@@ -253,7 +251,6 @@ func TestRaft_ParseConfig(t *testing.T) {
 				cfg.RetryJoin = "not-empty"
 				cfg.RaftNonVoter = true
 			},
-			wantWarns: ceOnlyWarnings("configuration for a Vault Enterprise feature has been ignored: field=retry_join_as_non_voter"),
 		},
 		{
 			name: "non-voter, no retry-join, valid env not-boolean",
@@ -274,7 +271,6 @@ func TestRaft_ParseConfig(t *testing.T) {
 				cfg.RetryJoin = "not-empty"
 				cfg.RaftNonVoter = true
 			},
-			wantWarns: ceOnlyWarnings("configuration for a Vault Enterprise feature has been ignored: field=retry_join_as_non_voter"),
 		},
 		{
 			name: "non-voter, no retry-join, valid env empty",
@@ -320,7 +316,6 @@ func TestRaft_ParseConfig(t *testing.T) {
 				cfg.RetryJoin = "not-empty"
 				cfg.RaftNonVoter = true // Env should win
 			},
-			wantWarns: ceOnlyWarnings("configuration for a Vault Enterprise feature has been ignored: field=retry_join_as_non_voter"),
 		},
 
 		// Entry Size Limits -----------------------------------------------------
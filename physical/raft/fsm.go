@@ -40,6 +40,7 @@ const (
 	restoreCallbackOp
 	getOp
 	verifierCheckpointOp
+	compactOp
 
 	chunkingPrefix   = "raftchunking/"
 	databaseFilename = "vault.db"
@@ -178,6 +179,10 @@ type FSM struct {
 
 	chunker *logVerificationChunkingShim
 
+	// isLeader holds a func() bool reporting whether this node is the raft
+	// leader, which ignores compactOps
+	isLeader atomic.Value
+
 	localID         string
 	desiredSuffrage string
 	// metricSuffix should contain a dash, since it will be appended directly to the end of the key string.
@@ -743,6 +748,22 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 		}
 	}
 
+	// Compaction needs exclusive access to the database, so it runs once the
+	// read lock below has been released. The in-memory index is only advanced
+	// after it finished, since that is the index followers report to the
+	// leader coordinating the compaction.
+	var compact bool
+	defer func() {
+		if !compact {
+			return
+		}
+		f.compactAfterApply()
+		if len(logIndex) > 0 {
+			atomic.StoreUint64(f.latestTerm, lastLog.Term)
+			atomic.StoreUint64(f.latestIndex, lastLog.Index)
+		}
+	}()
+
 	f.l.RLock()
 	defer f.l.RUnlock()
 
@@ -752,7 +773,7 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 
 	err = f.db.Update(func(tx *bolt.Tx) error {
 		b := tx.Bucket(dataBucketName)
-		for _, commandRaw := range commands {
+		for i, commandRaw := range commands {
 			entrySlice := make([]*FSMEntry, 0)
 			switch command := commandRaw.(type) {
 			case *LogData:
@@ -780,6 +801,13 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 							// Kick off the restore callback function in a go routine
 							go f.restoreCb(context.Background())
 						}
+					case compactOp:
+						// The latest index is stored in the same transaction as
+						// the compactOp, so a log at or below it is being
+						// replayed and was compacted for when first applied.
+						if op.Key == f.localID && logs[i].Index > latestIndex.Index {
+							compact = true
+						}
 					default:
 						return fmt.Errorf("%q is not a supported transaction operation", op.OpType)
 					}
@@ -818,7 +846,7 @@ func (f *FSM) ApplyBatch(logs []*raft.Log) []interface{} {
 	}
 
 	// If we advanced the latest value, update the in-memory representation too.
-	if len(logIndex) > 0 {
+	if len(logIndex) > 0 && !compact {
 		atomic.StoreUint64(f.latestTerm, lastLog.Term)
 		atomic.StoreUint64(f.latestIndex, lastLog.Index)
 	}
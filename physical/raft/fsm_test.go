@@ -7,6 +7,8 @@ import (
 	"context"
 	"fmt"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"sort"
 	"testing"
 
@@ -19,6 +21,10 @@ import (
 )
 
 func getFSM(t testing.TB) *FSM {
+	return getFSMWithID(t, "")
+}
+
+func getFSMWithID(t testing.TB, localID string) *FSM {
 	raftDir := t.TempDir()
 	t.Logf("raft dir: %s", raftDir)
 
@@ -27,7 +33,7 @@ func getFSM(t testing.TB) *FSM {
 		Level: hclog.Trace,
 	})
 
-	fsm, err := NewFSM(raftDir, "", logger)
+	fsm, err := NewFSM(raftDir, localID, logger)
 	if err != nil {
 		t.Fatal(err)
 	}
@@ -194,3 +200,85 @@ func TestFSM_UnknownOperation(t *testing.T) {
 
 	require.Fail(t, "failed to panic")
 }
+
+// TestFSM_Compact verifies that applying a compactOp addressed to the local
+// node shrinks the database file while keeping its data, and that a compactOp
+// addressed to another node is ignored.
+func TestFSM_Compact(t *testing.T) {
+	ctx := context.Background()
+	fsm := getFSMWithID(t, "node1")
+	dbPath := filepath.Join(fsm.path, databaseFilename)
+
+	value := make([]byte, 4096)
+	for i := 0; i < 10000; i++ {
+		require.NoError(t, fsm.Put(ctx, &physical.Entry{Key: fmt.Sprintf("tidy/%d", i), Value: value}))
+	}
+	require.NoError(t, fsm.Put(ctx, &physical.Entry{Key: "keep", Value: []byte("value")}))
+	require.NoError(t, fsm.DeletePrefix(ctx, "tidy/"))
+
+	fileSize := func() int64 {
+		st, err := os.Stat(dbPath)
+		require.NoError(t, err)
+		return st.Size()
+	}
+	applyCompact := func(index uint64, id string) {
+		commandBytes, err := proto.Marshal(&LogData{
+			Operations: []*LogOperation{{OpType: compactOp, Key: id}},
+		})
+		require.NoError(t, err)
+		resp := fsm.Apply(&raft.Log{Index: index, Term: 1, Type: raft.LogCommand, Data: commandBytes})
+		require.True(t, resp.(*FSMApplyResponse).Success)
+	}
+
+	before := fileSize()
+	applyCompact(1, "node2")
+	require.Equal(t, before, fileSize())
+
+	applyCompact(2, "node1")
+	require.Less(t, fileSize(), before/2)
+
+	entry, err := fsm.Get(ctx, "keep")
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), entry.Value)
+
+	keys, err := fsm.List(ctx, "tidy/")
+	require.NoError(t, err)
+	require.Empty(t, keys)
+
+	index, _ := fsm.LatestState()
+	require.Equal(t, uint64(2), index.Index)
+
+	// Replaying the log, e.g. after a restart, doesn't compact again
+	compacted, err := os.Stat(dbPath)
+	require.NoError(t, err)
+	applyCompact(2, "node1")
+	replayed, err := os.Stat(dbPath)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(compacted, replayed))
+	require.NoFileExists(t, dbPath+compactFilenameSuffix)
+}
+
+// TestFSM_Compact_Leader verifies that the leader ignores a compactOp
+// addressed to it, since compacting would block every apply.
+func TestFSM_Compact_Leader(t *testing.T) {
+	fsm := getFSMWithID(t, "node1")
+	fsm.isLeader.Store(func() bool { return true })
+	dbPath := filepath.Join(fsm.path, databaseFilename)
+
+	before, err := os.Stat(dbPath)
+	require.NoError(t, err)
+
+	commandBytes, err := proto.Marshal(&LogData{
+		Operations: []*LogOperation{{OpType: compactOp, Key: "node1"}},
+	})
+	require.NoError(t, err)
+	resp := fsm.Apply(&raft.Log{Index: 1, Term: 1, Type: raft.LogCommand, Data: commandBytes})
+	require.True(t, resp.(*FSMApplyResponse).Success)
+
+	after, err := os.Stat(dbPath)
+	require.NoError(t, err)
+	require.True(t, os.SameFile(before, after))
+
+	index, _ := fsm.LatestState()
+	require.Equal(t, uint64(1), index.Index)
+}
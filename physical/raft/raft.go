@@ -261,6 +261,11 @@ type RaftBackend struct {
 	removed              *atomic.Bool
 	removedCallback      func()
 	removedServerCleanup func(context.Context, string) (bool, error)
+
+	// compaction is the status of the latest compaction of the FSM databases
+	// coordinated by this node.
+	compactionLock sync.Mutex
+	compaction     *CompactionStatus
 }
 
 func (b *RaftBackend) IsNodeRemoved(ctx context.Context, nodeID string) (bool, error) {
@@ -1295,8 +1300,8 @@ func (b *RaftBackend) SetupCluster(ctx context.Context, opts SetupOpts) error {
 			return fmt.Errorf("raft recovery failed to parse peers.json: %w", err)
 		}
 
-		// Non-voting servers are only allowed in enterprise. If Suffrage is disabled,
-		// error out to indicate that it isn't allowed.
+		// Non-voting servers are only allowed if the build supports them. If
+		// Suffrage is disabled, error out to indicate that it isn't allowed.
 		for idx := range recoveryConfig.Servers {
 			if !nonVotersAllowed && recoveryConfig.Servers[idx].Suffrage == raft.Nonvoter {
 				return fmt.Errorf("raft recovery failed to parse configuration for node %q: setting `non_voter` is only supported in enterprise", recoveryConfig.Servers[idx].ID)
@@ -1361,6 +1366,9 @@ func (b *RaftBackend) SetupCluster(ctx context.Context, opts SetupOpts) error {
 
 	b.raft = raftObj
 	b.raftNotifyCh = raftNotifyCh
+	b.fsm.isLeader.Store(func() bool {
+		return raftObj.State() == raft.Leader
+	})
 
 	if err := b.fsm.upgradeLocalNodeConfig(); err != nil {
 		b.logger.Error("failed to upgrade local node configuration")
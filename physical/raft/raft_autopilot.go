@@ -75,7 +75,7 @@ type AutopilotConfig struct {
 	// with Raft protocol version 3 or higher.
 	ServerStabilizationTime time.Duration `mapstructure:"-"`
 
-	// (Enterprise-only) DisableUpgradeMigration will disable Autopilot's upgrade migration
+	// DisableUpgradeMigration will disable Autopilot's upgrade migration
 	// strategy of waiting until enough newer-versioned servers have been added to the
 	// cluster before promoting them to voters.
 	DisableUpgradeMigration bool `mapstructure:"disable_upgrade_migration"`
@@ -84,8 +84,9 @@ type AutopilotConfig struct {
 	// servers into zones for redundancy. If left blank, this feature will be disabled.
 	RedundancyZoneTag string `mapstructure:"redundancy_zone_tag"`
 
-	// (Enterprise-only) UpgradeVersionTag is the node tag to use for version info when
-	// performing upgrade migrations. If left blank, the Consul version will be used.
+	// UpgradeVersionTag is the node tag to use for version info when
+	// performing upgrade migrations. Each node publishes its upgrade version
+	// under this tag. If left blank, AutopilotUpgradeVersionTag is used.
 	UpgradeVersionTag string `mapstructure:"upgrade_version_tag"`
 }
 
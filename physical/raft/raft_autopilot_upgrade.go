@@ -0,0 +1,239 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package raft
+
+import (
+	"sort"
+	"time"
+
+	"github.com/hashicorp/go-version"
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+)
+
+const (
+	// AutopilotUpgradeStatusIdle means that all voters run the same version.
+	AutopilotUpgradeStatusIdle = "idle"
+
+	// AutopilotUpgradeStatusDisabled means that upgrade migration is disabled
+	// in the autopilot configuration.
+	AutopilotUpgradeStatusDisabled = "disabled"
+
+	// AutopilotUpgradeStatusAwaitNewServers means that a newer version was
+	// detected, but there are not yet as many healthy servers running it as
+	// there are voters running an older version.
+	AutopilotUpgradeStatusAwaitNewServers = "await-new-servers"
+
+	// AutopilotUpgradeStatusPromoting means that servers running the target
+	// version are being promoted to voters.
+	AutopilotUpgradeStatusPromoting = "promoting"
+
+	// AutopilotUpgradeStatusDemoting means that voters running an older
+	// version are being demoted to non-voters.
+	AutopilotUpgradeStatusDemoting = "demoting"
+
+	// AutopilotUpgradeStatusLeaderTransfer means that leadership is being
+	// transferred to a voter running the target version.
+	AutopilotUpgradeStatusLeaderTransfer = "leader-transfer"
+
+	// AutopilotUpgradeStatusAwaitServerRemoval means that all voters run the
+	// target version and the servers running an older version can be removed.
+	AutopilotUpgradeStatusAwaitServerRemoval = "await-server-removal"
+)
+
+// AutopilotNodeTypeReadReplica is the node type of servers which joined as
+// non-voters. They receive the log and serve as standbys, but are never
+// promoted to voters.
+const AutopilotNodeTypeReadReplica autopilot.NodeType = "read-replica"
+
+// raftServerExt is the Ext of an autopilot server.
+type raftServerExt struct {
+	ReadReplica bool
+}
+
+func isReadReplica(srv *autopilot.Server) bool {
+	ext, ok := srv.Ext.(*raftServerExt)
+	return ok && ext.ReadReplica
+}
+
+// upgradeMigrationConfig is the Ext of the autopilot config read by
+// upgradePromoter.
+type upgradeMigrationConfig struct {
+	DisableUpgradeMigration bool
+
+	// UpgradeVersionTag is the server meta key holding the version servers
+	// are grouped by. If empty, AutopilotUpgradeVersionTag is used.
+	UpgradeVersionTag string
+}
+
+// upgradePromoter orchestrates rolling upgrades of the cluster. Servers are
+// grouped by the version in their upgrade version tag. Once there are as many
+// healthy servers running the newest version as there are voters running an
+// older one, the new servers are promoted, the old voters are demoted and
+// leadership is transferred to a new server. Outside of an upgrade it behaves
+// like the default autopilot promoter. Read replicas are never promoted.
+type upgradePromoter struct {
+	autopilot.StablePromoter
+}
+
+var _ autopilot.Promoter = (*upgradePromoter)(nil)
+
+func (p *upgradePromoter) GetNodeTypes(_ *autopilot.Config, s *autopilot.State) map[raft.ServerID]autopilot.NodeType {
+	types := make(map[raft.ServerID]autopilot.NodeType, len(s.Servers))
+	for id, srv := range s.Servers {
+		if isReadReplica(&srv.Server) {
+			types[id] = AutopilotNodeTypeReadReplica
+			continue
+		}
+		types[id] = autopilot.NodeVoter
+	}
+	return types
+}
+
+// GetStateExt returns the *AutopilotUpgrade describing the progress of the
+// upgrade.
+func (p *upgradePromoter) GetStateExt(c *autopilot.Config, s *autopilot.State) interface{} {
+	upgrade, _ := p.calculate(c, s, time.Now())
+	return upgrade
+}
+
+func (p *upgradePromoter) CalculatePromotionsAndDemotions(c *autopilot.Config, s *autopilot.State) autopilot.RaftChanges {
+	_, changes := p.calculate(c, s, time.Now())
+	return changes
+}
+
+// calculate returns the upgrade status and the raft changes needed to make
+// progress on it.
+func (p *upgradePromoter) calculate(c *autopilot.Config, s *autopilot.State, now time.Time) (*AutopilotUpgrade, autopilot.RaftChanges) {
+	minStableDuration := s.ServerStabilizationTime(c)
+	isStable := func(srv *autopilot.ServerState) bool {
+		return srv.Health.IsStable(now, minStableDuration)
+	}
+
+	ids := make([]raft.ServerID, 0, len(s.Servers))
+	for id := range s.Servers {
+		ids = append(ids, id)
+	}
+	sort.Slice(ids, func(i, j int) bool { return ids[i] < ids[j] })
+
+	// stablePromotions are the promotions the default promoter would make,
+	// restricted to the servers matching the filter
+	stablePromotions := func(filter func(raft.ServerID) bool) []raft.ServerID {
+		var promotions []raft.ServerID
+		for _, id := range ids {
+			srv := s.Servers[id]
+			if srv.State == autopilot.RaftNonVoter && !isReadReplica(&srv.Server) && isStable(srv) && filter(id) {
+				promotions = append(promotions, id)
+			}
+		}
+		return promotions
+	}
+	all := func(raft.ServerID) bool { return true }
+
+	tag := AutopilotUpgradeVersionTag
+	if ext, ok := c.Ext.(*upgradeMigrationConfig); ok {
+		if ext.DisableUpgradeMigration {
+			return &AutopilotUpgrade{Status: AutopilotUpgradeStatusDisabled}, autopilot.RaftChanges{Promotions: stablePromotions(all)}
+		}
+		if ext.UpgradeVersionTag != "" {
+			tag = ext.UpgradeVersionTag
+		}
+	}
+
+	// Find the newest version among the servers. Without a version for every
+	// server there is nothing to orchestrate.
+	versions := make(map[raft.ServerID]*version.Version, len(ids))
+	var target *version.Version
+	var targetRaw string
+	for _, id := range ids {
+		raw := s.Servers[id].Server.Meta[tag]
+		v, err := version.NewVersion(raw)
+		if err != nil {
+			return &AutopilotUpgrade{Status: AutopilotUpgradeStatusIdle}, autopilot.RaftChanges{Promotions: stablePromotions(all)}
+		}
+		versions[id] = v
+		if target == nil || v.GreaterThan(target) {
+			target = v
+			targetRaw = raw
+		}
+	}
+
+	upgrade := &AutopilotUpgrade{
+		Status:        AutopilotUpgradeStatusIdle,
+		TargetVersion: targetRaw,
+	}
+	isTarget := func(id raft.ServerID) bool {
+		return versions[id].Equal(target)
+	}
+	var healthyTarget int
+	for _, id := range ids {
+		srv := s.Servers[id]
+		switch {
+		case isReadReplica(&srv.Server) && !srv.HasVotingRights():
+			if isTarget(id) {
+				upgrade.TargetVersionReadReplicas = append(upgrade.TargetVersionReadReplicas, string(id))
+			} else {
+				upgrade.OtherVersionReadReplicas = append(upgrade.OtherVersionReadReplicas, string(id))
+			}
+		case isTarget(id) && srv.HasVotingRights():
+			upgrade.TargetVersionVoters = append(upgrade.TargetVersionVoters, string(id))
+			if srv.Health.Healthy {
+				healthyTarget++
+			}
+		case isTarget(id):
+			upgrade.TargetVersionNonVoters = append(upgrade.TargetVersionNonVoters, string(id))
+			if isStable(srv) {
+				healthyTarget++
+			}
+		case srv.HasVotingRights():
+			upgrade.OtherVersionVoters = append(upgrade.OtherVersionVoters, string(id))
+		default:
+			upgrade.OtherVersionNonVoters = append(upgrade.OtherVersionNonVoters, string(id))
+		}
+	}
+
+	if len(upgrade.OtherVersionVoters) == 0 {
+		if len(upgrade.OtherVersionNonVoters) > 0 || len(upgrade.OtherVersionReadReplicas) > 0 {
+			upgrade.Status = AutopilotUpgradeStatusAwaitServerRemoval
+		}
+		return upgrade, autopilot.RaftChanges{Promotions: stablePromotions(isTarget)}
+	}
+
+	// Keep the new servers as non-voters until they can replace every old
+	// voter, so that the failure tolerance of the cluster never drops
+	if healthyTarget < len(upgrade.OtherVersionVoters) {
+		upgrade.Status = AutopilotUpgradeStatusAwaitNewServers
+		return upgrade, autopilot.RaftChanges{}
+	}
+
+	if promotions := stablePromotions(isTarget); len(promotions) > 0 {
+		upgrade.Status = AutopilotUpgradeStatusPromoting
+		return upgrade, autopilot.RaftChanges{Promotions: promotions}
+	}
+
+	// The leader cannot demote itself, so demote the other old voters first
+	// and then hand leadership over to a new voter, which demotes the former
+	// leader.
+	var changes autopilot.RaftChanges
+	for _, id := range upgrade.OtherVersionVoters {
+		if raft.ServerID(id) != s.Leader {
+			changes.Demotions = append(changes.Demotions, raft.ServerID(id))
+		}
+	}
+	if len(changes.Demotions) > 0 {
+		upgrade.Status = AutopilotUpgradeStatusDemoting
+		return upgrade, changes
+	}
+
+	for _, id := range upgrade.TargetVersionVoters {
+		if s.Servers[raft.ServerID(id)].Health.Healthy {
+			upgrade.Status = AutopilotUpgradeStatusLeaderTransfer
+			changes.Leader = raft.ServerID(id)
+			break
+		}
+	}
+	return upgrade, changes
+}
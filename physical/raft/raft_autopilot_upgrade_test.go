@@ -0,0 +1,238 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package raft
+
+import (
+	"testing"
+	"time"
+
+	"github.com/hashicorp/raft"
+	autopilot "github.com/hashicorp/raft-autopilot"
+	"github.com/stretchr/testify/require"
+)
+
+// TestUpgradePromoter walks through the stages of a rolling upgrade of a
+// three node cluster.
+func TestUpgradePromoter(t *testing.T) {
+	now := time.Now()
+	config := &autopilot.Config{ServerStabilizationTime: 10 * time.Second}
+
+	type server struct {
+		version     string
+		state       autopilot.RaftState
+		stable      bool
+		readReplica bool
+	}
+	newState := func(leader raft.ServerID, servers map[raft.ServerID]server) *autopilot.State {
+		state := &autopilot.State{
+			Leader:  leader,
+			Servers: make(map[raft.ServerID]*autopilot.ServerState),
+		}
+		for id, srv := range servers {
+			stableSince := now
+			if srv.stable {
+				stableSince = now.Add(-time.Minute)
+			}
+			state.Servers[id] = &autopilot.ServerState{
+				Server: autopilot.Server{
+					ID:         id,
+					NodeStatus: autopilot.NodeAlive,
+					Meta:       map[string]string{AutopilotUpgradeVersionTag: srv.version},
+					Ext:        &raftServerExt{ReadReplica: srv.readReplica},
+				},
+				State: srv.state,
+				Health: autopilot.ServerHealth{
+					Healthy:     true,
+					StableSince: stableSince,
+				},
+			}
+		}
+		return state
+	}
+
+	oldVoters := map[raft.ServerID]server{
+		"a": {version: "1.18.0", state: autopilot.RaftLeader, stable: true},
+		"b": {version: "1.18.0", state: autopilot.RaftVoter, stable: true},
+		"c": {version: "1.18.0", state: autopilot.RaftVoter, stable: true},
+	}
+	withServers := func(extra map[raft.ServerID]server) map[raft.ServerID]server {
+		servers := make(map[raft.ServerID]server)
+		for id, srv := range oldVoters {
+			servers[id] = srv
+		}
+		for id, srv := range extra {
+			servers[id] = srv
+		}
+		return servers
+	}
+
+	cases := map[string]struct {
+		state         *autopilot.State
+		disabled      bool
+		wantStatus    string
+		wantTarget    string
+		wantPromote   []raft.ServerID
+		wantDemote    []raft.ServerID
+		wantLeader    raft.ServerID
+		wantNewVoters []string
+	}{
+		"idle": {
+			state: newState("a", withServers(map[raft.ServerID]server{
+				"d": {version: "1.18.0", state: autopilot.RaftNonVoter, stable: true},
+			})),
+			wantStatus:  AutopilotUpgradeStatusIdle,
+			wantTarget:  "1.18.0",
+			wantPromote: []raft.ServerID{"d"},
+		},
+		"await-new-servers": {
+			state: newState("a", withServers(map[raft.ServerID]server{
+				"d": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true},
+				"e": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true},
+				"f": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: false},
+			})),
+			wantStatus: AutopilotUpgradeStatusAwaitNewServers,
+			wantTarget: "1.19.0",
+		},
+		"promoting": {
+			state: newState("a", withServers(map[raft.ServerID]server{
+				"d": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true},
+				"e": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true},
+				"f": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true},
+			})),
+			wantStatus:  AutopilotUpgradeStatusPromoting,
+			wantTarget:  "1.19.0",
+			wantPromote: []raft.ServerID{"d", "e", "f"},
+		},
+		"demoting": {
+			state: newState("a", withServers(map[raft.ServerID]server{
+				"d": {version: "1.19.0", state: autopilot.RaftVoter, stable: true},
+				"e": {version: "1.19.0", state: autopilot.RaftVoter, stable: true},
+				"f": {version: "1.19.0", state: autopilot.RaftVoter, stable: true},
+			})),
+			wantStatus:    AutopilotUpgradeStatusDemoting,
+			wantTarget:    "1.19.0",
+			wantDemote:    []raft.ServerID{"b", "c"},
+			wantNewVoters: []string{"d", "e", "f"},
+		},
+		"leader-transfer": {
+			state: newState("a", map[raft.ServerID]server{
+				"a": {version: "1.18.0", state: autopilot.RaftLeader, stable: true},
+				"b": {version: "1.18.0", state: autopilot.RaftNonVoter, stable: true},
+				"d": {version: "1.19.0", state: autopilot.RaftVoter, stable: true},
+				"e": {version: "1.19.0", state: autopilot.RaftVoter, stable: true},
+			}),
+			wantStatus:    AutopilotUpgradeStatusLeaderTransfer,
+			wantTarget:    "1.19.0",
+			wantLeader:    "d",
+			wantNewVoters: []string{"d", "e"},
+		},
+		"demote-former-leader": {
+			state: newState("d", map[raft.ServerID]server{
+				"a": {version: "1.18.0", state: autopilot.RaftVoter, stable: true},
+				"d": {version: "1.19.0", state: autopilot.RaftLeader, stable: true},
+				"e": {version: "1.19.0", state: autopilot.RaftVoter, stable: true},
+			}),
+			wantStatus:    AutopilotUpgradeStatusDemoting,
+			wantTarget:    "1.19.0",
+			wantDemote:    []raft.ServerID{"a"},
+			wantNewVoters: []string{"d", "e"},
+		},
+		"await-server-removal": {
+			state: newState("d", map[raft.ServerID]server{
+				"a": {version: "1.18.0", state: autopilot.RaftNonVoter, stable: true},
+				"d": {version: "1.19.0", state: autopilot.RaftLeader, stable: true},
+				"e": {version: "1.19.0", state: autopilot.RaftVoter, stable: true},
+			}),
+			wantStatus:    AutopilotUpgradeStatusAwaitServerRemoval,
+			wantTarget:    "1.19.0",
+			wantNewVoters: []string{"d", "e"},
+		},
+		"read-replica": {
+			state: newState("a", withServers(map[raft.ServerID]server{
+				"d": {version: "1.18.0", state: autopilot.RaftNonVoter, stable: true, readReplica: true},
+				"e": {version: "1.18.0", state: autopilot.RaftNonVoter, stable: true},
+			})),
+			wantStatus:  AutopilotUpgradeStatusIdle,
+			wantTarget:  "1.18.0",
+			wantPromote: []raft.ServerID{"e"},
+		},
+		"read-replicas-do-not-replace-voters": {
+			state: newState("a", withServers(map[raft.ServerID]server{
+				"d": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true, readReplica: true},
+				"e": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true, readReplica: true},
+				"f": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true, readReplica: true},
+			})),
+			wantStatus: AutopilotUpgradeStatusAwaitNewServers,
+			wantTarget: "1.19.0",
+		},
+		"disabled": {
+			state: newState("a", withServers(map[raft.ServerID]server{
+				"d": {version: "1.19.0", state: autopilot.RaftNonVoter, stable: true},
+			})),
+			disabled:    true,
+			wantStatus:  AutopilotUpgradeStatusDisabled,
+			wantPromote: []raft.ServerID{"d"},
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			c := *config
+			c.Ext = &upgradeMigrationConfig{DisableUpgradeMigration: tc.disabled}
+
+			upgrade, changes := new(upgradePromoter).calculate(&c, tc.state, now)
+			require.Equal(t, tc.wantStatus, upgrade.Status)
+			require.Equal(t, tc.wantTarget, upgrade.TargetVersion)
+			require.Equal(t, tc.wantPromote, changes.Promotions)
+			require.Equal(t, tc.wantDemote, changes.Demotions)
+			require.Equal(t, tc.wantLeader, changes.Leader)
+			if tc.wantNewVoters != nil {
+				require.Equal(t, tc.wantNewVoters, upgrade.TargetVersionVoters)
+			}
+		})
+	}
+}
+
+// TestUpgradePromoter_UpgradeVersionTag verifies that servers are grouped by
+// the version in the tag set by upgrade_version_tag.
+func TestUpgradePromoter_UpgradeVersionTag(t *testing.T) {
+	now := time.Now()
+	newServer := func(id raft.ServerID, state autopilot.RaftState, meta map[string]string) *autopilot.ServerState {
+		return &autopilot.ServerState{
+			Server: autopilot.Server{
+				ID:         id,
+				NodeStatus: autopilot.NodeAlive,
+				Meta:       meta,
+			},
+			State: state,
+			Health: autopilot.ServerHealth{
+				Healthy:     true,
+				StableSince: now.Add(-time.Minute),
+			},
+		}
+	}
+	state := &autopilot.State{
+		Leader: "a",
+		Servers: map[raft.ServerID]*autopilot.ServerState{
+			"a": newServer("a", autopilot.RaftLeader, map[string]string{AutopilotUpgradeVersionTag: "1.18.0", "build": "1.18.0"}),
+			"b": newServer("b", autopilot.RaftNonVoter, map[string]string{AutopilotUpgradeVersionTag: "1.18.0", "build": "1.19.0"}),
+		},
+	}
+	config := &autopilot.Config{ServerStabilizationTime: 10 * time.Second}
+
+	config.Ext = &upgradeMigrationConfig{}
+	upgrade, changes := new(upgradePromoter).calculate(config, state, now)
+	require.Equal(t, AutopilotUpgradeStatusIdle, upgrade.Status)
+	require.Equal(t, "1.18.0", upgrade.TargetVersion)
+	require.Equal(t, []raft.ServerID{"b"}, changes.Promotions)
+
+	config.Ext = &upgradeMigrationConfig{UpgradeVersionTag: "build"}
+	upgrade, changes = new(upgradePromoter).calculate(config, state, now)
+	require.Equal(t, AutopilotUpgradeStatusPromoting, upgrade.Status)
+	require.Equal(t, "1.19.0", upgrade.TargetVersion)
+	require.Equal(t, []string{"a"}, upgrade.OtherVersionVoters)
+	require.Equal(t, []raft.ServerID{"b"}, changes.Promotions)
+}
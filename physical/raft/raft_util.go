@@ -8,37 +8,98 @@ package raft
 import (
 	"context"
 	"errors"
+	"sort"
 
+	"github.com/hashicorp/raft"
 	autopilot "github.com/hashicorp/raft-autopilot"
 )
 
-const nonVotersAllowed = false
+const nonVotersAllowed = true
 
 func (b *RaftBackend) autopilotPromoter() autopilot.Promoter {
-	return autopilot.DefaultPromoter()
+	return new(upgradePromoter)
 }
 
-// AddNonVotingPeer adds a new server to the raft cluster
+// AddNonVotingPeer adds a new server to the raft cluster as a read replica,
+// which receives the log but never votes nor becomes leader
 func (b *RaftBackend) AddNonVotingPeer(ctx context.Context, peerID, clusterAddr string) error {
-	return errors.New("adding non voting peer is not allowed")
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.l.RLock()
+	defer b.l.RUnlock()
+
+	if b.disableAutopilot {
+		if b.raft == nil {
+			return errors.New("raft storage is not initialized")
+		}
+		b.logger.Trace("adding non-voting server to raft", "id", peerID, "addr", clusterAddr)
+		future := b.raft.AddNonvoter(raft.ServerID(peerID), raft.ServerAddress(clusterAddr), 0, 0)
+		return future.Error()
+	}
+
+	if b.autopilot == nil {
+		return errors.New("raft storage autopilot is not initialized")
+	}
+
+	b.logger.Trace("adding non-voting server to raft via autopilot", "id", peerID, "addr", clusterAddr)
+	return b.autopilot.AddServer(&autopilot.Server{
+		ID:          raft.ServerID(peerID),
+		Name:        peerID,
+		Address:     raft.ServerAddress(clusterAddr),
+		RaftVersion: raft.ProtocolVersionMax,
+		NodeType:    AutopilotNodeTypeReadReplica,
+		Ext:         &raftServerExt{ReadReplica: true},
+	})
 }
 
-func autopilotToAPIServerEnterprise(_ *autopilot.Server, _ *AutopilotServer) error {
+func autopilotToAPIServerEnterprise(srv *autopilot.Server, apiSrv *AutopilotServer) error {
+	apiSrv.UpgradeVersion = srv.Meta[AutopilotUpgradeVersionTag]
+	apiSrv.ReadReplica = isReadReplica(srv)
 	return nil
 }
 
-func autopilotToAPIStateEnterprise(_ *autopilot.State, _ *AutopilotState) error {
+func autopilotToAPIStateEnterprise(state *autopilot.State, apiState *AutopilotState) error {
+	for id, srv := range state.Servers {
+		if srv.State == autopilot.RaftNonVoter {
+			apiState.NonVoters = append(apiState.NonVoters, string(id))
+		}
+	}
+	sort.Strings(apiState.NonVoters)
+
+	if upgrade, ok := state.Ext.(*AutopilotUpgrade); ok {
+		apiState.Upgrade = upgrade
+	}
 	return nil
 }
 
 func (d *Delegate) autopilotConfigExt() interface{} {
-	return nil
+	return &upgradeMigrationConfig{
+		DisableUpgradeMigration: d.autopilotConfig.DisableUpgradeMigration,
+		UpgradeVersionTag:       d.autopilotConfig.UpgradeVersionTag,
+	}
 }
 
-func (d *Delegate) autopilotServerExt(_ *FollowerState) interface{} {
-	return nil
+func (d *Delegate) autopilotServerExt(state *FollowerState) interface{} {
+	if state == nil {
+		return nil
+	}
+	return &raftServerExt{
+		ReadReplica: state.DesiredSuffrage == "non-voter",
+	}
 }
 
-func (d *Delegate) meta(_ *FollowerState) map[string]string {
-	return nil
+func (d *Delegate) meta(state *FollowerState) map[string]string {
+	if state == nil || state.UpgradeVersion == "" {
+		return nil
+	}
+	meta := map[string]string{
+		AutopilotUpgradeVersionTag: state.UpgradeVersion,
+	}
+	// The upgrade promoter reads the version from the configured tag
+	if tag := d.autopilotConfig.UpgradeVersionTag; tag != "" {
+		meta[tag] = state.UpgradeVersion
+	}
+	return meta
 }
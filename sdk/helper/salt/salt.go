@@ -11,10 +11,12 @@ import (
 	"encoding/hex"
 	"fmt"
 	"hash"
+	"io"
 
 	"github.com/hashicorp/errwrap"
 	uuid "github.com/hashicorp/go-uuid"
 	"github.com/hashicorp/vault/sdk/logical"
+	"golang.org/x/crypto/hkdf"
 )
 
 const (
@@ -155,6 +157,18 @@ func (s *Salt) GetIdentifiedHMAC(data string) string {
 	return s.config.HMACType + ":" + s.GetHMAC(data)
 }
 
+// DeriveKey returns a key of the given length derived from the salt for the
+// given purpose, using HKDF with SHA-256. Unlike the result of GetHMAC, the key
+// cannot be computed from HMACs of chosen data returned by GetHMAC, so it can be
+// used where those HMACs are exposed (e.g. by sys/audit-hash).
+func (s *Salt) DeriveKey(purpose string, length int) ([]byte, error) {
+	key := make([]byte, length)
+	if _, err := io.ReadFull(hkdf.New(sha256.New, []byte(s.salt), nil, []byte(purpose)), key); err != nil {
+		return nil, fmt.Errorf("failed to derive key: %w", err)
+	}
+	return key, nil
+}
+
 // DidGenerate returns true if the underlying salt value was generated
 // on initialization.
 func (s *Salt) DidGenerate() bool {
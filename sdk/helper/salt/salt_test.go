@@ -4,9 +4,11 @@
 package salt
 
 import (
+	"bytes"
 	"context"
 	"crypto/sha1"
 	"crypto/sha256"
+	"encoding/hex"
 	"testing"
 
 	uuid "github.com/hashicorp/go-uuid"
@@ -89,3 +91,36 @@ func TestSaltID(t *testing.T) {
 		t.Fatalf("mismatch")
 	}
 }
+
+func TestSalt_DeriveKey(t *testing.T) {
+	salt := NewNonpersistentSalt()
+
+	key1, err := salt.DeriveKey("purpose", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(key1) != 32 {
+		t.Fatalf("Bad len: %d", len(key1))
+	}
+
+	key2, err := salt.DeriveKey("purpose", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(key1, key2) {
+		t.Fatalf("mismatch")
+	}
+
+	other, err := salt.DeriveKey("other", 32)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Equal(key1, other) {
+		t.Fatalf("keys derived for different purposes must differ")
+	}
+
+	// The key is not the HMAC of the purpose
+	if hex.EncodeToString(key1) == salt.GetHMAC("purpose") {
+		t.Fatalf("derived key matches the HMAC of the purpose")
+	}
+}
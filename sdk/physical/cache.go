@@ -5,7 +5,10 @@ package physical
 
 import (
 	"context"
+	"sort"
+	"strings"
 	"sync/atomic"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 	metrics "github.com/hashicorp/go-metrics/compat"
@@ -21,6 +24,10 @@ const (
 	// refreshCacheCtxKey is a ctx value that denotes the cache should be
 	// refreshed during a Get call.
 	refreshCacheCtxKey = "refresh_cache"
+
+	// defaultCachePartitionLabel is the metrics label of the cache used for
+	// keys that do not match a configured prefix
+	defaultCachePartitionLabel = "default"
 )
 
 // These paths don't need to be cached by the LRU cache. This should
@@ -60,6 +67,46 @@ func cacheRefreshFromContext(ctx context.Context) bool {
 	return r
 }
 
+// CacheConfig configures a Cache.
+type CacheConfig struct {
+	// Size is the number of entries held for keys that do not match any of
+	// the PrefixSizes. If zero, DefaultCacheSize is used.
+	Size int
+
+	// PrefixSizes gives keys under each prefix a cache of their own with the
+	// given number of entries, so that a busy prefix cannot evict the rest.
+	// Keys are assigned to the longest matching prefix.
+	PrefixSizes map[string]int
+
+	// ExcludePaths are paths or prefixes, in addition to the built-in
+	// exceptions, that are never cached.
+	ExcludePaths []string
+
+	// TTL is how long an entry may be served from the cache before it is
+	// read again from the backend. If zero, entries do not expire. It is
+	// meant for backends without HA, which may be modified out of band.
+	TTL time.Duration
+
+	// DisableNegativeCache disables caching that a key does not exist.
+	DisableNegativeCache bool
+}
+
+// cachePartition is the cache of the keys under a prefix.
+type cachePartition struct {
+	prefix string
+	size   int
+	lru    *lru.TwoQueueCache
+	labels []metrics.Label
+}
+
+// cacheEntry is the value stored in the LRU when a TTL is configured.
+// Otherwise the *Entry is stored as is, as it always has been. A nil entry
+// records that the key does not exist.
+type cacheEntry struct {
+	entry   *Entry
+	expires time.Time
+}
+
 // Cache is used to wrap an underlying physical backend
 // and provide an LRU cache layer on top. Most of the reads done by
 // Vault are for policy objects so there is a large read reduction
@@ -72,6 +119,13 @@ type Cache struct {
 	enabled         *uint32
 	cacheExceptions *pathmanager.PathManager
 	metricSink      metrics.MetricSink
+
+	// partitions are sorted by descending prefix length, the last one being
+	// the default partition with an empty prefix which backs lru
+	partitions    []*cachePartition
+	ttl           time.Duration
+	negativeCache bool
+	now           func() time.Time
 }
 
 // TransactionalCache is a Cache that wraps the physical that is transactional
@@ -92,8 +146,18 @@ var (
 // NewCache returns a physical cache of the given size.
 // If no size is provided, the default size is used.
 func NewCache(b Backend, size int, logger log.Logger, metricSink metrics.MetricSink) *Cache {
+	return NewCacheWithConfig(b, &CacheConfig{Size: size}, logger, metricSink)
+}
+
+// NewCacheWithConfig returns a physical cache configured by the given
+// config. A nil config uses the defaults.
+func NewCacheWithConfig(b Backend, config *CacheConfig, logger log.Logger, metricSink metrics.MetricSink) *Cache {
+	if config == nil {
+		config = &CacheConfig{}
+	}
+	size := config.Size
 	if logger.IsDebug() {
-		logger.Debug("creating LRU cache", "size", size)
+		logger.Debug("creating LRU cache", "size", size, "prefix_sizes", config.PrefixSizes, "ttl", config.TTL)
 	}
 	if size <= 0 {
 		size = DefaultCacheSize
@@ -101,29 +165,132 @@ func NewCache(b Backend, size int, logger log.Logger, metricSink metrics.MetricS
 
 	pm := pathmanager.New()
 	pm.AddPaths(cacheExceptionsPaths)
+	pm.AddPaths(config.ExcludePaths)
+
+	var partitions []*cachePartition
+	for prefix, prefixSize := range config.PrefixSizes {
+		if prefix == "" || prefixSize <= 0 {
+			continue
+		}
+		partitions = append(partitions, newCachePartition(prefix, prefixSize, prefix))
+	}
+	sort.Slice(partitions, func(i, j int) bool {
+		return len(partitions[i].prefix) > len(partitions[j].prefix)
+	})
+	defaultPartition := newCachePartition("", size, defaultCachePartitionLabel)
+	partitions = append(partitions, defaultPartition)
 
-	cache, _ := lru.New2Q(size)
 	c := &Cache{
 		backend: b,
-		lru:     cache,
+		lru:     defaultPartition.lru,
 		locks:   locksutil.CreateLocks(),
 		logger:  logger,
 		// This fails safe.
 		enabled:         new(uint32),
 		cacheExceptions: pm,
 		metricSink:      metricSink,
+		partitions:      partitions,
+		ttl:             config.TTL,
+		negativeCache:   !config.DisableNegativeCache,
+		now:             time.Now,
 	}
 	return c
 }
 
+func newCachePartition(prefix string, size int, label string) *cachePartition {
+	cache, _ := lru.New2Q(size)
+	return &cachePartition{
+		prefix: prefix,
+		size:   size,
+		lru:    cache,
+		labels: []metrics.Label{{Name: "prefix", Value: label}},
+	}
+}
+
 func NewTransactionalCache(b Backend, size int, logger log.Logger, metricSink metrics.MetricSink) *TransactionalCache {
+	return NewTransactionalCacheWithConfig(b, &CacheConfig{Size: size}, logger, metricSink)
+}
+
+// NewTransactionalCacheWithConfig returns a transactional physical cache
+// configured by the given config. A nil config uses the defaults.
+func NewTransactionalCacheWithConfig(b Backend, config *CacheConfig, logger log.Logger, metricSink metrics.MetricSink) *TransactionalCache {
 	c := &TransactionalCache{
-		Cache:         NewCache(b, size, logger, metricSink),
+		Cache:         NewCacheWithConfig(b, config, logger, metricSink),
 		Transactional: b.(Transactional),
 	}
 	return c
 }
 
+// partition returns the partition caching the given key.
+func (c *Cache) partition(key string) *cachePartition {
+	for _, p := range c.partitions {
+		if strings.HasPrefix(key, p.prefix) {
+			return p
+		}
+	}
+	// Unreachable as the default partition matches every key
+	return c.partitions[len(c.partitions)-1]
+}
+
+// add caches the entry of the given key, which is nil if the key does not
+// exist.
+func (c *Cache) add(key string, entry *Entry) {
+	p := c.partition(key)
+	if entry == nil && !c.negativeCache {
+		p.lru.Remove(key)
+		return
+	}
+
+	var value interface{} = entry
+	if c.ttl > 0 {
+		value = &cacheEntry{entry: entry, expires: c.now().Add(c.ttl)}
+	}
+
+	// The 2Q cache does not report evictions, but adding a key it does not
+	// hold to a full cache always evicts one.
+	if !p.lru.Contains(key) && p.lru.Len() >= p.size {
+		c.metricSink.IncrCounterWithLabels([]string{"cache", "evict"}, 1, p.labels)
+	}
+	p.lru.Add(key, value)
+}
+
+// cachedValue returns the entry held by a value stored in the LRU and whether
+// it is still valid.
+func (c *Cache) cachedValue(raw interface{}) (*Entry, bool) {
+	switch v := raw.(type) {
+	case *cacheEntry:
+		if !v.expires.IsZero() && !c.now().Before(v.expires) {
+			return nil, false
+		}
+		return v.entry, true
+	case *Entry:
+		return v, true
+	}
+	return nil, false
+}
+
+// remove drops the given key from the cache.
+func (c *Cache) remove(key string) {
+	c.partition(key).lru.Remove(key)
+}
+
+// CachedEntry returns the entry cached for the given key, and whether the key
+// is cached. A nil entry records that the key does not exist. The caller must
+// hold the lock of the key from Locks.
+func (c *Cache) CachedEntry(key string) (*Entry, bool) {
+	raw, ok := c.partition(key).lru.Peek(key)
+	if !ok {
+		return nil, false
+	}
+	return c.cachedValue(raw)
+}
+
+// RemoveCachedEntry drops the given key from the cache. The caller must hold
+// the lock of the key from Locks.
+func (c *Cache) RemoveCachedEntry(key string) {
+	c.remove(key)
+}
+
 func (c *Cache) ShouldCache(key string) bool {
 	if atomic.LoadUint32(c.enabled) == 0 {
 		return false
@@ -150,7 +317,9 @@ func (c *Cache) Purge(ctx context.Context) {
 		defer lock.Unlock()
 	}
 
-	c.lru.Purge()
+	for _, p := range c.partitions {
+		p.lru.Purge()
+	}
 }
 
 func (c *Cache) Put(ctx context.Context, entry *Entry) error {
@@ -164,8 +333,8 @@ func (c *Cache) Put(ctx context.Context, entry *Entry) error {
 
 	err := c.backend.Put(ctx, entry)
 	if err == nil {
-		c.lru.Add(entry.Key, entry)
-		c.metricSink.IncrCounter([]string{"cache", "write"}, 1)
+		c.add(entry.Key, entry)
+		c.metricSink.IncrCounterWithLabels([]string{"cache", "write"}, 1, c.partition(entry.Key).labels)
 	}
 	return err
 }
@@ -179,26 +348,32 @@ func (c *Cache) Get(ctx context.Context, key string) (*Entry, error) {
 	lock.RLock()
 	defer lock.RUnlock()
 
+	p := c.partition(key)
+
 	// Check the LRU first
 	if !cacheRefreshFromContext(ctx) {
-		if raw, ok := c.lru.Get(key); ok {
-			if raw == nil {
-				return nil, nil
+		if raw, ok := p.lru.Get(key); ok {
+			if entry, ok := c.cachedValue(raw); ok {
+				if entry == nil {
+					c.metricSink.IncrCounterWithLabels([]string{"cache", "negative_hit"}, 1, p.labels)
+					return nil, nil
+				}
+				c.metricSink.IncrCounterWithLabels([]string{"cache", "hit"}, 1, p.labels)
+				return entry, nil
 			}
-			c.metricSink.IncrCounter([]string{"cache", "hit"}, 1)
-			return raw.(*Entry), nil
+			c.metricSink.IncrCounterWithLabels([]string{"cache", "expired"}, 1, p.labels)
 		}
 	}
 
-	c.metricSink.IncrCounter([]string{"cache", "miss"}, 1)
+	c.metricSink.IncrCounterWithLabels([]string{"cache", "miss"}, 1, p.labels)
 	// Read from the underlying backend
 	ent, err := c.backend.Get(ctx, key)
 	if err != nil {
 		return nil, err
 	}
 
-	// Cache the result, even if nil
-	c.lru.Add(key, ent)
+	// Cache the result, even if nil unless negative caching is disabled
+	c.add(key, ent)
 
 	return ent, nil
 }
@@ -214,7 +389,7 @@ func (c *Cache) Delete(ctx context.Context, key string) error {
 
 	err := c.backend.Delete(ctx, key)
 	if err == nil {
-		c.lru.Remove(key)
+		c.remove(key)
 	}
 	return err
 }
@@ -226,11 +401,16 @@ func (c *Cache) List(ctx context.Context, prefix string) ([]string, error) {
 	return c.backend.List(ctx, prefix)
 }
 
-func (c *TransactionalCache) Locks() []*locksutil.LockEntry {
+func (c *Cache) Locks() []*locksutil.LockEntry {
 	return c.locks
 }
 
-func (c *TransactionalCache) LRU() *lru.TwoQueueCache {
+// LRU returns the cache of the keys that do not match any configured prefix.
+// Its values are *Entry unless a TTL is configured.
+//
+// Deprecated: LRU does not cover the keys of the configured prefixes, use
+// CachedEntry and RemoveCachedEntry instead.
+func (c *Cache) LRU() *lru.TwoQueueCache {
 	return c.lru
 }
 
@@ -260,13 +440,14 @@ func (c *TransactionalCache) Transaction(ctx context.Context, txns []*TxnEntry)
 			continue
 		}
 
+		labels := c.partition(txn.Entry.Key).labels
 		switch txn.Operation {
 		case PutOperation:
-			c.lru.Add(txn.Entry.Key, txn.Entry)
-			c.metricSink.IncrCounter([]string{"cache", "write"}, 1)
+			c.add(txn.Entry.Key, txn.Entry)
+			c.metricSink.IncrCounterWithLabels([]string{"cache", "write"}, 1, labels)
 		case DeleteOperation:
-			c.lru.Remove(txn.Entry.Key)
-			c.metricSink.IncrCounter([]string{"cache", "delete"}, 1)
+			c.remove(txn.Entry.Key)
+			c.metricSink.IncrCounterWithLabels([]string{"cache", "delete"}, 1, labels)
 		}
 	}
 
@@ -5,12 +5,15 @@ package inmem
 
 import (
 	"context"
+	"fmt"
 	"testing"
+	"time"
 
 	log "github.com/hashicorp/go-hclog"
 	metrics "github.com/hashicorp/go-metrics/compat"
 	"github.com/hashicorp/vault/sdk/helper/logging"
 	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCache(t *testing.T) {
@@ -331,3 +334,163 @@ func TestCache_Refresh(t *testing.T) {
 		t.Fatalf("expected value baz, got %s", string(r.Value))
 	}
 }
+
+// TestCache_PrefixSizes verifies that keys under a prefix with a size of its
+// own do not evict other keys, and that evictions are counted per prefix.
+func TestCache_PrefixSizes(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewInmem(nil, logger)
+	require.NoError(t, err)
+	sink := metrics.NewInmemSink(time.Hour, time.Hour)
+	cache := physical.NewCacheWithConfig(inm, &physical.CacheConfig{
+		Size: 10,
+		PrefixSizes: map[string]int{
+			"identity/":         2,
+			"identity/entity/":  5,
+			"ignored/negative/": -1,
+		},
+	}, logger, sink)
+	cache.SetEnabled(true)
+
+	require.NoError(t, cache.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+	for i := 0; i < 20; i++ {
+		require.NoError(t, cache.Put(ctx, &physical.Entry{Key: fmt.Sprintf("identity/alias/%d", i), Value: []byte("alias")}))
+	}
+	for i := 0; i < 5; i++ {
+		require.NoError(t, cache.Put(ctx, &physical.Entry{Key: fmt.Sprintf("identity/entity/%d", i), Value: []byte("entity")}))
+	}
+
+	// Remove the keys from under the cache to see which are still cached
+	require.NoError(t, inm.Delete(ctx, "foo"))
+	require.NoError(t, inm.Delete(ctx, "identity/alias/0"))
+	require.NoError(t, inm.Delete(ctx, "identity/entity/0"))
+
+	out, err := cache.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	out, err = cache.Get(ctx, "identity/entity/0")
+	require.NoError(t, err)
+	require.NotNil(t, out)
+	out, err = cache.Get(ctx, "identity/alias/0")
+	require.NoError(t, err)
+	require.Nil(t, out)
+
+	// Caching the miss of the last read evicts one more alias
+	counters := sink.Data()[0].Counters
+	require.Equal(t, 19, counters["cache.evict;prefix=identity/"].Count)
+	require.NotContains(t, counters, "cache.evict;prefix=identity/entity/")
+	require.NotContains(t, counters, "cache.evict;prefix=default")
+	require.Equal(t, 2, counters["cache.hit;prefix=default"].Count+counters["cache.hit;prefix=identity/entity/"].Count)
+}
+
+// TestCache_NegativeCache verifies that a missing key is cached unless
+// negative caching is disabled.
+func TestCache_NegativeCache(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewVaultLogger(log.Debug)
+
+	for _, disable := range []bool{false, true} {
+		t.Run(fmt.Sprintf("disabled=%t", disable), func(t *testing.T) {
+			inm, err := NewInmem(nil, logger)
+			require.NoError(t, err)
+			cache := physical.NewCacheWithConfig(inm, &physical.CacheConfig{DisableNegativeCache: disable}, logger, &metrics.BlackholeSink{})
+			cache.SetEnabled(true)
+
+			out, err := cache.Get(ctx, "foo")
+			require.NoError(t, err)
+			require.Nil(t, out)
+
+			// Write from under the cache
+			require.NoError(t, inm.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+			out, err = cache.Get(ctx, "foo")
+			require.NoError(t, err)
+			require.Equal(t, disable, out != nil)
+		})
+	}
+}
+
+// TestCache_TTL verifies that cached entries are read again from the backend
+// once they expire.
+func TestCache_TTL(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewInmem(nil, logger)
+	require.NoError(t, err)
+	cache := physical.NewCacheWithConfig(inm, &physical.CacheConfig{TTL: 50 * time.Millisecond}, logger, &metrics.BlackholeSink{})
+	cache.SetEnabled(true)
+
+	require.NoError(t, cache.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+	require.NoError(t, inm.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("baz")}))
+
+	out, err := cache.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), out.Value)
+
+	require.Eventually(t, func() bool {
+		out, err := cache.Get(ctx, "foo")
+		return err == nil && string(out.Value) == "baz"
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+// TestCache_ExcludePaths verifies that configured paths are not cached.
+func TestCache_ExcludePaths(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewInmem(nil, logger)
+	require.NoError(t, err)
+	cache := physical.NewCacheWithConfig(inm, &physical.CacheConfig{ExcludePaths: []string{"sys/counters/"}}, logger, &metrics.BlackholeSink{})
+	cache.SetEnabled(true)
+
+	require.False(t, cache.ShouldCache("sys/counters/requests/2024/01"))
+	require.True(t, cache.ShouldCache("sys/policy/default"))
+}
+
+// TestCache_CachedEntry verifies that cached entries, including cached misses,
+// are returned and can be removed.
+func TestCache_CachedEntry(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewInmem(nil, logger)
+	require.NoError(t, err)
+	cache := physical.NewCacheWithConfig(inm, &physical.CacheConfig{PrefixSizes: map[string]int{"identity/": 10}}, logger, &metrics.BlackholeSink{})
+	cache.SetEnabled(true)
+
+	require.NoError(t, cache.Put(ctx, &physical.Entry{Key: "identity/foo", Value: []byte("bar")}))
+	_, err = cache.Get(ctx, "missing")
+	require.NoError(t, err)
+
+	entry, ok := cache.CachedEntry("identity/foo")
+	require.True(t, ok)
+	require.Equal(t, []byte("bar"), entry.Value)
+	entry, ok = cache.CachedEntry("missing")
+	require.True(t, ok)
+	require.Nil(t, entry)
+	_, ok = cache.CachedEntry("other")
+	require.False(t, ok)
+
+	cache.RemoveCachedEntry("identity/foo")
+	_, ok = cache.CachedEntry("identity/foo")
+	require.False(t, ok)
+}
+
+// TestCache_LRU verifies that the deprecated LRU accessor still returns the
+// default cache with *Entry values.
+func TestCache_LRU(t *testing.T) {
+	ctx := context.Background()
+	logger := logging.NewVaultLogger(log.Debug)
+
+	inm, err := NewTransactionalInmem(nil, logger)
+	require.NoError(t, err)
+	cache := physical.NewTransactionalCache(inm, 0, logger, &metrics.BlackholeSink{})
+	cache.SetEnabled(true)
+
+	require.NoError(t, cache.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+
+	raw, ok := cache.LRU().Get("foo")
+	require.True(t, ok)
+	require.Equal(t, []byte("bar"), raw.(*physical.Entry).Value)
+}
@@ -104,6 +104,13 @@ func TestPseudo_SuccessfulTransaction(t *testing.T) {
 	physical.ExerciseTransactionalBackend(t, p)
 }
 
+func TestPseudo_TransactionalGet(t *testing.T) {
+	logger := logging.NewVaultLogger(log.Debug)
+	p := newFaultyPseudo(logger, nil)
+
+	physical.ExerciseTransactionalGet(t, p)
+}
+
 func TestPseudo_FailedTransaction(t *testing.T) {
 	logger := logging.NewVaultLogger(log.Debug)
 	p := newFaultyPseudo(logger, []string{"zip"})
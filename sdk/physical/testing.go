@@ -458,6 +458,61 @@ func ExerciseTransactionalBackend(t testing.TB, b Backend) {
 	}
 }
 
+// ExerciseTransactionalGet verifies that get operations can be mixed with puts
+// and deletes in a transaction of a backend that supports them, and that the
+// values read are set on their entries.
+func ExerciseTransactionalGet(t testing.TB, b Backend) {
+	t.Helper()
+	ctx := context.Background()
+
+	tb, ok := b.(Transactional)
+	if !ok {
+		t.Fatal("Not a transactional backend")
+	}
+
+	if err := b.Put(ctx, &Entry{Key: "txn/foo", Value: []byte("bar")}); err != nil {
+		t.Fatal(err)
+	}
+	if err := b.Put(ctx, &Entry{Key: "txn/zip", Value: []byte("zap")}); err != nil {
+		t.Fatal(err)
+	}
+
+	txns := []*TxnEntry{
+		{Operation: GetOperation, Entry: &Entry{Key: "txn/foo"}},
+		{Operation: PutOperation, Entry: &Entry{Key: "txn/foo", Value: []byte("baz")}},
+		{Operation: GetOperation, Entry: &Entry{Key: "txn/zip"}},
+		{Operation: DeleteOperation, Entry: &Entry{Key: "txn/zip"}},
+		{Operation: GetOperation, Entry: &Entry{Key: "txn/missing"}},
+	}
+	if err := tb.Transaction(ctx, txns); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(txns[0].Entry.Value); got != "bar" {
+		t.Fatalf("expected %q, got %q", "bar", got)
+	}
+	if got := string(txns[2].Entry.Value); got != "zap" {
+		t.Fatalf("expected %q, got %q", "zap", got)
+	}
+	if txns[4].Entry.Value != nil {
+		t.Fatalf("expected nil value, got %q", txns[4].Entry.Value)
+	}
+
+	entry, err := b.Get(ctx, "txn/foo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry == nil || string(entry.Value) != "baz" {
+		t.Fatalf("expected put to apply, got %#v", entry)
+	}
+	entry, err = b.Get(ctx, "txn/zip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if entry != nil {
+		t.Fatalf("expected delete to apply, got %#v", entry)
+	}
+}
+
 func SetupTestingTransactions(t testing.TB, b Backend) []*TxnEntry {
 	t.Helper()
 	ctx := context.Background()
@@ -68,6 +68,7 @@ func (c *Core) generateAuditTestProbe() (*logical.LogInput, error) {
 // to be normalized for conformance. All audit backends must have an entry.
 var auditBackendEntryAddrs = map[string][]string{
 	"file":   {},
+	"http":   {"url"},
 	"noop":   {},
 	"socket": {"address"},
 	"syslog": {},
@@ -600,6 +601,10 @@ func (c *Core) newAuditBackend(entry *MountEntry, view logical.Storage, conf map
 		if auditLogger.IsDebug() && entry.Options != nil {
 			auditLogger.Debug("syslog backend options", "path", entry.Path, "facility", entry.Options["facility"], "tag", entry.Options["tag"])
 		}
+	case audit.TypeHTTP:
+		if auditLogger.IsDebug() && entry.Options != nil {
+			auditLogger.Debug("http backend options", "path", entry.Path, "url", entry.Options["url"], "spool_dir", entry.Options["spool_dir"])
+		}
 	}
 
 	c.AddLogger(auditLogger)
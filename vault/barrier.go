@@ -125,7 +125,8 @@ type SecurityBarrier interface {
 
 	// Rotate is used to create a new encryption key. All future writes
 	// should use the new key, while old values should still be decryptable.
-	Rotate(ctx context.Context, reader io.Reader) (uint32, error)
+	// The reason is recorded in the keyring history.
+	Rotate(ctx context.Context, reader io.Reader, reason string) (uint32, error)
 
 	// CreateUpgrade creates an upgrade path key to the given term from the previous term
 	CreateUpgrade(ctx context.Context, term uint32) error
@@ -145,6 +146,22 @@ type SecurityBarrier interface {
 	// SetRotationConfig updates the auto-rotation config for the barrier key
 	SetRotationConfig(ctx context.Context, config KeyRotationConfig) error
 
+	// PathBindingRequired returns whether entries stored in the legacy format,
+	// which does not bind an entry to its storage path, are rejected
+	PathBindingRequired() (bool, error)
+
+	// SetPathBindingRequired updates whether entries stored in the legacy
+	// format are rejected
+	SetPathBindingRequired(ctx context.Context, required bool) error
+
+	// ReloadPathBindingRequired re-reads whether entries stored in the legacy
+	// format are rejected from the stored keyring
+	ReloadPathBindingRequired(ctx context.Context) error
+
+	// UpgradeUnboundEntries re-encrypts all entries stored in the legacy
+	// format so that they are bound to their storage path
+	UpgradeUnboundEntries(ctx context.Context) (int, error)
+
 	// Rekey is used to change the master key used to protect the keyring
 	Rekey(context.Context, []byte) error
 
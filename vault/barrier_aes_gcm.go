@@ -25,6 +25,7 @@ import (
 	"github.com/hashicorp/go-secure-stdlib/strutil"
 	"github.com/hashicorp/vault/helper/locking"
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
 	"go.uber.org/atomic"
@@ -40,6 +41,18 @@ const (
 
 	autoRotateCheckInterval = 5 * time.Minute
 	legacyRotateReason      = "legacy rotation"
+
+	// Reasons recorded in the keyring when a key is installed
+	initializeRotateReason    = "initialized"
+	manualRotateReason        = "manual rotation"
+	maxOperationsRotateReason = "reached max operations"
+	intervalRotateReason      = "rotation interval reached"
+	scheduleRotateReason      = "rotation schedule reached"
+
+	// barrierKeyAlertEventType is the type of the event sent when the active
+	// key nears its maximum number of encryptions
+	barrierKeyAlertEventType = "sys/barrier-key-alert"
+
 	// The keyring is persisted before the root key.
 	defaultKeyringTimeout            = 1 * time.Second
 	bestEffortKeyringTimeoutOverride = "VAULT_ENCRYPTION_COUNT_PERSIST_TIMEOUT"
@@ -57,6 +70,11 @@ type barrierInit struct {
 	Key     []byte // Key is the primary encryption key
 }
 
+// ErrPathBindingRequired is returned when reading an entry in the legacy
+// storage format, which is not bound to its storage path, while the keyring
+// requires path binding.
+var ErrPathBindingRequired = errors.New("entry is not bound to its storage path")
+
 // Validate AESGCMBarrier satisfies SecurityBarrier interface
 var (
 	_                      SecurityBarrier = &AESGCMBarrier{}
@@ -84,6 +102,14 @@ type AESGCMBarrier struct {
 	cache     map[uint32]cipher.AEAD
 	cacheLock sync.RWMutex
 
+	// locks serialize writes to a key with the upgrade of that key to the
+	// path bound storage format. They are only taken while upgrades is
+	// non-zero; writes made without them are counted by unlockedWrites so
+	// that an upgrade can wait for them to complete before it starts.
+	locks          []*locksutil.LockEntry
+	upgrades       *atomic.Int32
+	unlockedWrites *atomic.Int64
+
 	// currentAESGCMVersionByte is prefixed to a message to allow for
 	// future versioning of barrier implementations. It's var instead
 	// of const to allow for testing
@@ -118,6 +144,70 @@ func (b *AESGCMBarrier) SetRotationConfig(ctx context.Context, rotConfig KeyRota
 	return nil
 }
 
+func (b *AESGCMBarrier) PathBindingRequired() (bool, error) {
+	b.l.RLock()
+	defer b.l.RUnlock()
+	if b.keyring == nil {
+		return false, errors.New("keyring not yet present")
+	}
+	return b.keyring.pathBindingRequired, nil
+}
+
+// ReloadPathBindingRequired re-reads whether path binding is required from the
+// stored keyring. Standbys call it periodically, since the setting is changed
+// by the active node without rotating the keyring.
+func (b *AESGCMBarrier) ReloadPathBindingRequired(ctx context.Context) error {
+	b.l.RLock()
+	if b.sealed {
+		b.l.RUnlock()
+		return ErrBarrierSealed
+	}
+	plain, err := b.readKeyring(ctx)
+	b.l.RUnlock()
+	defer memzero(plain)
+	if err != nil {
+		return err
+	}
+
+	stored, err := DeserializeKeyring(plain)
+	if err != nil {
+		return fmt.Errorf("keyring deserialization failed: %w", err)
+	}
+
+	b.l.Lock()
+	defer b.l.Unlock()
+	if b.sealed {
+		return ErrBarrierSealed
+	}
+	if b.keyring.pathBindingRequired == stored.pathBindingRequired {
+		return nil
+	}
+
+	keyring := b.keyring.Clone()
+	keyring.pathBindingRequired = stored.pathBindingRequired
+	b.keyring = keyring
+	return nil
+}
+
+func (b *AESGCMBarrier) SetPathBindingRequired(ctx context.Context, required bool) error {
+	b.l.Lock()
+	defer b.l.Unlock()
+	if b.sealed {
+		return ErrBarrierSealed
+	}
+	if b.keyring.pathBindingRequired == required {
+		return nil
+	}
+
+	keyring := b.keyring.Clone()
+	keyring.pathBindingRequired = required
+	if err := b.persistKeyring(ctx, keyring); err != nil {
+		return err
+	}
+	b.keyring = keyring
+	return nil
+}
+
 // NewAESGCMBarrier is used to construct a new barrier that uses
 // the provided physical backend for storage.
 func NewAESGCMBarrier(physical physical.Backend, detectDeadlocks bool) (*AESGCMBarrier, error) {
@@ -136,6 +226,9 @@ func NewAESGCMBarrier(physical physical.Backend, detectDeadlocks bool) (*AESGCMB
 		l:                        &locking.SyncRWMutex{},
 		sealed:                   true,
 		cache:                    make(map[uint32]cipher.AEAD),
+		locks:                    locksutil.CreateLocks(),
+		upgrades:                 atomic.NewInt32(0),
+		unlockedWrites:           atomic.NewInt64(0),
 		currentAESGCMVersionByte: byte(AESGCMVersion2),
 		UnaccountedEncryptions:   atomic.NewInt64(0),
 		RemoteEncryptions:        atomic.NewInt64(0),
@@ -207,9 +300,10 @@ func (b *AESGCMBarrier) Initialize(ctx context.Context, key []byte, sealKey []by
 	keyring := NewKeyring()
 	keyring = keyring.SetRootKey(key)
 	keyring, err = keyring.AddKey(&Key{
-		Term:    1,
-		Version: 1,
-		Value:   encryptionKey,
+		Term:           1,
+		Version:        1,
+		Value:          encryptionKey,
+		RotationReason: initializeRotateReason,
 	})
 	if err != nil {
 		return fmt.Errorf("failed to create keyring: %w", err)
@@ -372,47 +466,58 @@ func (b *AESGCMBarrier) ReloadKeyring(ctx context.Context) error {
 	b.l.Lock()
 	defer b.l.Unlock()
 
+	plain, err := b.readKeyring(ctx)
+	defer memzero(plain)
+	if err != nil {
+		return err
+	}
+
+	// Reset enc. counters, this may be a leadership change
+	b.totalLocalEncryptions.Store(0)
+	b.totalLocalEncryptions.Store(0)
+	b.UnaccountedEncryptions.Store(0)
+	b.RemoteEncryptions.Store(0)
+
+	return b.recoverKeyring(plain)
+}
+
+// readKeyring reads and decrypts the stored keyring. The caller must hold the
+// lock and zero the returned plaintext.
+func (b *AESGCMBarrier) readKeyring(ctx context.Context) ([]byte, error) {
 	// Create the AES-GCM
 	gcm, err := b.aeadFromKey(b.keyring.RootKey())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Read in the keyring
 	out, err := b.backend.Get(ctx, keyringPath)
 	if err != nil {
-		return fmt.Errorf("failed to check for keyring: %w", err)
+		return nil, fmt.Errorf("failed to check for keyring: %w", err)
 	}
 
 	// Ensure that the keyring exists. This should never happen,
 	// and indicates something really bad has happened.
 	if out == nil {
-		return errors.New("keyring unexpectedly missing")
+		return nil, errors.New("keyring unexpectedly missing")
 	}
 
 	// Verify the term is always just one
 	term := binary.BigEndian.Uint32(out.Value[:4])
 	if term != initialKeyTerm {
-		return errors.New("term mis-match")
+		return nil, errors.New("term mis-match")
 	}
 
 	// Decrypt the barrier init key
 	plain, err := b.decrypt(keyringPath, gcm, out.Value)
-	defer memzero(plain)
 	if err != nil {
+		memzero(plain)
 		if strings.Contains(err.Error(), "message authentication failed") {
-			return ErrBarrierInvalidKey
+			return nil, ErrBarrierInvalidKey
 		}
-		return err
+		return nil, err
 	}
-
-	// Reset enc. counters, this may be a leadership change
-	b.totalLocalEncryptions.Store(0)
-	b.totalLocalEncryptions.Store(0)
-	b.UnaccountedEncryptions.Store(0)
-	b.RemoteEncryptions.Store(0)
-
-	return b.recoverKeyring(plain)
+	return plain, nil
 }
 
 func (b *AESGCMBarrier) recoverKeyring(plaintext []byte) error {
@@ -588,6 +693,17 @@ func (b *AESGCMBarrier) Unseal(ctx context.Context, key []byte) error {
 	return nil
 }
 
+// unsealWithKeyring unseals the barrier with a keyring obtained out of band,
+// such as from a keyring escrow bundle, instead of the keyring persisted in
+// storage. It is only used to decrypt a copy of the storage offline.
+func (b *AESGCMBarrier) unsealWithKeyring(keyring *Keyring) {
+	b.l.Lock()
+	defer b.l.Unlock()
+
+	b.keyring = keyring
+	b.sealed = false
+}
+
 // Seal is used to re-seal the barrier. This requires the barrier to
 // be unsealed again to perform any further operations.
 func (b *AESGCMBarrier) Seal() error {
@@ -604,7 +720,8 @@ func (b *AESGCMBarrier) Seal() error {
 
 // Rotate is used to create a new encryption key. All future writes
 // should use the new key, while old values should still be decryptable.
-func (b *AESGCMBarrier) Rotate(ctx context.Context, randomSource io.Reader) (uint32, error) {
+// The reason of the rotation is recorded with the new key.
+func (b *AESGCMBarrier) Rotate(ctx context.Context, randomSource io.Reader, reason string) (uint32, error) {
 	b.l.Lock()
 	defer b.l.Unlock()
 	if b.sealed {
@@ -628,9 +745,10 @@ func (b *AESGCMBarrier) Rotate(ctx context.Context, randomSource io.Reader) (uin
 
 	// Add a new encryption key
 	newKeyring, err := b.keyring.AddKey(&Key{
-		Term:    newTerm,
-		Version: 1,
-		Value:   encrypt,
+		Term:           newTerm,
+		Version:        1,
+		Value:          encrypt,
+		RotationReason: reason,
 	})
 	if err != nil {
 		return 0, fmt.Errorf("failed to add new encryption key: %w", err)
@@ -869,6 +987,9 @@ func (b *AESGCMBarrier) putInternal(ctx context.Context, term uint32, primary ci
 		Value:    value,
 		SealWrap: entry.SealWrap,
 	}
+
+	defer b.lockForWrite(entry.Key)()
+
 	return b.backend.Put(ctx, pe)
 }
 
@@ -917,6 +1038,7 @@ func (b *AESGCMBarrier) lockSwitchedGet(ctx context.Context, key string, getLock
 	// It is expensive to do this first but it is not a
 	// normal case that this won't match
 	gcm, err := b.aeadForTerm(term)
+	requirePathBinding := b.keyring != nil && b.keyring.pathBindingRequired
 	if getLock {
 		b.l.RUnlock()
 	}
@@ -926,6 +1048,9 @@ func (b *AESGCMBarrier) lockSwitchedGet(ctx context.Context, key string, getLock
 	if gcm == nil {
 		return nil, fmt.Errorf("no decryption key available for term %d", term)
 	}
+	if requirePathBinding && len(pe.Value) > 4 && pe.Value[4] == AESGCMVersion1 {
+		return nil, fmt.Errorf("decryption of %q failed: %w", key, ErrPathBindingRequired)
+	}
 
 	// Decrypt the ciphertext
 	plain, err := b.decrypt(key, gcm, pe.Value)
@@ -952,9 +1077,29 @@ func (b *AESGCMBarrier) Delete(ctx context.Context, key string) error {
 		return ErrBarrierSealed
 	}
 
+	defer b.lockForWrite(key)()
+
 	return b.backend.Delete(ctx, key)
 }
 
+// lockForWrite serializes a write to the given key with UpgradeUnboundEntries
+// and returns the function that releases it. The per-key lock is only taken
+// while an upgrade is running, otherwise the write is counted so that an
+// upgrade starting concurrently waits for it.
+func (b *AESGCMBarrier) lockForWrite(key string) func() {
+	if b.upgrades.Load() == 0 {
+		b.unlockedWrites.Inc()
+		if b.upgrades.Load() == 0 {
+			return func() { b.unlockedWrites.Dec() }
+		}
+		b.unlockedWrites.Dec()
+	}
+
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	return lock.Unlock
+}
+
 // List is used ot list all the keys under a given
 // prefix, up to the next prefix.
 func (b *AESGCMBarrier) List(ctx context.Context, prefix string) ([]string, error) {
@@ -1148,6 +1293,7 @@ func (b *AESGCMBarrier) Decrypt(_ context.Context, key string, ciphertext []byte
 	// It is expensive to do this first but it is not a
 	// normal case that this won't match
 	gcm, err := b.aeadForTerm(term)
+	requirePathBinding := b.keyring.pathBindingRequired
 	b.l.RUnlock()
 	if err != nil {
 		return nil, err
@@ -1155,6 +1301,9 @@ func (b *AESGCMBarrier) Decrypt(_ context.Context, key string, ciphertext []byte
 	if gcm == nil {
 		return nil, fmt.Errorf("no decryption key available for term %d", term)
 	}
+	if requirePathBinding && len(ciphertext) > 4 && ciphertext[4] == AESGCMVersion1 {
+		return nil, fmt.Errorf("decryption failed: %w", ErrPathBindingRequired)
+	}
 
 	// Decrypt the ciphertext
 	plain, err := b.decrypt(key, gcm, ciphertext)
@@ -1165,6 +1314,132 @@ func (b *AESGCMBarrier) Decrypt(_ context.Context, key string, ciphertext []byte
 	return plain, nil
 }
 
+// UpgradeUnboundEntries re-encrypts all entries stored in the legacy
+// AESGCMVersion1 format, which does not bind an entry to its storage path,
+// with the active key and the current format. It returns the number of
+// entries that were upgraded.
+func (b *AESGCMBarrier) UpgradeUnboundEntries(ctx context.Context) (int, error) {
+	b.l.RLock()
+	if b.sealed {
+		b.l.RUnlock()
+		return 0, ErrBarrierSealed
+	}
+	keyring := b.keyring.Clone()
+	b.l.RUnlock()
+
+	// Make writes take the per-key locks, then wait for the writes that
+	// started without them.
+	b.upgrades.Inc()
+	defer b.upgrades.Dec()
+	for b.unlockedWrites.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		case <-time.After(time.Millisecond):
+		}
+	}
+
+	activeKey := keyring.ActiveKey()
+	primary, err := b.aeadFromKey(activeKey.Value)
+	if err != nil {
+		return 0, err
+	}
+	aeads := map[uint32]cipher.AEAD{
+		activeKey.Term: primary,
+	}
+
+	var upgraded int
+	dfs := []string{""}
+	for len(dfs) > 0 {
+		if err := ctx.Err(); err != nil {
+			return upgraded, err
+		}
+
+		key := dfs[len(dfs)-1]
+		dfs = dfs[:len(dfs)-1]
+
+		if key == "" || strings.HasSuffix(key, "/") {
+			children, err := b.backend.List(ctx, key)
+			if err != nil {
+				return upgraded, fmt.Errorf("failed to list %q: %w", key, err)
+			}
+			for _, child := range children {
+				dfs = append(dfs, key+child)
+			}
+			continue
+		}
+
+		// The keyring is encrypted with the root key rather than a term key
+		// and is rewritten in the current format whenever it is persisted.
+		if key == keyringPath {
+			continue
+		}
+
+		ok, err := b.upgradeUnboundEntry(ctx, key, keyring, aeads)
+		if err != nil {
+			return upgraded, fmt.Errorf("failed to upgrade %q: %w", key, err)
+		}
+		if ok {
+			upgraded++
+		}
+	}
+
+	return upgraded, nil
+}
+
+// upgradeUnboundEntry re-encrypts a single entry if it is a barrier entry
+// stored in the AESGCMVersion1 format. Entries that are not encrypted by the
+// barrier, such as the seal configuration, are left alone.
+func (b *AESGCMBarrier) upgradeUnboundEntry(ctx context.Context, key string, keyring *Keyring, aeads map[uint32]cipher.AEAD) (bool, error) {
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	pe, err := b.backend.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if pe == nil || len(pe.Value) <= termSize || pe.Value[termSize] != AESGCMVersion1 {
+		return false, nil
+	}
+
+	term := binary.BigEndian.Uint32(pe.Value[:termSize])
+	gcm, ok := aeads[term]
+	if !ok {
+		termKey := keyring.TermKey(term)
+		if termKey == nil {
+			return false, nil
+		}
+		gcm, err = b.aeadFromKey(termKey.Value)
+		if err != nil {
+			return false, err
+		}
+		aeads[term] = gcm
+	}
+
+	plain, err := b.decrypt(key, gcm, pe.Value)
+	if err != nil {
+		// Not encrypted with a barrier key
+		return false, nil
+	}
+	defer memzero(plain)
+
+	activeTerm := keyring.ActiveTerm()
+	value, err := b.encryptTracked(key, activeTerm, aeads[activeTerm], plain)
+	if err != nil {
+		return false, err
+	}
+	if err := b.backend.Put(ctx, &physical.Entry{
+		Key:      key,
+		Value:    value,
+		SealWrap: pe.SealWrap,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (b *AESGCMBarrier) Keyring() (*Keyring, error) {
 	b.l.RLock()
 	defer b.l.RUnlock()
@@ -1234,13 +1509,19 @@ func (b *AESGCMBarrier) CheckBarrierAutoRotate(ctx context.Context) (string, err
 			if !rc.Disabled {
 				activeKey := b.keyring.ActiveKey()
 				ops := b.encryptions()
+				nextScheduled, err := rc.NextScheduledRotation(activeKey.InstallTime)
+				if err != nil {
+					return "", err
+				}
 				switch {
 				case activeKey.Encryptions == 0 && !activeKey.InstallTime.IsZero() && time.Since(activeKey.InstallTime) > oneYear:
 					reason = legacyRotateReason
 				case ops > rc.MaxOperations:
-					reason = "reached max operations"
+					reason = maxOperationsRotateReason
 				case rc.Interval > 0 && time.Since(activeKey.InstallTime) > rc.Interval:
-					reason = "rotation interval reached"
+					reason = intervalRotateReason
+				case !nextScheduled.IsZero() && !activeKey.InstallTime.IsZero() && !time.Now().Before(nextScheduled):
+					reason = scheduleRotateReason
 				}
 			}
 			return reason, nil
@@ -8,8 +8,10 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/json"
+	"fmt"
 	"math"
 	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
 	"github.com/hashicorp/vault/sdk/physical/inmem"
+	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
 
@@ -450,6 +453,160 @@ func TestAESGCMBarrier_UpgradeV1toV2(t *testing.T) {
 	}
 }
 
+// TestAESGCMBarrier_PathBinding verifies that entries in the legacy format are
+// rejected once path binding is required, that they are upgraded, and that
+// the setting survives an unseal.
+func TestAESGCMBarrier_PathBinding(t *testing.T) {
+	ctx := context.Background()
+	inm, err := inmem.NewInmem(nil, logger)
+	require.NoError(t, err)
+	b, err := NewAESGCMBarrier(inm, false)
+	require.NoError(t, err)
+
+	key, _ := b.GenerateKey(rand.Reader)
+	require.NoError(t, b.Initialize(ctx, key, nil, rand.Reader))
+	require.NoError(t, b.Unseal(ctx, key))
+
+	// Write entries in the legacy format
+	b.currentAESGCMVersionByte = AESGCMVersion1
+	require.NoError(t, b.Put(ctx, &logical.StorageEntry{Key: "legacy/foo", Value: []byte("foo")}))
+	require.NoError(t, b.Put(ctx, &logical.StorageEntry{Key: "legacy/bar", Value: []byte("bar")}))
+	b.currentAESGCMVersionByte = AESGCMVersion2
+	require.NoError(t, b.Put(ctx, &logical.StorageEntry{Key: "bound", Value: []byte("bound")}))
+
+	// A non-barrier entry must be left alone by the upgrade
+	require.NoError(t, inm.Put(ctx, &physical.Entry{Key: "raw", Value: []byte{0, 0, 0, 1, AESGCMVersion1, 'x'}}))
+
+	required, err := b.PathBindingRequired()
+	require.NoError(t, err)
+	require.False(t, required)
+
+	require.NoError(t, b.SetPathBindingRequired(ctx, true))
+	_, err = b.Get(ctx, "legacy/foo")
+	require.ErrorIs(t, err, ErrPathBindingRequired)
+	entry, err := b.Get(ctx, "bound")
+	require.NoError(t, err)
+	require.Equal(t, []byte("bound"), entry.Value)
+
+	upgraded, err := b.UpgradeUnboundEntries(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, upgraded)
+
+	entry, err = b.Get(ctx, "legacy/foo")
+	require.NoError(t, err)
+	require.Equal(t, []byte("foo"), entry.Value)
+	entry, err = b.Get(ctx, "legacy/bar")
+	require.NoError(t, err)
+	require.Equal(t, []byte("bar"), entry.Value)
+	pe, err := inm.Get(ctx, "raw")
+	require.NoError(t, err)
+	require.Equal(t, []byte{0, 0, 0, 1, AESGCMVersion1, 'x'}, pe.Value)
+
+	upgraded, err = b.UpgradeUnboundEntries(ctx)
+	require.NoError(t, err)
+	require.Zero(t, upgraded)
+
+	// The setting is persisted in the keyring
+	require.NoError(t, b.Seal())
+	b, err = NewAESGCMBarrier(inm, false)
+	require.NoError(t, err)
+	require.NoError(t, b.Unseal(ctx, key))
+	required, err = b.PathBindingRequired()
+	require.NoError(t, err)
+	require.True(t, required)
+
+	require.NoError(t, b.SetPathBindingRequired(ctx, false))
+	required, err = b.PathBindingRequired()
+	require.NoError(t, err)
+	require.False(t, required)
+}
+
+// TestAESGCMBarrier_ReloadPathBindingRequired verifies that a standby picks
+// up the path binding setting changed by the active node.
+func TestAESGCMBarrier_ReloadPathBindingRequired(t *testing.T) {
+	ctx := context.Background()
+	inm, err := inmem.NewInmem(nil, logger)
+	require.NoError(t, err)
+	active, err := NewAESGCMBarrier(inm, false)
+	require.NoError(t, err)
+
+	key, _ := active.GenerateKey(rand.Reader)
+	require.NoError(t, active.Initialize(ctx, key, nil, rand.Reader))
+	require.NoError(t, active.Unseal(ctx, key))
+
+	standby, err := NewAESGCMBarrier(inm, false)
+	require.NoError(t, err)
+	require.NoError(t, standby.Unseal(ctx, key))
+
+	require.NoError(t, active.SetPathBindingRequired(ctx, true))
+	required, err := standby.PathBindingRequired()
+	require.NoError(t, err)
+	require.False(t, required)
+
+	require.NoError(t, standby.ReloadPathBindingRequired(ctx))
+	required, err = standby.PathBindingRequired()
+	require.NoError(t, err)
+	require.True(t, required)
+
+	require.NoError(t, standby.Seal())
+	require.ErrorIs(t, standby.ReloadPathBindingRequired(ctx), ErrBarrierSealed)
+}
+
+// TestAESGCMBarrier_UpgradeUnboundEntries_ConcurrentWrites verifies that
+// writes made while legacy entries are upgraded are not overwritten.
+func TestAESGCMBarrier_UpgradeUnboundEntries_ConcurrentWrites(t *testing.T) {
+	ctx := context.Background()
+	inm, err := inmem.NewInmem(nil, logger)
+	require.NoError(t, err)
+	b, err := NewAESGCMBarrier(inm, false)
+	require.NoError(t, err)
+
+	key, _ := b.GenerateKey(rand.Reader)
+	require.NoError(t, b.Initialize(ctx, key, nil, rand.Reader))
+	require.NoError(t, b.Unseal(ctx, key))
+
+	b.currentAESGCMVersionByte = AESGCMVersion1
+	for i := 0; i < 100; i++ {
+		require.NoError(t, b.Put(ctx, &logical.StorageEntry{Key: fmt.Sprintf("legacy/%d", i), Value: []byte("old")}))
+	}
+	b.currentAESGCMVersionByte = AESGCMVersion2
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			assert.NoError(t, b.Put(ctx, &logical.StorageEntry{Key: fmt.Sprintf("legacy/%d", i), Value: []byte("new")}))
+		}
+	}()
+	_, err = b.UpgradeUnboundEntries(ctx)
+	require.NoError(t, err)
+	wg.Wait()
+
+	for i := 0; i < 100; i++ {
+		entry, err := b.Get(ctx, fmt.Sprintf("legacy/%d", i))
+		require.NoError(t, err)
+		require.Equal(t, []byte("new"), entry.Value)
+	}
+	require.Zero(t, b.upgrades.Load())
+	require.Zero(t, b.unlockedWrites.Load())
+}
+
+// TestAESGCMBarrier_MovedEntry verifies that an entry copied to a different
+// storage path fails to decrypt.
+func TestAESGCMBarrier_MovedEntry(t *testing.T) {
+	ctx := context.Background()
+	inm, b, _ := mockBarrier(t)
+
+	require.NoError(t, b.Put(ctx, &logical.StorageEntry{Key: "foo", Value: []byte("secret")}))
+	pe, err := inm.Get(ctx, "foo")
+	require.NoError(t, err)
+	require.NoError(t, inm.Put(ctx, &physical.Entry{Key: "bar", Value: pe.Value}))
+
+	_, err = b.Get(ctx, "bar")
+	require.Error(t, err)
+}
+
 func TestEncrypt_Unique(t *testing.T) {
 	inm, err := inmem.NewInmem(nil, logger)
 	if err != nil {
@@ -621,7 +778,7 @@ func TestAESGCMBarrier_ReloadKeyring(t *testing.T) {
 			t.Fatalf("err: %v", err)
 		}
 		b2.Unseal(context.Background(), key)
-		_, err = b2.Rotate(context.Background(), rand.Reader)
+		_, err = b2.Rotate(context.Background(), rand.Reader, manualRotateReason)
 		if err != nil {
 			t.Fatalf("err: %v", err)
 		}
@@ -729,7 +886,7 @@ func TestBarrier_RotateFailsOnOverflow(t *testing.T) {
 	// Hack to avoid generating a lot of keys...
 	b.keyring.activeTerm = math.MaxUint32
 
-	_, err = b.Rotate(ctx, rand.Reader)
+	_, err = b.Rotate(ctx, rand.Reader, manualRotateReason)
 	if err == nil {
 		t.Fatalf("Rotate should fail on overflow but did not")
 	}
@@ -813,3 +970,32 @@ func TestBarrier_persistKeyring_Context(t *testing.T) {
 		})
 	}
 }
+
+// TestBarrier_ScheduledRotate verifies that the key is rotated once the
+// rotation schedule is reached after the installation of the active key.
+func TestBarrier_ScheduledRotate(t *testing.T) {
+	inm, err := inmem.NewInmem(nil, logger)
+	require.NoError(t, err)
+	b, err := NewAESGCMBarrier(inm, false)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	key, err := b.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	require.NoError(t, b.Initialize(ctx, key, nil, rand.Reader))
+	require.NoError(t, b.Unseal(ctx, key))
+
+	rc, err := b.RotationConfig()
+	require.NoError(t, err)
+	rc.Schedule = "0 3 * * *"
+	require.NoError(t, b.SetRotationConfig(ctx, rc))
+
+	reason, err := b.CheckBarrierAutoRotate(ctx)
+	require.NoError(t, err)
+	require.Empty(t, reason)
+
+	b.keyring.ActiveKey().InstallTime = time.Now().Add(-25 * time.Hour)
+	reason, err = b.CheckBarrierAutoRotate(ctx)
+	require.NoError(t, err)
+	require.Equal(t, scheduleRotateReason, reason)
+}
@@ -0,0 +1,82 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+const (
+	pathBindingUpgradeStateRunning   = "running"
+	pathBindingUpgradeStateCompleted = "completed"
+	pathBindingUpgradeStateFailed    = "failed"
+)
+
+// pathBindingUpgradeStatus reports the progress of the upgrade of barrier
+// entries to the path bound storage format.
+type pathBindingUpgradeStatus struct {
+	State           string
+	StartTime       time.Time
+	EndTime         time.Time
+	EntriesUpgraded int
+	Error           string
+}
+
+// readPathBindingUpgradeStatus returns a copy of the status of the last
+// upgrade, or nil if none was started on this node.
+func (c *Core) readPathBindingUpgradeStatus() *pathBindingUpgradeStatus {
+	c.pathBindingUpgradeLock.Lock()
+	defer c.pathBindingUpgradeLock.Unlock()
+
+	if c.pathBindingUpgrade == nil {
+		return nil
+	}
+	status := *c.pathBindingUpgrade
+	return &status
+}
+
+// startPathBindingUpgrade upgrades all barrier entries stored in the legacy
+// format in the background and requires path binding once every entry has
+// been upgraded.
+func (c *Core) startPathBindingUpgrade(ctx context.Context) error {
+	c.pathBindingUpgradeLock.Lock()
+	defer c.pathBindingUpgradeLock.Unlock()
+
+	if c.pathBindingUpgrade != nil && c.pathBindingUpgrade.State == pathBindingUpgradeStateRunning {
+		return errors.New("path binding upgrade already in progress")
+	}
+
+	c.pathBindingUpgrade = &pathBindingUpgradeStatus{
+		State:     pathBindingUpgradeStateRunning,
+		StartTime: time.Now(),
+	}
+
+	go func() {
+		logger := c.logger.Named("barrier")
+		logger.Info("upgrading barrier entries to the path bound format")
+
+		upgraded, err := c.barrier.UpgradeUnboundEntries(ctx)
+		if err == nil {
+			err = c.barrier.SetPathBindingRequired(ctx, true)
+		}
+
+		c.pathBindingUpgradeLock.Lock()
+		defer c.pathBindingUpgradeLock.Unlock()
+
+		c.pathBindingUpgrade.EntriesUpgraded = upgraded
+		c.pathBindingUpgrade.EndTime = time.Now()
+		if err != nil {
+			logger.Error("failed to upgrade barrier entries", "error", err)
+			c.pathBindingUpgrade.State = pathBindingUpgradeStateFailed
+			c.pathBindingUpgrade.Error = err.Error()
+			return
+		}
+		logger.Info("upgraded barrier entries to the path bound format", "entries_upgraded", upgraded)
+		c.pathBindingUpgrade.State = pathBindingUpgradeStateCompleted
+	}()
+
+	return nil
+}
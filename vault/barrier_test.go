@@ -284,7 +284,7 @@ func testBarrier_Rotate(t *testing.T, b SecurityBarrier) {
 	}
 
 	// Rotate the encryption key
-	newTerm, err := b.Rotate(context.Background(), rand.Reader)
+	newTerm, err := b.Rotate(context.Background(), rand.Reader, manualRotateReason)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -455,7 +455,7 @@ func testBarrier_Upgrade(t *testing.T, b1, b2 SecurityBarrier) {
 	}
 
 	// Rotate the encryption key
-	newTerm, err := b1.Rotate(context.Background(), rand.Reader)
+	newTerm, err := b1.Rotate(context.Background(), rand.Reader, manualRotateReason)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
@@ -485,7 +485,7 @@ func testBarrier_Upgrade(t *testing.T, b1, b2 SecurityBarrier) {
 	}
 
 	// Rotate the encryption key
-	newTerm, err = b1.Rotate(context.Background(), rand.Reader)
+	newTerm, err = b1.Rotate(context.Background(), rand.Reader, manualRotateReason)
 	if err != nil {
 		t.Fatalf("err: %v", err)
 	}
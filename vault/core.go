@@ -340,6 +340,23 @@ type Core struct {
 	// auditBackends is the mapping of backends to use for this core
 	auditBackends map[string]audit.Factory
 
+	// physicalBackends is the mapping of storage backends that can be the
+	// destination of an online storage migration
+	physicalBackends map[string]physical.Factory
+
+	// storageMigration runs online storage migrations on the active node
+	storageMigration *storageMigrationManager
+
+	// pathBindingUpgrade tracks the upgrade of barrier entries to the path
+	// bound storage format
+	pathBindingUpgradeLock sync.Mutex
+	pathBindingUpgrade     *pathBindingUpgradeStatus
+
+	// sealRewrap tracks the rewrap of seal wrapped entries under the current
+	// seal generation
+	sealRewrapLock sync.Mutex
+	sealRewrap     *sealRewrapStatus
+
 	// stateLock protects mutable state
 	stateLock locking.RWMutex
 	sealed    *uint32
@@ -639,6 +656,10 @@ type Core struct {
 	raftFollowerStates *raft.FollowerStates
 	// Stop channel for raft TLS rotations
 	raftTLSRotationStopCh chan struct{}
+	// raftAutoSnapshots runs the automatic snapshot schedules on the active node
+	raftAutoSnapshots *raftAutoSnapshotManager
+	// holds the lock for modifying raftAutoSnapshots
+	raftAutoSnapshotsLock sync.RWMutex
 	// Stores the pending peers we are waiting to give answers
 	pendingRaftPeers *lru.Cache[string, *raftBootstrapChallenge]
 	// holds the lock for modifying pendingRaftPeers
@@ -680,6 +701,10 @@ type Core struct {
 
 	autoRotateCancel context.CancelFunc
 
+	// barrierKeyAlertTerm is the last barrier key term for which an alert was
+	// sent because it neared its maximum number of encryptions
+	barrierKeyAlertTerm atomic.Uint32
+
 	updateLockedUserEntriesCancel context.CancelFunc
 
 	// number of workers to use for lease revocation in the expiration manager
@@ -795,6 +820,10 @@ type CoreConfig struct {
 
 	AuditBackends map[string]audit.Factory
 
+	// PhysicalBackends are the storage backends that can be used as the
+	// destination of an online storage migration
+	PhysicalBackends map[string]physical.Factory
+
 	Physical physical.Backend
 
 	StorageType string
@@ -836,6 +865,10 @@ type CoreConfig struct {
 	// Custom cache size for the LRU cache on the physical backend, or zero for default
 	CacheSize int
 
+	// CacheConfig configures the LRU cache on the physical backend beyond its
+	// size. CacheSize is used if it does not set a size.
+	CacheConfig *physical.CacheConfig
+
 	// Set as the leader address for HA
 	RedirectAddr string
 
@@ -961,6 +994,12 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		if conf.RedirectAddr == "" {
 			return nil, fmt.Errorf("missing API address, please set in configuration or via environment")
 		}
+
+		// Standbys rely on the cache being purged and invalidated when they
+		// take over, so entries must not expire on a timer of their own
+		if conf.CacheConfig != nil && conf.CacheConfig.TTL > 0 {
+			return nil, fmt.Errorf("cache ttl is not supported with an HA enabled storage backend")
+		}
 	}
 
 	if conf.DefaultLeaseTTL == 0 {
@@ -1039,6 +1078,7 @@ func CreateCore(conf *CoreConfig) (*Core, error) {
 		physical:             conf.Physical,
 		serviceRegistration:  conf.GetServiceRegistration(),
 		underlyingPhysical:   conf.Physical,
+		physicalBackends:     conf.PhysicalBackends,
 		storageType:          conf.StorageType,
 		redirectAddr:         conf.RedirectAddr,
 		clusterAddr:          new(atomic.Value),
@@ -1651,6 +1691,38 @@ func (c *Core) Unseal(key []byte) (bool, error) {
 	return !c.Sealed(), err
 }
 
+// VerifyUnsealShare checks whether the given key share belongs to the current
+// split of the unseal key, or of the recovery key when the seal supports
+// recovery keys. The share is not used to unseal and is not kept. It returns
+// whether the share is valid, and whether it was checked against the recovery
+// key.
+func (c *Core) VerifyUnsealShare(ctx context.Context, share []byte) (bool, bool, error) {
+	init, err := c.Initialized(ctx)
+	if err != nil {
+		return false, false, err
+	}
+	if !init {
+		return false, false, ErrNotInit
+	}
+
+	recovery := c.seal.RecoveryKeySupported()
+	var config *SealConfig
+	if recovery {
+		config, err = c.seal.RecoveryConfig(ctx)
+	} else {
+		config, err = c.seal.BarrierConfig(ctx)
+	}
+	if err != nil {
+		return false, recovery, err
+	}
+	if config == nil {
+		return false, recovery, ErrNotInit
+	}
+
+	valid, err := config.VerifyShare(share)
+	return valid, recovery, err
+}
+
 // unseal takes a key fragment and attempts to use it to unseal Vault.
 // Vault may remain sealed afterwards even when no error is returned,
 // depending on whether enough key fragments were provided to meet the
@@ -2975,6 +3047,8 @@ func (c *Core) preSeal() error {
 
 	c.stopRaftActiveNode()
 
+	c.stopStorageMigration()
+
 	c.clusterParamsLock.Lock()
 	if err := c.entStopReplication(); err != nil {
 		result = multierror.Append(result, fmt.Errorf("error stopping replication: %w", err))
@@ -3910,13 +3984,57 @@ func (c *Core) checkBarrierAutoRotate(ctx context.Context) {
 			// the replication canary
 			c.logger.Info("automatic barrier key rotation triggered", "reason", reason)
 
-			_, err := c.systemBackend.handleRotate(ctx, nil, nil)
+			err := c.systemBackend.rotateBarrierKey(ctx, reason)
 			if err != nil {
 				c.logger.Error("error automatically rotating barrier key", "error", err)
 			} else {
 				metrics.IncrCounter(barrierRotationsMetric, 1)
 			}
+			return
 		}
+		c.checkBarrierKeyAlert(ctx)
+	}
+}
+
+// checkBarrierKeyAlert sends an alert on the event bus, once per key term,
+// when the active barrier key exceeds the alert threshold of its maximum
+// number of encryptions.
+func (c *Core) checkBarrierKeyAlert(ctx context.Context) {
+	info, err := c.barrier.ActiveKeyInfo()
+	if err != nil {
+		c.logger.Error("error reading barrier key status", "error", err)
+		return
+	}
+	rc, err := c.barrier.RotationConfig()
+	if err != nil {
+		c.logger.Error("error reading barrier key rotation config", "error", err)
+		return
+	}
+
+	term := uint32(info.Term)
+	if info.Encryptions < rc.AlertOperations() || c.barrierKeyAlertTerm.Load() == term {
+		return
+	}
+	c.barrierKeyAlertTerm.Store(term)
+
+	c.logger.Warn("barrier key is nearing its maximum number of encryptions",
+		"term", term, "encryptions", info.Encryptions, "max_operations", rc.MaxOperations, "rotation_enabled", !rc.Disabled)
+
+	if c.events == nil {
+		return
+	}
+	sender, err := c.events.WithPlugin(namespace.RootNamespace, nil)
+	if err == nil {
+		err = logical.SendEvent(ctx, sender, barrierKeyAlertEventType,
+			logical.EventMetadataPath, "sys/key-status",
+			"term", strconv.FormatUint(uint64(term), 10),
+			"encryptions", strconv.FormatInt(info.Encryptions, 10),
+			"max_operations", strconv.FormatInt(rc.MaxOperations, 10),
+			"rotation_enabled", strconv.FormatBool(!rc.Disabled),
+		)
+	}
+	if err != nil {
+		c.logger.Error("error sending barrier key alert", "error", err)
 	}
 }
 
@@ -4589,6 +4707,17 @@ func (c *Core) IsRaftVoter() bool {
 	return !raftInfo.nonVoter
 }
 
+// IsRaftReadReplica specifies whether the node joined the raft cluster as a
+// permanent non-voter, which is always false if raft storage is not in use.
+func (c *Core) IsRaftReadReplica() bool {
+	raftBackend := c.getRaftBackend()
+	if raftBackend == nil {
+		return false
+	}
+
+	return raftBackend.DesiredSuffrage() == "non-voter"
+}
+
 func (c *Core) HAEnabled() bool {
 	return c.ha != nil && c.ha.HAEnabled()
 }
@@ -4632,6 +4761,47 @@ func (c *Core) SetSealsOnPerfStandby(ctx context.Context, grabLock bool, barrier
 	return c.setSeals(ctx, grabLock, barrierSeal, secureRandomReader, false, false)
 }
 
+// ValidateSeal checks that the given seal can decrypt the root key and, when
+// supported, the recovery key stored by the current seal. It is used to make
+// sure that a reloaded seal configuration can take over the current one.
+func (c *Core) ValidateSeal(ctx context.Context, barrierSeal Seal) error {
+	rootKey, err := c.seal.GetStoredKeys(ctx)
+	if err != nil {
+		return err
+	}
+	if len(rootKey) < 1 {
+		return errors.New("root key not found")
+	}
+
+	newRootKey, err := readStoredKeys(ctx, c.physical, barrierSeal.GetAccess())
+	if err != nil {
+		return fmt.Errorf("new seal cannot decrypt the root key: %w", err)
+	}
+	if len(rootKey) != len(newRootKey) {
+		return errors.New("new seal decrypted a different root key")
+	}
+	for i := range rootKey {
+		if subtle.ConstantTimeCompare(rootKey[i], newRootKey[i]) != 1 {
+			return errors.New("new seal decrypted a different root key")
+		}
+	}
+
+	if !c.seal.RecoveryKeySupported() || !barrierSeal.RecoveryKeySupported() {
+		return nil
+	}
+	pe, err := c.physical.Get(ctx, recoveryKeyPath)
+	if err != nil {
+		return fmt.Errorf("failed to read recovery key: %w", err)
+	}
+	if pe == nil {
+		return nil
+	}
+	if _, err := UnsealWrapRecoveryKey(ctx, barrierSeal.GetAccess(), pe); err != nil {
+		return fmt.Errorf("new seal cannot decrypt the recovery key: %w", err)
+	}
+	return nil
+}
+
 func (c *Core) setSeals(ctx context.Context, grabLock bool, barrierSeal Seal, secureRandomReader io.Reader, shouldRewrap bool, performWrite bool) error {
 	if grabLock {
 		ctx, _ = c.GetContext()
@@ -4672,6 +4842,10 @@ func (c *Core) setSeals(ctx context.Context, grabLock bool, barrierSeal Seal, se
 		}
 	}
 
+	// The health check of the previous seal is restarted on the new one
+	if autoSeal, ok := c.seal.(*autoSeal); ok {
+		autoSeal.StopHealthCheck()
+	}
 	c.seal = barrierSeal
 
 	return c.reloadSealsEnt(secureRandomReader, barrierSeal, c.logger, shouldRewrap)
@@ -1095,6 +1095,7 @@ func (c *Core) GetAuditDeviceCountByType() map[string]int {
 	auditCounts["socketTcp"] = 0
 	auditCounts["socketUnix"] = 0
 	auditCounts["syslog"] = 0
+	auditCounts["http"] = 0
 
 	c.auditLock.RLock()
 	defer c.auditLock.RUnlock()
@@ -1121,6 +1122,8 @@ func (c *Core) GetAuditDeviceCountByType() map[string]int {
 			}
 		case audit.TypeSyslog:
 			auditCounts["syslog"]++
+		case audit.TypeHTTP:
+			auditCounts["http"]++
 		}
 	}
 
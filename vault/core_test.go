@@ -353,6 +353,23 @@ func TestNewCore_badRedirectAddr(t *testing.T) {
 	}
 }
 
+// TestNewCore_cacheTTLWithHA verifies that a cache TTL is rejected with an HA
+// enabled storage backend.
+func TestNewCore_cacheTTLWithHA(t *testing.T) {
+	inm, err := inmem.NewInmemHA(nil, logger)
+	require.NoError(t, err)
+
+	conf := &CoreConfig{
+		RedirectAddr: "http://127.0.0.1:8200",
+		Physical:     inm,
+		HAPhysical:   inm.(physical.HABackend),
+		DisableMlock: true,
+		CacheConfig:  &physical.CacheConfig{TTL: time.Minute},
+	}
+	_, err = NewCore(conf)
+	require.ErrorContains(t, err, "cache ttl is not supported")
+}
+
 func TestSealConfig_Invalid(t *testing.T) {
 	s := &SealConfig{
 		SecretShares:    2,
@@ -3549,6 +3566,34 @@ func TestSetSeals(t *testing.T) {
 	}
 }
 
+// TestValidateSeal verifies that a new seal is only accepted if it can decrypt
+// the root key and recovery key stored by the current seal.
+func TestValidateSeal(t *testing.T) {
+	testSeal, _ := seal.NewTestSeal(&seal.TestSealOpts{
+		Secrets: [][]byte{[]byte("secret")},
+	})
+	testCore := TestCoreWithSeal(t, NewAutoSeal(testSeal), false)
+	_, keys, _ := TestCoreInitClusterWrapperSetup(t, testCore, nil)
+	for _, key := range keys {
+		_, err := TestCoreUnseal(testCore, key)
+		require.NoError(t, err)
+	}
+	require.False(t, testCore.Sealed())
+
+	ctx := context.Background()
+
+	sameKey, _ := seal.NewTestSeal(&seal.TestSealOpts{
+		Secrets: [][]byte{[]byte("secret")},
+	})
+	require.NoError(t, testCore.ValidateSeal(ctx, NewAutoSeal(sameKey)))
+
+	otherKey, _ := seal.NewTestSeal(&seal.TestSealOpts{
+		Secrets: [][]byte{[]byte("other secret")},
+	})
+	err := testCore.ValidateSeal(ctx, NewAutoSeal(otherKey))
+	require.ErrorContains(t, err, "new seal cannot decrypt the root key")
+}
+
 func TestExpiration_DeadlockDetection(t *testing.T) {
 	testCore := TestCore(t)
 	testCoreUnsealed(t, testCore)
@@ -3766,3 +3811,52 @@ func Test_administrativeNamespacePath(t *testing.T) {
 	core, _, _ := TestCoreUnsealedWithConfig(t, coreConfig)
 	require.Equal(t, core.administrativeNamespacePath(), adminNamespacePath)
 }
+
+// TestCore_BarrierKeyAlert verifies that an alert is sent on the event bus,
+// once per key term, when the active barrier key exceeds the alert threshold
+// of its maximum number of encryptions.
+func TestCore_BarrierKeyAlert(t *testing.T) {
+	c, _, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{})
+	ctx := namespace.RootContext(nil)
+
+	ch, cancel, err := c.events.Subscribe(ctx, namespace.RootNamespace, barrierKeyAlertEventType, "")
+	require.NoError(t, err)
+	defer cancel()
+
+	rc, err := c.barrier.RotationConfig()
+	require.NoError(t, err)
+	rc.Disabled = true
+	rc.MaxOperations = absoluteOperationMinimum
+	rc.AlertThreshold = 0.5
+	require.NoError(t, c.barrier.SetRotationConfig(ctx, rc))
+
+	// Below the threshold, no alert is sent
+	c.checkBarrierKeyAlert(ctx)
+	select {
+	case <-ch:
+		t.Fatal("unexpected alert")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	barrier := c.barrier.(*AESGCMBarrier)
+	barrier.keyring.ActiveKey().Encryptions = uint64(absoluteOperationMinimum / 2)
+	c.checkBarrierKeyAlert(ctx)
+	select {
+	case event := <-ch:
+		received := event.Payload.(*logical.EventReceived)
+		require.Equal(t, barrierKeyAlertEventType, received.EventType)
+		fields := received.Event.Metadata.AsMap()
+		require.Equal(t, "1", fields["term"])
+		require.Equal(t, "false", fields["rotation_enabled"])
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for alert")
+	}
+
+	// The alert is only sent once for the term
+	c.checkBarrierKeyAlert(ctx)
+	select {
+	case <-ch:
+		t.Fatal("unexpected alert")
+	case <-time.After(100 * time.Millisecond):
+	}
+}
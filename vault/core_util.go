@@ -40,7 +40,13 @@ type LicensingConfig struct {
 }
 
 func coreInit(c *Core, conf *CoreConfig) error {
-	phys := conf.Physical
+	// Wrap the physical backend so that writes can be mirrored during an
+	// online storage migration. The wrapper passes operations straight
+	// through while no migration is active.
+	migrationLogger := conf.Logger.Named("storage.migration")
+	c.allLoggers = append(c.allLoggers, migrationLogger)
+	phys := newStorageMigrationBackend(conf.Physical)
+	c.storageMigration = newStorageMigrationManager(phys, migrationLogger)
 	_, txnOK := phys.(physical.Transactional)
 	sealUnwrapperLogger := conf.Logger.Named("storage.sealunwrapper")
 	c.allLoggers = append(c.allLoggers, sealUnwrapperLogger)
@@ -48,10 +54,17 @@ func coreInit(c *Core, conf *CoreConfig) error {
 	// Wrap the physical backend in a cache layer if enabled
 	cacheLogger := c.baseLogger.Named("storage.cache")
 	c.allLoggers = append(c.allLoggers, cacheLogger)
+	cacheConfig := &physical.CacheConfig{Size: conf.CacheSize}
+	if conf.CacheConfig != nil {
+		cacheConfig = conf.CacheConfig
+		if cacheConfig.Size == 0 {
+			cacheConfig.Size = conf.CacheSize
+		}
+	}
 	if txnOK {
-		c.physical = physical.NewTransactionalCache(c.sealUnwrapper, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
+		c.physical = physical.NewTransactionalCacheWithConfig(c.sealUnwrapper, cacheConfig, cacheLogger, c.MetricSink().Sink)
 	} else {
-		c.physical = physical.NewCache(c.sealUnwrapper, conf.CacheSize, cacheLogger, c.MetricSink().Sink)
+		c.physical = physical.NewCacheWithConfig(c.sealUnwrapper, cacheConfig, cacheLogger, c.MetricSink().Sink)
 	}
 	c.physicalCache = c.physical.(physical.ToggleablePurgemonster)
 
@@ -172,7 +185,11 @@ func (c *Core) perfStandbyClusterHandler() (*replication.Cluster, chan struct{},
 
 func (c *Core) initSealsForMigration() {}
 
-func (c *Core) postSealMigration(ctx context.Context) error { return nil }
+// postSealMigration rewraps the seal wrapped entries with the new seal.
+func (c *Core) postSealMigration(ctx context.Context) error {
+	c.startSealRewrap(ctx)
+	return nil
+}
 
 func (c *Core) applyLeaseCountQuota(_ context.Context, in *quotas.Request) (*quotas.Response, error) {
 	return &quotas.Response{Allowed: true}, nil
@@ -6,63 +6,130 @@
 package audit
 
 import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/vault/api"
 	"github.com/hashicorp/vault/helper/testhelpers/minimal"
 	"github.com/stretchr/testify/require"
 )
 
-// TestAuditFilteringInCE ensures that the audit device 'filter'
-// option is only supported in the enterprise edition of the product.
+// TestAuditFilteringInCE ensures that the audit device 'filter' option is
+// supported in the community edition of the product. Only entries matching
+// the filter are written to the device, and requests filtered out by every
+// device still succeed.
 func TestAuditFilteringInCE(t *testing.T) {
 	t.Parallel()
 	cluster := minimal.NewTestSoloCluster(t, nil)
 	client := cluster.Cores[0].Client
 
-	// Attempt to create an audit device with filtering enabled.
+	err := client.Sys().Mount("kv/", &api.MountInput{Type: "kv"})
+	require.NoError(t, err)
+
+	// Create an audit device with filtering enabled.
+	auditFilePath := filepath.Join(t.TempDir(), "audit.log")
 	mountPointFilterDevicePath := "mountpoint"
 	mountPointFilterDeviceData := map[string]any{
 		"type":        "file",
 		"description": "",
 		"local":       false,
 		"options": map[string]any{
-			"file_path": "/tmp/audit.log",
-			"filter":    "mount_point == secret/",
+			"file_path": auditFilePath,
+			"filter":    "mount_point == \"kv/\"",
 		},
 	}
-	_, err := client.Logical().Write("sys/audit/"+mountPointFilterDevicePath, mountPointFilterDeviceData)
-	require.Error(t, err)
-	require.ErrorContains(t, err, "enterprise-only options supplied")
+	_, err = client.Logical().Write("sys/audit/"+mountPointFilterDevicePath, mountPointFilterDeviceData)
+	require.NoError(t, err)
 
-	// Ensure the device has not been created.
 	devices, err := client.Sys().ListAudit()
 	require.NoError(t, err)
-	require.Len(t, devices, 0)
+	require.Len(t, devices, 1)
+
+	// Requests which don't match the filter are not audited, but don't fail.
+	_, err = client.Logical().Write("secret/foo", map[string]any{"value": "bar"})
+	require.NoError(t, err)
+	_, err = client.Logical().Write("kv/foo", map[string]any{"value": "bar"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(auditFilePath)
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	for _, line := range lines {
+		var entry map[string]any
+		require.NoError(t, json.Unmarshal([]byte(line), &entry))
+		request := entry["request"].(map[string]any)
+		require.Equal(t, "kv/", request["mount_point"])
+		require.Equal(t, "kv/foo", request["path"])
+	}
 }
 
 // TestAuditFilteringFallbackDeviceInCE validates that the audit device
-// 'fallback' option is only available in the enterprise edition of the product.
+// 'fallback' option is available in the community edition of the product, and
+// that the fallback device receives the entries filtered out by every other
+// device.
 func TestAuditFilteringFallbackDeviceInCE(t *testing.T) {
 	t.Parallel()
 	cluster := minimal.NewTestSoloCluster(t, nil)
 	client := cluster.Cores[0].Client
 
+	err := client.Sys().Mount("kv/", &api.MountInput{Type: "kv"})
+	require.NoError(t, err)
+
+	filteredFilePath := filepath.Join(t.TempDir(), "filtered.log")
+	_, err = client.Logical().Write("sys/audit/filtered", map[string]any{
+		"type": "file",
+		"options": map[string]any{
+			"file_path": filteredFilePath,
+			"filter":    "mount_point == \"kv/\"",
+		},
+	})
+	require.NoError(t, err)
+
+	fallbackFilePath := filepath.Join(t.TempDir(), "fallback.log")
 	fallbackDevicePath := "fallback"
 	fallbackDeviceData := map[string]any{
 		"type":        "file",
 		"description": "",
 		"local":       false,
 		"options": map[string]any{
-			"file_path": "/tmp/audit.log",
+			"file_path": fallbackFilePath,
 			"fallback":  "true",
 		},
 	}
-	_, err := client.Logical().Write("sys/audit/"+fallbackDevicePath, fallbackDeviceData)
+	_, err = client.Logical().Write("sys/audit/"+fallbackDevicePath, fallbackDeviceData)
+	require.NoError(t, err)
+
+	// Only a single fallback device is supported.
+	_, err = client.Logical().Write("sys/audit/fallback2", map[string]any{
+		"type": "file",
+		"options": map[string]any{
+			"file_path": filepath.Join(t.TempDir(), "fallback2.log"),
+			"fallback":  "true",
+		},
+	})
 	require.Error(t, err)
-	require.ErrorContains(t, err, "enterprise-only options supplied")
+	require.ErrorContains(t, err, "vault only supports a single fallback audit device")
 
-	// Ensure the device has not been created.
 	devices, err := client.Sys().ListAudit()
 	require.NoError(t, err)
-	require.Len(t, devices, 0)
+	require.Len(t, devices, 2)
+
+	_, err = client.Logical().Write("kv/foo", map[string]any{"value": "bar"})
+	require.NoError(t, err)
+	_, err = client.Logical().Write("secret/foo", map[string]any{"value": "bar"})
+	require.NoError(t, err)
+
+	data, err := os.ReadFile(filteredFilePath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"path":"kv/foo"`)
+	require.NotContains(t, string(data), `"path":"secret/foo"`)
+
+	data, err = os.ReadFile(fallbackFilePath)
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"path":"secret/foo"`)
+	require.NotContains(t, string(data), `"path":"kv/foo"`)
 }
@@ -0,0 +1,94 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package audit
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/hashicorp/vault/helper/testhelpers/minimal"
+	"github.com/stretchr/testify/require"
+)
+
+// TestAudit_HTTPDevice ensures that an 'http' audit device sends the audit
+// entries of requests to its receiver, and that requests succeed once the
+// receiver is down when a spool directory is configured.
+func TestAudit_HTTPDevice(t *testing.T) {
+	t.Parallel()
+
+	var lock sync.Mutex
+	var paths []string
+	available := true
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+		if !available {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+
+		body, _ := io.ReadAll(r.Body)
+		for _, line := range strings.Split(strings.TrimSpace(string(body)), "\n") {
+			var entry map[string]any
+			if err := json.Unmarshal([]byte(line), &entry); err == nil {
+				request := entry["request"].(map[string]any)
+				paths = append(paths, request["path"].(string))
+			}
+		}
+	}))
+	t.Cleanup(receiver.Close)
+
+	received := func() []string {
+		lock.Lock()
+		defer lock.Unlock()
+		return append([]string(nil), paths...)
+	}
+
+	cluster := minimal.NewTestSoloCluster(t, nil)
+	client := cluster.Cores[0].Client
+
+	err := client.Sys().EnableAuditWithOptions("http", &api.EnableAuditOptions{
+		Type: "http",
+		Options: map[string]string{
+			"url":               receiver.URL,
+			"flush_interval":    "10ms",
+			"retry_min_backoff": "1ms",
+			"retry_max_backoff": "1ms",
+			"spool_dir":         t.TempDir(),
+		},
+	})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("secret/foo", map[string]any{"value": "bar"})
+	require.NoError(t, err)
+	require.Contains(t, received(), "secret/foo")
+
+	lock.Lock()
+	available = false
+	lock.Unlock()
+
+	_, err = client.Logical().Write("secret/spooled", map[string]any{"value": "bar"})
+	require.NoError(t, err)
+	require.NotContains(t, received(), "secret/spooled")
+
+	lock.Lock()
+	available = true
+	lock.Unlock()
+
+	require.Eventually(t, func() bool {
+		for _, path := range received() {
+			if path == "secret/spooled" {
+				return true
+			}
+		}
+		return false
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -16,7 +16,6 @@ import (
 	"github.com/google/go-cmp/cmp"
 	autopilot "github.com/hashicorp/raft-autopilot"
 	"github.com/hashicorp/vault/api"
-	"github.com/hashicorp/vault/helper/constants"
 	"github.com/hashicorp/vault/helper/testhelpers"
 	"github.com/hashicorp/vault/physical/raft"
 	"github.com/hashicorp/vault/sdk/helper/testcluster"
@@ -460,6 +459,52 @@ func TestRaft_VotersStayVoters(t *testing.T) {
 	require.NoError(t, err)
 }
 
+// TestRaft_Autopilot_ReadReplica tests that a node joining as a non-voter is
+// never promoted by autopilot, reports itself as a read replica and forwards
+// writes to the active node.
+func TestRaft_Autopilot_ReadReplica(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		DisableFollowerJoins: true,
+		InmemCluster:         true,
+		EnableAutopilot:      true,
+		PhysicalFactoryConfig: map[string]interface{}{
+			"performance_multiplier":       "5",
+			"autopilot_reconcile_interval": "300ms",
+			"autopilot_update_interval":    "100ms",
+		},
+	})
+	defer cluster.Cleanup()
+	testhelpers.WaitForActiveNode(t, cluster)
+
+	client := cluster.Cores[0].Client
+	_, err := client.Logical().Write("sys/storage/raft/autopilot/configuration", map[string]interface{}{
+		"server_stabilization_time": "1s",
+	})
+	require.NoError(t, err)
+
+	joinAndUnseal(t, cluster.Cores[1], cluster, true, true)
+	time.Sleep(3 * time.Second)
+
+	state, err := client.Sys().RaftAutopilotState()
+	require.NoError(t, err)
+	require.Equal(t, "non-voter", state.Servers["core-1"].Status)
+	require.True(t, state.Servers["core-1"].ReadReplica)
+	require.False(t, state.Servers["core-0"].ReadReplica)
+
+	replica := cluster.Cores[1].Client
+	health, err := replica.Sys().Health()
+	require.NoError(t, err)
+	require.True(t, health.Standby)
+	require.True(t, health.ReadReplica)
+
+	_, err = replica.Logical().Write("secret/foo", map[string]interface{}{"bar": "baz"})
+	require.NoError(t, err)
+	secret, err := client.Logical().Read("secret/foo")
+	require.NoError(t, err)
+	require.Equal(t, "baz", secret.Data["bar"])
+}
+
 // TestRaft_Autopilot_DeadServerCleanup tests that dead servers are correctly
 // removed by Vault and autopilot when a node stops and a replacement node joins.
 // The expected behavior is that removing a node from a 3 node cluster wouldn't
@@ -498,15 +543,11 @@ func TestRaft_Autopilot_DeadServerCleanup(t *testing.T) {
 	config.MinQuorum = 3
 	config.DisableUpgradeMigration = true
 
-	// We can't use Client.Sys().PutRaftAutopilotConfiguration(config) in OSS as disable_upgrade_migration isn't in OSS
 	b, err := json.Marshal(&config)
 	require.NoError(t, err)
 	var m map[string]interface{}
 	err = json.Unmarshal(b, &m)
 	require.NoError(t, err)
-	if !constants.IsEnterprise {
-		delete(m, "disable_upgrade_migration")
-	}
 	_, err = leader.Client.Logical().Write("sys/storage/raft/autopilot/configuration", m)
 	require.NoError(t, err)
 
@@ -0,0 +1,222 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package rafttests
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/helper/testhelpers"
+	"github.com/hashicorp/vault/vault"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRaft_SnapshotAuto configures an automatic snapshot schedule and verifies
+// that snapshots are written to the local directory, that old ones are pruned
+// and that the status endpoint reports the last snapshot.
+func TestRaft_SnapshotAuto(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		InmemCluster: true,
+		DisableMlock: true,
+		NumCores:     1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	dir := t.TempDir()
+
+	_, err := client.Logical().Write("sys/storage/raft/snapshot-auto/config/fast", map[string]interface{}{
+		"interval":     "1s",
+		"retain":       2,
+		"storage_type": "local",
+		"path_prefix":  dir,
+		"file_prefix":  "test",
+	})
+	require.NoError(t, err)
+
+	resp, err := client.Logical().List("sys/storage/raft/snapshot-auto/config")
+	require.NoError(t, err)
+	require.Equal(t, []interface{}{"fast"}, resp.Data["keys"])
+
+	resp, err = client.Logical().Read("sys/storage/raft/snapshot-auto/config/fast")
+	require.NoError(t, err)
+	require.Equal(t, "local", resp.Data["storage_type"])
+	require.Equal(t, "test", resp.Data["file_prefix"])
+
+	snapshots := func() []string {
+		matches, err := filepath.Glob(filepath.Join(dir, "test-*.snap"))
+		require.NoError(t, err)
+		return matches
+	}
+
+	// Wait until enough snapshots have been taken for pruning to kick in.
+	var status map[string]interface{}
+	require.Eventually(t, func() bool {
+		resp, err := client.Logical().Read("sys/storage/raft/snapshot-auto/status/fast")
+		if err != nil || resp == nil {
+			return false
+		}
+		status = resp.Data
+		return strings.HasSuffix(status["last_snapshot_url"].(string), ".snap") && len(snapshots()) == 2
+	}, 30*time.Second, 250*time.Millisecond)
+	require.Empty(t, status["last_snapshot_error"])
+
+	time.Sleep(3 * time.Second)
+	require.Len(t, snapshots(), 2)
+
+	info, err := os.Stat(snapshots()[1])
+	require.NoError(t, err)
+	require.NotZero(t, info.Size())
+
+	_, err = client.Logical().Delete("sys/storage/raft/snapshot-auto/config/fast")
+	require.NoError(t, err)
+
+	resp, err = client.Logical().Read("sys/storage/raft/snapshot-auto/status/fast")
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+// TestRaft_SnapshotAuto_Validation verifies that invalid configurations are
+// rejected.
+func TestRaft_SnapshotAuto_Validation(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		InmemCluster: true,
+		DisableMlock: true,
+		NumCores:     1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+
+	_, err := client.Logical().Write("sys/storage/raft/snapshot-auto/config/bad", map[string]interface{}{
+		"interval":     "1h",
+		"storage_type": "local",
+		"path_prefix":  "relative/dir",
+	})
+	require.ErrorContains(t, err, "path_prefix must be an absolute path")
+
+	_, err = client.Logical().Write("sys/storage/raft/snapshot-auto/config/bad", map[string]interface{}{
+		"interval":     "1h",
+		"storage_type": "tape",
+		"path_prefix":  t.TempDir(),
+	})
+	require.ErrorContains(t, err, `unsupported storage_type "tape"`)
+
+	resp, err := client.Logical().Read("sys/storage/raft/snapshot-auto/config/bad")
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+// TestRaft_SnapshotAuto_InvalidStoredConfig verifies that a stored automatic
+// snapshot configuration that cannot be decoded does not prevent the node
+// from becoming active, and that the remaining configurations are scheduled.
+func TestRaft_SnapshotAuto_InvalidStoredConfig(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		InmemCluster: true,
+		DisableMlock: true,
+		NumCores:     1,
+	})
+	defer cluster.Cleanup()
+
+	core := cluster.Cores[0]
+	client := core.Client
+
+	_, err := client.Logical().Write("sys/storage/raft/snapshot-auto/config/good", map[string]interface{}{
+		"interval":     "1h",
+		"retain":       1,
+		"storage_type": "local",
+		"path_prefix":  t.TempDir(),
+	})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("sys/raw/core/raft/snapshot-auto/config/broken", map[string]interface{}{
+		"value": "not json",
+	})
+	require.NoError(t, err)
+
+	testhelpers.EnsureCoreSealed(t, core)
+	cluster.UnsealCore(t, core)
+	vault.TestWaitActive(t, core.Core)
+
+	resp, err := client.Logical().Read("sys/storage/raft/snapshot-auto/status/good")
+	require.NoError(t, err)
+	require.NotNil(t, resp)
+
+	resp, err = client.Logical().Read("sys/storage/raft/snapshot-auto/status/broken")
+	require.NoError(t, err)
+	require.Nil(t, resp)
+}
+
+// TestRaft_SnapshotAuto_Restore verifies that a snapshot written by an
+// automatic snapshot configuration can be listed and restored by the server
+// directly from the configured storage.
+func TestRaft_SnapshotAuto_Restore(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		InmemCluster: true,
+		DisableMlock: true,
+		NumCores:     1,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+	dir := t.TempDir()
+
+	_, err := client.Logical().Write("secret/before", map[string]interface{}{"test": "data"})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("sys/storage/raft/snapshot-auto/config/restore", map[string]interface{}{
+		"interval":     "1s",
+		"retain":       5,
+		"storage_type": "local",
+		"path_prefix":  dir,
+	})
+	require.NoError(t, err)
+
+	var snapshot string
+	require.Eventually(t, func() bool {
+		resp, err := client.Logical().List("sys/storage/raft/snapshot-auto/snapshots/restore")
+		if err != nil || resp == nil {
+			return false
+		}
+		keys := resp.Data["keys"].([]interface{})
+		snapshot = keys[0].(string)
+		return true
+	}, 30*time.Second, 250*time.Millisecond)
+	require.True(t, strings.HasPrefix(snapshot, "vault-snapshot-"))
+
+	// Slow the schedule down so the snapshot we restore from isn't pruned
+	_, err = client.Logical().Write("sys/storage/raft/snapshot-auto/config/restore", map[string]interface{}{
+		"interval": "24h",
+	})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("secret/after", map[string]interface{}{"test": "data"})
+	require.NoError(t, err)
+
+	_, err = client.Logical().Write("sys/storage/raft/snapshot-auto/restore/restore", map[string]interface{}{
+		"snapshot": "missing.snap",
+	})
+	require.Error(t, err)
+
+	_, err = client.Logical().Write("sys/storage/raft/snapshot-auto/restore/restore", map[string]interface{}{
+		"snapshot": snapshot,
+	})
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		secret, err := client.Logical().Read("secret/after")
+		return err == nil && secret == nil
+	}, 30*time.Second, 250*time.Millisecond)
+
+	secret, err := client.Logical().Read("secret/before")
+	require.NoError(t, err)
+	require.NotNil(t, secret)
+}
@@ -0,0 +1,152 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package rafttests
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/hashicorp/vault/api"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRaft_SnapshotRestoreMount disables a secrets mount and an auth mount and
+// verifies that they are restored from a snapshot with their data and
+// accessor, while writes made elsewhere after the snapshot are kept.
+func TestRaft_SnapshotRestoreMount(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		InmemCluster: true,
+		DisableMlock: true,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+
+	require.NoError(t, client.Sys().Mount("kv/", &api.MountInput{Type: "kv"}))
+	_, err := client.Logical().Write("kv/foo", map[string]interface{}{"value": "before"})
+	require.NoError(t, err)
+	require.NoError(t, client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{Type: "userpass"}))
+	_, err = client.Logical().Write("auth/userpass/users/alice", map[string]interface{}{"password": "secret"})
+	require.NoError(t, err)
+
+	mounts, err := client.Sys().ListMounts()
+	require.NoError(t, err)
+	kvAccessor := mounts["kv/"].Accessor
+	auths, err := client.Sys().ListAuth()
+	require.NoError(t, err)
+	userpassAccessor := auths["userpass/"].Accessor
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, client.Sys().RaftSnapshot(buf))
+	snap := buf.Bytes()
+
+	// Changes made after the snapshot outside of the restored mounts are kept
+	_, err = client.Logical().Write("secret/after", map[string]interface{}{"value": "after"})
+	require.NoError(t, err)
+
+	// The mount cannot be restored while it is in use
+	_, err = client.Sys().RaftSnapshotRestoreMount(bytes.NewReader(snap), "kv/")
+	require.ErrorContains(t, err, "existing mount at kv/")
+
+	require.NoError(t, client.Sys().Unmount("kv/"))
+	require.NoError(t, client.Sys().DisableAuth("userpass"))
+
+	_, err = client.Sys().RaftSnapshotRestoreMount(bytes.NewReader(snap), "missing/")
+	require.ErrorContains(t, err, `no mount found at "missing/" in the snapshot`)
+
+	resp, err := client.Sys().RaftSnapshotRestoreMount(bytes.NewReader(snap), "kv")
+	require.NoError(t, err)
+	require.Equal(t, "kv/", resp.Data["path"])
+	require.Equal(t, "kv", resp.Data["type"])
+	require.Equal(t, kvAccessor, resp.Data["accessor"])
+
+	resp, err = client.Sys().RaftSnapshotRestoreMount(bytes.NewReader(snap), "auth/userpass/")
+	require.NoError(t, err)
+	require.Equal(t, "auth/userpass/", resp.Data["path"])
+	require.Equal(t, userpassAccessor, resp.Data["accessor"])
+
+	secret, err := client.Logical().Read("kv/foo")
+	require.NoError(t, err)
+	require.Equal(t, "before", secret.Data["value"])
+
+	secret, err = client.Logical().Read("secret/after")
+	require.NoError(t, err)
+	require.NotNil(t, secret)
+
+	secret, err = client.Logical().Write("auth/userpass/login/alice", map[string]interface{}{"password": "secret"})
+	require.NoError(t, err)
+	require.NotEmpty(t, secret.Auth.ClientToken)
+
+	mounts, err = client.Sys().ListMounts()
+	require.NoError(t, err)
+	require.Equal(t, kvAccessor, mounts["kv/"].Accessor)
+
+	// The restored mount is also served through the standbys
+	standby := cluster.Cores[1].Client
+	secret, err = standby.Logical().Read("kv/foo")
+	require.NoError(t, err)
+	require.Equal(t, "before", secret.Data["value"])
+}
+
+// TestRaft_SnapshotRestoreMountByUUID verifies that mounts are looked up by
+// UUID in the mount and auth tables of the snapshot, and restored at their
+// path in the snapshot or at a new path.
+func TestRaft_SnapshotRestoreMountByUUID(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		InmemCluster: true,
+		DisableMlock: true,
+	})
+	defer cluster.Cleanup()
+
+	client := cluster.Cores[0].Client
+
+	require.NoError(t, client.Sys().Mount("kv/", &api.MountInput{Type: "kv"}))
+	_, err := client.Logical().Write("kv/foo", map[string]interface{}{"value": "before"})
+	require.NoError(t, err)
+	require.NoError(t, client.Sys().EnableAuthWithOptions("userpass", &api.EnableAuthOptions{Type: "userpass"}))
+
+	mounts, err := client.Sys().ListMounts()
+	require.NoError(t, err)
+	kv := mounts["kv/"]
+	auths, err := client.Sys().ListAuth()
+	require.NoError(t, err)
+	userpass := auths["userpass/"]
+
+	buf := new(bytes.Buffer)
+	require.NoError(t, client.Sys().RaftSnapshot(buf))
+	snap := buf.Bytes()
+
+	require.NoError(t, client.Sys().Unmount("kv/"))
+	require.NoError(t, client.Sys().DisableAuth("userpass"))
+
+	_, err = client.Sys().RaftSnapshotRestoreMountByUUID(bytes.NewReader(snap), "missing", "")
+	require.ErrorContains(t, err, `no mount found with UUID "missing" in the snapshot`)
+
+	// The type of the mount must match the path it is restored at
+	_, err = client.Sys().RaftSnapshotRestoreMountByUUID(bytes.NewReader(snap), kv.UUID, "auth/kv")
+	require.ErrorContains(t, err, "is not an auth mount")
+	_, err = client.Sys().RaftSnapshotRestoreMountByUUID(bytes.NewReader(snap), userpass.UUID, "userpass")
+	require.ErrorContains(t, err, "is an auth mount")
+
+	resp, err := client.Sys().RaftSnapshotRestoreMountByUUID(bytes.NewReader(snap), kv.UUID, "kv-restored")
+	require.NoError(t, err)
+	require.Equal(t, "kv-restored/", resp.Data["path"])
+	require.Equal(t, kv.Accessor, resp.Data["accessor"])
+
+	resp, err = client.Sys().RaftSnapshotRestoreMountByUUID(bytes.NewReader(snap), userpass.UUID, "")
+	require.NoError(t, err)
+	require.Equal(t, "auth/userpass/", resp.Data["path"])
+	require.Equal(t, userpass.Accessor, resp.Data["accessor"])
+
+	secret, err := client.Logical().Read("kv-restored/foo")
+	require.NoError(t, err)
+	require.Equal(t, "before", secret.Data["value"])
+
+	mounts, err = client.Sys().ListMounts()
+	require.NoError(t, err)
+	require.NotContains(t, mounts, "kv/")
+	require.Equal(t, kv.UUID, mounts["kv-restored/"].UUID)
+}
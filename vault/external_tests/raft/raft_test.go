@@ -520,6 +520,75 @@ func TestRaft_RemovePeer(t *testing.T) {
 	}
 }
 
+// TestRaft_Compact verifies that compacting the raft storage goes through all
+// the standby nodes, skips the active one, and keeps the stored data.
+func TestRaft_Compact(t *testing.T) {
+	t.Parallel()
+	cluster, _ := raftCluster(t, &RaftClusterOpts{
+		DisableFollowerJoins: true,
+		InmemCluster:         true,
+		EnableAutopilot:      true,
+		PhysicalFactoryConfig: map[string]interface{}{
+			"autopilot_reconcile_interval": "300ms",
+			"autopilot_update_interval":    "100ms",
+		},
+	})
+	defer cluster.Cleanup()
+	testhelpers.WaitForActiveNode(t, cluster)
+
+	client := cluster.Cores[0].Client
+	_, err := client.Logical().Write("sys/storage/raft/autopilot/configuration", map[string]interface{}{
+		"server_stabilization_time": "1s",
+	})
+	require.NoError(t, err)
+
+	joinAsVoterAndUnseal(t, cluster.Cores[1], cluster)
+	joinAsVoterAndUnseal(t, cluster.Cores[2], cluster)
+
+	value := strings.Repeat("a", 8192)
+	for i := 0; i < 200; i++ {
+		_, err := client.Logical().Write(fmt.Sprintf("secret/tidy/%d", i), map[string]interface{}{"value": value})
+		require.NoError(t, err)
+	}
+	for i := 0; i < 200; i++ {
+		_, err := client.Logical().Delete(fmt.Sprintf("secret/tidy/%d", i))
+		require.NoError(t, err)
+	}
+	_, err = client.Logical().Write("secret/keep", map[string]interface{}{"value": "kept"})
+	require.NoError(t, err)
+
+	status, err := client.Sys().RaftCompactionStatus()
+	require.NoError(t, err)
+	require.Nil(t, status)
+
+	require.NoError(t, client.Sys().RaftCompact())
+
+	testhelpers.RetryUntil(t, time.Minute, func() error {
+		status, err = client.Sys().RaftCompactionStatus()
+		if err != nil {
+			return err
+		}
+		if status.Status == raft.CompactionStatusRunning {
+			return errors.New("compaction is still running")
+		}
+		return nil
+	})
+	require.Equal(t, raft.CompactionStatusCompleted, status.Status, status.Error)
+	require.Len(t, status.Servers, 3)
+	for i, id := range []string{"core-1", "core-2"} {
+		require.Equal(t, id, status.Servers[i].ID)
+		require.Equal(t, raft.CompactionStatusCompleted, status.Servers[i].Status)
+	}
+	require.Equal(t, "core-0", status.Servers[2].ID)
+	require.Equal(t, raft.CompactionStatusSkipped, status.Servers[2].Status)
+
+	for _, c := range cluster.Cores {
+		secret, err := c.Client.Logical().Read("secret/keep")
+		require.NoError(t, err)
+		require.Equal(t, "kept", secret.Data["value"])
+	}
+}
+
 func TestRaft_NodeIDHeader(t *testing.T) {
 	t.Parallel()
 	testCases := []struct {
@@ -9,15 +9,31 @@ import (
 	"encoding/base64"
 	"errors"
 	"fmt"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/hashicorp/go-uuid"
+	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/sdk/helper/consts"
+	"github.com/hashicorp/vault/sdk/helper/policyutil"
 	"github.com/hashicorp/vault/sdk/helper/roottoken"
+	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/shamir"
 )
 
-const coreDROperationTokenPath = "core/dr-operation-token"
+const (
+	coreDROperationTokenPath = "core/dr-operation-token"
+
+	// rootTokenGeneratedEventType is the type of the event sent when a root
+	// generation completes
+	rootTokenGeneratedEventType = "sys/root-token-generated"
+
+	// generatedRootMetaKey is set in the metadata of tokens created by a root
+	// generation with a TTL or policies, so their use is flagged in audit logs
+	generatedRootMetaKey = "generated_root"
+)
 
 var (
 	// GenerateStandardRootTokenStrategy is the strategy used to generate a
@@ -53,7 +69,14 @@ func (g generateStandardRootToken) authenticate(ctx context.Context, c *Core, co
 }
 
 func (g generateStandardRootToken) generate(ctx context.Context, c *Core) (string, func(), error) {
-	te, err := c.tokenStore.rootToken(ctx)
+	var ttl time.Duration
+	var policies []string
+	if c.generateRootConfig != nil {
+		ttl = c.generateRootConfig.TTL
+		policies = c.generateRootConfig.Policies
+	}
+
+	te, err := c.tokenStore.generatedRootToken(ctx, ttl, policies)
 	if err != nil {
 		c.logger.Error("root token generation failed", "error", err)
 		return "", nil, err
@@ -78,6 +101,23 @@ type GenerateRootConfig struct {
 	PGPFingerprint string
 	OTP            string
 	Strategy       GenerateRootStrategy
+
+	// TTL and Policies limit the token created by a root token generation.
+	// A zero TTL means the token does not expire and no policies means the
+	// root policy.
+	TTL      time.Duration
+	Policies []string
+}
+
+// GenerateRootOptions holds optional settings for the token created by a root
+// token generation.
+type GenerateRootOptions struct {
+	// TTL is how long the token is valid for. The token cannot be renewed.
+	TTL time.Duration
+
+	// Policies replace the root policy of the token. A TTL is required when
+	// they are set.
+	Policies []string
 }
 
 // GenerateRootResult holds the result of a root generation update
@@ -128,12 +168,39 @@ func (c *Core) GenerateRootConfiguration() (*GenerateRootConfig, error) {
 		*conf = *c.generateRootConfig
 		conf.OTP = ""
 		conf.Strategy = nil
+		conf.Policies = append([]string(nil), c.generateRootConfig.Policies...)
 	}
 	return conf, nil
 }
 
 // GenerateRootInit is used to initialize the root generation settings
 func (c *Core) GenerateRootInit(otp, pgpKey string, strategy GenerateRootStrategy) error {
+	return c.GenerateRootInitWithOptions(otp, pgpKey, strategy, nil)
+}
+
+// GenerateRootInitWithOptions is used to initialize the root generation
+// settings with limits on the generated token
+func (c *Core) GenerateRootInitWithOptions(otp, pgpKey string, strategy GenerateRootStrategy, opts *GenerateRootOptions) error {
+	if opts == nil {
+		opts = &GenerateRootOptions{}
+	}
+	var policies []string
+	if opts.TTL != 0 || len(opts.Policies) > 0 {
+		if _, ok := strategy.(generateStandardRootToken); !ok {
+			return fmt.Errorf("ttl and policies can only be set when generating a root token")
+		}
+		if opts.TTL < 0 {
+			return fmt.Errorf("ttl must not be negative")
+		}
+		if c.maxLeaseTTL != 0 && opts.TTL > c.maxLeaseTTL {
+			return fmt.Errorf("ttl must not be greater than the system max lease TTL of %s", c.maxLeaseTTL)
+		}
+		policies = policyutil.SanitizePolicies(opts.Policies, policyutil.DoNotAddDefaultPolicy)
+		if len(policies) > 0 && opts.TTL == 0 {
+			return fmt.Errorf("ttl must be set when policies are set")
+		}
+	}
+
 	var fingerprint string
 	switch {
 	case len(otp) > 0:
@@ -192,12 +259,15 @@ func (c *Core) GenerateRootInit(otp, pgpKey string, strategy GenerateRootStrateg
 		PGPKey:         pgpKey,
 		PGPFingerprint: fingerprint,
 		Strategy:       strategy,
+		TTL:            opts.TTL,
+		Policies:       policies,
 	}
 
 	if c.logger.IsInfo() {
 		switch strategy.(type) {
 		case generateStandardRootToken:
-			c.logger.Info("root generation initialized", "nonce", c.generateRootConfig.Nonce)
+			c.logger.Info("root generation initialized", "nonce", c.generateRootConfig.Nonce,
+				"ttl", c.generateRootConfig.TTL, "policies", c.generateRootConfig.Policies)
 		case *generateRecoveryToken:
 			c.logger.Info("recovery token generation initialized", "nonce", c.generateRootConfig.Nonce)
 		default:
@@ -349,7 +419,9 @@ func (c *Core) GenerateRootUpdate(ctx context.Context, key []byte, nonce string,
 
 	switch strategy.(type) {
 	case generateStandardRootToken:
-		c.logger.Info("root generation finished", "nonce", c.generateRootConfig.Nonce)
+		c.logger.Info("root generation finished", "nonce", c.generateRootConfig.Nonce,
+			"ttl", c.generateRootConfig.TTL, "policies", c.generateRootConfig.Policies)
+		c.sendRootTokenGeneratedEvent(ctx, token)
 	case *generateRecoveryToken:
 		c.logger.Info("recovery token generation finished", "nonce", c.generateRootConfig.Nonce)
 	default:
@@ -380,3 +452,38 @@ func (c *Core) GenerateRootCancel() error {
 	c.generateRootProgress = nil
 	return nil
 }
+
+// sendRootTokenGeneratedEvent announces a generated root token on the event
+// bus, so that its use can be tracked until it expires or is revoked.
+func (c *Core) sendRootTokenGeneratedEvent(ctx context.Context, token string) {
+	if c.events == nil {
+		return
+	}
+	ctx = namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
+	te, err := c.tokenStore.Lookup(ctx, token)
+	if err == nil && te == nil {
+		err = errors.New("generated token not found")
+	}
+	if err != nil {
+		c.logger.Error("error sending root token generated event", "error", err)
+		return
+	}
+
+	var expireTime string
+	if te.TTL > 0 {
+		expireTime = time.Unix(te.CreationTime, 0).Add(te.TTL).UTC().Format(time.RFC3339)
+	}
+	sender, err := c.events.WithPlugin(namespace.RootNamespace, nil)
+	if err == nil {
+		err = logical.SendEvent(ctx, sender, rootTokenGeneratedEventType,
+			logical.EventMetadataPath, "sys/generate-root/update",
+			"accessor", te.Accessor,
+			"policies", strings.Join(te.Policies, ","),
+			"ttl", strconv.FormatInt(int64(te.TTL.Seconds()), 10),
+			"expire_time", expireTime,
+		)
+	}
+	if err != nil {
+		c.logger.Error("error sending root token generated event", "error", err)
+	}
+}
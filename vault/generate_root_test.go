@@ -6,11 +6,14 @@ package vault
 import (
 	"encoding/base64"
 	"testing"
+	"time"
 
 	"github.com/hashicorp/go-secure-stdlib/base62"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/sdk/helper/xor"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/stretchr/testify/require"
 )
 
 func TestCore_GenerateRoot_Lifecycle(t *testing.T) {
@@ -316,4 +319,118 @@ func testCore_GenerateRoot_Update_PGP_Common(t *testing.T, c *Core, keys [][]byt
 		len(te.Policies) != 1 || te.Policies[0] != "root" {
 		t.Fatalf("bad: %#v", *te)
 	}
+	if te.Meta[generatedRootMetaKey] != "true" {
+		t.Fatalf("expected the token to be flagged as generated: %#v", te.Meta)
+	}
+}
+
+// TestCore_GenerateRoot_Update_TTL verifies that a root generation with a TTL
+// and policies creates a non-renewable token that is flagged in its metadata,
+// registered for expiration and announced on the event bus.
+func TestCore_GenerateRoot_Update_TTL(t *testing.T) {
+	c, keys, _ := TestCoreUnsealedWithConfig(t, &CoreConfig{})
+	ctx := namespace.RootContext(nil)
+
+	ch, cancel, err := c.events.Subscribe(ctx, namespace.RootNamespace, rootTokenGeneratedEventType, "")
+	require.NoError(t, err)
+	defer cancel()
+
+	otp, err := base62.Random(TokenPrefixLength + TokenLength)
+	require.NoError(t, err)
+
+	opts := &GenerateRootOptions{
+		TTL:      time.Hour,
+		Policies: []string{"foo", "bar"},
+	}
+	require.NoError(t, c.GenerateRootInitWithOptions(otp, "", GenerateStandardRootTokenStrategy, opts))
+
+	rkconf, err := c.GenerateRootConfiguration()
+	require.NoError(t, err)
+	require.Equal(t, time.Hour, rkconf.TTL)
+	require.Equal(t, []string{"bar", "foo"}, rkconf.Policies)
+
+	var result *GenerateRootResult
+	for _, key := range keys {
+		result, err = c.GenerateRootUpdate(ctx, key, rkconf.Nonce, GenerateStandardRootTokenStrategy)
+		require.NoError(t, err)
+		if result.EncodedToken != "" {
+			break
+		}
+	}
+	require.NotEmpty(t, result.EncodedToken)
+
+	tokenBytes, err := base64.RawStdEncoding.DecodeString(result.EncodedToken)
+	require.NoError(t, err)
+	tokenBytes, err = xor.XORBytes(tokenBytes, []byte(otp))
+	require.NoError(t, err)
+
+	te, err := c.tokenStore.Lookup(ctx, string(tokenBytes))
+	require.NoError(t, err)
+	require.NotNil(t, te)
+	require.Equal(t, []string{"bar", "foo"}, te.Policies)
+	require.Equal(t, time.Hour, te.TTL)
+	require.Equal(t, time.Hour, te.ExplicitMaxTTL)
+	require.Equal(t, "true", te.Meta[generatedRootMetaKey])
+
+	expireTime, err := c.expiration.FetchLeaseTimesByToken(ctx, te)
+	require.NoError(t, err)
+	require.NotNil(t, expireTime)
+	require.False(t, expireTime.ExpireTime.IsZero())
+
+	select {
+	case event := <-ch:
+		received := event.Payload.(*logical.EventReceived)
+		fields := received.Event.Metadata.AsMap()
+		require.Equal(t, te.Accessor, fields["accessor"])
+		require.Equal(t, "bar,foo", fields["policies"])
+		require.Equal(t, "3600", fields["ttl"])
+		require.NotEmpty(t, fields["expire_time"])
+	case <-time.After(time.Second):
+		t.Fatal("timeout waiting for event")
+	}
+}
+
+// TestCore_GenerateRoot_InitOptions verifies the validation of the options of
+// a root generation.
+func TestCore_GenerateRoot_InitOptions(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	otp, err := base62.Random(TokenPrefixLength + TokenLength)
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		strategy GenerateRootStrategy
+		opts     *GenerateRootOptions
+		wantErr  string
+	}{
+		"negative ttl": {
+			strategy: GenerateStandardRootTokenStrategy,
+			opts:     &GenerateRootOptions{TTL: -time.Hour},
+			wantErr:  "ttl must not be negative",
+		},
+		"policies without ttl": {
+			strategy: GenerateStandardRootTokenStrategy,
+			opts:     &GenerateRootOptions{Policies: []string{"foo"}},
+			wantErr:  "ttl must be set when policies are set",
+		},
+		"recovery token": {
+			strategy: &generateRecoveryToken{},
+			opts:     &GenerateRootOptions{TTL: time.Hour},
+			wantErr:  "can only be set when generating a root token",
+		},
+		"ttl only": {
+			strategy: GenerateStandardRootTokenStrategy,
+			opts:     &GenerateRootOptions{TTL: time.Hour},
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := c.GenerateRootInitWithOptions(otp, "", tc.strategy, tc.opts)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.NoError(t, c.GenerateRootCancel())
+		})
+	}
 }
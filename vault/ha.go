@@ -596,6 +596,19 @@ func (c *Core) waitForLeadership(newLeaderCh chan func(), manualStepDownCh, stop
 			return
 		}
 
+		// Refuse to become active on storage that an online storage
+		// migration has moved away from
+		if err := c.checkStorageMigrationLock(namespace.RootContext(nil)); err != nil {
+			c.logger.Error("storage migration check failed", "error", err)
+			c.barrier.Seal()
+			c.logger.Warn("vault is sealed")
+			lock.Unlock()
+			close(continueCh)
+			c.stateLock.Unlock()
+			metrics.MeasureSince([]string{"core", "leadership_setup_failed"}, activeTime)
+			return
+		}
+
 		// Store the lock so that we can manually clear it later if needed
 		c.heldHALock = lock
 
@@ -975,6 +988,10 @@ func (c *Core) periodicCheckKeyUpgrades(ctx context.Context, stopCh chan struct{
 					c.logger.Error("key rotation periodic upgrade check failed", "error", err)
 				}
 
+				if err := c.barrier.ReloadPathBindingRequired(ctx); err != nil {
+					c.logger.Error("path binding periodic reload failed", "error", err)
+				}
+
 				if isRaft {
 					hasState, err := raftBackend.HasState()
 					if err != nil {
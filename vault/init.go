@@ -140,6 +140,11 @@ func (c *Core) generateShares(sc *SealConfig) ([]byte, [][]byte, error) {
 		unsealKeys = shares
 	}
 
+	// Store the verifiers of the shares before they are encrypted
+	if err := sc.SetShareVerifiers(c.secureRandomReader, unsealKeys); err != nil {
+		return nil, nil, err
+	}
+
 	// If we have PGP keys, perform the encryption
 	if len(sc.PGPKeys) > 0 {
 		hexEncodedShares := make([][]byte, len(unsealKeys))
@@ -309,6 +314,13 @@ func (c *Core) Initialize(ctx context.Context, initParams *InitParams) (*InitRes
 		}
 	}()
 
+	// With auto-unseal the root key is stored by the seal and no share of it
+	// is handed out, so there is nothing to verify
+	if c.seal.StoredKeysSupported() == seal.StoredKeysSupportedGeneric {
+		barrierConfig.ShareVerifierSalt = nil
+		barrierConfig.ShareVerifiers = nil
+	}
+
 	err = c.seal.SetBarrierConfig(ctx, barrierConfig)
 	if err != nil {
 		c.logger.Error("failed to save barrier configuration", "error", err)
@@ -374,25 +386,30 @@ func (c *Core) Initialize(ctx context.Context, initParams *InitParams) (*InitRes
 	// disabled. When using recovery keys they are stored in the barrier, so
 	// this must happen post-unseal.
 	if c.seal.RecoveryKeySupported() {
-		err = c.seal.SetRecoveryConfig(ctx, recoveryConfig)
-		if err != nil {
-			c.logger.Error("failed to save recovery configuration", "error", err)
-			return nil, fmt.Errorf("recovery configuration saving failed: %w", err)
-		}
-
+		// The shares are generated before saving the configuration, which
+		// stores their verifiers
+		var recoveryKey []byte
 		if recoveryConfig.SecretShares > 0 {
-			recoveryKey, recoveryUnsealKeys, err := c.generateShares(recoveryConfig)
+			var recoveryUnsealKeys [][]byte
+			recoveryKey, recoveryUnsealKeys, err = c.generateShares(recoveryConfig)
 			if err != nil {
 				c.logger.Error("failed to generate recovery shares", "error", err)
 				return nil, err
 			}
+			results.RecoveryShares = recoveryUnsealKeys
+		}
+
+		err = c.seal.SetRecoveryConfig(ctx, recoveryConfig)
+		if err != nil {
+			c.logger.Error("failed to save recovery configuration", "error", err)
+			return nil, fmt.Errorf("recovery configuration saving failed: %w", err)
+		}
 
+		if recoveryKey != nil {
 			err = c.seal.SetRecoveryKey(ctx, recoveryKey)
 			if err != nil {
 				return nil, err
 			}
-
-			results.RecoveryShares = recoveryUnsealKeys
 		}
 	}
 
@@ -7,9 +7,11 @@ import (
 	"bytes"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/robfig/cron/v3"
 )
 
 const (
@@ -18,11 +20,20 @@ const (
 	absoluteOperationMaximum = int64(3_865_470_566)
 	absoluteOperationMinimum = int64(1_000_000)
 	minimumRotationInterval  = 24 * time.Hour
+
+	// defaultRotationAlertThreshold is the default fraction of the maximum
+	// number of operations after which an alert is sent on the event bus.
+	defaultRotationAlertThreshold = 0.9
+
+	// rotationScheduleParseOptions are the fields of the cron expressions
+	// accepted for the rotation schedule.
+	rotationScheduleParseOptions = cron.Minute | cron.Hour | cron.Dom | cron.Month | cron.Dow
 )
 
 var (
 	defaultRotationConfig = KeyRotationConfig{
-		MaxOperations: absoluteOperationMaximum,
+		MaxOperations:  absoluteOperationMaximum,
+		AlertThreshold: defaultRotationAlertThreshold,
 	}
 	disabledRotationConfig = KeyRotationConfig{
 		Disabled: true,
@@ -42,13 +53,18 @@ type Keyring struct {
 	keys           map[uint32]*Key
 	activeTerm     uint32
 	rotationConfig KeyRotationConfig
+
+	// pathBindingRequired rejects entries stored in the legacy format that
+	// does not bind an entry to its storage path
+	pathBindingRequired bool
 }
 
 // EncodedKeyring is used for serialization of the keyring
 type EncodedKeyring struct {
-	MasterKey      []byte
-	Keys           []*Key
-	RotationConfig KeyRotationConfig
+	MasterKey           []byte
+	Keys                []*Key
+	RotationConfig      KeyRotationConfig
+	PathBindingRequired bool `json:",omitempty"`
 }
 
 // Key represents a single term, along with the key used.
@@ -58,12 +74,31 @@ type Key struct {
 	Value       []byte
 	InstallTime time.Time
 	Encryptions uint64 `json:"encryptions,omitempty"`
+
+	// RotationReason records why the key was installed, e.g. a manual
+	// rotation or the automatic rotation reason
+	RotationReason string `json:"rotation_reason,omitempty"`
+}
+
+// KeyRotation describes the installation of a key in the keyring
+type KeyRotation struct {
+	Term        uint32
+	InstallTime time.Time
+	Reason      string
 }
 
 type KeyRotationConfig struct {
 	Disabled      bool
 	MaxOperations int64
 	Interval      time.Duration
+
+	// Schedule is a cron expression, evaluated in UTC, at which the key is
+	// rotated
+	Schedule string `json:",omitempty"`
+
+	// AlertThreshold is the fraction of MaxOperations after which an alert
+	// is sent on the event bus
+	AlertThreshold float64 `json:",omitempty"`
 }
 
 // Serialize is used to create a byte encoded key
@@ -93,10 +128,11 @@ func NewKeyring() *Keyring {
 // Clone returns a new copy of the keyring
 func (k *Keyring) Clone() *Keyring {
 	clone := &Keyring{
-		rootKey:        k.rootKey,
-		keys:           make(map[uint32]*Key, len(k.keys)),
-		activeTerm:     k.activeTerm,
-		rotationConfig: k.rotationConfig,
+		rootKey:             k.rootKey,
+		keys:                make(map[uint32]*Key, len(k.keys)),
+		activeTerm:          k.activeTerm,
+		rotationConfig:      k.rotationConfig,
+		pathBindingRequired: k.pathBindingRequired,
 	}
 	for idx, key := range k.keys {
 		clone.keys[idx] = key
@@ -173,6 +209,23 @@ func (k *Keyring) TermKey(term uint32) *Key {
 	return k.keys[term]
 }
 
+// RotationHistory returns the install time and rotation reason of the keys
+// in the keyring, ordered by term
+func (k *Keyring) RotationHistory() []KeyRotation {
+	history := make([]KeyRotation, 0, len(k.keys))
+	for _, key := range k.keys {
+		history = append(history, KeyRotation{
+			Term:        key.Term,
+			InstallTime: key.InstallTime,
+			Reason:      key.RotationReason,
+		})
+	}
+	sort.Slice(history, func(i, j int) bool {
+		return history[i].Term < history[j].Term
+	})
+	return history
+}
+
 // SetRootKey is used to update the root key
 func (k *Keyring) SetRootKey(val []byte) *Keyring {
 	valCopy := make([]byte, len(val))
@@ -191,8 +244,9 @@ func (k *Keyring) RootKey() []byte {
 func (k *Keyring) Serialize() ([]byte, error) {
 	// Create the encoded entry
 	enc := EncodedKeyring{
-		MasterKey:      k.rootKey,
-		RotationConfig: k.rotationConfig,
+		MasterKey:           k.rootKey,
+		RotationConfig:      k.rotationConfig,
+		PathBindingRequired: k.pathBindingRequired,
 	}
 	for _, key := range k.keys {
 		enc.Keys = append(enc.Keys, key)
@@ -216,6 +270,7 @@ func DeserializeKeyring(buf []byte) (*Keyring, error) {
 	k.rootKey = enc.MasterKey
 	k.rotationConfig = enc.RotationConfig
 	k.rotationConfig.Sanitize()
+	k.pathBindingRequired = enc.PathBindingRequired
 	for _, key := range enc.Keys {
 		k.keys[key.Term] = key
 		if key.Term > k.activeTerm {
@@ -245,9 +300,11 @@ func (k *Keyring) Zeroize(keysToo bool) {
 
 func (c KeyRotationConfig) Clone() KeyRotationConfig {
 	clone := KeyRotationConfig{
-		MaxOperations: c.MaxOperations,
-		Interval:      c.Interval,
-		Disabled:      c.Disabled,
+		MaxOperations:  c.MaxOperations,
+		Interval:       c.Interval,
+		Disabled:       c.Disabled,
+		Schedule:       c.Schedule,
+		AlertThreshold: c.AlertThreshold,
 	}
 
 	clone.Sanitize()
@@ -264,8 +321,66 @@ func (c *KeyRotationConfig) Sanitize() {
 	if c.Interval > 0 && c.Interval < minimumRotationInterval {
 		c.Interval = minimumRotationInterval
 	}
+	if c.AlertThreshold <= 0 || c.AlertThreshold > 1 {
+		c.AlertThreshold = defaultRotationAlertThreshold
+	}
 }
 
 func (c *KeyRotationConfig) Equals(config KeyRotationConfig) bool {
-	return c.MaxOperations == config.MaxOperations && c.Interval == config.Interval
+	return c.MaxOperations == config.MaxOperations && c.Interval == config.Interval &&
+		c.Schedule == config.Schedule && c.AlertThreshold == config.AlertThreshold
+}
+
+// NextScheduledRotation returns the time of the first scheduled rotation
+// after the given install time, or the zero time if there is no schedule.
+func (c *KeyRotationConfig) NextScheduledRotation(installTime time.Time) (time.Time, error) {
+	if c.Schedule == "" {
+		return time.Time{}, nil
+	}
+	schedule, err := parseRotationSchedule(c.Schedule)
+	if err != nil {
+		return time.Time{}, err
+	}
+	return schedule.Next(installTime.UTC()), nil
+}
+
+// AlertOperations returns the number of operations after which an alert is
+// sent, before the key reaches its maximum number of operations.
+func (c *KeyRotationConfig) AlertOperations() int64 {
+	return int64(float64(c.MaxOperations) * c.AlertThreshold)
+}
+
+func parseRotationSchedule(schedule string) (cron.Schedule, error) {
+	parsed, err := cron.NewParser(rotationScheduleParseOptions).Parse(schedule)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rotation schedule %q: %w", schedule, err)
+	}
+	return parsed, nil
+}
+
+// validateRotationSchedule checks that the schedule is a valid cron
+// expression, and that it never rotates the key more often than the minimum
+// rotation interval.
+func validateRotationSchedule(schedule string) error {
+	parsed, err := parseRotationSchedule(schedule)
+	if err != nil {
+		return err
+	}
+
+	t := parsed.Next(time.Now().UTC())
+	if t.IsZero() {
+		return fmt.Errorf("rotation schedule %q never triggers", schedule)
+	}
+	// Check enough occurrences to cover a year of daily rotations
+	for i := 0; i < 400; i++ {
+		next := parsed.Next(t)
+		if next.IsZero() {
+			break
+		}
+		if next.Sub(t) < minimumRotationInterval {
+			return fmt.Errorf("rotation schedule %q must not trigger more than once every %s", schedule, minimumRotationInterval)
+		}
+		t = next
+	}
+	return nil
 }
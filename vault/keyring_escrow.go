@@ -0,0 +1,210 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/shamir"
+)
+
+const (
+	// keyringEscrowVersion is the version of the keyring escrow bundle format
+	keyringEscrowVersion = 1
+
+	// keyringEscrowKeyLength is the length of the random AES key used to
+	// encrypt the keyring in an escrow bundle
+	keyringEscrowKeyLength = 32
+
+	// keyringEscrowAAD is the additional data authenticated with the keyring
+	// in an escrow bundle
+	keyringEscrowAAD = "vault-keyring-escrow-v1"
+)
+
+// KeyringEscrow is an offline bundle of the barrier keyring, used to decrypt
+// storage when the seal is lost. The serialized keyring is encrypted with a
+// random escrow key using AES-GCM. The escrow key is split into one share per
+// PGP key with Shamir's secret sharing, and each share is encrypted with its
+// PGP key after being hex encoded. With a threshold of one, every PGP key gets
+// the whole escrow key.
+type KeyringEscrow struct {
+	Version          int       `json:"version"`
+	Term             uint32    `json:"term"`
+	CreatedTime      time.Time `json:"created_time"`
+	Threshold        int       `json:"threshold"`
+	PGPFingerprints  []string  `json:"pgp_fingerprints"`
+	EncryptedShares  []string  `json:"encrypted_shares"`
+	EncryptedKeyring string    `json:"encrypted_keyring"`
+}
+
+// EscrowKeyring exports the barrier keyring as an escrow bundle encrypted to
+// the given PGP keys. threshold is the number of shares required to open the
+// bundle.
+func (c *Core) EscrowKeyring(ctx context.Context, pgpKeys []string, threshold int) (*KeyringEscrow, error) {
+	if len(pgpKeys) == 0 {
+		return nil, errors.New("at least one PGP key is required")
+	}
+	if threshold < 1 || threshold > len(pgpKeys) {
+		return nil, fmt.Errorf("threshold must be between 1 and the number of PGP keys (%d)", len(pgpKeys))
+	}
+	if threshold == 1 && len(pgpKeys) > 1 {
+		c.logger.Warn("escrowing keyring with a threshold of one, each PGP key can open the bundle")
+	}
+
+	keyring, err := c.barrier.Keyring()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read keyring: %w", err)
+	}
+
+	plaintext, err := keyring.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("failed to serialize keyring: %w", err)
+	}
+	defer memzero(plaintext)
+
+	escrowKey := make([]byte, keyringEscrowKeyLength)
+	if _, err := io.ReadFull(c.secureRandomReader, escrowKey); err != nil {
+		return nil, fmt.Errorf("failed to generate escrow key: %w", err)
+	}
+	defer memzero(escrowKey)
+
+	ciphertext, err := keyringEscrowSeal(c.secureRandomReader, escrowKey, plaintext)
+	if err != nil {
+		return nil, err
+	}
+
+	var shares [][]byte
+	if threshold == 1 {
+		for range pgpKeys {
+			shares = append(shares, escrowKey)
+		}
+	} else {
+		shares, err = shamir.Split(escrowKey, len(pgpKeys), threshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to split escrow key: %w", err)
+		}
+	}
+
+	// Hex encode the shares as init does for unseal keys, so that they are
+	// printable once decrypted with PGP
+	hexEncodedShares := make([][]byte, len(shares))
+	for i := range shares {
+		hexEncodedShares[i] = []byte(hex.EncodeToString(shares[i]))
+	}
+	fingerprints, encryptedShares, err := pgpkeys.EncryptShares(hexEncodedShares, pgpKeys)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt escrow key shares: %w", err)
+	}
+
+	escrow := &KeyringEscrow{
+		Version:          keyringEscrowVersion,
+		Term:             keyring.ActiveTerm(),
+		CreatedTime:      time.Now().UTC(),
+		Threshold:        threshold,
+		PGPFingerprints:  fingerprints,
+		EncryptedKeyring: base64.StdEncoding.EncodeToString(ciphertext),
+	}
+	for _, share := range encryptedShares {
+		escrow.EncryptedShares = append(escrow.EncryptedShares, base64.StdEncoding.EncodeToString(share))
+	}
+
+	c.logger.Info("keyring escrow bundle created", "term", escrow.Term, "threshold", threshold, "pgp_fingerprints", fingerprints)
+	return escrow, nil
+}
+
+// OpenKeyringEscrow decrypts the keyring of an escrow bundle using at least
+// threshold escrow key shares, already decrypted with their PGP keys and hex
+// decoded.
+func OpenKeyringEscrow(escrow *KeyringEscrow, shares [][]byte) (*Keyring, error) {
+	if escrow == nil {
+		return nil, errors.New("missing keyring escrow bundle")
+	}
+	if escrow.Version != keyringEscrowVersion {
+		return nil, fmt.Errorf("unsupported keyring escrow bundle version %d", escrow.Version)
+	}
+	if len(shares) < escrow.Threshold {
+		return nil, fmt.Errorf("%d escrow key shares are required, got %d", escrow.Threshold, len(shares))
+	}
+
+	var escrowKey []byte
+	if escrow.Threshold == 1 {
+		escrowKey = shares[0]
+	} else {
+		var err error
+		escrowKey, err = shamir.Combine(shares)
+		if err != nil {
+			return nil, fmt.Errorf("failed to combine escrow key shares: %w", err)
+		}
+		defer memzero(escrowKey)
+	}
+
+	ciphertext, err := base64.StdEncoding.DecodeString(escrow.EncryptedKeyring)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode encrypted keyring: %w", err)
+	}
+	plaintext, err := keyringEscrowOpen(escrowKey, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	defer memzero(plaintext)
+
+	return DeserializeKeyring(plaintext)
+}
+
+// ParseKeyringEscrow parses a JSON encoded keyring escrow bundle.
+func ParseKeyringEscrow(buf []byte) (*KeyringEscrow, error) {
+	escrow := new(KeyringEscrow)
+	if err := jsonutil.DecodeJSON(buf, escrow); err != nil {
+		return nil, fmt.Errorf("failed to parse keyring escrow bundle: %w", err)
+	}
+	return escrow, nil
+}
+
+func keyringEscrowAEAD(key []byte) (cipher.AEAD, error) {
+	aesCipher, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	return cipher.NewGCM(aesCipher)
+}
+
+// keyringEscrowSeal encrypts the plaintext with the key, prefixing the nonce
+// to the ciphertext.
+func keyringEscrowSeal(rand io.Reader, key, plaintext []byte) ([]byte, error) {
+	gcm, err := keyringEscrowAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return gcm.Seal(nonce, nonce, plaintext, []byte(keyringEscrowAAD)), nil
+}
+
+func keyringEscrowOpen(key, ciphertext []byte) ([]byte, error) {
+	gcm, err := keyringEscrowAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+	if len(ciphertext) < gcm.NonceSize() {
+		return nil, errors.New("encrypted keyring is too short")
+	}
+	nonce, ciphertext := ciphertext[:gcm.NonceSize()], ciphertext[gcm.NonceSize():]
+	plaintext, err := gcm.Open(nil, nonce, ciphertext, []byte(keyringEscrowAAD))
+	if err != nil {
+		return nil, errors.New("failed to decrypt keyring, the escrow key shares may be incorrect")
+	}
+	return plaintext, nil
+}
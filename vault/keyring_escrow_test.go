@@ -0,0 +1,110 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_EscrowKeyring verifies that a keyring escrow bundle can be opened
+// offline with a threshold of the PGP decrypted shares and yields the barrier
+// keyring.
+func TestCore_EscrowKeyring(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	// Rotate so that the bundle holds more than one term
+	_, err := c.barrier.Rotate(ctx, c.secureRandomReader, manualRotateReason)
+	require.NoError(t, err)
+	expected, err := c.barrier.Keyring()
+	require.NoError(t, err)
+
+	pubKeys := []string{pgpkeys.TestPubKey1, pgpkeys.TestPubKey2, pgpkeys.TestPubKey3}
+	privKeys := []string{pgpkeys.TestPrivKey1, pgpkeys.TestPrivKey2, pgpkeys.TestPrivKey3}
+
+	tests := map[string]struct {
+		threshold int
+		use       []int
+		wantErr   string
+	}{
+		"threshold 1": {
+			threshold: 1,
+			use:       []int{2},
+		},
+		"threshold 2": {
+			threshold: 2,
+			use:       []int{0, 2},
+		},
+		"threshold 3": {
+			threshold: 3,
+			use:       []int{0, 1, 2},
+		},
+		"not enough shares": {
+			threshold: 2,
+			use:       []int{1},
+			wantErr:   "2 escrow key shares are required",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			escrow, err := c.EscrowKeyring(ctx, pubKeys, tc.threshold)
+			require.NoError(t, err)
+			require.Equal(t, uint32(2), escrow.Term)
+			require.Len(t, escrow.EncryptedShares, 3)
+			require.Len(t, escrow.PGPFingerprints, 3)
+
+			// Round trip the bundle through JSON as an operator would
+			buf, err := json.Marshal(escrow)
+			require.NoError(t, err)
+			escrow, err = ParseKeyringEscrow(buf)
+			require.NoError(t, err)
+
+			var shares [][]byte
+			for _, i := range tc.use {
+				decrypted, err := pgpkeys.DecryptBytes(escrow.EncryptedShares[i], privKeys[i])
+				require.NoError(t, err)
+				share, err := hex.DecodeString(decrypted.String())
+				require.NoError(t, err)
+				shares = append(shares, share)
+			}
+
+			keyring, err := OpenKeyringEscrow(escrow, shares)
+			if tc.wantErr != "" {
+				require.ErrorContains(t, err, tc.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, expected.RootKey(), keyring.RootKey())
+			require.Equal(t, expected.ActiveTerm(), keyring.ActiveTerm())
+			for term := uint32(1); term <= expected.ActiveTerm(); term++ {
+				require.Equal(t, expected.TermKey(term).Value, keyring.TermKey(term).Value)
+			}
+		})
+	}
+}
+
+// TestCore_EscrowKeyring_Invalid verifies the validation of escrow requests
+// and that a bundle is not opened with a wrong key.
+func TestCore_EscrowKeyring_Invalid(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	_, err := c.EscrowKeyring(ctx, nil, 1)
+	require.ErrorContains(t, err, "at least one PGP key is required")
+
+	_, err = c.EscrowKeyring(ctx, []string{pgpkeys.TestPubKey1}, 2)
+	require.ErrorContains(t, err, "threshold must be between 1 and the number of PGP keys")
+
+	escrow, err := c.EscrowKeyring(ctx, []string{pgpkeys.TestPubKey1}, 1)
+	require.NoError(t, err)
+
+	_, err = OpenKeyringEscrow(escrow, [][]byte{make([]byte, keyringEscrowKeyLength)})
+	require.ErrorContains(t, err, "failed to decrypt keyring")
+}
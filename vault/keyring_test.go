@@ -8,6 +8,8 @@ import (
 	"reflect"
 	"testing"
 	"time"
+
+	"github.com/stretchr/testify/require"
 )
 
 func TestKeyring(t *testing.T) {
@@ -209,3 +211,65 @@ func TestKey_Serialize(t *testing.T) {
 		t.Fatalf("bad: %#v", out)
 	}
 }
+
+func TestKeyring_RotationHistory(t *testing.T) {
+	k := NewKeyring()
+	k.rotationConfig.Schedule = "0 3 * * *"
+
+	now := time.Now()
+	k, _ = k.AddKey(&Key{Term: 2, Version: 1, Value: []byte("second"), InstallTime: now, RotationReason: manualRotateReason})
+	k, _ = k.AddKey(&Key{Term: 1, Version: 1, Value: []byte("first"), InstallTime: now.Add(-time.Hour), RotationReason: initializeRotateReason})
+
+	buf, err := k.Serialize()
+	require.NoError(t, err)
+	k2, err := DeserializeKeyring(buf)
+	require.NoError(t, err)
+
+	require.Equal(t, "0 3 * * *", k2.rotationConfig.Schedule)
+	require.Equal(t, defaultRotationAlertThreshold, k2.rotationConfig.AlertThreshold)
+
+	history := k2.RotationHistory()
+	require.Len(t, history, 2)
+	require.Equal(t, uint32(1), history[0].Term)
+	require.Equal(t, initializeRotateReason, history[0].Reason)
+	require.True(t, now.Add(-time.Hour).Equal(history[0].InstallTime))
+	require.Equal(t, uint32(2), history[1].Term)
+	require.Equal(t, manualRotateReason, history[1].Reason)
+}
+
+func TestValidateRotationSchedule(t *testing.T) {
+	for schedule, wantErr := range map[string]string{
+		"0 3 * * *":       "",
+		"0 3 * * 0":       "",
+		"30 1 1 */3 *":    "",
+		"0 */12 * * *":    "must not trigger more than once every 24h0m0s",
+		"* * * * *":       "must not trigger more than once every 24h0m0s",
+		"0 3 * * 1,2":     "",
+		"0 3 30 2 *":      "never triggers",
+		"not a schedule":  "invalid rotation schedule",
+		"0 3 * * * extra": "invalid rotation schedule",
+	} {
+		t.Run(schedule, func(t *testing.T) {
+			err := validateRotationSchedule(schedule)
+			if wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorContains(t, err, wantErr)
+		})
+	}
+}
+
+func TestKeyRotationConfig_NextScheduledRotation(t *testing.T) {
+	rc := KeyRotationConfig{Schedule: "0 3 * * *"}
+	installTime := time.Date(2024, 5, 1, 3, 2, 0, 0, time.UTC)
+
+	next, err := rc.NextScheduledRotation(installTime)
+	require.NoError(t, err)
+	require.Equal(t, time.Date(2024, 5, 2, 3, 0, 0, 0, time.UTC), next)
+
+	rc.Schedule = ""
+	next, err = rc.NextScheduledRotation(installTime)
+	require.NoError(t, err)
+	require.True(t, next.IsZero())
+}
@@ -123,6 +123,8 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"replication/dr/reindex",
 				"replication/performance/reindex",
 				"rotate",
+				"rotate/path-binding",
+				"keyring/escrow",
 				"config/cors",
 				"config/auditing/*",
 				"config/ui/headers/*",
@@ -134,6 +136,9 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"leases/revoke-force/*",
 				"leases/lookup/*",
 				"storage/raft/snapshot-auto/config/*",
+				"storage/migration",
+				"storage/migration/cutover",
+				"sealwrap/rewrap",
 				"leases",
 				"internal/inspect/*",
 				"internal/counters/activity/export",
@@ -171,6 +176,7 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 				"init",
 				"seal-status",
 				"unseal",
+				"unseal-verify",
 				"leader",
 				"health",
 				"generate-root/attempt",
@@ -233,6 +239,9 @@ func NewSystemBackend(core *Core, logger log.Logger, config *logical.BackendConf
 	b.Backend.Paths = append(b.Backend.Paths, b.introspectionPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.wellKnownPaths()...)
 	b.Backend.Paths = append(b.Backend.Paths, b.activationFlagsPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.raftAutoSnapshotPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.storageMigrationPaths()...)
+	b.Backend.Paths = append(b.Backend.Paths, b.sealRewrapPaths()...)
 
 	if core.rawEnabled {
 		b.Backend.Paths = append(b.Backend.Paths, b.rawPaths()...)
@@ -3953,6 +3962,41 @@ func (b *SystemBackend) handleAuditHash(ctx context.Context, req *logical.Reques
 	}, nil
 }
 
+// handleAuditVerify is used to verify the entries of a hash chained audit log
+// written by the specified audit backend. Only whether the entries are valid,
+// and the offset of the first invalid entry, are returned.
+func (b *SystemBackend) handleAuditVerify(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	path := sanitizePath(data.Get("path").(string))
+	entries := data.Get("entries").([]string)
+	if len(entries) == 0 {
+		return logical.ErrorResponse("the \"entries\" parameter is empty"), nil
+	}
+
+	lines := make([][]byte, 0, len(entries))
+	for _, entry := range entries {
+		lines = append(lines, []byte(entry))
+	}
+	var previous []byte
+	if p := data.Get("previous").(string); p != "" {
+		previous = []byte(p)
+	}
+
+	offset, err := b.Core.auditBroker.VerifyHashChain(ctx, path, previous, lines)
+	if err != nil {
+		return logical.ErrorResponse(err.Error()), nil
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"valid": offset < 0,
+		},
+	}
+	if offset >= 0 {
+		resp.Data["first_invalid"] = offset
+	}
+	return resp, nil
+}
+
 // handleEnableAudit is used to enable a new audit backend
 func (b *SystemBackend) handleEnableAudit(ctx context.Context, _ *logical.Request, data *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
@@ -4123,11 +4167,59 @@ func (b *SystemBackend) handleKeyStatus(ctx context.Context, req *logical.Reques
 		return nil, err
 	}
 
+	keyring, err := b.Core.barrier.Keyring()
+	if err != nil {
+		return nil, err
+	}
+	history := make([]map[string]interface{}, 0)
+	for _, rotation := range keyring.RotationHistory() {
+		entry := map[string]interface{}{
+			"term":   rotation.Term,
+			"reason": rotation.Reason,
+		}
+		if !rotation.InstallTime.IsZero() {
+			entry["install_time"] = rotation.InstallTime.Format(time.RFC3339Nano)
+		}
+		history = append(history, entry)
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"term":             info.Term,
+			"install_time":     info.InstallTime.Format(time.RFC3339Nano),
+			"encryptions":      info.Encryptions,
+			"rotation_history": history,
+		},
+	}
+	return resp, nil
+}
+
+// handleKeyringEscrow exports the barrier keyring as a bundle encrypted to a
+// set of PGP keys
+func (b *SystemBackend) handleKeyringEscrow(ctx context.Context, _ *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	pgpKeys := data.Get("pgp_keys").([]string)
+	if len(pgpKeys) == 0 {
+		return logical.ErrorResponse("at least one PGP key must be provided in pgp_keys"), logical.ErrInvalidRequest
+	}
+	threshold := data.Get("threshold").(int)
+	if threshold < 1 || threshold > len(pgpKeys) {
+		return logical.ErrorResponse("threshold must be between 1 and the number of PGP keys"), logical.ErrInvalidRequest
+	}
+
+	escrow, err := b.Core.EscrowKeyring(ctx, pgpKeys, threshold)
+	if err != nil {
+		return nil, err
+	}
+
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"term":         info.Term,
-			"install_time": info.InstallTime.Format(time.RFC3339Nano),
-			"encryptions":  info.Encryptions,
+			"version":           escrow.Version,
+			"term":              escrow.Term,
+			"created_time":      escrow.CreatedTime.Format(time.RFC3339Nano),
+			"threshold":         escrow.Threshold,
+			"pgp_fingerprints":  escrow.PGPFingerprints,
+			"encrypted_shares":  escrow.EncryptedShares,
+			"encrypted_keyring": escrow.EncryptedKeyring,
 		},
 	}
 	return resp, nil
@@ -4143,8 +4235,10 @@ func (b *SystemBackend) handleKeyRotationConfigRead(_ context.Context, _ *logica
 
 	resp := &logical.Response{
 		Data: map[string]interface{}{
-			"max_operations": rotConfig.MaxOperations,
-			"enabled":        !rotConfig.Disabled,
+			"max_operations":  rotConfig.MaxOperations,
+			"enabled":         !rotConfig.Disabled,
+			"schedule":        rotConfig.Schedule,
+			"alert_threshold": rotConfig.AlertThreshold,
 		},
 	}
 	if rotConfig.Interval > 0 {
@@ -4184,6 +4278,22 @@ func (b *SystemBackend) handleKeyRotationConfigUpdate(ctx context.Context, req *
 		rotConfig.Disabled = !enabled.(bool)
 	}
 
+	schedule, ok, err := data.GetOkErr("schedule")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		rotConfig.Schedule = strings.TrimSpace(schedule.(string))
+	}
+
+	alertThreshold, ok, err := data.GetOkErr("alert_threshold")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		rotConfig.AlertThreshold = alertThreshold.(float64)
+	}
+
 	// Reject out of range settings
 	if rotConfig.Interval < minimumRotationInterval && rotConfig.Interval != 0 {
 		return logical.ErrorResponse("interval must be greater or equal to %s", minimumRotationInterval.String()), logical.ErrInvalidRequest
@@ -4193,6 +4303,16 @@ func (b *SystemBackend) handleKeyRotationConfigUpdate(ctx context.Context, req *
 		return logical.ErrorResponse("max_operations must be in the range [%d,%d]", absoluteOperationMinimum, absoluteOperationMaximum), logical.ErrInvalidRequest
 	}
 
+	if rotConfig.AlertThreshold <= 0 || rotConfig.AlertThreshold > 1 {
+		return logical.ErrorResponse("alert_threshold must be in the range (0,1]"), logical.ErrInvalidRequest
+	}
+
+	if rotConfig.Schedule != "" {
+		if err := validateRotationSchedule(rotConfig.Schedule); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+	}
+
 	// Store the rotation config
 	err = b.Core.barrier.SetRotationConfig(ctx, rotConfig)
 	if err != nil {
@@ -4202,6 +4322,60 @@ func (b *SystemBackend) handleKeyRotationConfigUpdate(ctx context.Context, req *
 	return nil, nil
 }
 
+// handlePathBindingRead returns whether the barrier requires entries to be
+// bound to their storage path and the status of the last upgrade
+func (b *SystemBackend) handlePathBindingRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	required, err := b.Core.barrier.PathBindingRequired()
+	if err != nil {
+		return nil, err
+	}
+
+	resp := &logical.Response{
+		Data: map[string]interface{}{
+			"required": required,
+		},
+	}
+	if status := b.Core.readPathBindingUpgradeStatus(); status != nil {
+		resp.Data["upgrade_state"] = status.State
+		resp.Data["entries_upgraded"] = status.EntriesUpgraded
+		if status.Error != "" {
+			resp.Data["upgrade_error"] = status.Error
+		}
+	}
+	return resp, nil
+}
+
+// handlePathBindingUpdate requires or stops requiring entries to be bound to
+// their storage path. Requiring it first upgrades all existing entries in the
+// background.
+func (b *SystemBackend) handlePathBindingUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	repState := b.Core.ReplicationState()
+	if repState.HasState(consts.ReplicationPerformanceSecondary) {
+		return logical.ErrorResponse("cannot configure path binding on a replication secondary"), nil
+	}
+
+	required, ok, err := data.GetOkErr("required")
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return logical.ErrorResponse("required must be set"), logical.ErrInvalidRequest
+	}
+
+	if !required.(bool) {
+		if err := b.Core.barrier.SetPathBindingRequired(ctx, false); err != nil {
+			return handleError(err)
+		}
+		return nil, nil
+	}
+
+	// Use the active context so the upgrade outlives the request
+	if err := b.Core.startPathBindingUpgrade(b.Core.activeContext); err != nil {
+		return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+	}
+	return logical.RespondWithStatusCode(nil, req, http.StatusAccepted)
+}
+
 // handleRotate is used to trigger a key rotation
 func (b *SystemBackend) handleRotate(ctx context.Context, _ *logical.Request, _ *framework.FieldData) (*logical.Response, error) {
 	repState := b.Core.ReplicationState()
@@ -4209,7 +4383,7 @@ func (b *SystemBackend) handleRotate(ctx context.Context, _ *logical.Request, _
 		return logical.ErrorResponse("cannot rotate on a replication secondary"), nil
 	}
 
-	if err := b.rotateBarrierKey(ctx); err != nil {
+	if err := b.rotateBarrierKey(ctx, manualRotateReason); err != nil {
 		b.Backend.Logger().Error("error handling key rotation", "error", err)
 		return handleError(err)
 	}
@@ -5839,13 +6013,13 @@ func (b *SystemBackend) verifyDROperationTokenOnSecondary(f framework.OperationF
 	return f
 }
 
-func (b *SystemBackend) rotateBarrierKey(ctx context.Context) error {
+func (b *SystemBackend) rotateBarrierKey(ctx context.Context, reason string) error {
 	// Rotate to the new term
-	newTerm, err := b.Core.barrier.Rotate(ctx, b.Core.secureRandomReader)
+	newTerm, err := b.Core.barrier.Rotate(ctx, b.Core.secureRandomReader, reason)
 	if err != nil {
 		return errwrap.Wrap(errors.New("failed to create new encryption key"), err)
 	}
-	b.Backend.Logger().Info("installed new encryption key")
+	b.Backend.Logger().Info("installed new encryption key", "term", newTerm, "reason", reason)
 
 	// In HA mode, we need to an upgrade path for the standby instances
 	if b.Core.ha != nil && b.Core.KeyRotateGracePeriod() > 0 {
@@ -6341,6 +6515,17 @@ This path responds to the following HTTP methods.
         Unseals the Vault.
 		`,
 	},
+	"unseal-verify": {
+		"Verifies a key share without unsealing the Vault.",
+		`
+This path responds to the following HTTP methods.
+
+    PUT /
+        Checks that a key share belongs to the current split of the unseal
+        key, or of the recovery key with auto-unseal, without using it to
+        unseal and without revealing the key.
+		`,
+	},
 	"mounts": {
 		"List the currently mounted backends.",
 		`
@@ -6686,6 +6871,16 @@ or delete a policy.
 		"",
 	},
 
+	"audit-verify": {
+		"Verify the entries of a hash chained audit log written by the given audit backend",
+		`
+Verifies the sequence numbers and chained HMACs of the entries, which are
+keyed with a key derived from the salt of the audit backend. The key and the
+HMACs are never returned: the response only tells whether the entries are
+valid, and the offset of the first invalid entry otherwise.
+		`,
+	},
+
 	"audit-table": {
 		"List the currently enabled audit backends.",
 		`
@@ -6739,10 +6934,32 @@ Enable a new audit backend or disable an existing backend.
 	"key-status": {
 		"Provides information about the backend encryption key.",
 		`
-		Provides the current backend encryption key term and installation time.
+		Provides the current backend encryption key term and installation time,
+		along with the history of the key rotations and their reasons.
 		`,
 	},
 
+	"keyring-escrow": {
+		"Exports the backend encryption keyring encrypted to a set of PGP keys.",
+		`
+		Exports the backend encryption keyring as an offline bundle for disaster
+		recovery. The keyring is encrypted with a random escrow key, which is
+		split into one share per PGP key and encrypted with that key. A threshold
+		number of the shares can open the bundle and decrypt a snapshot of the
+		storage without the seal.
+		`,
+	},
+
+	"keyring-escrow-pgp-keys": {
+		"Base64-encoded PGP public keys to encrypt the escrow key shares with.",
+		"",
+	},
+
+	"keyring-escrow-threshold": {
+		"Number of escrow key shares required to open the bundle.",
+		"",
+	},
+
 	"rotate-config": {
 		"Configures settings related to the backend encryption key management.",
 		`
@@ -6750,6 +6967,36 @@ Enable a new audit backend or disable an existing backend.
 		`,
 	},
 
+	"rotate-path-binding": {
+		"Configures whether encrypted entries must be bound to their storage path.",
+		`
+		Entries written by the barrier are bound to their storage path, so that
+		an entry which is copied or moved to a different path fails to decrypt.
+		Entries written by older versions of Vault are not bound to their path.
+		Setting required to true upgrades every such entry in the background and
+		then rejects any entry which is not bound to its path. Reading this
+		endpoint returns the setting and the status of the upgrade.
+		`,
+	},
+
+	"sealwrap-rewrap": {
+		"Rewraps the seal wrapped entries under the current seal generation.",
+		`
+		Seal wrapped entries, such as the root key, are encrypted by the seal.
+		After the seals are changed or the key of a seal is rotated, existing
+		entries remain encrypted with the previous seal generation or key until
+		they are rewrapped. Writing to this endpoint rewraps every such entry in
+		the background, after which the previous seal key is no longer needed.
+		A rewrap is also started automatically after a seal migration. Reading
+		this endpoint returns the status of the last rewrap.
+		`,
+	},
+
+	"path-binding-required": {
+		"Whether entries which are not bound to their storage path are rejected.",
+		"",
+	},
+
 	"rotation-enabled": {
 		"Whether automatic rotation is enabled.",
 		"",
@@ -6762,6 +7009,14 @@ Enable a new audit backend or disable an existing backend.
 		"How long after installation of an active key term that the key will be automatically rotated.",
 		"",
 	},
+	"rotation-schedule": {
+		"A cron expression, evaluated in UTC, at which the barrier key is automatically rotated.",
+		"",
+	},
+	"rotation-alert-threshold": {
+		"The fraction of max_operations after which an alert is sent on the event bus.",
+		"",
+	},
 	"rotate": {
 		"Rotates the backend encryption key used to persist data.",
 		`
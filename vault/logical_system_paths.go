@@ -263,6 +263,14 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 					Type:        framework.TypeString,
 					Description: "Specifies a base64-encoded PGP public key.",
 				},
+				"ttl": {
+					Type:        framework.TypeDurationSecond,
+					Description: "The TTL of the generated root token. The token cannot be renewed. If not set, the token does not expire.",
+				},
+				"policies": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: "Policies to give the generated token instead of the root policy. Requires a TTL.",
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -316,6 +324,14 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 									Type:     framework.TypeInt,
 									Required: true,
 								},
+								"ttl": {
+									Type:     framework.TypeDurationSecond,
+									Required: false,
+								},
+								"policies": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: false,
+								},
 							},
 						}},
 					},
@@ -371,6 +387,14 @@ func (b *SystemBackend) configPaths() []*framework.Path {
 									Type:     framework.TypeInt,
 									Required: true,
 								},
+								"ttl": {
+									Type:     framework.TypeDurationSecond,
+									Required: false,
+								},
+								"policies": {
+									Type:     framework.TypeCommaStringSlice,
+									Required: false,
+								},
 							},
 						}},
 					},
@@ -1266,6 +1290,46 @@ func (b *SystemBackend) rekeyPaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysHelp["unseal"][0]),
 			HelpDescription: strings.TrimSpace(sysHelp["unseal"][1]),
 		},
+
+		{
+			Pattern: "unseal-verify$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "unseal",
+				OperationVerb:   "verify",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"key": {
+					Type:        framework.TypeString,
+					Description: "Specifies a single unseal key share, or recovery key share with auto-unseal.",
+					Required:    true,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Summary: "Verify that a key share belongs to the current split, without unsealing.",
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Fields: map[string]*framework.FieldSchema{
+								"valid": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"recovery_key": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+							},
+						}},
+					},
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["unseal-verify"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["unseal-verify"][1]),
+		},
 	}
 }
 
@@ -1533,9 +1597,63 @@ func (b *SystemBackend) auditHashPath() *framework.Path {
 	}
 }
 
+func (b *SystemBackend) auditVerifyPath() *framework.Path {
+	return &framework.Path{
+		Pattern: "audit-verify/(?P<path>.+)",
+
+		DisplayAttrs: &framework.DisplayAttributes{
+			OperationPrefix: "auditing",
+			OperationVerb:   "verify",
+			OperationSuffix: "hash-chain",
+		},
+
+		Fields: map[string]*framework.FieldSchema{
+			"path": {
+				Type:        framework.TypeString,
+				Description: strings.TrimSpace(sysHelp["audit_path"][0]),
+			},
+
+			"entries": {
+				Type:        framework.TypeStringSlice,
+				Description: "Entries of the hash chained audit log to verify, in the order they were written.",
+			},
+
+			"previous": {
+				Type:        framework.TypeString,
+				Description: "Entry of the audit log preceding the first entry, which the chain carries on from.",
+			},
+		},
+
+		Operations: map[logical.Operation]framework.OperationHandler{
+			logical.UpdateOperation: &framework.PathOperation{
+				Callback: b.handleAuditVerify,
+				Responses: map[int][]framework.Response{
+					http.StatusOK: {{
+						Description: "OK",
+						Fields: map[string]*framework.FieldSchema{
+							"valid": {
+								Type:     framework.TypeBool,
+								Required: true,
+							},
+							"first_invalid": {
+								Type:     framework.TypeInt,
+								Required: false,
+							},
+						},
+					}},
+				},
+			},
+		},
+
+		HelpSynopsis:    strings.TrimSpace(sysHelp["audit-verify"][0]),
+		HelpDescription: strings.TrimSpace(sysHelp["audit-verify"][1]),
+	}
+}
+
 func (b *SystemBackend) auditPaths() []*framework.Path {
 	return []*framework.Path{
 		b.auditHashPath(),
+		b.auditVerifyPath(),
 
 		{
 			Pattern: "audit$",
@@ -1763,6 +1881,14 @@ func (b *SystemBackend) sealPaths() []*framework.Path {
 					Type:        framework.TypeDurationSecond,
 					Description: strings.TrimSpace(sysHelp["rotation-interval"][0]),
 				},
+				"schedule": {
+					Type:        framework.TypeString,
+					Description: strings.TrimSpace(sysHelp["rotation-schedule"][0]),
+				},
+				"alert_threshold": {
+					Type:        framework.TypeFloat,
+					Description: strings.TrimSpace(sysHelp["rotation-alert-threshold"][0]),
+				},
 			},
 
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -1788,6 +1914,14 @@ func (b *SystemBackend) sealPaths() []*framework.Path {
 									Type:     framework.TypeDurationSecond,
 									Required: true,
 								},
+								"schedule": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+								"alert_threshold": {
+									Type:     framework.TypeFloat,
+									Required: true,
+								},
 							},
 						}},
 					},
@@ -1812,6 +1946,141 @@ func (b *SystemBackend) sealPaths() []*framework.Path {
 			HelpDescription: strings.TrimSpace(sysHelp["rotate-config"][1]),
 		},
 
+		{
+			Pattern: "rotate/path-binding$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "encryption-key",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"required": {
+					Type:        framework.TypeBool,
+					Description: strings.TrimSpace(sysHelp["path-binding-required"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handlePathBindingRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "path-binding",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"required": {
+									Type:     framework.TypeBool,
+									Required: true,
+								},
+								"upgrade_state": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+								"entries_upgraded": {
+									Type:     framework.TypeInt,
+									Required: false,
+								},
+								"upgrade_error": {
+									Type:     framework.TypeString,
+									Required: false,
+								},
+							},
+						}},
+					},
+					ForwardPerformanceStandby: true,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handlePathBindingUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "configure",
+						OperationSuffix: "path-binding",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+						http.StatusAccepted: {{
+							Description: "Accepted",
+						}},
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["rotate-path-binding"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["rotate-path-binding"][1]),
+		},
+
+		{
+			Pattern: "keyring/escrow$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "encryption-keyring",
+				OperationVerb:   "escrow",
+			},
+
+			Fields: map[string]*framework.FieldSchema{
+				"pgp_keys": {
+					Type:        framework.TypeCommaStringSlice,
+					Description: strings.TrimSpace(sysHelp["keyring-escrow-pgp-keys"][0]),
+					Required:    true,
+				},
+				"threshold": {
+					Type:        framework.TypeInt,
+					Default:     1,
+					Description: strings.TrimSpace(sysHelp["keyring-escrow-threshold"][0]),
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleKeyringEscrow,
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields: map[string]*framework.FieldSchema{
+								"version": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"term": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"created_time": {
+									Type:     framework.TypeTime,
+									Required: true,
+								},
+								"threshold": {
+									Type:     framework.TypeInt,
+									Required: true,
+								},
+								"pgp_fingerprints": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"encrypted_shares": {
+									Type:     framework.TypeStringSlice,
+									Required: true,
+								},
+								"encrypted_keyring": {
+									Type:     framework.TypeString,
+									Required: true,
+								},
+							},
+						}},
+					},
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["keyring-escrow"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["keyring-escrow"][1]),
+		},
+
 		{
 			Pattern: "rotate$",
 
@@ -10,19 +10,20 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"sort"
 	"strings"
 	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/go-uuid"
 	snapshot "github.com/hashicorp/raft-snapshot"
-	"github.com/hashicorp/vault/helper/constants"
 	"github.com/hashicorp/vault/helper/namespace"
 	"github.com/hashicorp/vault/physical/raft"
 	"github.com/hashicorp/vault/sdk/framework"
 	"github.com/hashicorp/vault/sdk/logical"
 	"github.com/hashicorp/vault/sdk/physical"
 	"github.com/hashicorp/vault/vault/seal"
+	"github.com/hashicorp/vault/vault/snapshots"
 	"github.com/mitchellh/mapstructure"
 )
 
@@ -146,7 +147,7 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 					Summary:  "Returns a snapshot of the current state of vault.",
 				},
 				logical.UpdateOperation: &framework.PathOperation{
-					Callback: b.handleStorageRaftSnapshotWrite(false, makeSealer(b.logger, "snapshot_write")),
+					Callback: b.handleStorageRaftSnapshotWrite(false, makeSealer(b.logger, "snapshot_write"), b.makeSnapshotSource),
 					Summary:  "Installs the provided snapshot, returning the cluster to the state defined in it.",
 				},
 			},
@@ -158,7 +159,7 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			Pattern: "storage/raft/snapshot-force",
 			Operations: map[logical.Operation]framework.OperationHandler{
 				logical.UpdateOperation: &framework.PathOperation{
-					Callback: b.handleStorageRaftSnapshotWrite(true, makeSealer(b.logger, "snapshot_write")),
+					Callback: b.handleStorageRaftSnapshotWrite(true, makeSealer(b.logger, "snapshot_write"), b.makeSnapshotSource),
 					Summary:  "Installs the provided snapshot, returning the cluster to the state defined in it. This bypasses checks ensuring the current Autounseal or Shamir keys are consistent with the snapshot data.",
 				},
 			},
@@ -166,6 +167,44 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][0]),
 			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-force"][1]),
 		},
+		{
+			Pattern: "storage/raft/snapshot/restore-mount" + framework.OptionalParamRegex("path"),
+			Fields: map[string]*framework.FieldSchema{
+				"path": {
+					Type:        framework.TypeString,
+					Description: `Path of the mount to restore, such as "secret/" or "auth/userpass/". If mount_uuid is set, the path to restore the mount at, defaulting to its path in the snapshot.`,
+				},
+				"mount_uuid": {
+					Type:        framework.TypeString,
+					Description: "UUID of the mount to restore, looked up in the mount and auth tables of the snapshot. Passed as a query parameter.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotRestoreMount(makeSealer(b.logger, "snapshot_write")),
+					Summary:  "Restores a single mount from the provided snapshot, leaving the rest of the cluster state unchanged.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-restore-mount"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-restore-mount"][1]),
+		},
+		{
+			Pattern: "storage/raft/compact",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftCompactRead(),
+					Summary:  "Returns the status of the latest compaction of the raft storage.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftCompactUpdate(),
+					Summary:  "Compacts the raft storage of the standby nodes of the cluster, one at a time.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-compact"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-compact"][1]),
+		},
 		{
 			Pattern: "storage/raft/autopilot/state",
 			Operations: map[logical.Operation]framework.OperationHandler{
@@ -231,6 +270,185 @@ func (b *SystemBackend) raftStoragePaths() []*framework.Path {
 	}
 }
 
+// raftAutoSnapshotPaths returns the paths used to manage automatic raft
+// snapshots. They are registered regardless of the storage in use so the API
+// is stable; the handlers reject requests when raft storage is not in use.
+func (b *SystemBackend) raftAutoSnapshotPaths() []*framework.Path {
+	makeSealer := func(logger hclog.Logger, use string) func() snapshot.Sealer {
+		return func() snapshot.Sealer {
+			return NewSealAccessSealer(b.Core.seal.GetAccess(), logger, use)
+		}
+	}
+
+	return []*framework.Path{
+		{
+			Pattern: "storage/raft/snapshot-auto/config/?$",
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigList(),
+					Summary:  "Lists the automatic snapshot configurations.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/config/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the automatic snapshot configuration.",
+				},
+				"interval": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Time between snapshots.",
+				},
+				"retain": {
+					Type:        framework.TypeInt,
+					Description: "Number of snapshots to keep. Older snapshots are removed after a new one is written. Set to 0 to only prune by max_age.",
+					Default:     1,
+				},
+				"max_age": {
+					Type:        framework.TypeDurationSecond,
+					Description: "Snapshots older than this are removed after a new one is written. Set to 0 to only prune by retain.",
+				},
+				"storage_type": {
+					Type:        framework.TypeString,
+					Description: `Where to write the snapshots, either "local" or "aws-s3".`,
+				},
+				"path_prefix": {
+					Type:        framework.TypeString,
+					Description: "For local storage, the directory on the active node in which to write the snapshots. For aws-s3 storage, the key prefix of the snapshot objects.",
+				},
+				"file_prefix": {
+					Type:        framework.TypeString,
+					Description: "Prefix of the snapshot file names. The time of the snapshot and the .snap extension are appended to it.",
+					Default:     raftAutoSnapshotDefaultFilePrefix,
+				},
+				"aws_s3_bucket": {
+					Type:        framework.TypeString,
+					Description: "For aws-s3 storage, the bucket to write the snapshots to.",
+				},
+				"aws_s3_region": {
+					Type:        framework.TypeString,
+					Description: "For aws-s3 storage, the region of the bucket.",
+				},
+				"aws_s3_endpoint": {
+					Type:        framework.TypeString,
+					Description: "For aws-s3 storage, the endpoint of an S3 compatible object store to use instead of AWS.",
+				},
+				"aws_s3_disable_tls": {
+					Type:        framework.TypeBool,
+					Description: "For aws-s3 storage, disables TLS for the connection to the endpoint.",
+				},
+				"aws_s3_force_path_style": {
+					Type:        framework.TypeBool,
+					Description: "For aws-s3 storage, uses path style instead of virtual hosted style bucket addressing.",
+				},
+				"aws_s3_kms_key": {
+					Type:        framework.TypeString,
+					Description: "For aws-s3 storage, the KMS key used to encrypt the snapshots server side.",
+				},
+				"aws_access_key_id": {
+					Type:        framework.TypeString,
+					Description: "For aws-s3 storage, the access key ID. If unset, credentials are sourced from the environment.",
+				},
+				"aws_secret_access_key": {
+					Type:        framework.TypeString,
+					Description: "For aws-s3 storage, the secret access key.",
+				},
+				"aws_session_token": {
+					Type:        framework.TypeString,
+					Description: "For aws-s3 storage, the session token.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback:                  b.handleStorageRaftSnapshotAutoConfigRead(),
+					Summary:                   "Returns the automatic snapshot configuration.",
+					ForwardPerformanceStandby: true,
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigUpdate(),
+					Summary:  "Creates or updates an automatic snapshot configuration.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoConfigDelete(),
+					Summary:  "Deletes an automatic snapshot configuration.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-config"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/snapshots/" + framework.GenericNameRegex("name") + "/?$",
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the automatic snapshot configuration.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ListOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoSnapshotsList(),
+					Summary:  "Lists the snapshots written by an automatic snapshot configuration.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-snapshots"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-snapshots"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/restore/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the automatic snapshot configuration.",
+				},
+				"snapshot": {
+					Type:        framework.TypeString,
+					Description: "Name of the snapshot to restore, as returned by the snapshots list.",
+					Required:    true,
+				},
+				"force": {
+					Type:        framework.TypeBool,
+					Description: "Bypass the checks ensuring the current Autounseal or Shamir keys are consistent with the snapshot data.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageRaftSnapshotAutoRestore(makeSealer(b.logger, "snapshot_write")),
+					Summary:  "Installs a snapshot written by an automatic snapshot configuration, returning the cluster to the state defined in it.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-restore"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-restore"][1]),
+		},
+		{
+			Pattern: "storage/raft/snapshot-auto/status/" + framework.GenericNameRegex("name"),
+			Fields: map[string]*framework.FieldSchema{
+				"name": {
+					Type:        framework.TypeString,
+					Description: "Name of the automatic snapshot configuration.",
+				},
+			},
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback:                  b.handleStorageRaftSnapshotAutoStatus(),
+					Summary:                   "Returns the status of an automatic snapshot configuration.",
+					ForwardPerformanceStandby: true,
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-status"][0]),
+			HelpDescription: strings.TrimSpace(sysRaftHelp["raft-snapshot-auto-status"][1]),
+		},
+	}
+}
+
 func (b *SystemBackend) handleRaftConfigurationGet() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		raftBackend := b.Core.getRaftBackend()
@@ -464,6 +682,51 @@ func (b *SystemBackend) handleStorageRaftSnapshotRead(makeSealer func() snapshot
 	}
 }
 
+func (b *SystemBackend) handleStorageRaftCompactRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftBackend := b.Core.getRaftBackend()
+		if raftBackend == nil {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		status := raftBackend.CompactionStatus()
+		if status == nil {
+			return nil, nil
+		}
+
+		data := map[string]interface{}{
+			"status":     status.Status,
+			"start_time": status.StartTime,
+			"servers":    status.Servers,
+		}
+		if !status.EndTime.IsZero() {
+			data["end_time"] = status.EndTime
+		}
+		if status.Error != "" {
+			data["error"] = status.Error
+		}
+
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftCompactUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftBackend := b.Core.getRaftBackend()
+		if raftBackend == nil {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		if err := raftBackend.StartCompaction(b.Core.activeContext); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		return logical.RespondWithStatusCode(nil, req, http.StatusAccepted)
+	}
+}
+
 func (b *SystemBackend) handleStorageRaftAutopilotState() framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		raftBackend := b.Core.getRaftBackend()
@@ -571,9 +834,6 @@ func (b *SystemBackend) handleStorageRaftAutopilotConfigUpdate() framework.Opera
 		}
 		disableUpgradeMigration, ok := d.GetOk("disable_upgrade_migration")
 		if ok {
-			if !constants.IsEnterprise {
-				return logical.ErrorResponse("disable_upgrade_migration is only available in Vault Enterprise"), logical.ErrInvalidRequest
-			}
 			config.DisableUpgradeMigration = disableUpgradeMigration.(bool)
 			persist = true
 		}
@@ -607,17 +867,321 @@ func (b *SystemBackend) handleStorageRaftAutopilotConfigUpdate() framework.Opera
 	}
 }
 
-func (b *SystemBackend) handleStorageRaftSnapshotWrite(force bool, makeSealer func() snapshot.Sealer) framework.OperationFunc {
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		names, err := b.Core.barrier.List(ctx, raftAutoSnapshotConfigPath)
+		if err != nil {
+			return nil, err
+		}
+
+		return logical.ListResponse(names), nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		config, err := b.Core.loadRaftAutoSnapshotConfig(ctx, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			return nil, nil
+		}
+
+		resp := &logical.Response{
+			Data: map[string]interface{}{
+				"interval":     int64(config.Interval.Seconds()),
+				"retain":       config.Retain,
+				"max_age":      int64(config.MaxAge.Seconds()),
+				"storage_type": config.StorageType,
+				"path_prefix":  config.PathPrefix,
+				"file_prefix":  config.FilePrefix,
+			},
+		}
+		if config.StorageType == raftAutoSnapshotStorageTypeAWSS3 {
+			// Credentials are write-only and never returned.
+			resp.Data["aws_s3_bucket"] = config.AWSS3Bucket
+			resp.Data["aws_s3_region"] = config.AWSS3Region
+			resp.Data["aws_s3_endpoint"] = config.AWSS3Endpoint
+			resp.Data["aws_s3_disable_tls"] = config.AWSS3DisableTLS
+			resp.Data["aws_s3_force_path_style"] = config.AWSS3ForcePathStyle
+			resp.Data["aws_s3_kms_key"] = config.AWSS3KMSKey
+			resp.Data["aws_access_key_id"] = config.AWSAccessKeyID
+		}
+
+		return resp, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigUpdate() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("name").(string)
+		config, err := b.Core.loadRaftAutoSnapshotConfig(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			config = &raftAutoSnapshotConfig{
+				Name:       name,
+				Retain:     d.Get("retain").(int),
+				FilePrefix: d.Get("file_prefix").(string),
+			}
+		}
+
+		if interval, ok := d.GetOk("interval"); ok {
+			config.Interval = time.Duration(interval.(int)) * time.Second
+		}
+		if retain, ok := d.GetOk("retain"); ok {
+			config.Retain = retain.(int)
+		}
+		if maxAge, ok := d.GetOk("max_age"); ok {
+			config.MaxAge = time.Duration(maxAge.(int)) * time.Second
+		}
+		if storageType, ok := d.GetOk("storage_type"); ok {
+			config.StorageType = storageType.(string)
+		}
+		if pathPrefix, ok := d.GetOk("path_prefix"); ok {
+			config.PathPrefix = pathPrefix.(string)
+		}
+		if filePrefix, ok := d.GetOk("file_prefix"); ok {
+			config.FilePrefix = filePrefix.(string)
+		}
+		if v, ok := d.GetOk("aws_s3_bucket"); ok {
+			config.AWSS3Bucket = v.(string)
+		}
+		if v, ok := d.GetOk("aws_s3_region"); ok {
+			config.AWSS3Region = v.(string)
+		}
+		if v, ok := d.GetOk("aws_s3_endpoint"); ok {
+			config.AWSS3Endpoint = v.(string)
+		}
+		if v, ok := d.GetOk("aws_s3_disable_tls"); ok {
+			config.AWSS3DisableTLS = v.(bool)
+		}
+		if v, ok := d.GetOk("aws_s3_force_path_style"); ok {
+			config.AWSS3ForcePathStyle = v.(bool)
+		}
+		if v, ok := d.GetOk("aws_s3_kms_key"); ok {
+			config.AWSS3KMSKey = v.(string)
+		}
+		if v, ok := d.GetOk("aws_access_key_id"); ok {
+			config.AWSAccessKeyID = v.(string)
+		}
+		if v, ok := d.GetOk("aws_secret_access_key"); ok {
+			config.AWSSecretAccessKey = v.(string)
+		}
+		if v, ok := d.GetOk("aws_session_token"); ok {
+			config.AWSSessionToken = v.(string)
+		}
+
+		if err := config.validate(); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		if err := b.Core.saveRaftAutoSnapshotConfig(ctx, config); err != nil {
+			return nil, err
+		}
+
+		b.Core.setRaftAutoSnapshotSchedule(config)
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoConfigDelete() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("name").(string)
+		if err := b.Core.barrier.Delete(ctx, raftAutoSnapshotConfigPath+name); err != nil {
+			return nil, err
+		}
+
+		b.Core.removeRaftAutoSnapshotSchedule(name)
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoSnapshotsList() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		config, err := b.Core.loadRaftAutoSnapshotConfig(ctx, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			return nil, nil
+		}
+
+		sink, err := config.sink(b.logger)
+		if err != nil {
+			return nil, err
+		}
+		names, err := sink.List(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		var keys []string
+		for _, name := range names {
+			if _, ok := parseRaftAutoSnapshotFileName(config.FilePrefix, name); ok {
+				keys = append(keys, name)
+			}
+		}
+		sort.Strings(keys)
+
+		return logical.ListResponse(keys), nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotRestoreMount(makeSealer func() snapshot.Sealer) framework.OperationFunc {
 	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 		raftStorage, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
 		if !ok {
 			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
 		}
-		body, ok := logical.ContextOriginalBodyValue(ctx)
+		path := d.Get("path").(string)
+		mountUUID := d.Get("mount_uuid").(string)
+		if path == "" && mountUUID == "" {
+			return logical.ErrorResponse("path or mount_uuid is required"), logical.ErrInvalidRequest
+		}
+
+		source, err := b.makeSnapshotSource(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		body, err := source.ReadCloser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
+		snapFile, cleanup, _, err := raftStorage.WriteSnapshotToTemp(body, makeSealer())
+		switch {
+		case err == nil:
+		case strings.Contains(err.Error(), "failed to open the sealed hashes"):
+			return logical.ErrorResponse("could not verify hash file, the snapshot was not taken by this cluster or its seal has changed"), logical.ErrInvalidRequest
+		default:
+			b.Core.logger.Error("raft snapshot restore mount: failed to write snapshot", "error", err)
+			return nil, err
+		}
+		defer cleanup()
+
+		entry, err := b.Core.restoreMountFromSnapshot(ctx, snapFile, path, mountUUID)
+		if err != nil {
+			return handleError(err)
+		}
+
+		path = entry.Path
+		if entry.Table == credentialTableType {
+			path = credentialRoutePrefix + path
+		}
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"path":     path,
+				"type":     entry.Type,
+				"accessor": entry.Accessor,
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoRestore(makeSealer func() snapshot.Sealer) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+
+		name := d.Get("snapshot").(string)
+		if name == "" {
+			return logical.ErrorResponse("snapshot is required"), logical.ErrInvalidRequest
+		}
+
+		config, err := b.Core.loadRaftAutoSnapshotConfig(ctx, d.Get("name").(string))
+		if err != nil {
+			return nil, err
+		}
+		if config == nil {
+			return logical.ErrorResponse("automatic snapshot configuration not found"), logical.ErrInvalidRequest
+		}
+
+		sink, err := config.sink(b.logger)
+		if err != nil {
+			return nil, err
+		}
+		makeSource := func(ctx context.Context, _ *framework.FieldData) (snapshots.Source, error) {
+			return sink.Source(ctx, name)
+		}
+
+		return b.handleStorageRaftSnapshotWrite(d.Get("force").(bool), makeSealer, makeSource)(ctx, req, d)
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotAutoStatus() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if _, ok := b.Core.underlyingPhysical.(*raft.RaftBackend); !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+		status, ok := b.Core.raftAutoSnapshotScheduleStatus(d.Get("name").(string))
 		if !ok {
-			return nil, errors.New("no reader for request")
+			return nil, nil
 		}
 
+		formatTime := func(t time.Time) string {
+			if t.IsZero() {
+				return ""
+			}
+			return t.UTC().Format(time.RFC3339)
+		}
+
+		return &logical.Response{
+			Data: map[string]interface{}{
+				"consecutive_errors":  status.ConsecutiveErrors,
+				"last_snapshot_start": formatTime(status.LastSnapshotStart),
+				"last_snapshot_end":   formatTime(status.LastSnapshotEnd),
+				"last_snapshot_error": status.LastSnapshotError,
+				"last_snapshot_url":   status.LastSnapshotURL,
+				"next_snapshot_start": formatTime(status.NextSnapshotStart),
+				"snapshot_start":      formatTime(status.SnapshotStart),
+			},
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageRaftSnapshotWrite(force bool, makeSealer func() snapshot.Sealer, makeSource func(context.Context, *framework.FieldData) (snapshots.Source, error)) framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		raftStorage, ok := b.Core.underlyingPhysical.(*raft.RaftBackend)
+		if !ok {
+			return logical.ErrorResponse("raft storage is not in use"), logical.ErrInvalidRequest
+		}
+		source, err := makeSource(ctx, d)
+		if err != nil {
+			return nil, err
+		}
+		body, err := source.ReadCloser(ctx)
+		if err != nil {
+			return nil, err
+		}
+		defer body.Close()
+
 		var sealer snapshot.Sealer
 		if !force {
 			sealer = makeSealer()
@@ -749,6 +1313,37 @@ var sysRaftHelp = map[string][2]string{
 		"Force restore a raft cluster snapshot",
 		"",
 	},
+	"raft-snapshot-restore-mount": {
+		"Restore a single mount from a raft cluster snapshot",
+		`Copies the storage of the secrets or auth mount at the given path in
+		the snapshot back into the cluster, and enables the mount again with its
+		original UUID and accessor. The path must not be in use. This reverts an
+		accidental disable of a mount without rolling back the rest of the
+		cluster. Leases and tokens of the mount are not restored.
+
+		If the mount_uuid query parameter is set, the mount with this UUID in the
+		snapshot is restored at the given path, or at its path in the snapshot if
+		no path is given. Only mounts of the root namespace can be restored.`,
+	},
+	"raft-snapshot-auto-config": {
+		"Manages automatic raft snapshot configurations.",
+		`Each named configuration takes a snapshot of the raft cluster every
+		interval on the active node, writes it to the configured storage and
+		removes old snapshots according to retain and max_age.`,
+	},
+	"raft-snapshot-auto-snapshots": {
+		"Lists the snapshots written by an automatic raft snapshot configuration.",
+		"",
+	},
+	"raft-snapshot-auto-restore": {
+		"Restores a snapshot written by an automatic raft snapshot configuration.",
+		`The snapshot is read by the server directly from the configured storage,
+		so it does not have to be streamed through the client.`,
+	},
+	"raft-snapshot-auto-status": {
+		"Returns the status of an automatic raft snapshot configuration.",
+		"",
+	},
 	"raft-autopilot-state": {
 		"Returns the state of the raft cluster under integrated storage as seen by autopilot.",
 		"",
@@ -757,6 +1352,15 @@ var sysRaftHelp = map[string][2]string{
 		"Returns autopilot configuration.",
 		"",
 	},
+	"raft-compact": {
+		"Compacts the raft storage to reclaim the disk space freed by deletes.",
+		`The storage of every standby node is rewritten, one node at a time,
+waiting for autopilot to report the cluster as healthy before moving on to the
+next node. A node does not serve requests while its storage is being
+compacted, so the active node is skipped; step it down and compact again to
+compact it. Reading this endpoint returns the progress of the latest
+compaction.`,
+	},
 }
 
 func NewSealAccessSealer(access seal.Access, logger hclog.Logger, use string) snapshot.Sealer {
@@ -0,0 +1,133 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// sealRewrapPaths returns the paths used to rewrap the seal wrapped entries
+// under the current seal generation.
+func (b *SystemBackend) sealRewrapPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "sealwrap/rewrap$",
+
+			DisplayAttrs: &framework.DisplayAttributes{
+				OperationPrefix: "sealwrap",
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleSealRewrapRead,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb:   "read",
+						OperationSuffix: "rewrap-status",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "OK",
+							Fields:      sealRewrapStatusFields,
+						}},
+					},
+					Summary: "Returns the status of the seal rewrap.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleSealRewrapUpdate,
+					DisplayAttrs: &framework.DisplayAttributes{
+						OperationVerb: "rewrap",
+					},
+					Responses: map[int][]framework.Response{
+						http.StatusOK: {{
+							Description: "A seal rewrap is already running",
+							Fields:      sealRewrapStatusFields,
+						}},
+						http.StatusNoContent: {{
+							Description: "OK",
+						}},
+					},
+					Summary: "Starts rewrapping the seal wrapped entries under the current seal generation.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysHelp["sealwrap-rewrap"][0]),
+			HelpDescription: strings.TrimSpace(sysHelp["sealwrap-rewrap"][1]),
+		},
+	}
+}
+
+var sealRewrapStatusFields = map[string]*framework.FieldSchema{
+	"is_running": {
+		Type:     framework.TypeBool,
+		Required: true,
+	},
+	"entries": {
+		Type:     framework.TypeMap,
+		Required: true,
+	},
+	"start_time": {
+		Type:     framework.TypeString,
+		Required: false,
+	},
+	"end_time": {
+		Type:     framework.TypeString,
+		Required: false,
+	},
+	"error": {
+		Type:     framework.TypeString,
+		Required: false,
+	},
+}
+
+// handleSealRewrapRead returns the status of the last seal rewrap
+func (b *SystemBackend) handleSealRewrapRead(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	return &logical.Response{
+		Data: sealRewrapStatusData(b.Core.readSealRewrapStatus()),
+	}, nil
+}
+
+// handleSealRewrapUpdate starts a seal rewrap in the background, unless one is
+// already running in which case its status is returned.
+func (b *SystemBackend) handleSealRewrapUpdate(ctx context.Context, req *logical.Request, data *framework.FieldData) (*logical.Response, error) {
+	// Use the active context so the rewrap outlives the request
+	if b.Core.startSealRewrap(b.Core.activeContext) {
+		return nil, nil
+	}
+	return &logical.Response{
+		Data: sealRewrapStatusData(b.Core.readSealRewrapStatus()),
+	}, nil
+}
+
+func sealRewrapStatusData(status *sealRewrapStatus) map[string]interface{} {
+	if status == nil {
+		status = &sealRewrapStatus{}
+	}
+
+	data := map[string]interface{}{
+		"is_running": status.Running,
+		"entries": map[string]interface{}{
+			"processed": status.Processed,
+			"succeeded": status.Succeeded,
+			"failed":    status.Failed,
+		},
+	}
+	if !status.StartTime.IsZero() {
+		data["start_time"] = status.StartTime.Format(time.RFC3339)
+	}
+	if !status.EndTime.IsZero() {
+		data["end_time"] = status.EndTime.Format(time.RFC3339)
+	}
+	if status.Error != "" {
+		data["error"] = status.Error
+	}
+	return data
+}
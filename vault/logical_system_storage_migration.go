@@ -0,0 +1,183 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/vault/sdk/framework"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// storageMigrationPaths returns the paths used to run an online migration of
+// the physical storage to a different backend.
+func (b *SystemBackend) storageMigrationPaths() []*framework.Path {
+	return []*framework.Path{
+		{
+			Pattern: "storage/migration$",
+			Fields: map[string]*framework.FieldSchema{
+				"destination_type": {
+					Type:        framework.TypeString,
+					Description: `Type of the destination storage backend, for example "raft".`,
+				},
+				"destination_config": {
+					Type:        framework.TypeKVPairs,
+					Description: "Configuration of the destination storage backend, using the same keys as its storage stanza.",
+				},
+				"max_parallel": {
+					Type:        framework.TypeInt,
+					Description: "Maximum number of keys copied concurrently.",
+					Default:     storageMigrationDefaultMaxParallel,
+				},
+			},
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.ReadOperation: &framework.PathOperation{
+					Callback: b.handleStorageMigrationRead(),
+					Summary:  "Returns the status of the online storage migration.",
+				},
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageMigrationStart(),
+					Summary:  "Starts an online migration to the destination storage.",
+				},
+				logical.DeleteOperation: &framework.PathOperation{
+					Callback: b.handleStorageMigrationAbort(),
+					Summary:  "Aborts the online storage migration and clears its status.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysStorageMigrationHelp["storage-migration"][0]),
+			HelpDescription: strings.TrimSpace(sysStorageMigrationHelp["storage-migration"][1]),
+		},
+		{
+			Pattern: "storage/migration/cutover$",
+
+			Operations: map[logical.Operation]framework.OperationHandler{
+				logical.UpdateOperation: &framework.PathOperation{
+					Callback: b.handleStorageMigrationCutover(),
+					Summary:  "Switches this node over to the destination storage.",
+				},
+			},
+
+			HelpSynopsis:    strings.TrimSpace(sysStorageMigrationHelp["storage-migration-cutover"][0]),
+			HelpDescription: strings.TrimSpace(sysStorageMigrationHelp["storage-migration-cutover"][1]),
+		},
+	}
+}
+
+func (b *SystemBackend) handleStorageMigrationRead() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		m := b.Core.storageMigration
+		status := m.readStatus()
+		if status == nil {
+			return &logical.Response{
+				Data: map[string]interface{}{
+					"state": "idle",
+				},
+			}, nil
+		}
+
+		data := map[string]interface{}{
+			"state":            status.State,
+			"destination_type": status.DestinationType,
+			"start_time":       status.StartTime.Format(time.RFC3339),
+			"keys_copied":      m.keysCopied.Load(),
+			"bytes_copied":     m.bytesCopied.Load(),
+			"mirrored_writes":  m.backend.mirrored.Load(),
+		}
+		if !status.CopyEndTime.IsZero() {
+			data["copy_end_time"] = status.CopyEndTime.Format(time.RFC3339)
+		}
+		if !status.CutoverTime.IsZero() {
+			data["cutover_time"] = status.CutoverTime.Format(time.RFC3339)
+		}
+		if status.Error != "" {
+			data["error"] = status.Error
+		}
+
+		return &logical.Response{
+			Data: data,
+		}, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageMigrationStart() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if !b.Core.storageMigrationOnActiveNode() {
+			return logical.ErrorResponse(errStorageMigrationNotActive.Error()), logical.ErrInvalidRequest
+		}
+
+		destinationType := d.Get("destination_type").(string)
+		if destinationType == "" {
+			return logical.ErrorResponse("destination_type is required"), logical.ErrInvalidRequest
+		}
+		maxParallel := d.Get("max_parallel").(int)
+		if maxParallel < 1 {
+			return logical.ErrorResponse("max_parallel must be at least 1"), logical.ErrInvalidRequest
+		}
+
+		destination, err := b.Core.newStorageMigrationDestination(destinationType, d.Get("destination_config").(map[string]string))
+		if err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		if err := b.Core.storageMigration.start(destinationType, destination, maxParallel); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		return logical.RespondWithStatusCode(nil, req, http.StatusAccepted)
+	}
+}
+
+func (b *SystemBackend) handleStorageMigrationCutover() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		if !b.Core.storageMigrationOnActiveNode() {
+			return logical.ErrorResponse(errStorageMigrationNotActive.Error()), logical.ErrInvalidRequest
+		}
+
+		if err := b.Core.storageMigration.cutover(ctx); err != nil {
+			return logical.ErrorResponse(err.Error()), logical.ErrInvalidRequest
+		}
+
+		return nil, nil
+	}
+}
+
+func (b *SystemBackend) handleStorageMigrationAbort() framework.OperationFunc {
+	return func(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
+		b.Core.storageMigration.abort()
+		return nil, nil
+	}
+}
+
+var sysStorageMigrationHelp = map[string][2]string{
+	"storage-migration": {
+		"Starts, reports on and aborts an online storage migration.",
+		`
+An online storage migration mirrors every write made by the active node to
+the destination storage and copies the existing keyspace in the background,
+while the node keeps serving requests. Once the copy has completed the
+migration is "ready" and can be cut over. Reading this endpoint returns the
+state of the migration and its progress. Deleting it aborts a migration that
+has not been cut over.
+
+The migration runs on the active node and is aborted if the node seals or
+loses active status before it has been cut over.
+		`,
+	},
+	"storage-migration-cutover": {
+		"Switches this node over to the destination storage.",
+		`
+Makes the destination the storage of this node and writes the storage
+migration lock to the source storage, so that a server still configured to use
+it refuses to start, and a standby still using it refuses to become active.
+The node keeps using the destination if it is sealed and unsealed again. The
+storage stanza of every server must be updated to the destination before it
+is restarted.
+		`,
+	},
+}
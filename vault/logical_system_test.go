@@ -33,6 +33,7 @@ import (
 	"github.com/hashicorp/vault/helper/experiments"
 	"github.com/hashicorp/vault/helper/identity"
 	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/pgpkeys"
 	"github.com/hashicorp/vault/helper/random"
 	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
 	"github.com/hashicorp/vault/helper/testhelpers/pluginhelpers"
@@ -2961,6 +2962,76 @@ func TestSystemBackend_auditHash(t *testing.T) {
 	}
 }
 
+// TestSystemBackend_auditVerify ensures that the entries of a hash chained
+// audit log are verified, and that only the offset of the first invalid entry
+// is returned.
+func TestSystemBackend_auditVerify(t *testing.T) {
+	c, b, root := testCoreSystemBackend(t)
+	ctx := namespace.RootContext(nil)
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "audit/foo")
+	req.Data = map[string]any{
+		"type": audit.TypeFile,
+		"options": map[string]string{
+			"file_path":  path,
+			"hash_chain": "true",
+		},
+	}
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	for i := 0; i < 3; i++ {
+		req := logical.TestRequest(t, logical.ReadOperation, "sys/mounts")
+		req.ClientToken = root
+		_, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+	}
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	entries := strings.SplitAfter(strings.TrimSpace(string(data)), "\n")
+	require.GreaterOrEqual(t, len(entries), 6)
+
+	verify := func(previous string, entries []string) *logical.Response {
+		t.Helper()
+		req := logical.TestRequest(t, logical.UpdateOperation, "audit-verify/foo")
+		req.Data["entries"] = entries
+		req.Data["previous"] = previous
+		resp, err := b.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+		schema.ValidateResponse(
+			t,
+			schema.GetResponseSchema(t, b.(*SystemBackend).Route(req.Path), req.Operation),
+			resp,
+			true,
+		)
+		return resp
+	}
+
+	resp = verify("", entries)
+	require.Equal(t, map[string]any{"valid": true}, resp.Data)
+
+	// The chain carries on from the previous entry.
+	resp = verify(entries[1], entries[2:])
+	require.Equal(t, map[string]any{"valid": true}, resp.Data)
+
+	modified := append([]string{}, entries...)
+	modified[3] = strings.Replace(modified[3], `"operation":"read"`, `"operation":"update"`, 1)
+	require.NotEqual(t, entries[3], modified[3])
+	resp = verify("", modified)
+	require.Equal(t, map[string]any{"valid": false, "first_invalid": 3}, resp.Data)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "audit-verify/bar")
+	req.Data["entries"] = entries
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.True(t, resp.IsError())
+	require.Contains(t, resp.Error().Error(), `unknown audit backend "bar/"`)
+}
+
 func TestSystemBackend_enableAudit_invalid(t *testing.T) {
 	b := testSystemBackend(t)
 	req := logical.TestRequest(t, logical.UpdateOperation, "audit/foo")
@@ -3633,6 +3704,7 @@ func TestSystemBackend_keyStatus(t *testing.T) {
 	}
 	delete(resp.Data, "install_time")
 	delete(resp.Data, "encryptions")
+	delete(resp.Data, "rotation_history")
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
 	}
@@ -3654,9 +3726,11 @@ func TestSystemBackend_rotateConfig(t *testing.T) {
 	)
 
 	exp := map[string]interface{}{
-		"max_operations": absoluteOperationMaximum,
-		"interval":       0,
-		"enabled":        true,
+		"max_operations":  absoluteOperationMaximum,
+		"interval":        0,
+		"enabled":         true,
+		"schedule":        "",
+		"alert_threshold": defaultRotationAlertThreshold,
 	}
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
@@ -3666,6 +3740,8 @@ func TestSystemBackend_rotateConfig(t *testing.T) {
 	req2.Data["max_operations"] = int64(3221225472)
 	req2.Data["interval"] = "5432h0m0s"
 	req2.Data["enabled"] = false
+	req2.Data["schedule"] = "0 3 * * 0"
+	req2.Data["alert_threshold"] = 0.75
 
 	resp, err = b.HandleRequest(namespace.RootContext(nil), req2)
 	if err != nil {
@@ -3689,14 +3765,76 @@ func TestSystemBackend_rotateConfig(t *testing.T) {
 	)
 
 	exp = map[string]interface{}{
-		"max_operations": int64(3221225472),
-		"interval":       "5432h0m0s",
-		"enabled":        false,
+		"max_operations":  int64(3221225472),
+		"interval":        "5432h0m0s",
+		"enabled":         false,
+		"schedule":        "0 3 * * 0",
+		"alert_threshold": 0.75,
 	}
 
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
 	}
+
+	for name, data := range map[string]map[string]interface{}{
+		"invalid schedule":    {"schedule": "not a schedule"},
+		"too frequent":        {"schedule": "0 */6 * * *"},
+		"alert threshold 0":   {"alert_threshold": 0},
+		"alert threshold > 1": {"alert_threshold": 1.5},
+	} {
+		t.Run(name, func(t *testing.T) {
+			req := logical.TestRequest(t, logical.UpdateOperation, "rotate/config")
+			req.Data = data
+			resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+			require.ErrorIs(t, err, logical.ErrInvalidRequest)
+			require.True(t, resp.IsError())
+		})
+	}
+}
+
+func TestSystemBackend_rotatePathBinding(t *testing.T) {
+	b := testSystemBackend(t)
+	c := b.(*SystemBackend).Core
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.ReadOperation, "rotate/path-binding")
+	resp, err := b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	schema.ValidateResponse(
+		t,
+		schema.GetResponseSchema(t, b.(*SystemBackend).Route(req.Path), req.Operation),
+		resp,
+		true,
+	)
+	require.Equal(t, map[string]interface{}{"required": false}, resp.Data)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "rotate/path-binding")
+	_, err = b.HandleRequest(ctx, req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+
+	req.Data["required"] = true
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.Data[logical.HTTPStatusCode])
+
+	require.Eventually(t, func() bool {
+		status := c.readPathBindingUpgradeStatus()
+		return status != nil && status.State == pathBindingUpgradeStateCompleted
+	}, 10*time.Second, 10*time.Millisecond)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "rotate/path-binding")
+	resp, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, true, resp.Data["required"])
+	require.Equal(t, pathBindingUpgradeStateCompleted, resp.Data["upgrade_state"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "rotate/path-binding")
+	req.Data["required"] = false
+	_, err = b.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	required, err := c.barrier.PathBindingRequired()
+	require.NoError(t, err)
+	require.False(t, required)
 }
 
 func TestSystemBackend_rotate(t *testing.T) {
@@ -3717,16 +3855,47 @@ func TestSystemBackend_rotate(t *testing.T) {
 		t.Fatalf("err: %v", err)
 	}
 
+	history := resp.Data["rotation_history"].([]map[string]interface{})
+	require.Len(t, history, 2)
+	require.Equal(t, uint32(1), history[0]["term"])
+	require.Equal(t, initializeRotateReason, history[0]["reason"])
+	require.Equal(t, uint32(2), history[1]["term"])
+	require.Equal(t, manualRotateReason, history[1]["reason"])
+	require.Equal(t, resp.Data["install_time"], history[1]["install_time"])
+
 	exp := map[string]interface{}{
 		"term": 2,
 	}
 	delete(resp.Data, "install_time")
 	delete(resp.Data, "encryptions")
+	delete(resp.Data, "rotation_history")
 	if !reflect.DeepEqual(resp.Data, exp) {
 		t.Fatalf("got: %#v expect: %#v", resp.Data, exp)
 	}
 }
 
+func TestSystemBackend_keyringEscrow(t *testing.T) {
+	b := testSystemBackend(t)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "keyring/escrow")
+	resp, err := b.HandleRequest(namespace.RootContext(nil), req)
+	require.ErrorIs(t, err, logical.ErrInvalidRequest)
+	require.True(t, resp.IsError())
+
+	req.Data["pgp_keys"] = []string{pgpkeys.TestPubKey1, pgpkeys.TestPubKey2}
+	req.Data["threshold"] = 2
+	resp, err = b.HandleRequest(namespace.RootContext(nil), req)
+	require.NoError(t, err)
+	require.Equal(t, keyringEscrowVersion, resp.Data["version"])
+	require.Equal(t, uint32(1), resp.Data["term"])
+	require.Equal(t, 2, resp.Data["threshold"])
+	require.Len(t, resp.Data["encrypted_shares"], 2)
+	require.NotEmpty(t, resp.Data["encrypted_keyring"])
+
+	paths := b.SpecialPaths()
+	require.Contains(t, paths.Root, "keyring/escrow")
+}
+
 func testSystemBackend(t *testing.T) logical.Backend {
 	t.Helper()
 	c, _, _ := TestCoreUnsealed(t)
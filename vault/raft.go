@@ -351,6 +351,8 @@ func (c *Core) setupRaftActiveNode(ctx context.Context) error {
 		return err
 	}
 
+	c.startRaftAutoSnapshots(ctx)
+
 	autopilotConfig, err := c.loadAutopilotConfiguration(ctx)
 	if err != nil {
 		c.logger.Error("failed to load autopilot config from storage when setting up cluster; continuing since autopilot falls back to default config", "error", err)
@@ -406,6 +408,7 @@ func (c *Core) stopRaftActiveNode() {
 
 	c.pendingRaftPeers = nil
 	c.stopPeriodicRaftTLSRotate()
+	c.stopRaftAutoSnapshots()
 }
 
 func (c *Core) startPeriodicRaftTLSRotate(ctx context.Context) error {
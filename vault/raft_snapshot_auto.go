@@ -0,0 +1,493 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/vault/snapshots"
+)
+
+const (
+	// raftAutoSnapshotConfigPath is the barrier prefix under which the named
+	// automatic snapshot configurations are stored.
+	raftAutoSnapshotConfigPath = "core/raft/snapshot-auto/config/"
+
+	raftAutoSnapshotStorageTypeLocal  = "local"
+	raftAutoSnapshotStorageTypeAWSS3  = "aws-s3"
+	raftAutoSnapshotDefaultFilePrefix = "vault-snapshot"
+	raftAutoSnapshotFileSuffix        = ".snap"
+
+	// raftAutoSnapshotTimeFormat is used to timestamp snapshot file names. It
+	// sorts lexicographically in chronological order.
+	raftAutoSnapshotTimeFormat = "20060102T150405.000Z"
+)
+
+// raftAutoSnapshotConfig is the persisted configuration of a named automatic
+// snapshot schedule.
+type raftAutoSnapshotConfig struct {
+	Name        string        `json:"name"`
+	Interval    time.Duration `json:"interval"`
+	Retain      int           `json:"retain"`
+	MaxAge      time.Duration `json:"max_age"`
+	StorageType string        `json:"storage_type"`
+	PathPrefix  string        `json:"path_prefix"`
+	FilePrefix  string        `json:"file_prefix"`
+
+	AWSS3Bucket         string `json:"aws_s3_bucket,omitempty"`
+	AWSS3Region         string `json:"aws_s3_region,omitempty"`
+	AWSS3Endpoint       string `json:"aws_s3_endpoint,omitempty"`
+	AWSS3DisableTLS     bool   `json:"aws_s3_disable_tls,omitempty"`
+	AWSS3ForcePathStyle bool   `json:"aws_s3_force_path_style,omitempty"`
+	AWSS3KMSKey         string `json:"aws_s3_kms_key,omitempty"`
+	AWSAccessKeyID      string `json:"aws_access_key_id,omitempty"`
+	AWSSecretAccessKey  string `json:"aws_secret_access_key,omitempty"`
+	AWSSessionToken     string `json:"aws_session_token,omitempty"`
+}
+
+func (c *raftAutoSnapshotConfig) validate() error {
+	if c.Interval <= 0 {
+		return errors.New("interval must be greater than zero")
+	}
+	if c.Retain < 0 {
+		return errors.New("retain must not be negative")
+	}
+	if c.MaxAge < 0 {
+		return errors.New("max_age must not be negative")
+	}
+	if c.Retain == 0 && c.MaxAge == 0 {
+		return errors.New("at least one of retain or max_age must be set")
+	}
+	switch c.StorageType {
+	case raftAutoSnapshotStorageTypeLocal:
+		if c.PathPrefix == "" {
+			return errors.New("path_prefix is required")
+		}
+		if !filepath.IsAbs(c.PathPrefix) {
+			return errors.New("path_prefix must be an absolute path")
+		}
+	case raftAutoSnapshotStorageTypeAWSS3:
+		if c.AWSS3Bucket == "" {
+			return errors.New("aws_s3_bucket is required")
+		}
+	case "":
+		return errors.New("storage_type is required")
+	default:
+		return fmt.Errorf("unsupported storage_type %q", c.StorageType)
+	}
+	if c.FilePrefix == "" || strings.ContainsAny(c.FilePrefix, `/\`) {
+		return errors.New("file_prefix must be a non-empty name without path separators")
+	}
+	return nil
+}
+
+// sink returns the snapshots.Sink the configuration writes to.
+func (c *raftAutoSnapshotConfig) sink(logger hclog.Logger) (snapshots.Sink, error) {
+	switch c.StorageType {
+	case raftAutoSnapshotStorageTypeLocal:
+		return snapshots.NewFileSnapshotSink(c.PathPrefix), nil
+	case raftAutoSnapshotStorageTypeAWSS3:
+		return snapshots.NewS3SnapshotSink(&snapshots.S3Config{
+			Bucket:          c.AWSS3Bucket,
+			PathPrefix:      c.PathPrefix,
+			Region:          c.AWSS3Region,
+			Endpoint:        c.AWSS3Endpoint,
+			AccessKeyID:     c.AWSAccessKeyID,
+			SecretAccessKey: c.AWSSecretAccessKey,
+			SessionToken:    c.AWSSessionToken,
+			DisableTLS:      c.AWSS3DisableTLS,
+			ForcePathStyle:  c.AWSS3ForcePathStyle,
+			KMSKeyID:        c.AWSS3KMSKey,
+		}, logger)
+	default:
+		return nil, fmt.Errorf("unsupported storage_type %q", c.StorageType)
+	}
+}
+
+// raftAutoSnapshotStatus reports the progress and outcome of the most recent
+// run of a schedule.
+type raftAutoSnapshotStatus struct {
+	ConsecutiveErrors int
+	LastSnapshotStart time.Time
+	LastSnapshotEnd   time.Time
+	LastSnapshotError string
+	LastSnapshotURL   string
+	NextSnapshotStart time.Time
+	SnapshotStart     time.Time
+}
+
+type raftAutoSnapshotSchedule struct {
+	config *raftAutoSnapshotConfig
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	statusLock sync.RWMutex
+	status     raftAutoSnapshotStatus
+}
+
+func (s *raftAutoSnapshotSchedule) getStatus() raftAutoSnapshotStatus {
+	s.statusLock.RLock()
+	defer s.statusLock.RUnlock()
+	return s.status
+}
+
+func (s *raftAutoSnapshotSchedule) updateStatus(f func(*raftAutoSnapshotStatus)) {
+	s.statusLock.Lock()
+	defer s.statusLock.Unlock()
+	f(&s.status)
+}
+
+// raftAutoSnapshotManager runs the automatic snapshot schedules on the active
+// node. It is created when the node becomes active and stopped when it steps
+// down or seals.
+type raftAutoSnapshotManager struct {
+	core   *Core
+	logger hclog.Logger
+	ctx    context.Context
+
+	l         sync.Mutex
+	stopped   bool
+	schedules map[string]*raftAutoSnapshotSchedule
+}
+
+// startRaftAutoSnapshots loads the stored automatic snapshot configurations
+// and starts a schedule for each one. It is a no-op unless raft is used as
+// the storage backend. Configurations that cannot be loaded are logged and
+// skipped so that they do not prevent the node from becoming active.
+func (c *Core) startRaftAutoSnapshots(ctx context.Context) {
+	if _, ok := c.underlyingPhysical.(*raft.RaftBackend); !ok {
+		return
+	}
+
+	logger := c.logger.Named("raft.snapshot-auto")
+	c.AddLogger(logger)
+
+	m := &raftAutoSnapshotManager{
+		core:      c,
+		logger:    logger,
+		ctx:       ctx,
+		schedules: make(map[string]*raftAutoSnapshotSchedule),
+	}
+
+	names, err := c.barrier.List(ctx, raftAutoSnapshotConfigPath)
+	if err != nil {
+		logger.Error("failed to list automatic snapshot configurations", "error", err)
+	}
+	for _, name := range names {
+		config, err := c.loadRaftAutoSnapshotConfig(ctx, name)
+		if err != nil {
+			logger.Error("failed to load automatic snapshot configuration; skipping it", "name", name, "error", err)
+			continue
+		}
+		if config == nil {
+			continue
+		}
+		if err := config.validate(); err != nil {
+			logger.Error("invalid automatic snapshot configuration; skipping it", "name", name, "error", err)
+			continue
+		}
+		m.set(config)
+	}
+
+	c.raftAutoSnapshotsLock.Lock()
+	c.raftAutoSnapshots = m
+	c.raftAutoSnapshotsLock.Unlock()
+}
+
+func (c *Core) stopRaftAutoSnapshots() {
+	c.raftAutoSnapshotsLock.Lock()
+	defer c.raftAutoSnapshotsLock.Unlock()
+
+	if c.raftAutoSnapshots == nil {
+		return
+	}
+	c.raftAutoSnapshots.stop()
+	c.raftAutoSnapshots = nil
+}
+
+// setRaftAutoSnapshotSchedule starts or replaces the schedule for the given
+// configuration, if automatic snapshots are running on this node.
+func (c *Core) setRaftAutoSnapshotSchedule(config *raftAutoSnapshotConfig) {
+	c.raftAutoSnapshotsLock.RLock()
+	defer c.raftAutoSnapshotsLock.RUnlock()
+
+	if c.raftAutoSnapshots != nil {
+		c.raftAutoSnapshots.set(config)
+	}
+}
+
+// removeRaftAutoSnapshotSchedule stops the named schedule, if automatic
+// snapshots are running on this node.
+func (c *Core) removeRaftAutoSnapshotSchedule(name string) {
+	c.raftAutoSnapshotsLock.RLock()
+	defer c.raftAutoSnapshotsLock.RUnlock()
+
+	if c.raftAutoSnapshots != nil {
+		c.raftAutoSnapshots.remove(name)
+	}
+}
+
+// raftAutoSnapshotScheduleStatus returns the status of the named schedule, or
+// false if automatic snapshots are not running on this node or there is no
+// such schedule.
+func (c *Core) raftAutoSnapshotScheduleStatus(name string) (raftAutoSnapshotStatus, bool) {
+	c.raftAutoSnapshotsLock.RLock()
+	defer c.raftAutoSnapshotsLock.RUnlock()
+
+	if c.raftAutoSnapshots == nil {
+		return raftAutoSnapshotStatus{}, false
+	}
+	return c.raftAutoSnapshots.status(name)
+}
+
+func (c *Core) loadRaftAutoSnapshotConfig(ctx context.Context, name string) (*raftAutoSnapshotConfig, error) {
+	entry, err := c.barrier.Get(ctx, raftAutoSnapshotConfigPath+name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read automatic snapshot configuration %q: %w", name, err)
+	}
+	if entry == nil {
+		return nil, nil
+	}
+
+	var config raftAutoSnapshotConfig
+	if err := entry.DecodeJSON(&config); err != nil {
+		return nil, fmt.Errorf("failed to decode automatic snapshot configuration %q: %w", name, err)
+	}
+	return &config, nil
+}
+
+func (c *Core) saveRaftAutoSnapshotConfig(ctx context.Context, config *raftAutoSnapshotConfig) error {
+	entry, err := logical.StorageEntryJSON(raftAutoSnapshotConfigPath+config.Name, config)
+	if err != nil {
+		return err
+	}
+	return c.barrier.Put(ctx, entry)
+}
+
+// set starts a schedule for the given configuration, replacing any existing
+// schedule of the same name.
+func (m *raftAutoSnapshotManager) set(config *raftAutoSnapshotConfig) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.stopped {
+		return
+	}
+	if old, ok := m.schedules[config.Name]; ok {
+		old.cancel()
+	}
+
+	s := &raftAutoSnapshotSchedule{
+		config: config,
+	}
+	s.ctx, s.cancel = context.WithCancel(m.ctx)
+	m.schedules[config.Name] = s
+	go m.run(s)
+}
+
+// remove stops and forgets the schedule with the given name.
+func (m *raftAutoSnapshotManager) remove(name string) {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if s, ok := m.schedules[name]; ok {
+		s.cancel()
+		delete(m.schedules, name)
+	}
+}
+
+func (m *raftAutoSnapshotManager) stop() {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	for name, s := range m.schedules {
+		s.cancel()
+		delete(m.schedules, name)
+	}
+	m.stopped = true
+}
+
+// status returns the status of the named schedule, or false if there is no
+// such schedule running.
+func (m *raftAutoSnapshotManager) status(name string) (raftAutoSnapshotStatus, bool) {
+	m.l.Lock()
+	s, ok := m.schedules[name]
+	m.l.Unlock()
+
+	if !ok {
+		return raftAutoSnapshotStatus{}, false
+	}
+	return s.getStatus(), true
+}
+
+func (m *raftAutoSnapshotManager) run(s *raftAutoSnapshotSchedule) {
+	logger := m.logger.With("name", s.config.Name)
+	next := time.Now().Add(s.config.Interval)
+
+	for {
+		s.updateStatus(func(status *raftAutoSnapshotStatus) {
+			status.NextSnapshotStart = next
+		})
+
+		timer := time.NewTimer(time.Until(next))
+		select {
+		case <-timer.C:
+		case <-s.ctx.Done():
+			timer.Stop()
+			return
+		}
+
+		start := time.Now()
+		s.updateStatus(func(status *raftAutoSnapshotStatus) {
+			status.SnapshotStart = start
+		})
+
+		url, err := m.snapshot(s.ctx, s.config, start)
+		if err != nil {
+			logger.Error("failed to take automatic snapshot", "error", err)
+		} else {
+			logger.Info("wrote automatic snapshot", "url", url, "duration", time.Since(start))
+		}
+
+		s.updateStatus(func(status *raftAutoSnapshotStatus) {
+			status.SnapshotStart = time.Time{}
+			status.LastSnapshotStart = start
+			status.LastSnapshotEnd = time.Now()
+			if err != nil {
+				status.ConsecutiveErrors++
+				status.LastSnapshotError = err.Error()
+				return
+			}
+			status.ConsecutiveErrors = 0
+			status.LastSnapshotError = ""
+			status.LastSnapshotURL = url
+		})
+
+		next = next.Add(s.config.Interval)
+		if now := time.Now(); next.Before(now) {
+			// A snapshot took longer than the interval; skip the missed runs
+			// rather than taking them back to back.
+			next = now.Add(s.config.Interval)
+		}
+	}
+}
+
+// snapshot takes a raft snapshot, writes it to the configured sink and
+// prunes the snapshots that fall outside of the retention policy. It returns
+// the URL of the new snapshot.
+func (m *raftAutoSnapshotManager) snapshot(ctx context.Context, config *raftAutoSnapshotConfig, now time.Time) (string, error) {
+	raftStorage, ok := m.core.underlyingPhysical.(*raft.RaftBackend)
+	if !ok {
+		return "", errors.New("raft storage is not in use")
+	}
+
+	sink, err := config.sink(m.logger)
+	if err != nil {
+		return "", fmt.Errorf("failed to create snapshot sink: %w", err)
+	}
+
+	// Stream the snapshot straight into the sink so it never has to be
+	// buffered in memory.
+	pr, pw := io.Pipe()
+	go func() {
+		sealer := NewSealAccessSealer(m.core.seal.GetAccess(), m.logger, "auto_snapshot")
+		pw.CloseWithError(raftStorage.Snapshot(pw, sealer))
+	}()
+
+	url, err := sink.Write(ctx, raftAutoSnapshotFileName(config.FilePrefix, now), pr)
+	// Unblock the snapshot writer if the sink gave up early.
+	pr.CloseWithError(err)
+	if err != nil {
+		return "", err
+	}
+
+	removed, err := pruneRaftAutoSnapshots(ctx, sink, config, now)
+	for _, r := range removed {
+		m.logger.Debug("removed expired automatic snapshot", "name", config.Name, "snapshot", r)
+	}
+	if err != nil {
+		return url, fmt.Errorf("failed to prune snapshots: %w", err)
+	}
+
+	return url, nil
+}
+
+func raftAutoSnapshotFileName(prefix string, t time.Time) string {
+	return prefix + "-" + t.UTC().Format(raftAutoSnapshotTimeFormat) + raftAutoSnapshotFileSuffix
+}
+
+// parseRaftAutoSnapshotFileName returns the time encoded in a snapshot file
+// name, or false if the name was not produced by raftAutoSnapshotFileName for
+// the given prefix.
+func parseRaftAutoSnapshotFileName(prefix, name string) (time.Time, bool) {
+	ts, ok := strings.CutPrefix(name, prefix+"-")
+	if !ok {
+		return time.Time{}, false
+	}
+	ts, ok = strings.CutSuffix(ts, raftAutoSnapshotFileSuffix)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(raftAutoSnapshotTimeFormat, ts)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return t, true
+}
+
+// pruneRaftAutoSnapshots removes the snapshots written to the sink for the
+// given configuration that exceed its retention count or maximum age.
+// Snapshots not matching the configured file prefix are left alone. It returns
+// the names of the removed snapshots.
+func pruneRaftAutoSnapshots(ctx context.Context, sink snapshots.Sink, config *raftAutoSnapshotConfig, now time.Time) ([]string, error) {
+	names, err := sink.List(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	type snap struct {
+		name string
+		time time.Time
+	}
+	var snaps []snap
+	for _, name := range names {
+		t, ok := parseRaftAutoSnapshotFileName(config.FilePrefix, name)
+		if !ok {
+			continue
+		}
+		snaps = append(snaps, snap{name: name, time: t})
+	}
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].time.Before(snaps[j].time)
+	})
+
+	var removed []string
+	var retErr error
+	for i, s := range snaps {
+		overCount := config.Retain > 0 && len(snaps)-i > config.Retain
+		overAge := config.MaxAge > 0 && now.Sub(s.time) > config.MaxAge
+		if !overCount && !overAge {
+			continue
+		}
+
+		if err := sink.Delete(ctx, s.name); err != nil {
+			retErr = errors.Join(retErr, err)
+			continue
+		}
+		removed = append(removed, s.name)
+	}
+
+	return removed, retErr
+}
@@ -0,0 +1,146 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/vault/vault/snapshots"
+	"github.com/stretchr/testify/require"
+)
+
+// TestRaftAutoSnapshotConfig_Validate verifies the validation of automatic
+// snapshot configurations.
+func TestRaftAutoSnapshotConfig_Validate(t *testing.T) {
+	valid := func() *raftAutoSnapshotConfig {
+		return &raftAutoSnapshotConfig{
+			Name:        "hourly",
+			Interval:    time.Hour,
+			Retain:      3,
+			StorageType: raftAutoSnapshotStorageTypeLocal,
+			PathPrefix:  "/var/vault/snapshots",
+			FilePrefix:  raftAutoSnapshotDefaultFilePrefix,
+		}
+	}
+
+	cases := map[string]struct {
+		modify  func(*raftAutoSnapshotConfig)
+		wantErr string
+	}{
+		"valid": {
+			modify: func(*raftAutoSnapshotConfig) {},
+		},
+		"max-age-only": {
+			modify: func(c *raftAutoSnapshotConfig) {
+				c.Retain = 0
+				c.MaxAge = 24 * time.Hour
+			},
+		},
+		"no-interval": {
+			modify:  func(c *raftAutoSnapshotConfig) { c.Interval = 0 },
+			wantErr: "interval must be greater than zero",
+		},
+		"no-retention": {
+			modify:  func(c *raftAutoSnapshotConfig) { c.Retain = 0 },
+			wantErr: "at least one of retain or max_age must be set",
+		},
+		"unknown-storage-type": {
+			modify:  func(c *raftAutoSnapshotConfig) { c.StorageType = "tape" },
+			wantErr: `unsupported storage_type "tape"`,
+		},
+		"s3": {
+			modify: func(c *raftAutoSnapshotConfig) {
+				c.StorageType = raftAutoSnapshotStorageTypeAWSS3
+				c.PathPrefix = ""
+				c.AWSS3Bucket = "snapshots"
+			},
+		},
+		"s3-no-bucket": {
+			modify: func(c *raftAutoSnapshotConfig) {
+				c.StorageType = raftAutoSnapshotStorageTypeAWSS3
+			},
+			wantErr: "aws_s3_bucket is required",
+		},
+		"relative-path": {
+			modify:  func(c *raftAutoSnapshotConfig) { c.PathPrefix = "snapshots" },
+			wantErr: "path_prefix must be an absolute path",
+		},
+		"file-prefix-separator": {
+			modify:  func(c *raftAutoSnapshotConfig) { c.FilePrefix = "../vault" },
+			wantErr: "file_prefix must be a non-empty name without path separators",
+		},
+	}
+
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			config := valid()
+			tc.modify(config)
+			err := config.validate()
+			if tc.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.EqualError(t, err, tc.wantErr)
+		})
+	}
+}
+
+// TestPruneRaftAutoSnapshots verifies that snapshots are pruned by count and
+// by age, and that unrelated files in the sink are left alone.
+func TestPruneRaftAutoSnapshots(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	setup := func(t *testing.T) string {
+		t.Helper()
+		dir := t.TempDir()
+		for i := 0; i < 5; i++ {
+			name := raftAutoSnapshotFileName("vault", now.Add(-time.Duration(i)*time.Hour))
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("snap"), 0o600))
+		}
+		for _, name := range []string{"vault-other.snap", "notes.txt", raftAutoSnapshotFileName("other", now.Add(-48*time.Hour))} {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte("keep"), 0o600))
+		}
+		return dir
+	}
+
+	remaining := func(t *testing.T, dir string) []string {
+		t.Helper()
+		entries, err := os.ReadDir(dir)
+		require.NoError(t, err)
+		var names []string
+		for _, e := range entries {
+			names = append(names, e.Name())
+		}
+		sort.Strings(names)
+		return names
+	}
+
+	t.Run("retain", func(t *testing.T) {
+		dir := setup(t)
+		removed, err := pruneRaftAutoSnapshots(context.Background(), snapshots.NewFileSnapshotSink(dir), &raftAutoSnapshotConfig{FilePrefix: "vault", Retain: 2}, now)
+		require.NoError(t, err)
+		require.Len(t, removed, 3)
+		require.Equal(t, []string{
+			"notes.txt",
+			raftAutoSnapshotFileName("other", now.Add(-48*time.Hour)),
+			raftAutoSnapshotFileName("vault", now.Add(-time.Hour)),
+			raftAutoSnapshotFileName("vault", now),
+			"vault-other.snap",
+		}, remaining(t, dir))
+	})
+
+	t.Run("max-age", func(t *testing.T) {
+		dir := setup(t)
+		removed, err := pruneRaftAutoSnapshots(context.Background(), snapshots.NewFileSnapshotSink(dir), &raftAutoSnapshotConfig{FilePrefix: "vault", MaxAge: 150 * time.Minute}, now)
+		require.NoError(t, err)
+		require.Len(t, removed, 2)
+		require.Contains(t, remaining(t, dir), raftAutoSnapshotFileName("vault", now.Add(-2*time.Hour)))
+		require.NotContains(t, remaining(t, dir), raftAutoSnapshotFileName("vault", now.Add(-3*time.Hour)))
+	})
+}
@@ -0,0 +1,216 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/logical"
+)
+
+// restoreMountSnapshotPrefixes are the storage prefixes loaded from a
+// snapshot to restore a mount: the keyring, the mount tables and the views
+// of the secrets and auth mounts.
+var restoreMountSnapshotPrefixes = []string{
+	keyringPath,
+	coreMountConfigPath,
+	coreLocalMountConfigPath,
+	coreAuthConfigPath,
+	coreLocalAuthConfigPath,
+	backendBarrierPrefix,
+	credentialBarrierPrefix,
+}
+
+// restoreMountFromSnapshot loads the snapshot state file into a side FSM and
+// restores the secrets mount at the given path, or the auth mount if the path
+// starts with "auth/". If mountUUID is set, the mount with this UUID in the
+// snapshot is restored instead, at the given path or at its path in the
+// snapshot if the path is empty. The storage of the mount in the snapshot is
+// copied into the barrier and the mount is enabled again with its original
+// UUID and accessor, so that identity aliases and policies referencing it
+// keep working. The path and the mount UUID must not be in use. Leases and
+// tokens of the mount are not restored. Only mounts of the root namespace can
+// be restored.
+func (c *Core) restoreMountFromSnapshot(ctx context.Context, snapFile io.ReadCloser, path, mountUUID string) (*MountEntry, error) {
+	ns, err := namespace.FromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if ns.ID != namespace.RootNamespaceID {
+		return nil, logical.CodedError(400, "mounts can only be restored from a snapshot in the root namespace")
+	}
+
+	path = strings.TrimPrefix(path, "/")
+	if path != "" && !strings.HasSuffix(path, "/") {
+		path += "/"
+	}
+	switch {
+	case path == "" && mountUUID == "":
+		return nil, logical.CodedError(400, "missing mount path")
+	case path == credentialRoutePrefix:
+		return nil, logical.CodedError(400, "missing auth mount path")
+	}
+	if path != "" {
+		if match := c.router.MountConflict(ctx, path); match != "" {
+			return nil, logical.CodedError(409, fmt.Sprintf("existing mount at %s", match))
+		}
+	}
+
+	keyring, err := c.barrier.Keyring()
+	if err != nil {
+		return nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "vault-restore-mount")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	logger := c.logger.Named("snapshot").Named("restore-mount")
+	fsm, err := raft.NewFSM(dir, "restore-mount", logger)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create FSM: %w", err)
+	}
+	defer fsm.Close()
+
+	filterKey := func(key string) bool {
+		for _, prefix := range restoreMountSnapshotPrefixes {
+			if strings.HasPrefix(key, prefix) {
+				return false
+			}
+		}
+		return true
+	}
+	if err := raft.LoadReadOnlySnapshot(fsm, snapFile, filterKey, logger); err != nil {
+		return nil, fmt.Errorf("failed to load snapshot: %w", err)
+	}
+
+	// The current keyring holds all the terms of an older snapshot of this
+	// cluster, even if the root key was rotated since.
+	snap, err := NewStorageExporter(ctx, &StorageExporterConfig{
+		Logger:  logger,
+		Backend: fsm,
+		Keyring: keyring,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt snapshot: %w", err)
+	}
+
+	entry, err := restoreMountSnapshotEntry(snap, path, mountUUID)
+	if err != nil {
+		return nil, err
+	}
+	if path == "" {
+		path = entry.Path
+		if entry.Table == credentialTableType {
+			path = credentialRoutePrefix + path
+		}
+		if match := c.router.MountConflict(ctx, path); match != "" {
+			return nil, logical.CodedError(409, fmt.Sprintf("existing mount at %s", match))
+		}
+	}
+	if match := c.router.MatchingMountByUUID(entry.UUID); match != nil {
+		return nil, logical.CodedError(409, fmt.Sprintf("mount %q of the snapshot is in use at %s", entry.UUID, match.Path))
+	}
+	if match := c.router.MatchingMountByAccessor(entry.Accessor); match != nil {
+		return nil, logical.CodedError(409, fmt.Sprintf("accessor %q of the snapshot is in use at %s", entry.Accessor, match.Path))
+	}
+
+	// Clear any data left over by a failed unmount before copying, so the
+	// view matches the snapshot exactly.
+	viewPath := entry.ViewPath()
+	view := NewBarrierView(c.barrier, viewPath)
+	if err := logical.ClearView(ctx, view); err != nil {
+		return nil, fmt.Errorf("failed to clear mount storage: %w", err)
+	}
+
+	logger.Info("copying mount storage from snapshot", "path", path, "uuid", entry.UUID)
+	if err := copyView(ctx, NewBarrierView(snap.barrier, viewPath), view); err != nil {
+		return nil, c.restoreMountCleanup(ctx, view, fmt.Errorf("failed to copy mount storage: %w", err))
+	}
+
+	if entry.Table == credentialTableType {
+		err = c.enableCredential(ctx, entry)
+	} else {
+		err = c.mount(ctx, entry)
+	}
+	if err != nil {
+		return nil, c.restoreMountCleanup(ctx, view, err)
+	}
+
+	logger.Info("restored mount from snapshot", "path", path, "type", entry.Type, "uuid", entry.UUID)
+	return entry, nil
+}
+
+// restoreMountSnapshotEntry returns the entry of the mount to restore from the
+// snapshot, looked up by UUID if mountUUID is set and by path otherwise. A
+// mount looked up by UUID is moved to the given path, if any.
+func restoreMountSnapshotEntry(snap *StorageExporter, path, mountUUID string) (*MountEntry, error) {
+	if mountUUID == "" {
+		entry := snap.mountEntry(path)
+		if entry == nil {
+			return nil, logical.CodedError(400, fmt.Sprintf("no mount found at %q in the snapshot", path))
+		}
+		return entry, nil
+	}
+
+	entry, err := snap.mountEntryByUUID(mountUUID)
+	if err != nil {
+		return nil, logical.CodedError(400, err.Error())
+	}
+	if entry == nil {
+		return nil, logical.CodedError(400, fmt.Sprintf("no mount found with UUID %q in the snapshot", mountUUID))
+	}
+	if path == "" {
+		return entry, nil
+	}
+	isAuth := entry.Table == credentialTableType
+	switch {
+	case isAuth && !strings.HasPrefix(path, credentialRoutePrefix):
+		return nil, logical.CodedError(400, fmt.Sprintf("mount %q of the snapshot is an auth mount and must be restored under %q", mountUUID, credentialRoutePrefix))
+	case !isAuth && strings.HasPrefix(path, credentialRoutePrefix):
+		return nil, logical.CodedError(400, fmt.Sprintf("mount %q of the snapshot is not an auth mount and cannot be restored at %q", mountUUID, path))
+	}
+	entry.Path = strings.TrimPrefix(path, credentialRoutePrefix)
+	return entry, nil
+}
+
+// restoreMountCleanup removes the storage copied for a mount that could not
+// be restored, and returns the original error.
+func (c *Core) restoreMountCleanup(ctx context.Context, view *BarrierView, err error) error {
+	if clearErr := logical.ClearView(ctx, view); clearErr != nil {
+		c.logger.Error("failed to clear storage of mount after failed restore", "prefix", view.Prefix(), "error", clearErr)
+	}
+	return err
+}
+
+// copyView copies all the entries of a view to another view.
+func copyView(ctx context.Context, from, to *BarrierView) error {
+	var keys []string
+	if err := logical.ScanView(ctx, from, func(path string) {
+		keys = append(keys, path)
+	}); err != nil {
+		return err
+	}
+	for _, key := range keys {
+		entry, err := from.Get(ctx, key)
+		if err != nil {
+			return err
+		}
+		if entry == nil {
+			continue
+		}
+		if err := to.Put(ctx, entry); err != nil {
+			return err
+		}
+	}
+	return nil
+}
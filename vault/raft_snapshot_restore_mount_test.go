@@ -0,0 +1,30 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/stretchr/testify/require"
+)
+
+// TestCore_RestoreMountFromSnapshot_Namespace verifies that mounts can only be
+// restored from a snapshot in the root namespace.
+func TestCore_RestoreMountFromSnapshot_Namespace(t *testing.T) {
+	c, _, _ := TestCoreUnsealed(t)
+
+	ns := &namespace.Namespace{ID: "ns1", Path: "ns1/"}
+	ctx := namespace.ContextWithNamespace(context.Background(), ns)
+	snap := io.NopCloser(strings.NewReader(""))
+
+	_, err := c.restoreMountFromSnapshot(ctx, snap, "kv/", "")
+	require.ErrorContains(t, err, "mounts can only be restored from a snapshot in the root namespace")
+
+	_, err = c.restoreMountFromSnapshot(ctx, snap, "", "5ebd6ab1-b9a8-4b2a-4c0f-6ae4bd2e2bd7")
+	require.ErrorContains(t, err, "mounts can only be restored from a snapshot in the root namespace")
+}
@@ -457,6 +457,12 @@ func (c *Core) BarrierRekeyUpdate(ctx context.Context, key []byte, nonce string)
 		}
 	}
 
+	// Store the verifiers of the shares before they are encrypted
+	if err := c.barrierRekeyConfig.SetShareVerifiers(c.secureRandomReader, results.SecretShares); err != nil {
+		c.logger.Error("failed to generate share verifiers", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to generate share verifiers: %w", err).Error())
+	}
+
 	// If PGP keys are passed in, encrypt shares with corresponding PGP keys.
 	if len(c.barrierRekeyConfig.PGPKeys) > 0 {
 		hexEncodedShares := make([][]byte, len(results.SecretShares))
@@ -702,6 +708,12 @@ func (c *Core) RecoveryRekeyUpdate(ctx context.Context, key []byte, nonce string
 		results.SecretShares = shares
 	}
 
+	// Store the verifiers of the shares before they are encrypted
+	if err := c.recoveryRekeyConfig.SetShareVerifiers(c.secureRandomReader, results.SecretShares); err != nil {
+		c.logger.Error("failed to generate share verifiers", "error", err)
+		return nil, logical.CodedError(http.StatusInternalServerError, fmt.Errorf("failed to generate share verifiers: %w", err).Error())
+	}
+
 	if len(c.recoveryRekeyConfig.PGPKeys) > 0 {
 		hexEncodedShares := make([][]byte, len(results.SecretShares))
 		for i := range results.SecretShares {
@@ -233,6 +233,9 @@ func testCore_Rekey_Update_Common_Error(t *testing.T, c *Core, keys [][]byte, ro
 	}
 
 	newConf.Nonce = rkconf.Nonce
+
+	testCore_Rekey_CheckShareVerifiers(t, sealConf, newConf, result.SecretShares, keys)
+
 	if !reflect.DeepEqual(sealConf, newConf) {
 		t.Fatalf("\nexpected: %#v\nactual: %#v\nexpType: %s\nrecovery: %t", newConf, sealConf, expType, recovery)
 	}
@@ -331,11 +334,41 @@ func testCore_Rekey_Update_Common_Error(t *testing.T, c *Core, keys [][]byte, ro
 	}
 
 	newConf.Nonce = rkconf.Nonce
+	testCore_Rekey_CheckShareVerifiers(t, sealConf, newConf, result.SecretShares, keys)
 	if !reflect.DeepEqual(sealConf, newConf) {
 		t.Fatalf("bad: %#v", sealConf)
 	}
 }
 
+// testCore_Rekey_CheckShareVerifiers checks that the stored share verifiers
+// accept the new shares and reject the old ones. Since the verifiers are
+// salted with a random value they are then copied into the expected config.
+func testCore_Rekey_CheckShareVerifiers(t *testing.T, sealConf, newConf *SealConfig, newShares, oldShares [][]byte) {
+	t.Helper()
+	if len(newShares) > 0 {
+		for _, share := range newShares {
+			valid, err := sealConf.VerifyShare(share)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if !valid {
+				t.Fatalf("new share did not verify")
+			}
+		}
+		for _, share := range oldShares {
+			valid, err := sealConf.VerifyShare(share)
+			if err != nil {
+				t.Fatalf("err: %v", err)
+			}
+			if valid {
+				t.Fatalf("old share verified after rekey")
+			}
+		}
+	}
+	newConf.ShareVerifierSalt = sealConf.ShareVerifierSalt
+	newConf.ShareVerifiers = sealConf.ShareVerifiers
+}
+
 func TestCore_Rekey_Legacy(t *testing.T) {
 	bc := &SealConfig{
 		SecretShares:    1,
@@ -0,0 +1,37 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"io"
+
+	"github.com/hashicorp/go-hclog"
+)
+
+// reloadSealsEnt finishes switching over to a reloaded seal. It is called with
+// the state lock held, after the new seal was set on the core.
+func (c *Core) reloadSealsEnt(secureRandomReader io.Reader, sealAccess Seal, logger hclog.Logger, shouldRewrap bool) error {
+	if secureRandomReader != nil {
+		c.secureRandomReader = secureRandomReader
+	}
+
+	if c.Sealed() || c.standby || c.activeContext == nil {
+		return nil
+	}
+
+	if autoSeal, ok := sealAccess.(*autoSeal); ok {
+		autoSeal.StartHealthCheck()
+	}
+
+	// Entries wrapped by the previous seal configuration are rewrapped with
+	// the new one, so that it no longer needs to be kept around
+	if shouldRewrap {
+		logger.Info("rewrapping seal wrapped entries with the reloaded seal")
+		c.startSealRewrap(c.activeContext)
+	}
+
+	return nil
+}
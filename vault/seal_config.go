@@ -5,14 +5,25 @@ package vault
 
 import (
 	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
 	"encoding/base64"
+	"errors"
 	"fmt"
+	"io"
 
 	"github.com/ProtonMail/go-crypto/openpgp"
 	"github.com/ProtonMail/go-crypto/openpgp/packet"
 	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
 )
 
+const shareVerifierSaltLength = 32
+
+// ErrNoShareVerifiers is returned when verifying a key share against a seal
+// configuration created before share verifiers were stored. A rekey creates
+// them.
+var ErrNoShareVerifiers = errors.New("no key share verifiers are stored, rekey to create them")
+
 // SealConfig is used to describe the seal configuration
 type SealConfig struct {
 	// The type, for sanity checking. See SealConfigType for valid values.
@@ -65,6 +76,14 @@ type SealConfig struct {
 
 	// Name is the name provided in the seal configuration to identify the seal
 	Name string `json:"name" mapstructure:"name"`
+
+	// ShareVerifierSalt is the random salt of the share verifiers
+	ShareVerifierSalt []byte `json:"share_verifier_salt,omitempty"`
+
+	// ShareVerifiers are salted HMACs of the key shares, which allow a
+	// keyholder to check that a share belongs to the current split of the key
+	// without revealing the key
+	ShareVerifiers [][]byte `json:"share_verifiers,omitempty"`
 }
 
 // Validate is used to sanity check the seal configuration
@@ -128,9 +147,65 @@ func (s *SealConfig) Clone() *SealConfig {
 		ret.VerificationKey = make([]byte, len(s.VerificationKey))
 		copy(ret.VerificationKey, s.VerificationKey)
 	}
+	if len(s.ShareVerifierSalt) > 0 {
+		ret.ShareVerifierSalt = make([]byte, len(s.ShareVerifierSalt))
+		copy(ret.ShareVerifierSalt, s.ShareVerifierSalt)
+	}
+	if len(s.ShareVerifiers) > 0 {
+		ret.ShareVerifiers = make([][]byte, len(s.ShareVerifiers))
+		for i, verifier := range s.ShareVerifiers {
+			ret.ShareVerifiers[i] = make([]byte, len(verifier))
+			copy(ret.ShareVerifiers[i], verifier)
+		}
+	}
 	return ret
 }
 
+// SetShareVerifiers computes the verifiers of the given key shares, using a
+// new salt read from rand. The shares must not be encrypted with PGP.
+func (s *SealConfig) SetShareVerifiers(rand io.Reader, shares [][]byte) error {
+	s.ShareVerifierSalt = nil
+	s.ShareVerifiers = nil
+	if len(shares) == 0 {
+		return nil
+	}
+
+	salt := make([]byte, shareVerifierSaltLength)
+	if _, err := io.ReadFull(rand, salt); err != nil {
+		return fmt.Errorf("failed to generate share verifier salt: %w", err)
+	}
+	s.ShareVerifierSalt = salt
+	for _, share := range shares {
+		s.ShareVerifiers = append(s.ShareVerifiers, s.shareVerifier(share))
+	}
+	return nil
+}
+
+// VerifyShare returns whether the given key share belongs to the split of
+// the key, without using it to unseal. It returns an error if the
+// configuration has no share verifiers.
+func (s *SealConfig) VerifyShare(share []byte) (bool, error) {
+	if len(s.ShareVerifiers) == 0 {
+		return false, ErrNoShareVerifiers
+	}
+
+	verifier := s.shareVerifier(share)
+	valid := false
+	for _, v := range s.ShareVerifiers {
+		// Compare with every verifier to avoid leaking the matching position
+		if hmac.Equal(v, verifier) {
+			valid = true
+		}
+	}
+	return valid, nil
+}
+
+func (s *SealConfig) shareVerifier(share []byte) []byte {
+	mac := hmac.New(sha256.New, s.ShareVerifierSalt)
+	mac.Write(share)
+	return mac.Sum(nil)
+}
+
 // SealConfigType specifies the "type" of a seal according to the following rules:
 // - For a defaultSeal, the type is SealConfigTypeShamir, since all defaultSeals use a shamir wrapper.
 //
@@ -0,0 +1,245 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/seal"
+)
+
+// sealRewrapStatus reports the progress of the rewrap of the seal wrapped
+// entries under the current seal generation.
+type sealRewrapStatus struct {
+	Running   bool
+	StartTime time.Time
+	EndTime   time.Time
+	Processed int
+	Succeeded int
+	Failed    int
+	Error     string
+}
+
+// readSealRewrapStatus returns a copy of the status of the last seal rewrap,
+// or nil if none was started on this node.
+func (c *Core) readSealRewrapStatus() *sealRewrapStatus {
+	c.sealRewrapLock.Lock()
+	defer c.sealRewrapLock.Unlock()
+
+	if c.sealRewrap == nil {
+		return nil
+	}
+	status := *c.sealRewrap
+	return &status
+}
+
+// startSealRewrap rewraps in the background every seal wrapped entry which is
+// not encrypted with the current seal generation and keys. Once all entries
+// have been rewrapped the seal generation is marked as rewrapped. It returns
+// false if a rewrap is already running.
+func (c *Core) startSealRewrap(ctx context.Context) bool {
+	c.sealRewrapLock.Lock()
+	defer c.sealRewrapLock.Unlock()
+
+	if c.sealRewrap != nil && c.sealRewrap.Running {
+		return false
+	}
+
+	c.sealRewrap = &sealRewrapStatus{
+		Running:   true,
+		StartTime: time.Now(),
+	}
+
+	go func() {
+		logger := c.logger.Named("seal-rewrap")
+		logger.Info("rewrapping seal wrapped entries")
+
+		err := c.sealRewrapEntries(ctx, logger)
+		if err == nil {
+			err = c.setSealRewrapped(ctx)
+		}
+
+		c.sealRewrapLock.Lock()
+		defer c.sealRewrapLock.Unlock()
+
+		c.sealRewrap.Running = false
+		c.sealRewrap.EndTime = time.Now()
+		if err != nil {
+			logger.Error("failed to rewrap seal wrapped entries", "error", err)
+			c.sealRewrap.Error = err.Error()
+			return
+		}
+		logger.Info("rewrapped seal wrapped entries", "processed", c.sealRewrap.Processed, "succeeded", c.sealRewrap.Succeeded)
+	}()
+
+	return true
+}
+
+// sealRewrapEntries walks the storage and rewraps the seal wrapped entries.
+// An entry which fails to be rewrapped does not stop the walk, but causes an
+// error to be returned at the end.
+func (c *Core) sealRewrapEntries(ctx context.Context, logger log.Logger) error {
+	access := c.seal.GetAccess()
+
+	var unwrapper *sealUnwrapper
+	switch u := c.sealUnwrapper.(type) {
+	case *sealUnwrapper:
+		unwrapper = u
+	case *transactionalSealUnwrapper:
+		unwrapper = u.sealUnwrapper
+	default:
+		return errors.New("storage does not support seal rewrapping")
+	}
+
+	record := func(key string, wrapped bool, err error) {
+		if !wrapped && err == nil {
+			return
+		}
+
+		c.sealRewrapLock.Lock()
+		defer c.sealRewrapLock.Unlock()
+
+		c.sealRewrap.Processed++
+		if err != nil {
+			logger.Warn("failed to rewrap entry", "key", key, "error", err)
+			c.sealRewrap.Failed++
+			return
+		}
+		c.sealRewrap.Succeeded++
+	}
+
+	// The root key is checked first as it refreshes the key IDs of the seal,
+	// which the other entries are compared against.
+	wrapped, err := c.sealRewrapStoredKeys(ctx, access)
+	record(StoredBarrierKeysPath, wrapped, err)
+	if c.seal.RecoveryKeySupported() {
+		wrapped, err := c.sealRewrapRecoveryKey(ctx, access)
+		record(recoveryKeyPath, wrapped, err)
+	}
+
+	err = walkPhysical(ctx, c.physical, "", func(key string) {
+		if key == StoredBarrierKeysPath || key == recoveryKeyPath {
+			return
+		}
+		wrapped, err := unwrapper.rewrap(ctx, access, key)
+		record(key, wrapped, err)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.sealRewrapLock.Lock()
+	defer c.sealRewrapLock.Unlock()
+	if c.sealRewrap.Failed > 0 {
+		return fmt.Errorf("failed to rewrap %d entries", c.sealRewrap.Failed)
+	}
+	return nil
+}
+
+// sealRewrapStoredKeys rewraps the root key if it is not up-to-date. It
+// returns false if there is no stored root key.
+func (c *Core) sealRewrapStoredKeys(ctx context.Context, access seal.Access) (bool, error) {
+	c.rekeyLock.Lock()
+	defer c.rekeyLock.Unlock()
+
+	pe, err := c.physical.Get(ctx, StoredBarrierKeysPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch stored keys: %w", err)
+	}
+	if pe == nil {
+		return false, nil
+	}
+
+	wrappedEntryValue, err := UnmarshalSealWrappedValue(pe.Value)
+	if err != nil {
+		return true, fmt.Errorf("failed to unmarshal stored keys: %w", err)
+	}
+	uptodate, err := access.IsUpToDate(ctx, wrappedEntryValue.getValue(), true)
+	if err != nil || uptodate {
+		return true, err
+	}
+
+	keys, err := UnsealWrapStoredBarrierKeys(ctx, access, pe)
+	if err != nil {
+		return true, fmt.Errorf("failed to decrypt stored keys: %w", err)
+	}
+	return true, writeStoredKeys(ctx, c.physical, access, keys)
+}
+
+// sealRewrapRecoveryKey rewraps the recovery key if it is not up-to-date.
+// It returns false if there is no stored recovery key.
+func (c *Core) sealRewrapRecoveryKey(ctx context.Context, access seal.Access) (bool, error) {
+	c.rekeyLock.Lock()
+	defer c.rekeyLock.Unlock()
+
+	pe, err := c.physical.Get(ctx, recoveryKeyPath)
+	if err != nil {
+		return false, fmt.Errorf("failed to fetch recovery key: %w", err)
+	}
+	if pe == nil {
+		return false, nil
+	}
+
+	wrappedEntryValue, err := UnmarshalSealWrappedValue(pe.Value)
+	if err != nil {
+		return true, fmt.Errorf("failed to unmarshal recovery key: %w", err)
+	}
+	uptodate, err := access.IsUpToDate(ctx, wrappedEntryValue.getValue(), false)
+	if err != nil || uptodate {
+		return true, err
+	}
+
+	key, err := UnsealWrapRecoveryKey(ctx, access, pe)
+	if err != nil {
+		return true, fmt.Errorf("failed to decrypt recovery key: %w", err)
+	}
+	be, err := SealWrapRecoveryKey(ctx, access, key)
+	if err != nil {
+		return true, err
+	}
+	return true, c.physical.Put(ctx, be)
+}
+
+// setSealRewrapped marks the current seal generation as rewrapped, persisting
+// it when multiple seals are enabled.
+func (c *Core) setSealRewrapped(ctx context.Context) error {
+	sealGenInfo := c.seal.GetAccess().GetSealGenerationInfo()
+	if sealGenInfo == nil {
+		return nil
+	}
+	sealGenInfo.SetRewrapped(true)
+	if c.IsMultisealEnabled() {
+		return c.SetPhysicalSealGenInfo(ctx, sealGenInfo)
+	}
+	return nil
+}
+
+// walkPhysical calls fn for every key stored under prefix.
+func walkPhysical(ctx context.Context, backend physical.Backend, prefix string, fn func(string)) error {
+	keys, err := backend.List(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list %q: %w", prefix, err)
+	}
+	for _, key := range keys {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if strings.HasSuffix(key, "/") {
+			if err := walkPhysical(ctx, backend, prefix+key, fn); err != nil {
+				return err
+			}
+			continue
+		}
+		fn(prefix + key)
+	}
+	return nil
+}
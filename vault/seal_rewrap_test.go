@@ -0,0 +1,173 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+//go:build !enterprise
+
+package vault
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	wrapping "github.com/hashicorp/go-kms-wrapping/v2"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/testhelpers/corehelpers"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/seal"
+	"github.com/stretchr/testify/require"
+)
+
+// TestSealRewrap verifies that the seal wrapped entries are rewrapped with the
+// current key of the seal, and that the progress is reported.
+func TestSealRewrap(t *testing.T) {
+	testSeal, wrappers := seal.NewTestSeal(nil)
+	wrappers[0].Wrapper.(*wrapping.TestWrapper).SetKeyId("kaz")
+
+	c := TestCoreWithSeal(t, NewAutoSeal(testSeal), false)
+	_, keys, root := TestCoreInitClusterWrapperSetup(t, c, nil)
+	for _, key := range keys {
+		_, err := TestCoreUnseal(c, key)
+		require.NoError(t, err)
+	}
+	require.False(t, c.Sealed())
+
+	ctx := namespace.RootContext(context.Background())
+
+	var underlying physical.Backend
+	switch u := c.sealUnwrapper.(type) {
+	case *sealUnwrapper:
+		underlying = u.underlying
+	case *transactionalSealUnwrapper:
+		underlying = u.underlying
+	}
+
+	wrappedEntryValue, err := SealWrapValue(ctx, c.seal.GetAccess(), true, []byte("value"), DisallowPartialSealWrap)
+	require.NoError(t, err)
+	value, err := MarshalSealWrappedValueWithCanary(wrappedEntryValue)
+	require.NoError(t, err)
+	require.NoError(t, c.sealUnwrapper.Put(ctx, &physical.Entry{
+		Key:   "test/wrapped",
+		Value: value,
+	}))
+
+	keyID := func(key string) string {
+		t.Helper()
+
+		pe, err := underlying.Get(ctx, key)
+		require.NoError(t, err)
+		require.NotNil(t, pe)
+
+		wrappedEntryValue, ok := UnmarshalSealWrappedValueWithCanary(pe.Value)
+		if !ok {
+			wrappedEntryValue, err = UnmarshalSealWrappedValue(pe.Value)
+			require.NoError(t, err)
+		}
+		return wrappedEntryValue.GetSlots()[0].KeyInfo.KeyId
+	}
+	for _, key := range []string{StoredBarrierKeysPath, recoveryKeyPath, "test/wrapped"} {
+		require.Equal(t, "kaz", keyID(key))
+	}
+
+	req := logical.TestRequest(t, logical.ReadOperation, "sys/sealwrap/rewrap")
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, false, resp.Data["is_running"])
+
+	wrappers[0].Wrapper.(*wrapping.TestWrapper).SetKeyId("primanti")
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/sealwrap/rewrap")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Nil(t, resp)
+
+	require.Eventually(t, func() bool {
+		status := c.readSealRewrapStatus()
+		return status != nil && !status.Running
+	}, 10*time.Second, 50*time.Millisecond)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/sealwrap/rewrap")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"processed": 3,
+		"succeeded": 3,
+		"failed":    0,
+	}, resp.Data["entries"])
+	require.NotContains(t, resp.Data, "error")
+
+	for _, key := range []string{StoredBarrierKeysPath, recoveryKeyPath, "test/wrapped"} {
+		require.Equal(t, "primanti", keyID(key))
+	}
+	require.True(t, c.seal.GetAccess().GetSealGenerationInfo().IsRewrapped())
+
+	// The rewrapped values are unchanged
+	pe, err := underlying.Get(ctx, "test/wrapped")
+	require.NoError(t, err)
+	wrappedEntryValue, _ = UnmarshalSealWrappedValueWithCanary(pe.Value)
+	pt, _, err := UnsealWrapValue(ctx, c.seal.GetAccess(), "test/wrapped", wrappedEntryValue)
+	require.NoError(t, err)
+	require.Equal(t, []byte("value"), pt)
+
+	_, err = c.seal.GetStoredKeys(ctx)
+	require.NoError(t, err)
+	_, err = c.seal.RecoveryKey(ctx)
+	require.NoError(t, err)
+}
+
+// entryBackend is a physical backend that stores the entries as given, so
+// that every field of them can be checked.
+type entryBackend struct {
+	physical.Backend
+	entries map[string]*physical.Entry
+}
+
+func (b *entryBackend) Get(_ context.Context, key string) (*physical.Entry, error) {
+	return b.entries[key], nil
+}
+
+func (b *entryBackend) Put(_ context.Context, entry *physical.Entry) error {
+	b.entries[entry.Key] = entry
+	return nil
+}
+
+// TestSealUnwrapper_Rewrap verifies that rewrapping an entry only replaces its
+// value.
+func TestSealUnwrapper_Rewrap(t *testing.T) {
+	ctx := context.Background()
+	access, wrappers := seal.NewTestSeal(nil)
+	wrappers[0].Wrapper.(*wrapping.TestWrapper).SetKeyId("kaz")
+
+	wrappedEntryValue, err := SealWrapValue(ctx, access, true, []byte("value"), DisallowPartialSealWrap)
+	require.NoError(t, err)
+	value, err := MarshalSealWrappedValueWithCanary(wrappedEntryValue)
+	require.NoError(t, err)
+
+	backend := &entryBackend{entries: map[string]*physical.Entry{
+		"test/wrapped": {
+			Key:       "test/wrapped",
+			Value:     value,
+			SealWrap:  true,
+			ValueHash: []byte("hash"),
+		},
+	}}
+	unwrapper := NewSealUnwrapper(backend, corehelpers.NewTestLogger(t)).(*sealUnwrapper)
+
+	// Encrypting refreshes the key IDs known to the seal
+	wrappers[0].Wrapper.(*wrapping.TestWrapper).SetKeyId("primanti")
+	_, errs := access.Encrypt(ctx, []byte{0})
+	require.Empty(t, errs)
+
+	wrapped, err := unwrapper.rewrap(ctx, access, "test/wrapped")
+	require.NoError(t, err)
+	require.True(t, wrapped)
+
+	entry := backend.entries["test/wrapped"]
+	require.NotEqual(t, value, entry.Value)
+	require.True(t, entry.SealWrap)
+	require.Equal(t, []byte("hash"), entry.ValueHash)
+}
@@ -5,6 +5,7 @@ package vault
 
 import (
 	"context"
+	"crypto/rand"
 	"reflect"
 	"testing"
 )
@@ -47,3 +48,51 @@ func TestDefaultSeal_Config(t *testing.T) {
 		t.Fatal("config mismatch")
 	}
 }
+
+// TestSealConfig_ShareVerifiers checks that stored share verifiers accept
+// exactly the shares they were created from.
+func TestSealConfig_ShareVerifiers(t *testing.T) {
+	sc := &SealConfig{
+		SecretShares:    3,
+		SecretThreshold: 2,
+	}
+	if _, err := sc.VerifyShare([]byte("share")); err != ErrNoShareVerifiers {
+		t.Fatalf("expected ErrNoShareVerifiers, got: %v", err)
+	}
+
+	shares := [][]byte{[]byte("share-1"), []byte("share-2"), []byte("share-3")}
+	if err := sc.SetShareVerifiers(rand.Reader, shares); err != nil {
+		t.Fatal(err)
+	}
+	for _, share := range shares {
+		valid, err := sc.VerifyShare(share)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !valid {
+			t.Fatalf("share %q did not verify", share)
+		}
+	}
+	valid, err := sc.VerifyShare([]byte("share-4"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if valid {
+		t.Fatal("unknown share verified")
+	}
+
+	// Cloning must not share the verifier slices
+	clone := sc.Clone()
+	clone.ShareVerifiers[0][0] ^= 0xff
+	if valid, _ := sc.VerifyShare(shares[0]); !valid {
+		t.Fatal("modifying the clone changed the original verifiers")
+	}
+
+	// Clearing the verifiers disables verification
+	if err := sc.SetShareVerifiers(rand.Reader, nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := sc.VerifyShare(shares[0]); err != ErrNoShareVerifiers {
+		t.Fatalf("expected ErrNoShareVerifiers, got: %v", err)
+	}
+}
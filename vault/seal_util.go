@@ -114,6 +114,16 @@ func MarshalSealWrappedValue(wrappedEntryValue *SealWrappedValue) ([]byte, error
 	return proto.Marshal(wrappedEntryValue.value.Slots[0])
 }
 
+// MarshalSealWrappedValueWithCanary marshals a SealWrappedValue into a byte slice, appending the 's'
+// canary value expected by UnmarshalSealWrappedValueWithCanary.
+func MarshalSealWrappedValueWithCanary(wrappedEntryValue *SealWrappedValue) ([]byte, error) {
+	value, err := MarshalSealWrappedValue(wrappedEntryValue)
+	if err != nil {
+		return nil, err
+	}
+	return append(value, 's'), nil
+}
+
 // UnmarshalSealWrappedValue attempts to unmarshal a SealWrappedValue. This method can unmarshal marshalled
 // SealWrappedValues as well as wrapping.BlobInfos. When a BlobInfo is encountered, a "transitory"
 // SealWrappedValue will be returned.
@@ -13,6 +13,7 @@ import (
 	log "github.com/hashicorp/go-hclog"
 	"github.com/hashicorp/vault/sdk/helper/locksutil"
 	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/vault/seal"
 )
 
 // NewSealUnwrapper creates a new seal unwrapper
@@ -135,6 +136,50 @@ func (d *sealUnwrapper) Delete(ctx context.Context, key string) error {
 	return d.underlying.Delete(ctx, key)
 }
 
+// rewrap re-encrypts the entry stored at key with the given seal access if it is
+// seal wrapped and not up-to-date. It returns false if the entry is not seal
+// wrapped and encrypted.
+func (d *sealUnwrapper) rewrap(ctx context.Context, access seal.Access, key string) (bool, error) {
+	locksutil.LockForKey(d.locks, key).Lock()
+	defer locksutil.LockForKey(d.locks, key).Unlock()
+
+	entry, err := d.underlying.Get(ctx, key)
+	if err != nil {
+		return false, err
+	}
+	if entry == nil {
+		return false, nil
+	}
+
+	wrappedEntryValue, unmarshaled := UnmarshalSealWrappedValueWithCanary(entry.Value)
+	if !unmarshaled || !wrappedEntryValue.isEncrypted() {
+		return false, nil
+	}
+
+	uptodate, err := access.IsUpToDate(ctx, wrappedEntryValue.getValue(), false)
+	if err != nil || uptodate {
+		return true, err
+	}
+
+	pt, _, err := UnsealWrapValue(ctx, access, key, wrappedEntryValue)
+	if err != nil {
+		return true, err
+	}
+	wrappedEntryValue, err = SealWrapValue(ctx, access, true, pt, DisallowPartialSealWrap)
+	if err != nil {
+		return true, err
+	}
+	value, err := MarshalSealWrappedValueWithCanary(wrappedEntryValue)
+	if err != nil {
+		return true, err
+	}
+
+	// Keep every other field of the entry, such as SealWrap
+	rewrapped := *entry
+	rewrapped.Value = value
+	return true, d.underlying.Put(ctx, &rewrapped)
+}
+
 func (d *sealUnwrapper) List(ctx context.Context, prefix string) ([]string, error) {
 	return d.underlying.List(ctx, prefix)
 }
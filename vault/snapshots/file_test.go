@@ -0,0 +1,83 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshots
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// TestFileSink verifies that snapshots written to a file sink can be listed,
+// read back through a source and deleted.
+func TestFileSink(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "snapshots")
+	sink := NewFileSnapshotSink(dir)
+
+	// Listing a directory that doesn't exist yet is not an error
+	names, err := sink.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, names)
+
+	testSink(t, sink)
+
+	url, err := sink.Write(ctx, "a.snap", strings.NewReader("a"))
+	require.NoError(t, err)
+	require.Equal(t, filepath.Join(dir, "a.snap"), url)
+
+	info, err := os.Stat(dir)
+	require.NoError(t, err)
+	require.Equal(t, os.FileMode(0o700), info.Mode().Perm())
+
+	// Hidden and non-regular files are not listed
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".partial.tmp"), nil, 0o600))
+	require.NoError(t, os.Mkdir(filepath.Join(dir, "subdir"), 0o700))
+	names, err = sink.List(ctx)
+	require.NoError(t, err)
+	require.Equal(t, []string{"a.snap"}, names)
+}
+
+// testSink exercises the behavior common to all Sink implementations.
+func testSink(t *testing.T, sink Sink) {
+	t.Helper()
+	ctx := context.Background()
+
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		_, err := sink.Write(ctx, name, strings.NewReader("data"))
+		require.ErrorIs(t, err, ErrInvalidName, "name %q", name)
+		_, err = sink.Source(ctx, name)
+		require.ErrorIs(t, err, ErrInvalidName, "name %q", name)
+		require.ErrorIs(t, sink.Delete(ctx, name), ErrInvalidName, "name %q", name)
+	}
+
+	_, err := sink.Write(ctx, "one.snap", strings.NewReader("first"))
+	require.NoError(t, err)
+	_, err = sink.Write(ctx, "two.snap", strings.NewReader("second"))
+	require.NoError(t, err)
+
+	names, err := sink.List(ctx)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []string{"one.snap", "two.snap"}, names)
+
+	source, err := sink.Source(ctx, "two.snap")
+	require.NoError(t, err)
+	r, err := source.ReadCloser(ctx)
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.NoError(t, r.Close())
+	require.Equal(t, "second", string(data))
+
+	require.NoError(t, sink.Delete(ctx, "one.snap"))
+	require.NoError(t, sink.Delete(ctx, "two.snap"))
+	names, err = sink.List(ctx)
+	require.NoError(t, err)
+	require.Empty(t, names)
+}
@@ -0,0 +1,203 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/session"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3manager"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-secure-stdlib/awsutil"
+)
+
+var (
+	_ Sink   = (*s3Sink)(nil)
+	_ Source = (*s3Source)(nil)
+)
+
+// S3Config configures access to an S3 compatible object store
+type S3Config struct {
+	Bucket string
+	// PathPrefix is the key prefix under which snapshots are stored
+	PathPrefix      string
+	Region          string
+	Endpoint        string
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	DisableTLS      bool
+	ForcePathStyle  bool
+	// KMSKeyID enables server side encryption of written snapshots with the
+	// given key when set
+	KMSKeyID string
+}
+
+type s3Sink struct {
+	client   *s3.S3
+	uploader *s3manager.Uploader
+	bucket   string
+	prefix   string
+	kmsKeyID string
+}
+
+// NewS3SnapshotSink creates a new Sink that stores snapshots as objects in an
+// S3 compatible bucket. Credentials not provided in the config are sourced
+// from the environment, AWS credential files or the instance role.
+func NewS3SnapshotSink(config *S3Config, logger hclog.Logger) (Sink, error) {
+	if config.Bucket == "" {
+		return nil, errors.New("bucket is required")
+	}
+
+	region := config.Region
+	if region == "" {
+		region = "us-east-1"
+	}
+
+	credsConfig := &awsutil.CredentialsConfig{
+		AccessKey:    config.AccessKeyID,
+		SecretKey:    config.SecretAccessKey,
+		SessionToken: config.SessionToken,
+		Logger:       logger,
+	}
+	creds, err := credsConfig.GenerateCredentialChain()
+	if err != nil {
+		return nil, err
+	}
+
+	sess, err := session.NewSession(&aws.Config{
+		Credentials: creds,
+		HTTPClient: &http.Client{
+			Transport: cleanhttp.DefaultPooledTransport(),
+		},
+		Endpoint:         aws.String(config.Endpoint),
+		Region:           aws.String(region),
+		S3ForcePathStyle: aws.Bool(config.ForcePathStyle),
+		DisableSSL:       aws.Bool(config.DisableTLS),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	client := s3.New(sess)
+	return &s3Sink{
+		client:   client,
+		uploader: s3manager.NewUploaderWithClient(client),
+		bucket:   config.Bucket,
+		prefix:   strings.Trim(config.PathPrefix, "/"),
+		kmsKeyID: config.KMSKeyID,
+	}, nil
+}
+
+func (s *s3Sink) Type(_ context.Context) string {
+	return "aws-s3"
+}
+
+func (s *s3Sink) key(name string) string {
+	return path.Join(s.prefix, name)
+}
+
+func (s *s3Sink) Write(ctx context.Context, name string, r io.Reader) (string, error) {
+	if err := validateName(name); err != nil {
+		return "", err
+	}
+
+	input := &s3manager.UploadInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+		Body:   r,
+	}
+	if s.kmsKeyID != "" {
+		input.ServerSideEncryption = aws.String("aws:kms")
+		input.SSEKMSKeyId = aws.String(s.kmsKeyID)
+	}
+
+	// The uploader streams the snapshot in parts, so it is never held in
+	// memory in its entirety.
+	if _, err := s.uploader.UploadWithContext(ctx, input); err != nil {
+		return "", fmt.Errorf("failed to upload snapshot: %w", err)
+	}
+
+	return fmt.Sprintf("s3://%s/%s", s.bucket, s.key(name)), nil
+}
+
+func (s *s3Sink) List(ctx context.Context) ([]string, error) {
+	prefix := ""
+	if s.prefix != "" {
+		prefix = s.prefix + "/"
+	}
+
+	var names []string
+	err := s.client.ListObjectsV2PagesWithContext(ctx, &s3.ListObjectsV2Input{
+		Bucket:    aws.String(s.bucket),
+		Prefix:    aws.String(prefix),
+		Delimiter: aws.String("/"),
+	}, func(page *s3.ListObjectsV2Output, _ bool) bool {
+		for _, obj := range page.Contents {
+			name := strings.TrimPrefix(aws.StringValue(obj.Key), prefix)
+			if name != "" {
+				names = append(names, name)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+
+	return names, nil
+}
+
+func (s *s3Sink) Delete(ctx context.Context, name string) error {
+	if err := validateName(name); err != nil {
+		return err
+	}
+
+	_, err := s.client.DeleteObjectWithContext(ctx, &s3.DeleteObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key(name)),
+	})
+	return err
+}
+
+func (s *s3Sink) Source(_ context.Context, name string) (Source, error) {
+	if err := validateName(name); err != nil {
+		return nil, err
+	}
+	return &s3Source{
+		client: s.client,
+		bucket: s.bucket,
+		key:    s.key(name),
+	}, nil
+}
+
+type s3Source struct {
+	client *s3.S3
+	bucket string
+	key    string
+}
+
+func (s *s3Source) Type(_ context.Context) string {
+	return "aws-s3"
+}
+
+func (s *s3Source) ReadCloser(ctx context.Context) (io.ReadCloser, error) {
+	out, err := s.client.GetObjectWithContext(ctx, &s3.GetObjectInput{
+		Bucket: aws.String(s.bucket),
+		Key:    aws.String(s.key),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to read snapshot: %w", err)
+	}
+	return out.Body, nil
+}
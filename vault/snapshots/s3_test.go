@@ -0,0 +1,57 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshots
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/testhelpers/minio"
+	"github.com/stretchr/testify/require"
+)
+
+// TestS3Sink verifies the S3 sink against a MinIO container, both at the root
+// of the bucket and under a path prefix.
+func TestS3Sink(t *testing.T) {
+	cleanup, config := minio.PrepareTestContainer(t, "")
+	defer cleanup()
+
+	conn, err := config.Conn()
+	require.NoError(t, err)
+	_, err = conn.CreateBucket(&s3.CreateBucketInput{Bucket: aws.String("snapshots")})
+	require.NoError(t, err)
+
+	for _, prefix := range []string{"", "cluster-a/daily"} {
+		t.Run("prefix="+prefix, func(t *testing.T) {
+			sink, err := NewS3SnapshotSink(&S3Config{
+				Bucket:          "snapshots",
+				PathPrefix:      prefix,
+				Region:          config.Region,
+				Endpoint:        config.Endpoint,
+				AccessKeyID:     config.AccessKeyID,
+				SecretAccessKey: config.SecretAccessKey,
+				DisableTLS:      true,
+				ForcePathStyle:  true,
+			}, hclog.NewNullLogger())
+			require.NoError(t, err)
+
+			testSink(t, sink)
+
+			url, err := sink.Write(context.Background(), "a.snap", strings.NewReader("a"))
+			require.NoError(t, err)
+			require.Equal(t, "s3://snapshots/"+strings.TrimPrefix(prefix+"/a.snap", "/"), url)
+			require.NoError(t, sink.Delete(context.Background(), "a.snap"))
+		})
+	}
+}
+
+// TestNewS3SnapshotSink_RequiresBucket verifies that a bucket must be given.
+func TestNewS3SnapshotSink_RequiresBucket(t *testing.T) {
+	_, err := NewS3SnapshotSink(&S3Config{}, hclog.NewNullLogger())
+	require.EqualError(t, err, "bucket is required")
+}
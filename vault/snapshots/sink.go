@@ -0,0 +1,41 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: MPL-2.0
+
+package snapshots
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidName is returned by a Sink when a snapshot name is empty or
+// contains a path separator.
+var ErrInvalidName = errors.New("invalid snapshot name")
+
+// Sink is used to write raw snapshot data and to manage the snapshots it has
+// previously written
+type Sink interface {
+	// Write stores the snapshot data read from r under the given name and
+	// returns a URL identifying the written snapshot
+	Write(ctx context.Context, name string, r io.Reader) (string, error)
+
+	// List returns the names of the snapshots stored by the sink
+	List(ctx context.Context) ([]string, error)
+
+	// Delete removes the named snapshot
+	Delete(ctx context.Context, name string) error
+
+	// Source returns a Source that reads back the named snapshot
+	Source(ctx context.Context, name string) (Source, error)
+
+	Type(ctx context.Context) string
+}
+
+func validateName(name string) error {
+	if name == "" || name == "." || name == ".." || strings.ContainsAny(name, `/\`) {
+		return ErrInvalidName
+	}
+	return nil
+}
@@ -0,0 +1,642 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang/protobuf/ptypes"
+	log "github.com/hashicorp/go-hclog"
+	aeadwrapper "github.com/hashicorp/go-kms-wrapping/wrappers/aead/v2"
+	kv "github.com/hashicorp/vault-plugin-secrets-kv"
+	"github.com/hashicorp/vault/helper/identity"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/storagepacker"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/shamir"
+	vaultseal "github.com/hashicorp/vault/vault/seal"
+)
+
+// StorageExporterConfig configures a StorageExporter. Exactly one of
+// UnsealKeys and Keyring must be set.
+type StorageExporterConfig struct {
+	Logger log.Logger
+
+	// Backend holds a copy of the storage, such as the contents of a raft
+	// snapshot. The exporter may write to it, so it must not be the storage
+	// of a running cluster.
+	Backend physical.Backend
+
+	// UnsealKeys are Shamir unseal key shares
+	UnsealKeys [][]byte
+
+	// Keyring is a keyring opened from a keyring escrow bundle
+	Keyring *Keyring
+}
+
+// StorageExporter decrypts a copy of the storage without a running core, to
+// export selected data as JSON for forensics or selective recovery.
+type StorageExporter struct {
+	logger  log.Logger
+	barrier *AESGCMBarrier
+	mounts  []*MountEntry
+	auth    []*MountEntry
+
+	// namespaceMounts are the secrets and auth mounts of namespaces other
+	// than the root namespace, which are not exported
+	namespaceMounts []*MountEntry
+}
+
+// KVMountExport holds the secrets exported from a KV mount, keyed by their
+// path within the mount. KV version 2 secrets hold their metadata and the
+// data of each of their versions.
+type KVMountExport struct {
+	Version int                    `json:"version"`
+	Secrets map[string]interface{} `json:"secrets"`
+}
+
+// IdentityExport holds the entities and groups exported from the identity
+// store, in the format of the identity API where possible.
+type IdentityExport struct {
+	Entities []map[string]interface{} `json:"entities"`
+	Groups   []map[string]interface{} `json:"groups"`
+}
+
+// NewStorageExporter unseals a barrier over the storage in the given
+// configuration and loads its mount table.
+func NewStorageExporter(ctx context.Context, conf *StorageExporterConfig) (*StorageExporter, error) {
+	if conf.Backend == nil {
+		return nil, errors.New("missing storage backend")
+	}
+	if (len(conf.UnsealKeys) == 0) == (conf.Keyring == nil) {
+		return nil, errors.New("exactly one of unseal keys and a keyring is required")
+	}
+
+	logger := conf.Logger
+	if logger == nil {
+		logger = log.NewNullLogger()
+	}
+
+	barrier, err := NewAESGCMBarrier(conf.Backend, false)
+	if err != nil {
+		return nil, fmt.Errorf("failed to construct barrier: %w", err)
+	}
+
+	e := &StorageExporter{
+		logger:  logger,
+		barrier: barrier,
+	}
+
+	if conf.Keyring != nil {
+		err = e.unsealWithKeyring(ctx, conf.Keyring)
+	} else {
+		err = e.unsealWithKeys(ctx, conf.Backend, conf.UnsealKeys)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := e.loadMounts(ctx); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *StorageExporter) unsealWithKeys(ctx context.Context, backend physical.Backend, keys [][]byte) error {
+	pe, err := backend.Get(ctx, barrierSealConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to read seal configuration: %w", err)
+	}
+	if pe == nil {
+		return errors.New("seal configuration missing, storage is not initialized")
+	}
+	sealConf := new(SealConfig)
+	if err := jsonutil.DecodeJSON(pe.Value, sealConf); err != nil {
+		return fmt.Errorf("failed to decode seal configuration: %w", err)
+	}
+	if sealConf.Type != SealConfigTypeShamir.String() {
+		return fmt.Errorf("storage uses a %q seal, unseal keys can only be used with a shamir seal", sealConf.Type)
+	}
+	if len(keys) < sealConf.SecretThreshold {
+		return fmt.Errorf("%d unseal keys are required, got %d", sealConf.SecretThreshold, len(keys))
+	}
+
+	var combinedKey []byte
+	if sealConf.SecretThreshold == 1 {
+		combinedKey = keys[0]
+	} else {
+		combinedKey, err = shamir.Combine(keys)
+		if err != nil {
+			return fmt.Errorf("failed to combine unseal keys: %w", err)
+		}
+		defer memzero(combinedKey)
+	}
+
+	// Without stored shares, the combined key is the root key
+	rootKey := combinedKey
+	if sealConf.StoredShares > 0 {
+		access, err := vaultseal.NewAccessFromWrapper(e.logger, aeadwrapper.NewShamirWrapper(), SealConfigTypeShamir.String())
+		if err != nil {
+			return fmt.Errorf("failed to setup seal wrapper: %w", err)
+		}
+		if err := access.SetShamirSealKey(combinedKey); err != nil {
+			return fmt.Errorf("failed to setup unseal key: %w", err)
+		}
+		storedKeys, err := readStoredKeys(ctx, backend, access)
+		if err == nil && len(storedKeys) != 1 {
+			err = fmt.Errorf("expected exactly one stored key, got %d", len(storedKeys))
+		}
+		if err != nil {
+			return fmt.Errorf("unable to retrieve stored keys, the unseal keys may be incorrect: %w", err)
+		}
+		rootKey = storedKeys[0]
+	}
+
+	if err := e.barrier.Unseal(ctx, rootKey); err != nil {
+		return fmt.Errorf("failed to unseal barrier: %w", err)
+	}
+	return nil
+}
+
+func (e *StorageExporter) unsealWithKeyring(ctx context.Context, keyring *Keyring) error {
+	// Prefer the keyring in storage, which has the terms added by rotations
+	// since the keyring was escrowed, as long as the root key is unchanged.
+	err := e.barrier.Unseal(ctx, keyring.RootKey())
+	switch {
+	case err == nil:
+		return nil
+	case errors.Is(err, ErrBarrierInvalidKey):
+		e.logger.Warn("root key has changed since the keyring was escrowed, using the escrowed keyring", "term", keyring.ActiveTerm())
+		e.barrier.unsealWithKeyring(keyring)
+		return nil
+	default:
+		return fmt.Errorf("failed to unseal barrier: %w", err)
+	}
+}
+
+func (e *StorageExporter) loadMounts(ctx context.Context) error {
+	var err error
+	e.mounts, err = e.loadMountTables(ctx, mountTableType, coreMountConfigPath, coreLocalMountConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load mount table: %w", err)
+	}
+	if len(e.mounts) == 0 {
+		return errors.New("mount table missing")
+	}
+	e.auth, err = e.loadMountTables(ctx, credentialTableType, coreAuthConfigPath, coreLocalAuthConfigPath)
+	if err != nil {
+		return fmt.Errorf("failed to load auth table: %w", err)
+	}
+	return nil
+}
+
+func (e *StorageExporter) loadMountTables(ctx context.Context, tableType string, paths ...string) ([]*MountEntry, error) {
+	var entries []*MountEntry
+	for _, path := range paths {
+		raw, err := e.barrier.Get(ctx, path)
+		if err != nil {
+			return nil, err
+		}
+		if raw == nil {
+			continue
+		}
+		table := new(MountTable)
+		if err := jsonutil.DecodeJSON(raw.Value, table); err != nil {
+			return nil, err
+		}
+		for _, entry := range table.Entries {
+			entry.Table = tableType
+			// Only the root namespace is supported
+			if entry.NamespaceID != "" && entry.NamespaceID != namespace.RootNamespaceID {
+				e.namespaceMounts = append(e.namespaceMounts, entry)
+				continue
+			}
+			entries = append(entries, entry)
+		}
+	}
+	return entries, nil
+}
+
+// mountEntry returns the entry of the secrets mount at the given path, or of
+// the auth mount if the path starts with "auth/".
+func (e *StorageExporter) mountEntry(path string) *MountEntry {
+	entries := e.mounts
+	if strings.HasPrefix(path, credentialRoutePrefix) {
+		entries = e.auth
+		path = strings.TrimPrefix(path, credentialRoutePrefix)
+	}
+	for _, entry := range entries {
+		if entry.Path == path {
+			return entry
+		}
+	}
+	return nil
+}
+
+// mountEntryByUUID returns the entry of the secrets or auth mount with the
+// given UUID. An error is returned if the mount belongs to a namespace other
+// than the root namespace.
+func (e *StorageExporter) mountEntryByUUID(uuid string) (*MountEntry, error) {
+	for _, entries := range [][]*MountEntry{e.mounts, e.auth} {
+		for _, entry := range entries {
+			if entry.UUID == uuid {
+				return entry, nil
+			}
+		}
+	}
+	for _, entry := range e.namespaceMounts {
+		if entry.UUID == uuid {
+			return nil, fmt.Errorf("mount %q belongs to namespace %q, only mounts of the root namespace are supported", uuid, entry.NamespaceID)
+		}
+	}
+	return nil, nil
+}
+
+func (e *StorageExporter) mountView(entry *MountEntry) *BarrierView {
+	return NewBarrierView(e.barrier, entry.ViewPath())
+}
+
+func isKVMount(entry *MountEntry) bool {
+	return entry.Type == mountTypeKV || entry.Type == "generic"
+}
+
+// ExportKV exports the secrets of KV mounts. Each path is a mount path,
+// optionally followed by the prefix of the secrets to export from the mount.
+// All KV mounts are exported when no path is given.
+func (e *StorageExporter) ExportKV(ctx context.Context, paths []string) (map[string]*KVMountExport, error) {
+	prefixes := make(map[*MountEntry][]string)
+	if len(paths) == 0 {
+		for _, entry := range e.mounts {
+			if isKVMount(entry) {
+				prefixes[entry] = []string{""}
+			}
+		}
+	}
+	for _, p := range paths {
+		p = strings.TrimPrefix(p, "/")
+		var match *MountEntry
+		for _, entry := range e.mounts {
+			if !isKVMount(entry) || !strings.HasPrefix(p+"/", entry.Path) {
+				continue
+			}
+			if match == nil || len(entry.Path) > len(match.Path) {
+				match = entry
+			}
+		}
+		if match == nil {
+			return nil, fmt.Errorf("no KV mount found for %q", p)
+		}
+		var prefix string
+		if len(p) > len(match.Path) {
+			prefix = p[len(match.Path):]
+		}
+		prefixes[match] = append(prefixes[match], prefix)
+	}
+
+	out := make(map[string]*KVMountExport, len(prefixes))
+	for entry, entryPrefixes := range prefixes {
+		export, err := e.exportKVMount(ctx, entry, entryPrefixes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to export mount %q: %w", entry.Path, err)
+		}
+		out[entry.Path] = export
+	}
+	return out, nil
+}
+
+// exportKVMount runs the KV backend over the storage of the mount and reads
+// the secrets under the given prefixes through it.
+func (e *StorageExporter) exportKVMount(ctx context.Context, entry *MountEntry, prefixes []string) (*KVMountExport, error) {
+	config := make(map[string]string, len(entry.Options))
+	for k, v := range entry.Options {
+		config[k] = v
+	}
+	version := 1
+	if config["version"] != "" {
+		var err error
+		version, err = strconv.Atoi(config["version"])
+		if err != nil {
+			return nil, fmt.Errorf("invalid KV version %q", config["version"])
+		}
+	}
+
+	view := e.mountView(entry)
+	backend, err := kv.Factory(ctx, &logical.BackendConfig{
+		StorageView: view,
+		Logger:      e.logger.Named("kv"),
+		System: &logical.StaticSystemView{
+			DefaultLeaseTTLVal: 32 * 24 * time.Hour,
+			MaxLeaseTTLVal:     32 * 24 * time.Hour,
+		},
+		BackendUUID: entry.BackendAwareUUID,
+		Config:      config,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create KV backend: %w", err)
+	}
+	defer backend.Cleanup(ctx)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) (map[string]interface{}, error) {
+		resp, err := backend.HandleRequest(ctx, &logical.Request{
+			Operation: op,
+			Path:      path,
+			Data:      data,
+			Storage:   view,
+		})
+		if err != nil {
+			return nil, err
+		}
+		if resp == nil {
+			return nil, nil
+		}
+		// Deleted and destroyed versions of KV version 2 secrets are returned
+		// with a 404 status and their metadata
+		if resp.Data[logical.HTTPStatusCode] == http.StatusNotFound {
+			var raw map[string]interface{}
+			if err := jsonutil.DecodeJSON([]byte(resp.Data[logical.HTTPRawBody].(string)), &raw); err == nil {
+				if data, ok := raw["data"].(map[string]interface{}); ok {
+					return data, nil
+				}
+			}
+			return nil, nil
+		}
+		if resp.IsError() {
+			return nil, resp.Error()
+		}
+		return resp.Data, nil
+	}
+
+	export := &KVMountExport{
+		Version: version,
+		Secrets: make(map[string]interface{}),
+	}
+
+	listRoot := ""
+	if version == 2 {
+		listRoot = "metadata/"
+	}
+	var keys []string
+	for _, prefix := range prefixes {
+		found, err := e.listKV(request, listRoot, prefix)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, found...)
+	}
+
+	for _, key := range keys {
+		if version != 2 {
+			data, err := request(logical.ReadOperation, key, nil)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read %q: %w", key, err)
+			}
+			export.Secrets[key] = data
+			continue
+		}
+
+		metadata, err := request(logical.ReadOperation, "metadata/"+key, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read metadata of %q: %w", key, err)
+		}
+		versions := make(map[string]interface{})
+		if versionsMeta, ok := metadata["versions"].(map[string]interface{}); ok {
+			for v := range versionsMeta {
+				data, err := request(logical.ReadOperation, "data/"+key, map[string]interface{}{"version": v})
+				if err != nil {
+					return nil, fmt.Errorf("failed to read version %s of %q: %w", v, key, err)
+				}
+				versions[v] = data
+			}
+		}
+		export.Secrets[key] = map[string]interface{}{
+			"metadata": metadata,
+			"versions": versions,
+		}
+	}
+
+	return export, nil
+}
+
+// listKV recursively lists the secrets under prefix. A prefix that is not a
+// folder is returned as is if the secret exists.
+func (e *StorageExporter) listKV(request func(logical.Operation, string, map[string]interface{}) (map[string]interface{}, error), root, prefix string) ([]string, error) {
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		data, err := request(logical.ReadOperation, root+prefix, nil)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %q: %w", prefix, err)
+		}
+		var keys []string
+		if data != nil {
+			keys = append(keys, prefix)
+		}
+		folder, err := e.listKV(request, root, prefix+"/")
+		if err != nil {
+			return nil, err
+		}
+		return append(keys, folder...), nil
+	}
+
+	data, err := request(logical.ListOperation, root+prefix, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list %q: %w", prefix, err)
+	}
+	var keys []string
+	list, _ := data["keys"].([]string)
+	for _, k := range list {
+		if strings.HasSuffix(k, "/") {
+			children, err := e.listKV(request, root, prefix+k)
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, children...)
+			continue
+		}
+		keys = append(keys, prefix+k)
+	}
+	return keys, nil
+}
+
+// ExportPolicies exports the ACL policies, keyed by name.
+func (e *StorageExporter) ExportPolicies(ctx context.Context) (map[string]string, error) {
+	view := NewBarrierView(e.barrier, systemBarrierPrefix+policyACLSubPath)
+	names, err := view.List(ctx, "")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list policies: %w", err)
+	}
+
+	policies := make(map[string]string, len(names))
+	for _, name := range names {
+		out, err := view.Get(ctx, name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read policy %q: %w", name, err)
+		}
+		if out == nil {
+			continue
+		}
+		var entry PolicyEntry
+		if err := out.DecodeJSON(&entry); err != nil {
+			return nil, fmt.Errorf("failed to decode policy %q: %w", name, err)
+		}
+		policies[name] = entry.Raw
+	}
+	return policies, nil
+}
+
+// ExportIdentity exports the entities, with their local aliases, and the
+// groups of the identity store.
+func (e *StorageExporter) ExportIdentity(ctx context.Context) (*IdentityExport, error) {
+	var entry *MountEntry
+	for _, m := range e.mounts {
+		if m.Type == mountTypeIdentity {
+			entry = m
+			break
+		}
+	}
+	if entry == nil {
+		return nil, errors.New("identity store mount missing")
+	}
+	view := e.mountView(entry)
+
+	export := &IdentityExport{
+		Entities: make([]map[string]interface{}, 0),
+		Groups:   make([]map[string]interface{}, 0),
+	}
+
+	localAliases := make(map[string][]*identity.Alias)
+	err := e.walkPacker(ctx, view, localAliasesBucketsPrefix, func(item *storagepacker.Item) error {
+		var aliases identity.LocalAliases
+		if err := ptypes.UnmarshalAny(item.Message, &aliases); err != nil {
+			return fmt.Errorf("failed to decode local aliases: %w", err)
+		}
+		localAliases[item.ID] = aliases.Aliases
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.walkPacker(ctx, view, storagepacker.StoragePackerBucketsPrefix, func(item *storagepacker.Item) error {
+		var entity identity.Entity
+		if err := ptypes.UnmarshalAny(item.Message, &entity); err != nil {
+			return fmt.Errorf("failed to decode entity: %w", err)
+		}
+		entity.Aliases = append(entity.Aliases, localAliases[entity.ID]...)
+		export.Entities = append(export.Entities, exportEntity(&entity))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.walkPacker(ctx, view, groupBucketsPrefix, func(item *storagepacker.Item) error {
+		var group identity.Group
+		if err := ptypes.UnmarshalAny(item.Message, &group); err != nil {
+			return fmt.Errorf("failed to decode group: %w", err)
+		}
+		export.Groups = append(export.Groups, exportGroup(&group))
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Slice(export.Entities, func(i, j int) bool {
+		return export.Entities[i]["name"].(string) < export.Entities[j]["name"].(string)
+	})
+	sort.Slice(export.Groups, func(i, j int) bool {
+		return export.Groups[i]["name"].(string) < export.Groups[j]["name"].(string)
+	})
+
+	return export, nil
+}
+
+// walkPacker calls fn for each item stored by a storage packer under prefix.
+func (e *StorageExporter) walkPacker(ctx context.Context, view logical.Storage, prefix string, fn func(*storagepacker.Item) error) error {
+	packer, err := storagepacker.NewStoragePacker(view, e.logger, prefix)
+	if err != nil {
+		return err
+	}
+	buckets, err := view.List(ctx, prefix)
+	if err != nil {
+		return fmt.Errorf("failed to list %q: %w", prefix, err)
+	}
+	for _, key := range buckets {
+		bucket, err := packer.GetBucket(ctx, prefix+key)
+		if err != nil {
+			return err
+		}
+		if bucket == nil {
+			continue
+		}
+		for _, item := range bucket.Items {
+			if err := fn(item); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func exportEntity(entity *identity.Entity) map[string]interface{} {
+	aliases := make([]map[string]interface{}, 0, len(entity.Aliases))
+	for _, alias := range entity.Aliases {
+		aliases = append(aliases, exportAlias(alias))
+	}
+	return map[string]interface{}{
+		"id":                entity.ID,
+		"name":              entity.Name,
+		"namespace_id":      entity.NamespaceID,
+		"policies":          entity.Policies,
+		"metadata":          entity.Metadata,
+		"disabled":          entity.Disabled,
+		"merged_entity_ids": entity.MergedEntityIDs,
+		"creation_time":     entity.CreationTime.AsTime(),
+		"last_update_time":  entity.LastUpdateTime.AsTime(),
+		"aliases":           aliases,
+	}
+}
+
+func exportGroup(group *identity.Group) map[string]interface{} {
+	out := map[string]interface{}{
+		"id":                group.ID,
+		"name":              group.Name,
+		"namespace_id":      group.NamespaceID,
+		"type":              group.Type,
+		"policies":          group.Policies,
+		"metadata":          group.Metadata,
+		"member_entity_ids": group.MemberEntityIDs,
+		"parent_group_ids":  group.ParentGroupIDs,
+		"creation_time":     group.CreationTime.AsTime(),
+		"last_update_time":  group.LastUpdateTime.AsTime(),
+	}
+	if group.Alias != nil {
+		out["alias"] = exportAlias(group.Alias)
+	}
+	return out
+}
+
+func exportAlias(alias *identity.Alias) map[string]interface{} {
+	return map[string]interface{}{
+		"id":               alias.ID,
+		"canonical_id":     alias.CanonicalID,
+		"name":             alias.Name,
+		"mount_accessor":   alias.MountAccessor,
+		"mount_type":       alias.MountType,
+		"metadata":         alias.Metadata,
+		"custom_metadata":  alias.CustomMetadata,
+		"local":            alias.Local,
+		"creation_time":    alias.CreationTime.AsTime(),
+		"last_update_time": alias.LastUpdateTime.AsTime(),
+	}
+}
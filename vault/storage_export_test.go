@@ -0,0 +1,272 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/helper/pgpkeys"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/inmem"
+	"github.com/stretchr/testify/require"
+)
+
+// testCopyPhysical copies all entries of a physical backend into a new
+// in-memory backend.
+func testCopyPhysical(t *testing.T, ctx context.Context, from physical.Backend) physical.Backend {
+	t.Helper()
+
+	to, err := inmem.NewInmem(nil, nil)
+	require.NoError(t, err)
+
+	var copyPrefix func(prefix string)
+	copyPrefix = func(prefix string) {
+		keys, err := from.List(ctx, prefix)
+		require.NoError(t, err)
+		for _, key := range keys {
+			if strings.HasSuffix(key, "/") {
+				copyPrefix(prefix + key)
+				continue
+			}
+			entry, err := from.Get(ctx, prefix+key)
+			require.NoError(t, err)
+			require.NoError(t, to.Put(ctx, entry))
+		}
+	}
+	copyPrefix("")
+	return to
+}
+
+// testStorageExporterCore returns an unsealed core with KV secrets, a policy
+// and an entity, its unseal keys and a copy of its storage.
+func testStorageExporterCore(t *testing.T) (*Core, [][]byte, physical.Backend) {
+	t.Helper()
+
+	c, keys, root := TestCoreUnsealed(t)
+	ctx := namespace.RootContext(nil)
+
+	request := func(op logical.Operation, path string, data map[string]interface{}) {
+		t.Helper()
+		req := logical.TestRequest(t, op, path)
+		req.ClientToken = root
+		req.Data = data
+		resp, err := c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.False(t, resp.IsError(), "request to %q failed: %v", path, resp.Error())
+	}
+
+	request(logical.UpdateOperation, "sys/mounts/kv1", map[string]interface{}{
+		"type":    "kv",
+		"options": map[string]interface{}{"version": "1"},
+	})
+	request(logical.UpdateOperation, "sys/mounts/kv2", map[string]interface{}{
+		"type":    "kv",
+		"options": map[string]interface{}{"version": "2"},
+	})
+	request(logical.UpdateOperation, "kv1/app/config", map[string]interface{}{"foo": "bar"})
+	request(logical.UpdateOperation, "kv1/other", map[string]interface{}{"baz": "qux"})
+	request(logical.UpdateOperation, "kv2/data/app/db", map[string]interface{}{
+		"data": map[string]interface{}{"password": "one"},
+	})
+	request(logical.UpdateOperation, "kv2/data/app/db", map[string]interface{}{
+		"data": map[string]interface{}{"password": "two"},
+	})
+	request(logical.DeleteOperation, "kv2/data/app/db", nil)
+	request(logical.UpdateOperation, "kv2/data/other", map[string]interface{}{
+		"data": map[string]interface{}{"key": "value"},
+	})
+	request(logical.UpdateOperation, "sys/policy/test", map[string]interface{}{
+		"policy": `path "kv2/*" { capabilities = ["read"] }`,
+	})
+	request(logical.UpdateOperation, "identity/entity", map[string]interface{}{
+		"name":     "alice",
+		"policies": []string{"test"},
+	})
+	request(logical.UpdateOperation, "identity/group", map[string]interface{}{
+		"name":     "admins",
+		"policies": []string{"test"},
+	})
+
+	// Rotate so that the storage holds data encrypted with several terms
+	_, err := c.barrier.Rotate(ctx, c.secureRandomReader, manualRotateReason)
+	require.NoError(t, err)
+	request(logical.UpdateOperation, "kv1/after-rotation", map[string]interface{}{"term": "2"})
+
+	return c, keys, testCopyPhysical(t, ctx, c.physical)
+}
+
+// TestStorageExporter verifies that KV secrets, policies and identity can be
+// exported from a copy of the storage with unseal keys or an escrowed keyring.
+func TestStorageExporter(t *testing.T) {
+	c, keys, backend := testStorageExporterCore(t)
+	ctx := namespace.RootContext(nil)
+
+	escrow, err := c.EscrowKeyring(ctx, []string{pgpkeys.TestPubKey1}, 1)
+	require.NoError(t, err)
+	decrypted, err := pgpkeys.DecryptBytes(escrow.EncryptedShares[0], pgpkeys.TestPrivKey1)
+	require.NoError(t, err)
+	share, err := hex.DecodeString(decrypted.String())
+	require.NoError(t, err)
+	keyring, err := OpenKeyringEscrow(escrow, [][]byte{share})
+	require.NoError(t, err)
+
+	tests := map[string]*StorageExporterConfig{
+		"unseal keys": {
+			Backend:    backend,
+			UnsealKeys: keys,
+		},
+		"keyring": {
+			Backend: backend,
+			Keyring: keyring,
+		},
+	}
+	for name, conf := range tests {
+		t.Run(name, func(t *testing.T) {
+			e, err := NewStorageExporter(ctx, conf)
+			require.NoError(t, err)
+
+			kvs, err := e.ExportKV(ctx, nil)
+			require.NoError(t, err)
+			require.Contains(t, kvs, "kv1/")
+			require.Contains(t, kvs, "kv2/")
+
+			kv1 := kvs["kv1/"]
+			require.Equal(t, 1, kv1.Version)
+			require.Equal(t, map[string]interface{}{"foo": "bar"}, kv1.Secrets["app/config"])
+			require.Equal(t, map[string]interface{}{"term": "2"}, kv1.Secrets["after-rotation"])
+
+			kv2 := kvs["kv2/"]
+			require.Equal(t, 2, kv2.Version)
+			require.Len(t, kv2.Secrets, 2)
+			db := kv2.Secrets["app/db"].(map[string]interface{})
+			versions := db["versions"].(map[string]interface{})
+			require.Len(t, versions, 2)
+			first := versions["1"].(map[string]interface{})
+			require.Equal(t, map[string]interface{}{"password": "one"}, first["data"])
+			// The deleted version is exported with its metadata only
+			second := versions["2"].(map[string]interface{})
+			require.Nil(t, second["data"])
+			require.NotEmpty(t, second["metadata"].(map[string]interface{})["deletion_time"])
+
+			policies, err := e.ExportPolicies(ctx)
+			require.NoError(t, err)
+			require.Equal(t, `path "kv2/*" { capabilities = ["read"] }`, policies["test"])
+			require.Contains(t, policies, "default")
+
+			ident, err := e.ExportIdentity(ctx)
+			require.NoError(t, err)
+			require.Len(t, ident.Entities, 1)
+			require.Equal(t, "alice", ident.Entities[0]["name"])
+			require.Equal(t, []string{"test"}, ident.Entities[0]["policies"])
+			require.Len(t, ident.Groups, 1)
+			require.Equal(t, "admins", ident.Groups[0]["name"])
+		})
+	}
+}
+
+// TestStorageExporter_KVPaths verifies that a single secret or a folder can be
+// selected for export.
+func TestStorageExporter_KVPaths(t *testing.T) {
+	_, keys, backend := testStorageExporterCore(t)
+	ctx := namespace.RootContext(nil)
+
+	e, err := NewStorageExporter(ctx, &StorageExporterConfig{
+		Backend:    backend,
+		UnsealKeys: keys,
+	})
+	require.NoError(t, err)
+
+	kvs, err := e.ExportKV(ctx, []string{"kv2/app/db", "kv1/app/"})
+	require.NoError(t, err)
+	require.Len(t, kvs, 2)
+	require.Len(t, kvs["kv2/"].Secrets, 1)
+	require.Contains(t, kvs["kv2/"].Secrets, "app/db")
+	require.Len(t, kvs["kv1/"].Secrets, 1)
+	require.Contains(t, kvs["kv1/"].Secrets, "app/config")
+
+	_, err = e.ExportKV(ctx, []string{"missing/foo"})
+	require.ErrorContains(t, err, `no KV mount found for "missing/foo"`)
+}
+
+// TestStorageExporter_MountEntryByUUID verifies that mounts are looked up by
+// UUID in the mount and auth tables, and that mounts of other namespaces are
+// reported as unsupported.
+func TestStorageExporter_MountEntryByUUID(t *testing.T) {
+	c, keys, backend := testStorageExporterCore(t)
+	ctx := namespace.RootContext(nil)
+
+	e, err := NewStorageExporter(ctx, &StorageExporterConfig{
+		Backend:    backend,
+		UnsealKeys: keys,
+	})
+	require.NoError(t, err)
+
+	kv1 := c.router.MatchingMountEntry(ctx, "kv1/")
+	require.NotNil(t, kv1)
+	entry, err := e.mountEntryByUUID(kv1.UUID)
+	require.NoError(t, err)
+	require.Equal(t, "kv1/", entry.Path)
+	require.Equal(t, mountTableType, entry.Table)
+
+	token := c.router.MatchingMountEntry(ctx, "auth/token/")
+	require.NotNil(t, token)
+	entry, err = e.mountEntryByUUID(token.UUID)
+	require.NoError(t, err)
+	require.Equal(t, "token/", entry.Path)
+	require.Equal(t, credentialTableType, entry.Table)
+
+	entry, err = e.mountEntryByUUID("missing")
+	require.NoError(t, err)
+	require.Nil(t, entry)
+
+	// Add a mount of another namespace to the mount table of the storage
+	raw, err := e.barrier.Get(ctx, coreMountConfigPath)
+	require.NoError(t, err)
+	table := new(MountTable)
+	require.NoError(t, jsonutil.DecodeJSON(raw.Value, table))
+	table.Entries = append(table.Entries, &MountEntry{
+		Path:        "kv/",
+		Type:        mountTypeKV,
+		UUID:        "ns1-kv",
+		NamespaceID: "ns1",
+	})
+	value, err := jsonutil.EncodeJSON(table)
+	require.NoError(t, err)
+	require.NoError(t, e.barrier.Put(ctx, &logical.StorageEntry{Key: coreMountConfigPath, Value: value}))
+
+	e, err = NewStorageExporter(ctx, &StorageExporterConfig{
+		Backend:    backend,
+		UnsealKeys: keys,
+	})
+	require.NoError(t, err)
+	require.Nil(t, e.mountEntry("kv/"))
+	_, err = e.mountEntryByUUID("ns1-kv")
+	require.ErrorContains(t, err, `mount "ns1-kv" belongs to namespace "ns1", only mounts of the root namespace are supported`)
+}
+
+// TestStorageExporter_Invalid verifies that the storage is not opened with
+// missing or incorrect unseal keys.
+func TestStorageExporter_Invalid(t *testing.T) {
+	_, keys, backend := testStorageExporterCore(t)
+	ctx := namespace.RootContext(nil)
+
+	_, err := NewStorageExporter(ctx, &StorageExporterConfig{Backend: backend})
+	require.ErrorContains(t, err, "exactly one of unseal keys and a keyring is required")
+
+	wrong := make([][]byte, len(keys))
+	for i := range keys {
+		wrong[i] = make([]byte, len(keys[i]))
+	}
+	_, err = NewStorageExporter(ctx, &StorageExporterConfig{
+		Backend:    backend,
+		UnsealKeys: wrong,
+	})
+	require.Error(t, err)
+}
@@ -0,0 +1,645 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/physical/raft"
+	"github.com/hashicorp/vault/sdk/helper/jsonutil"
+	"github.com/hashicorp/vault/sdk/helper/locksutil"
+	"github.com/hashicorp/vault/sdk/physical"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	// storageMigrationLockPath is the key written to the source storage on
+	// cutover. It is the same key "vault operator migrate" uses, so servers
+	// still configured with the source storage refuse to start.
+	storageMigrationLockPath = "core/migration"
+
+	storageMigrationDefaultMaxParallel = 10
+
+	storageMigrationStateCopying   = "copying"
+	storageMigrationStateReady     = "ready"
+	storageMigrationStateCompleted = "completed"
+	storageMigrationStateFailed    = "failed"
+)
+
+var (
+	errStorageMigrationDetached = errors.New("destination storage is no longer attached")
+
+	// errStorageMigrationNotActive is returned when a migration is started
+	// or cut over on a node that does not hold the HA lock.
+	errStorageMigrationNotActive = errors.New("online storage migrations can only be run on the active node")
+)
+
+var (
+	_ physical.Backend       = (*storageMigrationBackend)(nil)
+	_ physical.Transactional = (*transactionalStorageMigrationBackend)(nil)
+)
+
+// storageMigrationBackend sits directly on top of the configured physical
+// backend. While no migration is active it passes every operation straight
+// through to the primary backend without taking any lock. While a destination
+// is attached, every write is applied to the primary backend first and then
+// mirrored to the destination, so that an online migration can copy the
+// existing keyspace in the background while the node keeps serving requests.
+type storageMigrationBackend struct {
+	// primary is the backend that operations are passed to. It only changes
+	// on cutover.
+	primary atomic.Pointer[storageMigrationTarget]
+
+	// mirror is only set while a migration is active.
+	mirror atomic.Pointer[storageMigrationMirror]
+
+	// locks serialize writes of the same key with the background copy and
+	// with attaching, detaching and promoting the destination, so that a
+	// stale copy never overwrites a newer mirrored write and no write that
+	// started before a destination was attached is missed.
+	locks []*locksutil.LockEntry
+
+	mirrored atomic.Uint64
+}
+
+// storageMigrationTarget holds a physical backend so it can be swapped
+// atomically.
+type storageMigrationTarget struct {
+	physical.Backend
+}
+
+// storageMigrationMirror is the destination of an active migration.
+type storageMigrationMirror struct {
+	backend physical.Backend
+	onError func(error)
+}
+
+// transactionalStorageMigrationBackend is a storageMigrationBackend that wraps
+// a physical backend that is transactional.
+type transactionalStorageMigrationBackend struct {
+	*storageMigrationBackend
+}
+
+func newStorageMigrationBackend(underlying physical.Backend) physical.Backend {
+	ret := &storageMigrationBackend{
+		locks: locksutil.CreateLocks(),
+	}
+	ret.primary.Store(&storageMigrationTarget{Backend: underlying})
+
+	if _, ok := underlying.(physical.Transactional); ok {
+		return &transactionalStorageMigrationBackend{
+			storageMigrationBackend: ret,
+		}
+	}
+
+	return ret
+}
+
+// storageMigrationSkipKey returns true for keys that belong to the source
+// storage only and are neither copied nor mirrored.
+func storageMigrationSkipKey(key string) bool {
+	return key == storageMigrationLockPath || key == CoreLockPath
+}
+
+func (b *storageMigrationBackend) Get(ctx context.Context, key string) (*physical.Entry, error) {
+	return b.primary.Load().Get(ctx, key)
+}
+
+func (b *storageMigrationBackend) List(ctx context.Context, prefix string) ([]string, error) {
+	return b.primary.Load().List(ctx, prefix)
+}
+
+func (b *storageMigrationBackend) Put(ctx context.Context, entry *physical.Entry) error {
+	if storageMigrationSkipKey(entry.Key) {
+		return b.primary.Load().Put(ctx, entry)
+	}
+
+	lock := locksutil.LockForKey(b.locks, entry.Key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.primary.Load().Put(ctx, entry); err != nil {
+		return err
+	}
+	if mirror := b.mirror.Load(); mirror != nil {
+		b.mirrorResult(mirror, mirror.backend.Put(ctx, entry))
+	}
+	return nil
+}
+
+func (b *storageMigrationBackend) Delete(ctx context.Context, key string) error {
+	if storageMigrationSkipKey(key) {
+		return b.primary.Load().Delete(ctx, key)
+	}
+
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	if err := b.primary.Load().Delete(ctx, key); err != nil {
+		return err
+	}
+	if mirror := b.mirror.Load(); mirror != nil {
+		b.mirrorResult(mirror, mirror.backend.Delete(ctx, key))
+	}
+	return nil
+}
+
+func (b *transactionalStorageMigrationBackend) Transaction(ctx context.Context, txns []*physical.TxnEntry) error {
+	keys := make([]string, 0, len(txns))
+	for _, txn := range txns {
+		keys = append(keys, txn.Entry.Key)
+	}
+	for _, lock := range locksutil.LocksForKeys(b.locks, keys) {
+		lock.Lock()
+		defer lock.Unlock()
+	}
+
+	if err := b.primary.Load().Backend.(physical.Transactional).Transaction(ctx, txns); err != nil {
+		return err
+	}
+
+	mirror := b.mirror.Load()
+	if mirror == nil {
+		return nil
+	}
+
+	if txnMirror, ok := mirror.backend.(physical.Transactional); ok {
+		b.mirrorResult(mirror, txnMirror.Transaction(ctx, txns))
+		return nil
+	}
+
+	for _, txn := range txns {
+		var err error
+		switch txn.Operation {
+		case physical.PutOperation:
+			err = mirror.backend.Put(ctx, txn.Entry)
+		case physical.DeleteOperation:
+			err = mirror.backend.Delete(ctx, txn.Entry.Key)
+		}
+		if err != nil {
+			b.mirrorResult(mirror, err)
+			return nil
+		}
+	}
+	b.mirrorResult(mirror, nil)
+	return nil
+}
+
+// mirrorResult records the outcome of a mirrored write. Failures to mirror do
+// not fail the write itself, since the primary backend remains authoritative
+// until cutover, but they do fail the migration.
+func (b *storageMigrationBackend) mirrorResult(mirror *storageMigrationMirror, err error) {
+	if err != nil {
+		if mirror.onError != nil {
+			mirror.onError(err)
+		}
+		return
+	}
+	b.mirrored.Add(1)
+}
+
+// lockAll takes every key lock, which waits for all writes in flight to
+// complete and blocks new ones until the returned function is called.
+func (b *storageMigrationBackend) lockAll() func() {
+	for _, lock := range b.locks {
+		lock.Lock()
+	}
+	return func() {
+		for _, lock := range b.locks {
+			lock.Unlock()
+		}
+	}
+}
+
+// attach starts mirroring writes to the given destination. Writes that were
+// in flight when attach was called have completed by the time it returns.
+func (b *storageMigrationBackend) attach(mirror physical.Backend, onMirrorError func(error)) {
+	unlock := b.lockAll()
+	defer unlock()
+
+	b.mirror.Store(&storageMigrationMirror{
+		backend: mirror,
+		onError: onMirrorError,
+	})
+	b.mirrored.Store(0)
+}
+
+// detach stops mirroring writes to the given destination. It does nothing if
+// a different destination has been attached since.
+func (b *storageMigrationBackend) detach(mirror physical.Backend) {
+	unlock := b.lockAll()
+	defer unlock()
+
+	if current := b.mirror.Load(); current == nil || current.backend != mirror {
+		return
+	}
+	b.mirror.Store(nil)
+}
+
+// copyKey copies a single key from the primary backend to the destination
+// and returns the size of the copied value.
+func (b *storageMigrationBackend) copyKey(ctx context.Context, key string) (int, error) {
+	lock := locksutil.LockForKey(b.locks, key)
+	lock.Lock()
+	defer lock.Unlock()
+
+	mirror := b.mirror.Load()
+	if mirror == nil {
+		return 0, errStorageMigrationDetached
+	}
+
+	entry, err := b.primary.Load().Get(ctx, key)
+	if err != nil {
+		return 0, err
+	}
+	if entry == nil {
+		// Deleted since it was listed, the delete has already been mirrored.
+		return 0, nil
+	}
+
+	return len(entry.Value), mirror.backend.Put(ctx, entry)
+}
+
+// promote makes the destination the primary backend. The migration lock is
+// written to the old primary first so that servers still configured to use
+// it refuse to start or to become active.
+func (b *storageMigrationBackend) promote(ctx context.Context) error {
+	unlock := b.lockAll()
+	defer unlock()
+
+	mirror := b.mirror.Load()
+	if mirror == nil {
+		return errStorageMigrationDetached
+	}
+
+	enc, err := jsonutil.EncodeJSON(map[string]time.Time{"start": time.Now()})
+	if err != nil {
+		return err
+	}
+	if err := b.primary.Load().Put(ctx, &physical.Entry{Key: storageMigrationLockPath, Value: enc}); err != nil {
+		return fmt.Errorf("failed to write migration lock to source storage: %w", err)
+	}
+
+	b.primary.Store(&storageMigrationTarget{Backend: mirror.backend})
+	b.mirror.Store(nil)
+	return nil
+}
+
+// storageMigrationStatus is the state of an online storage migration as
+// reported by sys/storage/migration.
+type storageMigrationStatus struct {
+	State           string
+	DestinationType string
+	StartTime       time.Time
+	CopyEndTime     time.Time
+	CutoverTime     time.Time
+	Error           string
+}
+
+// storageMigrationManager runs online storage migrations on the active node.
+type storageMigrationManager struct {
+	backend *storageMigrationBackend
+	logger  log.Logger
+
+	// l serializes start, cutover and abort.
+	l           sync.Mutex
+	destination physical.Backend
+	cancel      context.CancelFunc
+	doneCh      chan struct{}
+
+	statusLock  sync.RWMutex
+	status      *storageMigrationStatus
+	keysCopied  atomic.Uint64
+	bytesCopied atomic.Uint64
+}
+
+func newStorageMigrationManager(backend physical.Backend, logger log.Logger) *storageMigrationManager {
+	m := &storageMigrationManager{
+		logger: logger,
+	}
+
+	switch b := backend.(type) {
+	case *storageMigrationBackend:
+		m.backend = b
+	case *transactionalStorageMigrationBackend:
+		m.backend = b.storageMigrationBackend
+	}
+
+	return m
+}
+
+// readStatus returns a copy of the current status, or nil if no migration has
+// been started.
+func (m *storageMigrationManager) readStatus() *storageMigrationStatus {
+	m.statusLock.RLock()
+	defer m.statusLock.RUnlock()
+
+	if m.status == nil {
+		return nil
+	}
+	status := *m.status
+	return &status
+}
+
+func (m *storageMigrationManager) state() string {
+	if status := m.readStatus(); status != nil {
+		return status.State
+	}
+	return ""
+}
+
+// start attaches the destination and begins copying the existing keyspace.
+func (m *storageMigrationManager) start(destinationType string, destination physical.Backend, maxParallel int) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	switch m.state() {
+	case storageMigrationStateCopying, storageMigrationStateReady:
+		return errors.New("a storage migration is already in progress")
+	case storageMigrationStateFailed:
+		return errors.New("a failed storage migration must be aborted before starting a new one")
+	}
+
+	if _, ok := m.backend.primary.Load().Backend.(physical.Transactional); ok {
+		if _, ok := destination.(physical.Transactional); !ok {
+			return errors.New("destination storage must support transactions when the current storage does")
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	doneCh := make(chan struct{})
+	m.destination = destination
+	m.cancel = cancel
+	m.doneCh = doneCh
+	m.keysCopied.Store(0)
+	m.bytesCopied.Store(0)
+
+	m.statusLock.Lock()
+	m.status = &storageMigrationStatus{
+		State:           storageMigrationStateCopying,
+		DestinationType: destinationType,
+		StartTime:       time.Now(),
+	}
+	m.statusLock.Unlock()
+
+	m.backend.attach(destination, m.fail)
+	m.logger.Info("starting online storage migration", "destination_type", destinationType)
+
+	go func(doneCh chan struct{}) {
+		defer close(doneCh)
+		err := m.copyAll(ctx, maxParallel)
+
+		m.statusLock.Lock()
+		defer m.statusLock.Unlock()
+		if m.status.State != storageMigrationStateCopying {
+			return
+		}
+		if err != nil {
+			m.failLocked(err)
+			return
+		}
+		m.status.State = storageMigrationStateReady
+		m.status.CopyEndTime = time.Now()
+		m.logger.Info("online storage migration copy complete, ready for cutover",
+			"keys_copied", m.keysCopied.Load(), "bytes_copied", m.bytesCopied.Load())
+	}(doneCh)
+
+	return nil
+}
+
+// copyAll walks the keyspace of the primary backend depth first and copies
+// every key to the destination.
+func (m *storageMigrationManager) copyAll(ctx context.Context, maxParallel int) error {
+	eg, ctx := errgroup.WithContext(ctx)
+	eg.SetLimit(maxParallel)
+
+	dfs := []string{""}
+	for len(dfs) > 0 {
+		if err := ctx.Err(); err != nil {
+			eg.Wait()
+			return err
+		}
+
+		key := dfs[len(dfs)-1]
+		dfs = dfs[:len(dfs)-1]
+
+		if key == "" || strings.HasSuffix(key, "/") {
+			children, err := m.backend.List(ctx, key)
+			if err != nil {
+				eg.Wait()
+				return fmt.Errorf("failed to list %q: %w", key, err)
+			}
+			sort.Strings(children)
+
+			// add children in reverse order so they are copied in
+			// lexicographic order
+			for i := len(children) - 1; i >= 0; i-- {
+				if children[i] != "" {
+					dfs = append(dfs, key+children[i])
+				}
+			}
+			continue
+		}
+
+		if storageMigrationSkipKey(key) {
+			continue
+		}
+
+		eg.Go(func() error {
+			n, err := m.backend.copyKey(ctx, key)
+			if err != nil {
+				return fmt.Errorf("failed to copy %q: %w", key, err)
+			}
+			m.keysCopied.Add(1)
+			m.bytesCopied.Add(uint64(n))
+			return nil
+		})
+	}
+
+	return eg.Wait()
+}
+
+// fail marks the migration as failed. It is called with the backend lock
+// held, so the destination is detached asynchronously.
+func (m *storageMigrationManager) fail(err error) {
+	m.statusLock.Lock()
+	defer m.statusLock.Unlock()
+
+	m.failLocked(err)
+}
+
+func (m *storageMigrationManager) failLocked(err error) {
+	if m.status == nil {
+		return
+	}
+	switch m.status.State {
+	case storageMigrationStateCopying, storageMigrationStateReady:
+	default:
+		return
+	}
+
+	m.logger.Error("online storage migration failed", "error", err)
+	m.status.State = storageMigrationStateFailed
+	m.status.Error = err.Error()
+	m.cancel()
+	go m.backend.detach(m.destination)
+}
+
+// cutover promotes the destination to be the primary storage of this node.
+func (m *storageMigrationManager) cutover(ctx context.Context) error {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if state := m.state(); state != storageMigrationStateReady {
+		if state == "" {
+			return errors.New("no storage migration in progress")
+		}
+		return fmt.Errorf("storage migration is not ready for cutover, current state is %q", state)
+	}
+
+	if err := m.backend.promote(ctx); err != nil {
+		return err
+	}
+
+	m.statusLock.Lock()
+	m.status.State = storageMigrationStateCompleted
+	m.status.CutoverTime = time.Now()
+	m.statusLock.Unlock()
+
+	m.cancel()
+	m.destination = nil
+	m.logger.Warn("online storage migration cutover complete, update the storage configuration of all servers to the destination before restarting them")
+	return nil
+}
+
+// abort stops a running migration, detaches the destination and clears the
+// migration status. After cutover it only clears the status.
+func (m *storageMigrationManager) abort() {
+	m.l.Lock()
+	defer m.l.Unlock()
+
+	if m.cancel != nil {
+		m.cancel()
+		<-m.doneCh
+		m.cancel = nil
+	}
+
+	if m.destination != nil {
+		m.backend.detach(m.destination)
+		if raftBackend, ok := m.destination.(*raft.RaftBackend); ok {
+			if err := raftBackend.TeardownCluster(nil); err != nil {
+				m.logger.Warn("failed to stop destination raft storage", "error", err)
+			}
+		}
+		m.destination = nil
+	}
+
+	m.statusLock.Lock()
+	if m.status != nil && m.status.State != storageMigrationStateCompleted {
+		m.logger.Info("online storage migration aborted")
+	}
+	m.status = nil
+	m.statusLock.Unlock()
+}
+
+// stopStorageMigration aborts any online storage migration that has not been
+// cut over. Mirroring only happens on the active node, so a migration cannot
+// survive losing active status. After cutover the node keeps using the
+// destination storage, including when it is unsealed again, since the source
+// storage is locked; its storage configuration must be updated before it is
+// restarted.
+func (c *Core) stopStorageMigration() {
+	if c.storageMigration == nil {
+		return
+	}
+
+	switch c.storageMigration.state() {
+	case storageMigrationStateCopying, storageMigrationStateReady, storageMigrationStateFailed:
+		c.storageMigration.abort()
+	case storageMigrationStateCompleted:
+		c.storageMigration.logger.Warn("storage migration was cut over, this node keeps using the destination storage until it is restarted with an updated storage configuration")
+	}
+}
+
+// storageMigrationOnActiveNode returns true if online storage migrations can
+// be run on this node, which is the case for the active node of an HA cluster
+// while it holds the HA lock. It must be called with the state lock held.
+func (c *Core) storageMigrationOnActiveNode() bool {
+	if c.ha == nil {
+		return true
+	}
+	return !c.standby && c.heldHALock != nil
+}
+
+// checkStorageMigrationLock returns an error if the storage this node uses
+// has been migrated away from by an online storage migration on another
+// node. It is called before a standby becomes active, so that it never serves
+// requests from the stale source storage.
+func (c *Core) checkStorageMigrationLock(ctx context.Context) error {
+	if c.storageMigration == nil || c.storageMigration.backend == nil {
+		return nil
+	}
+
+	entry, err := c.storageMigration.backend.Get(ctx, storageMigrationLockPath)
+	if err != nil {
+		return fmt.Errorf("failed to read storage migration lock: %w", err)
+	}
+	if entry != nil {
+		return errors.New("storage has been migrated to a different backend, update the storage configuration of this server and restart it")
+	}
+	return nil
+}
+
+// newStorageMigrationDestination creates the destination backend of an
+// online storage migration.
+func (c *Core) newStorageMigrationDestination(kind string, conf map[string]string) (physical.Backend, error) {
+	factory, ok := c.physicalBackends[kind]
+	if !ok {
+		return nil, fmt.Errorf("unknown destination_type %q", kind)
+	}
+
+	backend, err := factory(conf, c.baseLogger.Named("storage-migration").Named(kind))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create destination storage: %w", err)
+	}
+
+	raftBackend, ok := backend.(*raft.RaftBackend)
+	if !ok {
+		return backend, nil
+	}
+
+	clusterAddr := c.ClusterAddr()
+	if clusterAddr == "" {
+		return nil, errors.New("cluster_addr must be set to migrate to raft storage")
+	}
+	parsedClusterAddr, err := url.Parse(clusterAddr)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing cluster address: %w", err)
+	}
+	if err := raftBackend.Bootstrap([]raft.Peer{
+		{
+			ID:      raftBackend.NodeID(),
+			Address: parsedClusterAddr.Host,
+		},
+	}); err != nil {
+		return nil, fmt.Errorf("could not bootstrap destination raft storage: %w", err)
+	}
+	if err := raftBackend.SetupCluster(context.Background(), raft.SetupOpts{
+		StartAsLeader: true,
+	}); err != nil {
+		return nil, fmt.Errorf("could not start destination raft storage: %w", err)
+	}
+
+	return raftBackend, nil
+}
@@ -0,0 +1,292 @@
+// Copyright (c) HashiCorp, Inc.
+// SPDX-License-Identifier: BUSL-1.1
+
+package vault
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	log "github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/vault/helper/namespace"
+	"github.com/hashicorp/vault/sdk/logical"
+	"github.com/hashicorp/vault/sdk/physical"
+	"github.com/hashicorp/vault/sdk/physical/inmem"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// physicalKeys returns all keys and values of the given backend.
+func physicalKeys(t *testing.T, b physical.Backend) map[string]string {
+	t.Helper()
+
+	ctx := context.Background()
+	result := make(map[string]string)
+	var walk func(prefix string)
+	walk = func(prefix string) {
+		keys, err := b.List(ctx, prefix)
+		require.NoError(t, err)
+		for _, key := range keys {
+			if key[len(key)-1] == '/' {
+				walk(prefix + key)
+				continue
+			}
+			entry, err := b.Get(ctx, prefix+key)
+			require.NoError(t, err)
+			result[prefix+key] = string(entry.Value)
+		}
+	}
+	walk("")
+	return result
+}
+
+func waitForStorageMigrationState(t *testing.T, m *storageMigrationManager, state string) {
+	t.Helper()
+
+	require.Eventually(t, func() bool {
+		return m.state() == state
+	}, 10*time.Second, 10*time.Millisecond)
+}
+
+// TestStorageMigration_MirrorAndCutover verifies that the existing keyspace is
+// copied, that concurrent writes are mirrored and that after cutover the
+// destination is used.
+func TestStorageMigration_MirrorAndCutover(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNullLogger()
+
+	source, err := inmem.NewTransactionalInmem(nil, logger)
+	require.NoError(t, err)
+	destination, err := inmem.NewTransactionalInmem(nil, logger)
+	require.NoError(t, err)
+
+	backend := newStorageMigrationBackend(source)
+	_, ok := backend.(physical.Transactional)
+	require.True(t, ok)
+	m := newStorageMigrationManager(backend, logger)
+
+	for i := 0; i < 200; i++ {
+		require.NoError(t, backend.Put(ctx, &physical.Entry{Key: fmt.Sprintf("dir%d/key%d", i%7, i), Value: []byte("v1")}))
+	}
+	require.NoError(t, source.Put(ctx, &physical.Entry{Key: CoreLockPath, Value: []byte("lock")}))
+
+	require.NoError(t, m.start("inmem", destination, 4))
+
+	var wg sync.WaitGroup
+	for w := 0; w < 4; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for i := w; i < 200; i += 4 {
+				key := fmt.Sprintf("dir%d/key%d", i%7, i)
+				switch i % 3 {
+				case 0:
+					assert.NoError(t, backend.Delete(ctx, key))
+				case 1:
+					assert.NoError(t, backend.(physical.Transactional).Transaction(ctx, []*physical.TxnEntry{
+						{Operation: physical.PutOperation, Entry: &physical.Entry{Key: key, Value: []byte("v2")}},
+						{Operation: physical.PutOperation, Entry: &physical.Entry{Key: key + "-txn", Value: []byte("v2")}},
+					}))
+				default:
+					assert.NoError(t, backend.Put(ctx, &physical.Entry{Key: key, Value: []byte("v3")}))
+				}
+			}
+		}(w)
+	}
+	wg.Wait()
+
+	waitForStorageMigrationState(t, m, storageMigrationStateReady)
+	require.Error(t, m.start("inmem", destination, 4))
+
+	sourceKeys := physicalKeys(t, source)
+	delete(sourceKeys, CoreLockPath)
+	require.Equal(t, sourceKeys, physicalKeys(t, destination))
+
+	require.NoError(t, m.cutover(ctx))
+	require.Equal(t, storageMigrationStateCompleted, m.state())
+
+	lock, err := source.Get(ctx, storageMigrationLockPath)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+	lock, err = destination.Get(ctx, storageMigrationLockPath)
+	require.NoError(t, err)
+	require.Nil(t, lock)
+
+	require.NoError(t, backend.Put(ctx, &physical.Entry{Key: "after-cutover", Value: []byte("v4")}))
+	entry, err := destination.Get(ctx, "after-cutover")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+	entry, err = source.Get(ctx, "after-cutover")
+	require.NoError(t, err)
+	require.Nil(t, entry)
+
+	m.abort()
+	require.Nil(t, m.readStatus())
+	entry, err = backend.Get(ctx, "after-cutover")
+	require.NoError(t, err)
+	require.NotNil(t, entry)
+}
+
+// failingPutBackend fails every Put.
+type failingPutBackend struct {
+	physical.Backend
+}
+
+func (b *failingPutBackend) Put(context.Context, *physical.Entry) error {
+	return fmt.Errorf("destination unavailable")
+}
+
+// TestStorageMigration_Failure verifies that a failure to write to the
+// destination fails the migration without failing writes to the source.
+func TestStorageMigration_Failure(t *testing.T) {
+	ctx := context.Background()
+	logger := log.NewNullLogger()
+
+	source, err := inmem.NewInmem(nil, logger)
+	require.NoError(t, err)
+	destination, err := inmem.NewInmem(nil, logger)
+	require.NoError(t, err)
+
+	backend := newStorageMigrationBackend(source)
+	_, ok := backend.(physical.Transactional)
+	require.False(t, ok)
+	m := newStorageMigrationManager(backend, logger)
+
+	require.NoError(t, backend.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("bar")}))
+	require.NoError(t, m.start("inmem", &failingPutBackend{Backend: destination}, 1))
+	waitForStorageMigrationState(t, m, storageMigrationStateFailed)
+	require.Contains(t, m.readStatus().Error, "destination unavailable")
+
+	require.NoError(t, backend.Put(ctx, &physical.Entry{Key: "foo", Value: []byte("baz")}))
+	require.ErrorContains(t, m.cutover(ctx), `current state is "failed"`)
+	require.ErrorContains(t, m.start("inmem", destination, 1), "must be aborted")
+
+	m.abort()
+	require.NoError(t, m.start("inmem", destination, 1))
+	waitForStorageMigrationState(t, m, storageMigrationStateReady)
+	require.Equal(t, map[string]string{"foo": "baz"}, physicalKeys(t, destination))
+}
+
+// TestSystemBackend_StorageMigration verifies an online migration of an
+// unsealed core through the sys/storage/migration endpoints.
+func TestSystemBackend_StorageMigration(t *testing.T) {
+	var destination physical.Backend
+	c, _, root := TestCoreUnsealedWithConfig(t, &CoreConfig{
+		PhysicalBackends: map[string]physical.Factory{
+			"inmem": func(conf map[string]string, logger log.Logger) (physical.Backend, error) {
+				var err error
+				destination, err = inmem.NewInmem(conf, logger)
+				return destination, err
+			},
+		},
+	})
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "secret/before")
+	req.Data["value"] = "before"
+	req.ClientToken = root
+	_, err := c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/storage/migration")
+	req.Data["destination_type"] = "consul"
+	req.ClientToken = root
+	resp, err := c.HandleRequest(ctx, req)
+	require.Error(t, err)
+	require.Contains(t, resp.Data["error"], `unknown destination_type "consul"`)
+
+	req.Data["destination_type"] = "inmem"
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.Data[logical.HTTPStatusCode])
+
+	waitForStorageMigrationState(t, c.storageMigration, storageMigrationStateReady)
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/storage/migration")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, storageMigrationStateReady, resp.Data["state"])
+	require.Equal(t, "inmem", resp.Data["destination_type"])
+	require.NotZero(t, resp.Data["keys_copied"])
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/storage/migration/cutover")
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "secret/after")
+	req.Data["value"] = "after"
+	req.ClientToken = root
+	_, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+
+	for _, path := range []string{"secret/before", "secret/after"} {
+		req = logical.TestRequest(t, logical.ReadOperation, path)
+		req.ClientToken = root
+		resp, err = c.HandleRequest(ctx, req)
+		require.NoError(t, err)
+		require.NotNil(t, resp)
+	}
+
+	req = logical.TestRequest(t, logical.ReadOperation, "sys/storage/migration")
+	req.ClientToken = root
+	resp, err = c.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, storageMigrationStateCompleted, resp.Data["state"])
+
+	lock, err := c.underlyingPhysical.Get(ctx, storageMigrationLockPath)
+	require.NoError(t, err)
+	require.NotNil(t, lock)
+	keys, err := destination.List(ctx, "")
+	require.NoError(t, err)
+	require.NotEmpty(t, keys)
+}
+
+// TestSystemBackend_StorageMigration_HA verifies that an online migration is
+// run by the active node of an HA cluster, and that after cutover a standby
+// still using the source storage refuses to become active.
+func TestSystemBackend_StorageMigration_HA(t *testing.T) {
+	cluster := NewTestCluster(t, &CoreConfig{
+		PhysicalBackends: map[string]physical.Factory{
+			"inmem": inmem.NewInmem,
+		},
+	}, &TestClusterOptions{
+		NumCores: 2,
+	})
+	cluster.Start()
+	defer cluster.Cleanup()
+
+	active := cluster.Cores[0].Core
+	standby := cluster.Cores[1].Core
+	TestWaitActive(t, active)
+	ctx := namespace.RootContext(nil)
+
+	req := logical.TestRequest(t, logical.UpdateOperation, "sys/storage/migration")
+	req.Data["destination_type"] = "inmem"
+	req.ClientToken = cluster.RootToken
+	resp, err := active.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusAccepted, resp.Data[logical.HTTPStatusCode])
+
+	waitForStorageMigrationState(t, active.storageMigration, storageMigrationStateReady)
+
+	req = logical.TestRequest(t, logical.UpdateOperation, "sys/storage/migration/cutover")
+	req.ClientToken = cluster.RootToken
+	_, err = active.HandleRequest(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, storageMigrationStateCompleted, active.storageMigration.state())
+
+	require.NoError(t, active.Seal(cluster.RootToken))
+	require.Eventually(t, func() bool {
+		sealed, err := standby.barrier.Sealed()
+		return err == nil && sealed
+	}, 10*time.Second, 50*time.Millisecond)
+	isStandby, err := standby.Standby()
+	require.NoError(t, err)
+	require.True(t, isStandby)
+}
@@ -137,6 +137,7 @@ func TestCoreWithSeal(t testing.TB, testSeal Seal, enableRaw bool) *Core {
 			audit.TypeFile:   audit.NewFileBackend,
 			audit.TypeSocket: audit.NewSocketBackend,
 			audit.TypeSyslog: audit.NewSyslogBackend,
+			audit.TypeHTTP:   audit.NewHTTPBackend,
 		},
 	}
 	return TestCoreWithSealAndUI(t, conf)
@@ -257,6 +258,7 @@ func TestCoreWithSealAndUINoCleanup(t testing.TB, opts *CoreConfig) *Core {
 	for k, v := range opts.AuditBackends {
 		conf.AuditBackends[k] = v
 	}
+	conf.PhysicalBackends = opts.PhysicalBackends
 	if opts.RollbackPeriod != time.Duration(0) {
 		conf.RollbackPeriod = opts.RollbackPeriod
 	}
@@ -1454,6 +1456,7 @@ func NewTestCluster(t testing.TB, base *CoreConfig, opts *TestClusterOptions) *T
 			audit.TypeFile:   audit.NewFileBackend,
 			audit.TypeSocket: audit.NewSocketBackend,
 			audit.TypeSyslog: audit.NewSyslogBackend,
+			audit.TypeHTTP:   audit.NewHTTPBackend,
 		},
 		RedirectAddr:    fmt.Sprintf("https://127.0.0.1:%d", listeners[0][0].Address.Port),
 		ClusterAddr:     "https://127.0.0.1:0",
@@ -1534,6 +1537,7 @@ func NewTestCluster(t testing.TB, base *CoreConfig, opts *TestClusterOptions) *T
 				coreConfig.AuditBackends[k] = v
 			}
 		}
+		coreConfig.PhysicalBackends = base.PhysicalBackends
 		if base.Logger != nil {
 			coreConfig.Logger = base.Logger
 		}
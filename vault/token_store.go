@@ -933,6 +933,12 @@ func (ts *TokenStore) SaltID(ctx context.Context, id string) (string, error) {
 
 // rootToken is used to generate a new token with root privileges and no parent
 func (ts *TokenStore) rootToken(ctx context.Context) (*logical.TokenEntry, error) {
+	return ts.rootTokenWithMeta(ctx, nil)
+}
+
+// rootTokenWithMeta is used to generate a new token with root privileges, no
+// parent and the given metadata
+func (ts *TokenStore) rootTokenWithMeta(ctx context.Context, meta map[string]string) (*logical.TokenEntry, error) {
 	ctx = namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
 	te := &logical.TokenEntry{
 		Policies:     []string{"root"},
@@ -941,6 +947,7 @@ func (ts *TokenStore) rootToken(ctx context.Context) (*logical.TokenEntry, error
 		CreationTime: time.Now().Unix(),
 		NamespaceID:  namespace.RootNamespaceID,
 		Type:         logical.TokenTypeService,
+		Meta:         meta,
 	}
 	if err := ts.create(ctx, te); err != nil {
 		return nil, err
@@ -948,6 +955,69 @@ func (ts *TokenStore) rootToken(ctx context.Context) (*logical.TokenEntry, error
 	return te, nil
 }
 
+// generatedRootToken creates the token for a root token generation. The token
+// is flagged in its metadata so that requests using it can be told apart in
+// audit logs. Without a TTL or policies this is otherwise a regular root token.
+// With them the token is registered with the expiration manager, so that it is
+// revoked once the TTL has passed. The token cannot be renewed.
+func (ts *TokenStore) generatedRootToken(ctx context.Context, ttl time.Duration, policies []string) (*logical.TokenEntry, error) {
+	if ttl == 0 && len(policies) == 0 {
+		return ts.rootTokenWithMeta(ctx, map[string]string{
+			generatedRootMetaKey: "true",
+		})
+	}
+	if ttl <= 0 {
+		return nil, errors.New("a ttl is required for a limited root token")
+	}
+	if len(policies) == 0 {
+		policies = []string{"root"}
+	}
+
+	ctx = namespace.ContextWithNamespace(ctx, namespace.RootNamespace)
+	te := &logical.TokenEntry{
+		Policies:       policies,
+		Path:           "auth/token/root",
+		DisplayName:    "root",
+		CreationTime:   time.Now().Unix(),
+		NamespaceID:    namespace.RootNamespaceID,
+		Type:           logical.TokenTypeService,
+		TTL:            ttl,
+		ExplicitMaxTTL: ttl,
+		Meta: map[string]string{
+			generatedRootMetaKey: "true",
+		},
+	}
+	if err := ts.create(ctx, te); err != nil {
+		return nil, err
+	}
+
+	auth := &logical.Auth{
+		ClientToken:    te.ID,
+		Accessor:       te.Accessor,
+		DisplayName:    te.DisplayName,
+		Policies:       te.Policies,
+		TokenPolicies:  te.Policies,
+		Metadata:       te.Meta,
+		ExplicitMaxTTL: te.ExplicitMaxTTL,
+		TokenType:      te.Type,
+		LeaseOptions: logical.LeaseOptions{
+			TTL:       te.TTL,
+			Renewable: false,
+		},
+	}
+	if err := ts.expiration.RegisterAuth(ctx, te, auth, ""); err != nil {
+		if revokeErr := ts.revokeOrphan(ctx, te.ID); revokeErr != nil {
+			ts.logger.Warn("failed to clean up generated root token", "error", revokeErr)
+		}
+		return nil, fmt.Errorf("failed to register generated root token lease: %w", err)
+	}
+
+	// Like root tokens, the token must not be a server side consistent token
+	// so that its size is known when creating the OTP
+	te.ExternalID = te.ID
+	return te, nil
+}
+
 func (ts *TokenStore) tokenStoreAccessorList(ctx context.Context, req *logical.Request, d *framework.FieldData) (*logical.Response, error) {
 	ns, err := namespace.FromContext(ctx)
 	if err != nil {